@@ -4,15 +4,25 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/target"
 )
 
 // An Allocator is responsible for creating and managing a number of browsers.
@@ -114,9 +124,41 @@ type ExecAllocator struct {
 
 	modifyCmdFunc func(cmd *exec.Cmd)
 
+	// userDataDirTemplate is set up by CloneUserDataDir.
+	userDataDirTemplate string
+
+	// preferences is set up by WithPreferences.
+	preferences map[string]interface{}
+
+	// tempDirBase and tempDirPattern are set up by TempUserDataDir.
+	tempDirBase    string
+	tempDirPattern string
+
 	wg sync.WaitGroup
 
 	combinedOutputWriter io.Writer
+
+	// processLogSink is set up by WithProcessLogSink.
+	processLogSink func(ProcessLogLine)
+
+	// onExit is set up by OnExit.
+	onExit func(state *os.ProcessState)
+
+	// containerChecks is set up by DockerDefaults.
+	containerChecks bool
+
+	// handleZombies is set up by HandleZombies.
+	handleZombies bool
+
+	// probeTimeout and probeRetries are set up by ReadinessProbe.
+	probeTimeout time.Duration
+	probeRetries int
+
+	// recycleTabs, recycleAge and recycleRSS are set up by
+	// WithRecycleAfter.
+	recycleTabs int
+	recycleAge  time.Duration
+	recycleRSS  int64
 }
 
 // allocTempDir is used to group all ExecAllocator temporary user data dirs in
@@ -131,6 +173,9 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 		return nil, ErrInvalidContext
 	}
 
+	if err := validateFlags(a.initFlags); err != nil {
+		return nil, err
+	}
 	var args []string
 	for name, value := range a.initFlags {
 		switch value := value.(type) {
@@ -140,22 +185,38 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 			if value {
 				args = append(args, fmt.Sprintf("--%s", name))
 			}
-		default:
-			return nil, fmt.Errorf("invalid exec pool flag")
 		}
 	}
 
 	removeDir := false
 	dataDir, ok := a.initFlags["user-data-dir"].(string)
+	if ok && a.userDataDirTemplate != "" {
+		return nil, errors.New("CloneUserDataDir can not be used with UserDataDir")
+	}
 	if !ok {
-		tempDir, err := os.MkdirTemp(allocTempDir, "chromedp-runner")
+		base, pattern := a.tempUserDataDir()
+		tempDir, err := os.MkdirTemp(base, pattern)
 		if err != nil {
-			return nil, err
+			return nil, userDataDirError(err)
+		}
+		if a.userDataDirTemplate != "" {
+			if err := copyUserDataDir(tempDir, a.userDataDirTemplate); err != nil {
+				os.RemoveAll(tempDir)
+				return nil, userDataDirError(err)
+			}
 		}
 		args = append(args, "--user-data-dir="+tempDir)
 		dataDir = tempDir
 		removeDir = true
 	}
+	if len(a.preferences) > 0 {
+		if err := writePreferences(dataDir, a.preferences); err != nil {
+			if removeDir {
+				os.RemoveAll(dataDir)
+			}
+			return nil, userDataDirError(err)
+		}
+	}
 	if _, ok := a.initFlags["no-sandbox"]; !ok && os.Getuid() == 0 {
 		// Running as root, for example in a Linux container. Chrome
 		// needs --no-sandbox when running as root, so make that the
@@ -165,7 +226,6 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 	if _, ok := a.initFlags["remote-debugging-port"]; !ok {
 		args = append(args, "--remote-debugging-port=0")
 	}
-
 	// Force the first page to be blank, instead of the welcome page;
 	// --no-first-run doesn't enforce that.
 	args = append(args, "about:blank")
@@ -185,6 +245,11 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 	} else {
 		allocateCmdOptions(cmd)
 	}
+	if a.handleZombies {
+		if err := zombieCmdOptions(cmd); err != nil {
+			return nil, err
+		}
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -204,6 +269,7 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
+	started := time.Now()
 
 	select {
 	case <-ctx.Done():
@@ -211,15 +277,21 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 	case <-c.allocated: // for this browser's root context
 	}
 	a.wg.Add(1) // for the entire allocator
-	if a.combinedOutputWriter != nil {
+	output, flushOutput := a.outputWriter()
+	if output != nil {
 		a.wg.Add(1) // for the io.Copy in a separate goroutine
 	}
+	exited := make(chan struct{})
 	go func() {
 		// First wait for the process to be finished.
 		// TODO: do we care about this error in any scenario? if the
 		// user cancelled the context and killed chrome, this will most
 		// likely just be "signal: killed", which isn't interesting.
 		cmd.Wait()
+		if a.handleZombies {
+			reapProcessGroup(cmd.Process.Pid)
+		}
+		close(exited)
 
 		// Then delete the temporary user data directory, if needed.
 		if removeDir {
@@ -232,6 +304,9 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 				c.cancelErr = err
 			}
 		}
+		if a.onExit != nil {
+			a.onExit(cmd.ProcessState)
+		}
 		a.wg.Done()
 		close(c.allocated)
 	}()
@@ -239,7 +314,10 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 	var wsURL string
 	wsURLChan := make(chan struct{}, 1)
 	go func() {
-		wsURL, err = readOutput(stdout, a.combinedOutputWriter, a.wg.Done)
+		wsURL, err = readOutput(stdout, output, func() {
+			flushOutput()
+			a.wg.Done()
+		})
 		wsURLChan <- struct{}{}
 	}()
 	select {
@@ -248,7 +326,7 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 		err = errors.New("websocket url timeout reached")
 	}
 	if err != nil {
-		if a.combinedOutputWriter != nil {
+		if output != nil {
 			// There's no io.Copy goroutine to call the done func.
 			// TODO: a cleaner way to deal with this edge case?
 			a.wg.Done()
@@ -275,10 +353,66 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 		}
 	}()
 	browser.process = cmd.Process
+	browser.processStart = started
+	browser.processArgs = cmd.Args
 	browser.userDataDir = dataDir
+	if a.probeTimeout > 0 {
+		if err := a.probe(ctx, browser, exited, cmd); err != nil {
+			cmd.Process.Kill()
+			return nil, err
+		}
+	}
+	browser.kind = detectBrowserKind(ctx, browser)
+	if a.initFlags["headless"] == "new" && browser.kind == BrowserHeadlessShell {
+		browser.warnFeature("headless=new", "not supported by "+a.execPath)
+	}
+	if a.containerChecks {
+		if warning := shmWarning(a.initFlags); warning != "" {
+			browser.logf("WARNING: %s", warning)
+		}
+	}
 	return browser, nil
 }
 
+// probe checks that the browser b, running as cmd, replies to the commands,
+// until it succeeds or the retries set up by ReadinessProbe are spent.
+func (a *ExecAllocator) probe(ctx context.Context, b *Browser, exited <-chan struct{}, cmd *exec.Cmd) error {
+	var err error
+	for i := 0; i <= a.probeRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		if err = probeBrowser(ctx, b, a.probeTimeout); err == nil {
+			return nil
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("browser exited before it was ready (%v): %w", cmd.ProcessState, err)
+		default:
+		}
+	}
+	return fmt.Errorf("browser not ready after %d attempts: %w", a.probeRetries+1, err)
+}
+
+// probeBrowser runs Browser.getVersion and Target.getTargets against b, within
+// timeout.
+func probeBrowser(ctx context.Context, b *Browser, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx = cdp.WithExecutor(ctx, b)
+	if _, _, _, _, _, err := browser.GetVersion().Do(ctx); err != nil {
+		return fmt.Errorf("%s: %w", browser.CommandGetVersion, err)
+	}
+	if _, err := target.GetTargets().Do(ctx); err != nil {
+		return fmt.Errorf("%s: %w", target.CommandGetTargets, err)
+	}
+	return nil
+}
+
 // readOutput grabs the websocket address from chrome's output, returning as
 // soon as it is found. All read output is forwarded to forward, if non-nil.
 // done is used to signal that the asynchronous io.Copy is done, if any.
@@ -290,8 +424,12 @@ readLoop:
 	for {
 		line, err := bufr.ReadBytes('\n')
 		if err != nil {
-			return "", fmt.Errorf("chrome failed to start:\n%s",
-				accumulated.Bytes())
+			if bytes.Contains(accumulated.Bytes(), []byte("Old Headless mode has been removed")) {
+				return "", fmt.Errorf("chrome failed to start (%w):\n%s",
+					ErrOldHeadlessRemoved, accumulated.Bytes())
+			}
+			return "", fmt.Errorf("chrome failed to start:\n%s%s",
+				accumulated.Bytes(), startFailureHint(accumulated.Bytes()))
 		}
 		if forward != nil {
 			if _, err := forward.Write(line); err != nil {
@@ -397,12 +535,39 @@ func findExecPath() string {
 // Flag is a generic command line option to pass a flag to Chrome. If the value
 // is a string, it will be passed as --name=value. If it's a boolean, it will be
 // passed as --name if value is true.
+//
+// The flags are checked before Chrome is started: Allocate fails with an
+// ErrInvalidFlag error for a name with leading dashes or a value, for another
+// type of value, or for the flags which conflict with each other, such as
+// "window-size" and "start-maximized".
 func Flag(name string, value interface{}) ExecAllocatorOption {
 	return func(a *ExecAllocator) {
 		a.initFlags[name] = value
 	}
 }
 
+// RemoveFlag is the command line option to not pass the flag with the given
+// name to Chrome, such as a flag of DefaultExecAllocatorOptions. Unlike
+// setting the flag to false, it also removes the string flags, and the flags
+// which Chrome doesn't allow to be negated, such as "enable-automation".
+//
+// It should thus be used after the options which set the flag.
+func RemoveFlag(name string) ExecAllocatorOption {
+	return func(a *ExecAllocator) {
+		delete(a.initFlags, name)
+	}
+}
+
+// Flags returns the command line flags passed to Chrome by the allocator, as
+// set up by Flag and the other options, keyed by their names without the
+// leading dashes. Modifying the returned map doesn't affect the allocator.
+//
+// The flags set up by Allocate itself, such as the temporary user data dir,
+// aren't included.
+func (a *ExecAllocator) Flags() map[string]interface{} {
+	return maps.Clone(a.initFlags)
+}
+
 // Env is a list of generic environment variables in the form NAME=value
 // to pass into the new Chrome process. These will be appended to the
 // environment of the Go process as retrieved by os.Environ.
@@ -431,6 +596,237 @@ func UserDataDir(dir string) ExecAllocatorOption {
 	return Flag("user-data-dir", dir)
 }
 
+// CloneUserDataDir is the option to copy the template directory into the
+// temporary user data dir of each browser before starting it, so that each
+// browser starts from a prepared profile, with its extensions, logins and
+// preferences, without sharing its state with the other browsers.
+//
+// The lock files of the template, left by a browser using it, are not copied.
+// This option can't be used with UserDataDir.
+func CloneUserDataDir(template string) ExecAllocatorOption {
+	return func(a *ExecAllocator) {
+		a.userDataDirTemplate = template
+	}
+}
+
+// copyUserDataDir copies the tree of the user data dir src into dst, keeping
+// the file modes and symlinks, and skipping the lock files of the browser.
+func copyUserDataDir(dst, src string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if !d.IsDir() && (strings.HasPrefix(d.Name(), "Singleton") || d.Name() == "lockfile") {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, info.Mode().Perm()|0o700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(target, path, info.Mode().Perm())
+		}
+		// skip sockets and other special files.
+		return nil
+	})
+}
+
+func copyFile(dst, src string, perm fs.FileMode) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// WithPreferences is the option to merge prefs into the preferences of the
+// Default profile of the user data dir, before starting the browser. Many
+// behaviors of the browser can only be configured this way, such as:
+//
+//	WithPreferences(map[string]interface{}{
+//		"download.prompt_for_download": false,
+//		"intl.accept_languages":        "fr-FR,fr",
+//	})
+//
+// The keys are paths of dot-separated names. The preferences of a user data
+// dir set by UserDataDir or CloneUserDataDir are kept, unless overridden by
+// prefs. Using the option multiple times merges the preferences.
+func WithPreferences(prefs map[string]interface{}) ExecAllocatorOption {
+	return func(a *ExecAllocator) {
+		if a.preferences == nil {
+			a.preferences = make(map[string]interface{})
+		}
+		for k, v := range prefs {
+			a.preferences[k] = v
+		}
+	}
+}
+
+// writePreferences merges prefs into the "Default/Preferences" file of the user
+// data dir.
+func writePreferences(dataDir string, prefs map[string]interface{}) error {
+	dir := filepath.Join(dataDir, "Default")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	file := filepath.Join(dir, "Preferences")
+	root := make(map[string]interface{})
+	if buf, err := os.ReadFile(file); err == nil {
+		if err := json.Unmarshal(buf, &root); err != nil {
+			return fmt.Errorf("could not parse preferences %q: %w", file, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for k, v := range prefs {
+		m := root
+		names := strings.Split(k, ".")
+		for _, name := range names[:len(names)-1] {
+			child, ok := m[name].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				m[name] = child
+			}
+			m = child
+		}
+		m[names[len(names)-1]] = v
+	}
+	buf, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf, 0o600)
+}
+
+// defaultTempDirPattern is the default pattern of the names of the temporary
+// user data dirs.
+const defaultTempDirPattern = "chromedp-runner*"
+
+// TempUserDataDir is the option to create the temporary user data dirs in the
+// base directory instead of the system's default temporary directory, with
+// names following pattern, as used by os.MkdirTemp, instead of
+// "chromedp-runner*". An empty base or pattern keeps the default.
+//
+// It has no effect when UserDataDir is used.
+func TempUserDataDir(base, pattern string) ExecAllocatorOption {
+	return func(a *ExecAllocator) {
+		a.tempDirBase = base
+		a.tempDirPattern = pattern
+	}
+}
+
+// tempUserDataDir returns the base directory and the name pattern of the
+// temporary user data dirs.
+func (a *ExecAllocator) tempUserDataDir() (base, pattern string) {
+	base, pattern = a.tempDirBase, a.tempDirPattern
+	if base == "" {
+		base = allocTempDir
+	}
+	if pattern == "" {
+		pattern = defaultTempDirPattern
+	}
+	return base, pattern
+}
+
+// userDataDirError wraps the errors about a lack of disk space with
+// ErrNoSpace, as setting up a user data dir is usually where it shows first.
+func userDataDirError(err error) error {
+	if errors.Is(err, syscall.ENOSPC) {
+		return fmt.Errorf("%w: %w", ErrNoSpace, err)
+	}
+	return err
+}
+
+// PurgeStaleUserDataDirs removes the temporary user data dirs which were not
+// modified for olderThan, such as those leaked by crashed processes, and
+// returns their paths. The ExecAllocator options are used to find the dirs,
+// as set up by TempUserDataDir.
+//
+// The dirs locked by a running browser are kept. As the lock can only be
+// checked on Linux, all the locked dirs are kept on other systems.
+func PurgeStaleUserDataDirs(olderThan time.Duration, opts ...ExecAllocatorOption) ([]string, error) {
+	a := &ExecAllocator{initFlags: make(map[string]interface{})}
+	for _, o := range opts {
+		o(a)
+	}
+	base, pattern := a.tempUserDataDir()
+	if base == "" {
+		base = os.TempDir()
+	}
+	if !strings.Contains(pattern, "*") {
+		pattern += "*"
+	}
+	dirs, err := filepath.Glob(filepath.Join(base, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	deadline := time.Now().Add(-olderThan)
+	for _, dir := range dirs {
+		info, err := os.Lstat(dir)
+		if err != nil || !info.IsDir() || info.ModTime().After(deadline) {
+			continue
+		}
+		if userDataDirLocked(dir) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, err
+		}
+		removed = append(removed, dir)
+	}
+	return removed, nil
+}
+
+// userDataDirLocked reports whether the user data dir is locked by a browser
+// which is still running. The lock is a symlink to "hostname-pid".
+func userDataDirLocked(dir string) bool {
+	link, err := os.Readlink(filepath.Join(dir, "SingletonLock"))
+	if err != nil {
+		return false
+	}
+	i := strings.LastIndexByte(link, '-')
+	if i < 0 {
+		return true
+	}
+	if host, err := os.Hostname(); err != nil || host != link[:i] {
+		// locked by another host sharing the directory.
+		return true
+	}
+	pid, err := strconv.Atoi(link[i+1:])
+	if err != nil {
+		return true
+	}
+	return processAlive(pid)
+}
+
 // ProxyServer is the command line option to set the outbound proxy server.
 func ProxyServer(proxy string) ExecAllocatorOption {
 	return Flag("proxy-server", proxy)
@@ -480,6 +876,105 @@ func Headless(a *ExecAllocator) {
 	Flag("mute-audio", true)(a)
 }
 
+// HeadlessNew is the command line option to run in the new headless mode,
+// which is the full Chrome browser without windows, unlike the old headless
+// mode of Headless and headless-shell. It is also the only headless mode of
+// Chrome since version 132; Chrome then fails to start with
+// ErrOldHeadlessRemoved when the old mode is set with Flag("headless", "old").
+//
+// As the new mode behaves like a headful browser, it removes the flags set by
+// Headless to hide scrollbars and mute audio, and DisableGPU isn't needed. It
+// should thus be used after DefaultExecAllocatorOptions. A browser which
+// doesn't support the new mode, such as headless-shell, calls the
+// WithFeatureWarning func.
+func HeadlessNew(a *ExecAllocator) {
+	Flag("headless", "new")(a)
+	RemoveFlag("hide-scrollbars")(a)
+	RemoveFlag("mute-audio")(a)
+}
+
+// DockerDefaults is the option bundle to run Chrome in a Docker container, or
+// a similar Linux container:
+//
+//   - the sandbox is disabled when running as root, or when the container
+//     doesn't allow the user namespaces the sandbox needs;
+//   - the /dev/shm shared memory, which is only 64MB by default in Docker, is
+//     not used, and a warning is logged with the logf func of the browser,
+//     as set up by WithLogf, if it's small and used anyway;
+//   - the GPU, usually missing in containers, is disabled.
+//
+// It should be used after DefaultExecAllocatorOptions. The errors of a browser
+// failing to start because of common container pitfalls, such as missing
+// shared libraries, explain how to fix them. When the program runs as PID 1,
+// without an init process such as docker run --init, add HandleZombies too.
+func DockerDefaults(a *ExecAllocator) {
+	if os.Getuid() == 0 || !userNamespacesAvailable() {
+		NoSandbox(a)
+	}
+	Flag("disable-dev-shm-usage", true)(a)
+	DisableGPU(a)
+	a.containerChecks = true
+}
+
+// HandleZombies is the option to reap the processes left by a browser once it
+// exits, such as its renderers, which otherwise linger as zombie processes when
+// the program runs as PID 1 in a container, as nothing waits for them.
+//
+// The browser is started in its own process group, and the program becomes the
+// subreaper of its descendants, so that the orphaned processes of the browser
+// are reparented to it; they are killed and waited for once the browser exits.
+// It only has an effect on Linux.
+//
+// Note that being the subreaper affects the whole process, and lasts until it
+// exits: the orphans of the other processes it starts are reparented to it
+// too, and only those of the browsers are waited for, so that the others
+// linger as zombies unless the program waits for them. As PID 1 already gets
+// the orphans, it doesn't become the subreaper then. Starting the browser
+// fails if it can't become the subreaper.
+func HandleZombies(a *ExecAllocator) {
+	a.handleZombies = true
+}
+
+// minShmSize is the size of /dev/shm under which the browser tabs are likely to
+// crash when they use it.
+const minShmSize = 512 << 20
+
+// shmWarning returns a warning if the browser started with flags uses a small
+// /dev/shm.
+func shmWarning(flags map[string]interface{}) string {
+	if disabled, _ := flags["disable-dev-shm-usage"].(bool); disabled {
+		return ""
+	}
+	size, ok := shmSize()
+	if !ok || size >= minShmSize {
+		return ""
+	}
+	return fmt.Sprintf("/dev/shm is only %dMB, so the browser tabs may crash; "+
+		"set the disable-dev-shm-usage flag, or run the container with a larger --shm-size", size>>20)
+}
+
+// startFailureHints are the hints to fix the common reasons a browser fails to
+// start with, by the output of the browser.
+var startFailureHints = []struct {
+	output, hint string
+}{
+	{"Running as root without --no-sandbox", "use NoSandbox or DockerDefaults, or run as a non-root user"},
+	{"No usable sandbox", "use NoSandbox or DockerDefaults, or allow the user namespaces in the container"},
+	{"error while loading shared libraries", "install the shared libraries of Chrome in the container, such as with the chromedp/headless-shell image"},
+	{"Missing X server or $DISPLAY", "use Headless, or run the browser with a display such as Xvfb"},
+}
+
+// startFailureHint returns the hint to fix the failure of a browser to start
+// with output, or the empty string.
+func startFailureHint(output []byte) string {
+	for _, h := range startFailureHints {
+		if bytes.Contains(output, []byte(h.output)) {
+			return "hint: " + h.hint
+		}
+	}
+	return ""
+}
+
 // DisableGPU is the command line option to disable the GPU process.
 //
 // The --disable-gpu option is a temporary workaround for a few bugs
@@ -502,6 +997,35 @@ func CombinedOutput(w io.Writer) ExecAllocatorOption {
 	}
 }
 
+// ReadinessProbe is the option to check that a started browser replies to the
+// Browser.getVersion and Target.getTargets commands, within timeout, before
+// returning it. The check is retried up to retries times.
+//
+// It turns a browser which can't work, such as one missing some libraries or
+// a binary which is not a browser, into a precise allocation error instead of
+// the first actions hanging. The process of such a browser is killed.
+func ReadinessProbe(timeout time.Duration, retries int) ExecAllocatorOption {
+	if timeout <= 0 {
+		panic("timeout must be positive")
+	}
+	return func(a *ExecAllocator) {
+		a.probeTimeout = timeout
+		a.probeRetries = retries
+	}
+}
+
+// OnExit is the option to call fn once the process of a browser exits, and its
+// temporary user data dir is removed. state is nil if the process could not be
+// waited for.
+//
+// Along with Browser.PID, it can be used by supervisors to correlate the
+// crashes of browsers with system metrics.
+func OnExit(fn func(state *os.ProcessState)) ExecAllocatorOption {
+	return func(a *ExecAllocator) {
+		a.onExit = fn
+	}
+}
+
 // WSURLReadTimeout sets the waiting time for reading the WebSocket URL.
 // The default value is 20 seconds.
 func WSURLReadTimeout(t time.Duration) ExecAllocatorOption {
@@ -524,11 +1048,16 @@ func WSURLReadTimeout(t time.Duration) ExecAllocatorOption {
 // But "ws://127.0.0.1:9222/devtools/browser/" are not accepted.
 // Because the allocator won't try to modify it and it's obviously invalid.
 //
-// Use chromedp.NoModifyURL to prevent it from modifying the url.
+// Use chromedp.NoModifyURL to prevent it from modifying the url, and
+// chromedp.WithVersionPath or chromedp.WithWebSocketURLResolver when the
+// browser is behind a gateway which rewrites the paths.
+//
+// See the client package for the other HTTP endpoints of remote browsers,
+// such as listing and closing targets.
 func NewRemoteAllocator(parent context.Context, url string, opts ...RemoteAllocatorOption) (context.Context, context.CancelFunc) {
-	a := &RemoteAllocator{
-		wsURL:         url,
-		modifyURLFunc: modifyURL,
+	a := &RemoteAllocator{wsURL: url}
+	a.modifyURLFunc = func(ctx context.Context, wsURL string) (string, error) {
+		return modifyURL(ctx, wsURL, a.versionPath)
 	}
 	for _, o := range opts {
 		o(a)
@@ -548,6 +1077,16 @@ type RemoteAllocatorOption = func(*RemoteAllocator)
 type RemoteAllocator struct {
 	wsURL         string
 	modifyURLFunc func(ctx context.Context, wsURL string) (string, error)
+	versionPath   string
+	detachOnly    bool
+	inPageUploads bool
+
+	// dialer is set up by WithDialer.
+	dialer func(ctx context.Context, urlstr string) (Transport, error)
+
+	// reconnectAttempts and reconnectBackoff are set up by WithReconnect.
+	reconnectAttempts int
+	reconnectBackoff  time.Duration
 
 	wg sync.WaitGroup
 }
@@ -568,23 +1107,56 @@ func (a *RemoteAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (
 		}
 	}
 
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.allocated: // for this browser's root context
+	}
+
+	if a.dialer != nil {
+		opts = append(opts[:len(opts):len(opts)], func(b *Browser) { b.dialer = a.dialer })
+	}
+	if a.reconnectAttempts > 0 {
+		policy := &reconnectPolicy{
+			attempts: a.reconnectAttempts,
+			backoff:  a.reconnectBackoff,
+			url: func(ctx context.Context) (string, error) {
+				if a.modifyURLFunc == nil {
+					return a.wsURL, nil
+				}
+				return a.modifyURLFunc(ctx, a.wsURL)
+			},
+		}
+		opts = append(opts[:len(opts):len(opts)], func(b *Browser) { b.reconnect = policy })
+	}
+
 	// Use a different context for the websocket, so we can have a chance at
 	// closing the relevant pages before closing the websocket connection.
 	wctx, cancel := context.WithCancel(context.Background())
+	browser, err := NewBrowser(wctx, wsURL, opts...)
+	if err != nil {
+		cancel()
+		close(c.allocated)
+		return nil, err
+	}
 
-	close(c.allocated)
 	a.wg.Add(1) // for the entire allocator
 	go func() {
 		<-ctx.Done()
-		Cancel(ctx) // block until all pages are closed
-		cancel()    // close the websocket connection
+		// Clean up the pages in order: first the one of this context,
+		// then the ones of its child contexts, and only then close the
+		// websocket connection.
+		c.closedTarget.Wait()
+		browser.waitCleanups()
+		cancel()
+		select {
+		case <-browser.LostConnection:
+		case <-time.After(targetCleanupTimeout):
+			c.cancelErr = errors.Join(c.cancelErr, errors.New("timed out closing the websocket connection"))
+		}
 		a.wg.Done()
+		close(c.allocated)
 	}()
-
-	browser, err := NewBrowser(wctx, wsURL, opts...)
-	if err != nil {
-		return nil, err
-	}
 	go func() {
 		// If the browser loses connection, kill the entire process and
 		// handler at once.
@@ -595,6 +1167,7 @@ func (a *RemoteAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (
 			Cancel(ctx)
 		}
 	}()
+	browser.kind = detectBrowserKind(wctx, browser)
 	return browser, nil
 }
 
@@ -608,3 +1181,96 @@ func (a *RemoteAllocator) Wait() {
 func NoModifyURL(a *RemoteAllocator) {
 	a.modifyURLFunc = nil
 }
+
+// WithVersionPath is a RemoteAllocatorOption to query the websocket debugger
+// URL from the version endpoint at path, such as "/chrome/json/version",
+// instead of "/json/version", for example when the browser is behind a proxy
+// which exposes it under a prefix.
+//
+// Note that the websocket debugger URL is still the one returned by the
+// endpoint; use WithWebSocketURLResolver if it needs to be rewritten as well.
+func WithVersionPath(path string) RemoteAllocatorOption {
+	return func(a *RemoteAllocator) {
+		a.versionPath = path
+	}
+}
+
+// WithWebSocketURLResolver is a RemoteAllocatorOption to derive the websocket
+// debugger URL to connect to from the url passed to NewRemoteAllocator with
+// resolve, instead of querying the version endpoint, for example for the
+// gateways which expose browsers at their own URLs, or that need a token to
+// be added.
+//
+// The URL returned by resolve is used as is. The client package can be used
+// to query the endpoints of the browser.
+func WithWebSocketURLResolver(resolve func(ctx context.Context, base string) (string, error)) RemoteAllocatorOption {
+	return func(a *RemoteAllocator) {
+		a.modifyURLFunc = resolve
+	}
+}
+
+// WithDetachOnly is a RemoteAllocatorOption to only detach from the pages of
+// the cancelled contexts, instead of closing them, for example when the remote
+// browser is shared with other users whose pages must be left alone.
+//
+// Note that the pages created by the contexts then remain open as well, except
+// those in the BrowserContexts created by WithNewBrowserContext, which are
+// still disposed.
+func WithDetachOnly() RemoteAllocatorOption {
+	return func(a *RemoteAllocator) {
+		a.detachOnly = true
+	}
+}
+
+// WithInPageUploads is a RemoteAllocatorOption to create the files to upload
+// in the pages, from their content, instead of setting their paths with
+// dom.SetFileInputFiles, for example when the remote browser runs in a
+// container, where the local paths don't exist.
+//
+// The files set with SendKeys, SetUploadFiles and SetUploadBytes are then
+// read by the client, and sent to the browser over the connection; keep in
+// mind that they're held in memory, and encoded in the CDP messages.
+func WithInPageUploads() RemoteAllocatorOption {
+	return func(a *RemoteAllocator) {
+		a.inPageUploads = true
+	}
+}
+
+// WithReconnect is a RemoteAllocatorOption to dial the browser again when the
+// websocket connection to it drops, such as on a network blip between
+// containers, instead of cancelling the browser context along with all of its
+// tab contexts. The browser is dialed up to attempts times, waiting backoff
+// after the first failed attempt, and twice as long after each of the next
+// ones, up to 30 seconds. The websocket URL is resolved again for each attempt,
+// so that a restarted browser is found at its new URL.
+//
+// Once reconnected, the tabs of the contexts are attached to again by their
+// target IDs, and their domains and emulation are set up as before in their
+// new sessions. The actions of the contexts whose tabs are gone, such as those
+// closed meanwhile, or those of a browser which restarted, fail with
+// ErrTargetLost.
+//
+// The commands in flight when the connection dropped fail with
+// ErrConnectionLost once the browser is dialed again, as they aren't replied
+// to; they may or may not have been run. Note that the events sent meanwhile
+// are lost. The browser may also dispose of the BrowserContexts created by
+// WithNewBrowserContext once the connection drops, along with their tabs. If
+// all the attempts fail, LostConnection is closed, which cancels the browser
+// context.
+func WithReconnect(attempts int, backoff time.Duration) RemoteAllocatorOption {
+	return func(a *RemoteAllocator) {
+		a.reconnectAttempts = attempts
+		a.reconnectBackoff = backoff
+	}
+}
+
+// WithDialer is a RemoteAllocatorOption to connect to the browser with dial,
+// which is given the websocket URL, instead of dialing a websocket connection
+// to it, such as to connect to a fake browser in process, or over another kind
+// of connection. Note that WithKeepAlive needs the websocket connection dialed
+// by chromedp, so it's not supported with the Transport dial returns.
+func WithDialer(dial func(ctx context.Context, urlstr string) (Transport, error)) RemoteAllocatorOption {
+	return func(a *RemoteAllocator) {
+		a.dialer = dial
+	}
+}
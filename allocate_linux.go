@@ -4,9 +4,16 @@
 package chromedp
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 func allocateCmdOptions(cmd *exec.Cmd) {
@@ -20,3 +27,136 @@ func allocateCmdOptions(cmd *exec.Cmd) {
 	// When the parent process dies (Go), kill the child as well.
 	cmd.SysProcAttr.Pdeathsig = syscall.SIGKILL
 }
+
+// zombieCmdOptions starts cmd in its own process group, for reapProcessGroup,
+// and makes the current process the subreaper of its descendants, so that the
+// orphaned browser processes are reparented to it instead of to PID 1.
+func zombieCmdOptions(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setpgid = true
+	subreaperOnce.Do(func() {
+		if os.Getpid() != 1 {
+			// PR_SET_CHILD_SUBREAPER; PID 1 already gets the orphans.
+			if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, 36, 1, 0); errno != 0 {
+				subreaperErr = fmt.Errorf("could not become the subreaper of the browser processes: %w", errno)
+			}
+		}
+	})
+	return subreaperErr
+}
+
+var (
+	subreaperOnce sync.Once
+	subreaperErr  error
+)
+
+// reapProcessGroup kills the processes left in the process group pgid of a
+// browser which exited, and waits for those reparented to the current process,
+// so that they don't linger as zombies.
+func reapProcessGroup(pgid int) {
+	syscall.Kill(-pgid, syscall.SIGKILL)
+	// the killed processes are reparented once their parents are reaped,
+	// so retry a few times.
+	for i := 0; i < 3; i++ {
+		for {
+			_, err := syscall.Wait4(-pgid, nil, 0, nil)
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil {
+				break // ECHILD: no processes of the group left
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// processAlive reports whether the process pid is running.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// shmSize returns the size of /dev/shm.
+func shmSize() (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs("/dev/shm", &st); err != nil {
+		return 0, false
+	}
+	return int64(st.Blocks) * int64(st.Bsize), true
+}
+
+// userNamespacesAvailable reports whether unprivileged processes can create
+// user namespaces, as needed by the sandbox of the browser.
+func userNamespacesAvailable() bool {
+	for _, file := range []string{
+		"/proc/sys/kernel/unprivileged_userns_clone", // Debian and Ubuntu
+		"/proc/sys/user/max_user_namespaces",
+	} {
+		buf, err := os.ReadFile(file)
+		if err == nil && strings.TrimSpace(string(buf)) == "0" {
+			return false
+		}
+	}
+	return true
+}
+
+// processTreeRSS returns the resident memory of the process pid and of its
+// descendants, such as the renderers of a browser, or 0 if it's not known.
+func processTreeRSS(pid int) int64 {
+	if pid <= 0 {
+		return 0
+	}
+	children := make(map[int][]int)
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	for _, stat := range stats {
+		buf, err := os.ReadFile(stat)
+		if err != nil {
+			continue
+		}
+		// the command name in parentheses may hold spaces.
+		i := bytes.LastIndexByte(buf, ')')
+		if i < 0 {
+			continue
+		}
+		fields := bytes.Fields(buf[i+1:])
+		if len(fields) < 2 {
+			continue
+		}
+		child, err1 := strconv.Atoi(filepath.Base(filepath.Dir(stat)))
+		ppid, err2 := strconv.Atoi(string(fields[1]))
+		if err1 == nil && err2 == nil {
+			children[ppid] = append(children[ppid], child)
+		}
+	}
+
+	var total int64
+	pending := []int{pid}
+	for len(pending) > 0 {
+		p := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		pending = append(pending, children[p]...)
+		total += processRSS(p)
+	}
+	return total
+}
+
+// processRSS returns the resident memory of the process pid, or 0 if it's not
+// known.
+func processRSS(pid int) int64 {
+	buf, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0
+	}
+	fields := bytes.Fields(buf)
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseInt(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * int64(os.Getpagesize())
+}
@@ -7,3 +7,43 @@ import "os/exec"
 
 func allocateCmdOptions(cmd *exec.Cmd) {
 }
+
+// zombieCmdOptions does nothing, as the orphaned processes are only reaped on
+// Linux.
+func zombieCmdOptions(cmd *exec.Cmd) error {
+	return nil
+}
+
+// reapProcessGroup does nothing, as the orphaned processes are only reaped on
+// Linux.
+func reapProcessGroup(pgid int) {
+}
+
+// processAlive reports whether the process pid is running. It can't be checked
+// portably, so the process is assumed to be running.
+func processAlive(pid int) bool {
+	return true
+}
+
+// shmSize returns the size of /dev/shm, which is Linux-specific.
+func shmSize() (int64, bool) {
+	return 0, false
+}
+
+// userNamespacesAvailable reports whether the sandbox of the browser can use
+// user namespaces, which are Linux-specific.
+func userNamespacesAvailable() bool {
+	return true
+}
+
+// processTreeRSS returns the resident memory of the process pid and of its
+// descendants, which is only known on Linux.
+func processTreeRSS(pid int) int64 {
+	return 0
+}
+
+// processRSS returns the resident memory of the process pid, which is only
+// known on Linux.
+func processRSS(pid int) int64 {
+	return 0
+}
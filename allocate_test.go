@@ -5,15 +5,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httputil"
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/gobwas/ws"
+
+	cdpruntime "github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp/client"
 )
 
 func TestExecAllocator(t *testing.T) {
@@ -298,6 +310,304 @@ func testRemoteAllocator(t *testing.T, modifyURL func(wsURL string) string, want
 	cmd.Wait()
 }
 
+// startRemoteBrowser starts a browser for a RemoteAllocator, returning its
+// websocket URL.
+func startRemoteBrowser(t *testing.T) string {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, execPath,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--user-data-dir="+t.TempDir(),
+		"--remote-debugging-port=0",
+		"about:blank",
+	)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cmd.Wait()
+	})
+	wsURL, err := readOutput(stderr, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wsURL
+}
+
+// remoteTargets returns the IDs of the page targets of the remote browser
+// at wsURL.
+func remoteTargets(t *testing.T, wsURL string) map[target.ID]bool {
+	allocCtx, cancel := NewRemoteAllocator(context.Background(), wsURL, WithDetachOnly())
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	infos, err := Targets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[target.ID]bool)
+	for _, info := range infos {
+		if info.Type == "page" {
+			ids[info.TargetID] = true
+		}
+	}
+	return ids
+}
+
+func TestRemoteAllocatorCleanup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       []RemoteAllocatorOption
+		wantClosed bool
+	}{
+		{"close", nil, true},
+		{"WithDetachOnly", []RemoteAllocatorOption{WithDetachOnly()}, false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wsURL := startRemoteBrowser(t)
+			allocCtx, allocCancel := NewRemoteAllocator(context.Background(), wsURL, tt.opts...)
+			defer allocCancel()
+
+			// the pages of the child contexts are cleaned up
+			// before the websocket connection is closed.
+			ctx, cancel := NewContext(allocCtx)
+			defer cancel()
+			var ids []target.ID
+			for _, ctx := range []context.Context{ctx, func() context.Context {
+				child, _ := NewContext(ctx)
+				return child
+			}()} {
+				if err := Run(ctx, Navigate(testdataDir+"/form.html")); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, FromContext(ctx).Target.TargetID)
+			}
+			b := FromContext(ctx).Browser
+			if err := Cancel(ctx); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-b.LostConnection:
+			default:
+				t.Fatal("want the websocket connection to be closed")
+			}
+
+			open := remoteTargets(t, wsURL)
+			for _, id := range ids {
+				if open[id] == tt.wantClosed {
+					t.Errorf("want target %s closed=%v, got open=%v", id, tt.wantClosed, open[id])
+				}
+			}
+		})
+	}
+}
+
+func TestRemoteAllocatorGateway(t *testing.T) {
+	t.Parallel()
+
+	wsURL := startRemoteBrowser(t)
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the gateway exposes the endpoints of the browser under /gw/, and the
+	// websocket endpoints at their original paths as well.
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: u.Host})
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/gw/"):
+			r.URL.Path = strings.TrimPrefix(r.URL.Path, "/gw")
+		case strings.HasPrefix(r.URL.Path, "/devtools/"):
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(gw.Close)
+
+	tests := []struct {
+		name    string
+		opts    []RemoteAllocatorOption
+		wantErr string
+	}{
+		{"default", nil, "could not get the version information"},
+		{"WithVersionPath", []RemoteAllocatorOption{WithVersionPath("/gw/json/version")}, ""},
+		{"WithWebSocketURLResolver", []RemoteAllocatorOption{WithWebSocketURLResolver(func(ctx context.Context, base string) (string, error) {
+			v, err := client.New(base, client.WithVersionPath("/gw/json/version")).Version(ctx)
+			if err != nil {
+				return "", err
+			}
+			u, err := url.Parse(v.WebSocketDebuggerURL)
+			if err != nil {
+				return "", err
+			}
+			u.Path = "/gw" + u.Path
+			return u.String(), nil
+		})}, ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			allocCtx, allocCancel := NewRemoteAllocator(context.Background(), gw.URL, tt.opts...)
+			defer allocCancel()
+			ctx, cancel := NewContext(allocCtx)
+			defer cancel()
+
+			err := Run(ctx, Navigate(testdataDir+"/form.html"))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("want error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRemoteAllocatorReconnect(t *testing.T) {
+	t.Parallel()
+
+	wsURL := startRemoteBrowser(t)
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := client.New("http://" + u.Host)
+	allocCtx, allocCancel := NewRemoteAllocator(context.Background(), wsURL, WithReconnect(3, 50*time.Millisecond))
+	defer allocCancel()
+
+	// the second tab is closed while the connection is down, before the
+	// browser is dialed again.
+	var lostID target.ID
+	conns := make(chan Transport, 2)
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithTransportWrapper(func(c Transport) Transport {
+		if lostID != "" {
+			closeRemoteTarget(t, cl, lostID)
+		}
+		conns <- c
+		return c
+	})))
+	defer cancel()
+	if err := Run(ctx,
+		Navigate(testdataDir+"/form.html"),
+		EmulateViewport(500, 400),
+	); err != nil {
+		t.Fatal(err)
+	}
+	lostCtx, lostCancel := NewContext(ctx)
+	defer lostCancel()
+	if err := Run(lostCtx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+	c := FromContext(ctx)
+	id, sessionID := c.Target.TargetID, c.Target.SessionID
+	lostID = FromContext(lostCtx).Target.TargetID
+
+	// the command in flight when the connection drops fails.
+	inFlight := make(chan error, 1)
+	go func() {
+		inFlight <- Run(ctx, Evaluate(`new Promise(() => {})`, nil, func(p *cdpruntime.EvaluateParams) *cdpruntime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}))
+	}()
+	for sent := false; !sent; {
+		time.Sleep(10 * time.Millisecond)
+		c.Browser.pendingMu.Lock()
+		for _, cmd := range c.Browser.pending {
+			sent = sent || cmd.sent
+		}
+		c.Browser.pendingMu.Unlock()
+	}
+
+	(<-conns).Close()
+	select {
+	case <-conns:
+	case <-time.After(10 * time.Second):
+		t.Fatal("want the browser to be dialed again")
+	}
+
+	select {
+	case err := <-inFlight:
+		if !errors.Is(err, ErrConnectionLost) {
+			t.Errorf("want ErrConnectionLost for the command in flight, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("want the command in flight to fail")
+	}
+
+	var width int64
+	var value string
+	if err := Run(ctx,
+		Evaluate(`window.innerWidth`, &width),
+		Value("#keyword", &value, ByQuery),
+		Navigate(testdataDir+"/form.html?again"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if width != 500 {
+		t.Errorf("want the emulation to be applied again, got a width of %d", width)
+	}
+	if value != "chromedp" {
+		t.Errorf("want the value of the input, got %q", value)
+	}
+	if c.Target.TargetID != id || c.Target.SessionID == sessionID {
+		t.Errorf("want target %s attached to again in a new session, got %s in %s", id, c.Target.TargetID, c.Target.SessionID)
+	}
+	select {
+	case <-c.Browser.LostConnection:
+		t.Fatal("want the connection to be reconnected")
+	default:
+	}
+	if err := Run(lostCtx, Evaluate(`1`, nil)); !errors.Is(err, ErrTargetLost) {
+		t.Errorf("want ErrTargetLost for the closed tab, got %v", err)
+	}
+	if err := Cancel(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// closeRemoteTarget closes the target id of a remote browser with cl, and
+// waits for it to be gone.
+func closeRemoteTarget(t *testing.T, cl *client.Client, id target.ID) {
+	ctx := context.Background()
+	if err := cl.Close(ctx, string(id)); err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 50; i++ {
+		targets, err := cl.List(ctx)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !slices.ContainsFunc(targets, func(t *client.Target) bool { return t.ID == string(id) }) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("want target %s to be closed", id)
+}
+
 func TestExecAllocatorMissingWebsocketAddr(t *testing.T) {
 	t.Parallel()
 
@@ -481,3 +791,503 @@ func TestStartsWithNonBlankTab(t *testing.T) {
 		}
 	}
 }
+
+func TestCloneUserDataDir(t *testing.T) {
+	t.Parallel()
+
+	template := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(template, "Default"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(template, "Default", "chromedp-template"), []byte("warm"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// a lock left by a browser which used the template.
+	if err := os.Symlink("other-host-123", filepath.Join(template, "SingletonLock")); err != nil {
+		t.Fatal(err)
+	}
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], CloneUserDataDir(template))...)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := NewContext(allocCtx)
+		if err := Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+		dir := FromContext(ctx).Browser.userDataDir
+		if dir == template {
+			t.Fatal("want a copy of the template")
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "Default", "chromedp-template"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "warm" {
+			t.Errorf("want the template file content %q, got %q", "warm", got)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Default", "chromedp-template"), []byte("changed"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+	}
+
+	got, err := os.ReadFile(filepath.Join(template, "Default", "chromedp-template"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "warm" {
+		t.Errorf("want the template to be unchanged, got %q", got)
+	}
+}
+
+func TestCopyUserDataDir(t *testing.T) {
+	t.Parallel()
+
+	src, dst := t.TempDir(), t.TempDir()
+	for _, name := range []string{"Local State", "lockfile"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("host-1", filepath.Join(src, "SingletonLock")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("Local State", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyUserDataDir(dst, src); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := "Local State,link"; strings.Join(names, ",") != want {
+		t.Errorf("want entries %q, got %q", want, names)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "Local State" {
+		t.Errorf("want the symlink to be copied, got %q, %v", link, err)
+	}
+}
+
+func TestWithPreferences(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Default"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Default", "Preferences"),
+		[]byte(`{"download":{"default_directory":"/tmp","prompt_for_download":true},"other":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := setupExecAllocator(
+		WithPreferences(map[string]interface{}{"download.prompt_for_download": false}),
+		WithPreferences(map[string]interface{}{"intl.accept_languages": "fr-FR,fr"}),
+	)
+	if err := writePreferences(dir, a.preferences); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := os.ReadFile(filepath.Join(dir, "Default", "Preferences"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"download":{"default_directory":"/tmp","prompt_for_download":false},"intl":{"accept_languages":"fr-FR,fr"},"other":1}`
+	if string(buf) != want {
+		t.Errorf("want preferences %s, got %s", want, buf)
+	}
+}
+
+func TestWithPreferencesBrowser(t *testing.T) {
+	if os.Getenv("HEADLESS_SHELL") != "" {
+		t.Skip("Skip in headless-shell, as it does not read the profile preferences")
+	}
+	t.Parallel()
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)],
+			WithPreferences(map[string]interface{}{"intl.accept_languages": "fr-FR,fr"}))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+
+	var langs []string
+	if err := Run(ctx, Evaluate(`navigator.languages`, &langs)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "fr-FR,fr"; strings.Join(langs, ",") != want {
+		t.Errorf("want languages %q, got %q", want, langs)
+	}
+}
+
+func TestTempUserDataDir(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], TempUserDataDir(base, "job-*-profile"))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := FromContext(ctx).Browser.userDataDir
+	if filepath.Dir(dir) != base {
+		t.Errorf("want a user data dir in %q, got %q", base, dir)
+	}
+	if name := filepath.Base(dir); !strings.HasPrefix(name, "job-") || !strings.HasSuffix(name, "-profile") {
+		t.Errorf("want a user data dir named after the pattern, got %q", name)
+	}
+}
+
+func TestPurgeStaleUserDataDirs(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	mkdir := func(name string) string {
+		dir := filepath.Join(base, name)
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	stale := mkdir("chromedp-runner123")
+	recent := mkdir("chromedp-runner456")
+	other := mkdir("other789")
+	locked := mkdir("chromedp-runner000")
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// locked by this test process, which is running.
+	if err := os.Symlink(fmt.Sprintf("%s-%d", host, os.Getpid()), filepath.Join(locked, "SingletonLock")); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{stale, other, locked} {
+		if err := os.Chtimes(dir, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := PurgeStaleUserDataDirs(time.Hour, TempUserDataDir(base, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{stale}; strings.Join(removed, ",") != strings.Join(want, ",") {
+		t.Errorf("want removed dirs %q, got %q", want, removed)
+	}
+	for _, dir := range []string{recent, other, locked} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("want %q to be kept: %v", dir, err)
+		}
+	}
+}
+
+func TestUserDataDirError(t *testing.T) {
+	t.Parallel()
+
+	err := userDataDirError(&os.PathError{Op: "mkdir", Path: "/tmp/x", Err: syscall.ENOSPC})
+	if !errors.Is(err, ErrNoSpace) || !errors.Is(err, syscall.ENOSPC) || !strings.Contains(err.Error(), "/tmp/x") {
+		t.Errorf("want an ErrNoSpace error wrapping the error with the path, got %v", err)
+	}
+	if err := userDataDirError(os.ErrPermission); errors.Is(err, ErrNoSpace) {
+		t.Errorf("want no ErrNoSpace error, got %v", err)
+	}
+}
+
+func TestProcessInfo(t *testing.T) {
+	t.Parallel()
+
+	exited := make(chan *os.ProcessState, 1)
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], OnExit(func(state *os.ProcessState) {
+			exited <- state
+		}))...)
+	defer cancel()
+	ctx, _ := NewContext(allocCtx)
+	before := time.Now()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	b := FromContext(ctx).Browser
+	if pid := b.PID(); pid == 0 || pid != b.Process().Pid {
+		t.Errorf("want the PID of the process, got %d", pid)
+	}
+	if start := b.StartTime(); start.Before(before) || start.After(time.Now()) {
+		t.Errorf("want the start time of the process, got %v", start)
+	}
+	args := b.CommandLine()
+	if len(args) == 0 || !slices.ContainsFunc(args, func(arg string) bool {
+		return strings.HasPrefix(arg, "--user-data-dir=")
+	}) {
+		t.Errorf("want the command line with the resolved flags, got %q", args)
+	}
+
+	cancel()
+	select {
+	case state := <-exited:
+		if state == nil || state.Pid() != b.PID() {
+			t.Errorf("want the state of the exited process, got %v", state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want OnExit to be called")
+	}
+}
+
+func TestReadinessProbe(t *testing.T) {
+	t.Parallel()
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], ReadinessProbe(5*time.Second, 2))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadinessProbeNotReady(t *testing.T) {
+	t.Parallel()
+
+	// the server accepts the websocket connection, but never replies.
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}))
+	defer s.Close()
+
+	// a fake browser printing the websocket URL of the server.
+	script := filepath.Join(t.TempDir(), "fake-browser")
+	wsURL := strings.Replace(s.URL, "http://", "ws://", 1) + "/devtools/browser/fake"
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"DevTools listening on "+wsURL+"\"\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		ExecPath(script),
+		ReadinessProbe(100*time.Millisecond, 1),
+	)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	err := Run(ctx)
+	if want := "browser not ready after 2 attempts: Browser.getVersion: context deadline exceeded"; err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+}
+
+func TestHeadlessNew(t *testing.T) {
+	t.Parallel()
+
+	a := setupExecAllocator(append(DefaultExecAllocatorOptions[:], HeadlessNew)...)
+	if got := a.initFlags["headless"]; got != "new" {
+		t.Errorf("want the headless flag %q, got %v", "new", got)
+	}
+	for _, name := range []string{"hide-scrollbars", "mute-audio"} {
+		if _, ok := a.initFlags[name]; ok {
+			t.Errorf("want no %q flag", name)
+		}
+	}
+
+	if os.Getenv("HEADLESS_SHELL") == "" {
+		return
+	}
+	var warnings []string
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], HeadlessNew)...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithFeatureWarning(func(feature, reason string) {
+		warnings = append(warnings, feature)
+	})))
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := "headless=new"; len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("want a warning for %q with headless-shell, got %q", want, warnings)
+	}
+}
+
+func TestRemoveFlag(t *testing.T) {
+	t.Parallel()
+
+	a := setupExecAllocator(append(DefaultExecAllocatorOptions[:],
+		RemoveFlag("enable-automation"),
+		RemoveFlag("disable-features"),
+	)...)
+	flags := a.Flags()
+	for _, name := range []string{"enable-automation", "disable-features"} {
+		if _, ok := flags[name]; ok {
+			t.Errorf("want no %q flag", name)
+		}
+	}
+	if flags["headless"] != true {
+		t.Errorf("want the headless flag, got %v", flags["headless"])
+	}
+	flags["headless"] = false
+	if a.initFlags["headless"] != true {
+		t.Error("want the flags of the allocator to be left alone")
+	}
+
+	var args []string
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)],
+			Flag("enable-automation", true),
+			RemoveFlag("enable-automation"),
+			ModifyCmdFunc(func(cmd *exec.Cmd) {
+				args = cmd.Args
+			}),
+		)...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(args) == 0 {
+		t.Fatal("want the command to be modified")
+	}
+	if slices.Contains(args, "--enable-automation") {
+		t.Errorf("want no --enable-automation argument, got %q", args)
+	}
+}
+
+func TestOldHeadlessRemoved(t *testing.T) {
+	t.Parallel()
+
+	// a fake browser failing as Chrome 132 in the old headless mode.
+	script := filepath.Join(t.TempDir(), "fake-chrome")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"[0101/000000.000000:FATAL:headless_shell.cc(48)] Old Headless mode has been removed from the Chrome binary.\" >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	allocCtx, cancel := NewExecAllocator(context.Background(), ExecPath(script), Flag("headless", "old"))
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); !errors.Is(err, ErrOldHeadlessRemoved) {
+		t.Fatalf("want error %v, got %v", ErrOldHeadlessRemoved, err)
+	}
+}
+
+func TestDockerDefaults(t *testing.T) {
+	t.Parallel()
+
+	a := setupExecAllocator(append(DefaultExecAllocatorOptions[:], DockerDefaults)...)
+	for _, name := range []string{"disable-dev-shm-usage", "disable-gpu"} {
+		if a.initFlags[name] != true {
+			t.Errorf("want the %q flag", name)
+		}
+	}
+	if os.Getuid() == 0 && a.initFlags["no-sandbox"] != true {
+		t.Error("want the no-sandbox flag as root")
+	}
+	if !a.containerChecks {
+		t.Error("want the container checks")
+	}
+	if warning := shmWarning(a.initFlags); warning != "" {
+		t.Errorf("want no warning when /dev/shm is not used, got %q", warning)
+	}
+}
+
+func TestStartFailureHint(t *testing.T) {
+	t.Parallel()
+
+	// a fake browser failing as Chrome missing a shared library.
+	script := filepath.Join(t.TempDir(), "fake-chrome")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"chrome: error while loading shared libraries: libnss3.so: cannot open shared object file\" >&2\nexit 127\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	allocCtx, cancel := NewExecAllocator(context.Background(), ExecPath(script))
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	err := Run(ctx)
+	if want := "libnss3.so: cannot open shared object file\nhint: install the shared libraries"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("want error with %q, got %v", want, err)
+	}
+
+	if hint := startFailureHint([]byte("[0101/000000.000000:ERROR:browser_main_loop.cc(1)] something else")); hint != "" {
+		t.Errorf("want no hint, got %q", hint)
+	}
+}
+
+func TestResidentMemory(t *testing.T) {
+	if _, err := os.Stat("/proc/self/statm"); err != nil {
+		t.Skip("no /proc")
+	}
+	t.Parallel()
+
+	if rss := processTreeRSS(os.Getpid()); rss <= 0 {
+		t.Errorf("want the RSS of the test process, got %d", rss)
+	}
+	if rss := processTreeRSS(0); rss != 0 {
+		t.Errorf("want 0 for an unknown process, got %d", rss)
+	}
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rss := FromContext(ctx).Browser.ResidentMemory(); rss <= 0 {
+		t.Errorf("want the RSS of the browser, got %d", rss)
+	}
+}
+
+func TestHandleZombies(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("zombies are only reaped on Linux")
+	}
+	t.Parallel()
+
+	// a fake browser leaving an orphaned child process.
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "pid")
+	script := filepath.Join(dir, "fake-chrome")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nsleep 60 >/dev/null 2>&1 &\necho $! >"+pidFile+"\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	allocCtx, cancel := NewExecAllocator(context.Background(), ExecPath(script), HandleZombies)
+	ctx, _ := NewContext(allocCtx)
+	if err := Run(ctx); err == nil {
+		t.Fatal("want the fake browser to fail to start")
+	}
+	cancel()
+
+	buf, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proc := "/proc/" + strings.TrimSpace(string(buf))
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		// a zombie process still has its /proc entry.
+		if _, err := os.Stat(proc); os.IsNotExist(err) {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			status, _ := os.ReadFile(proc + "/status")
+			t.Fatalf("want the orphaned process to be reaped, got:\n%s", status)
+		}
+	}
+}
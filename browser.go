@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,7 +30,7 @@ type Browser struct {
 	next int64
 
 	// LostConnection is closed when the websocket connection to Chrome is
-	// dropped. This can be useful to make sure that Browser's context is
+	// dropped, and couldn't be reconnected with WithReconnect. This can be useful to make sure that Browser's context is
 	// cancelled (and the handler stopped) once the connection has failed.
 	LostConnection chan struct{}
 
@@ -42,11 +43,64 @@ type Browser struct {
 
 	dialTimeout time.Duration
 
+	// keepAliveInterval and keepAliveTimeout are set up by WithKeepAlive.
+	keepAliveInterval time.Duration
+	keepAliveTimeout  time.Duration
+
+	// cmdFilter is set up by WithCommandFilter. If non-nil, it's called
+	// before any command is sent to the browser or its targets.
+	cmdFilter func(method string) error
+
+	// cmdTimeout is set up by WithCommandTimeout.
+	cmdTimeout time.Duration
+
+	// msgInterceptor is set up by WithMessageInterceptor. If non-nil, it's
+	// called with every message sent to or received from the browser.
+	msgInterceptor func(dir Direction, msg *cdproto.Message) *cdproto.Message
+
+	// dialer is set up by the WithDialer option of RemoteAllocator. If
+	// non-nil, it connects to the browser instead of a websocket connection.
+	dialer func(ctx context.Context, urlstr string) (Transport, error)
+
+	// wrapTransport is set up by WithTransportWrapper. If non-nil, it wraps
+	// the connection once it's dialed.
+	wrapTransport func(Transport) Transport
+
+	// reconnect is set up by the WithReconnect option of RemoteAllocator.
+	// If non-nil, the browser is dialed again once the connection is lost.
+	reconnect *reconnectPolicy
+
+	// sessionAliases maps the sessions the targets had before the browser
+	// reconnected to their current ones, for the commands queued meanwhile.
+	sessionAliases map[target.SessionID]target.SessionID
+
+	// held holds the commands sent to the targets being set up again once
+	// the browser reconnected, indexed by their session ID, until they're
+	// resumed. The commands setting them up are sent via resumeQueue, and
+	// the resumed sessions are sent to resumedQueue.
+	held         map[target.SessionID][]*cdproto.Message
+	resumeQueue  chan *cdproto.Message
+	resumedQueue chan target.SessionID
+
+	// pendingMu protects pending, the commands waiting for their reply
+	// indexed by their IDs, which are tracked with WithReconnect, so that
+	// those lost along with the connection, or held for a target which
+	// isn't attached to again, fail right away.
+	pendingMu sync.Mutex
+	pending   map[int64]*pendingCommand
+
 	// pages keeps track of the attached targets, indexed by each's session
 	// ID. The only reason this is a field is so that the tests can check the
 	// map once a browser is closed.
 	pages map[target.SessionID]*Target
 
+	// cleanupsMu guards cleanupsDone. The contexts register the cleanup of
+	// their targets in cleanups, so that the websocket connection of a
+	// remote browser is only closed once they are closed or detached.
+	cleanupsMu   sync.Mutex
+	cleanups     sync.WaitGroup
+	cleanupsDone bool
+
 	listenersMu sync.Mutex
 	listeners   []cancelableListener
 
@@ -60,6 +114,15 @@ type Browser struct {
 	// cmdQueue is the outgoing command queue.
 	cmdQueue chan *cdproto.Message
 
+	// kind is the kind of the browser, detected by the allocators.
+	kind BrowserKind
+
+	// featureWarning is set up by WithFeatureWarning, and warned holds the
+	// features it was called with.
+	featureWarning func(feature, reason string)
+	warnedMu       sync.Mutex
+	warned         map[string]bool
+
 	// logging funcs
 	logf func(string, ...interface{})
 	errf func(string, ...interface{})
@@ -71,6 +134,11 @@ type Browser struct {
 	// when allocating a browser.
 	process *os.Process
 
+	// processStart and processArgs are the start time and the command line
+	// of process.
+	processStart time.Time
+	processArgs  []string
+
 	// userDataDir can be initialized by the allocators which set up user
 	// data dirs directly.
 	userDataDir string
@@ -90,6 +158,9 @@ func NewBrowser(ctx context.Context, urlstr string, opts ...BrowserOption) (*Bro
 		// Fit some jobs without blocking, to reduce blocking in Execute.
 		cmdQueue: make(chan *cdproto.Message, 32),
 
+		resumeQueue:  make(chan *cdproto.Message),
+		resumedQueue: make(chan target.SessionID),
+
 		logf: log.Printf,
 	}
 	// apply options
@@ -101,21 +172,38 @@ func NewBrowser(ctx context.Context, urlstr string, opts ...BrowserOption) (*Bro
 		b.errf = func(s string, v ...interface{}) { b.logf("ERROR: "+s, v...) }
 	}
 
-	dialCtx := ctx
+	var err error
+	if b.conn, err = b.dial(ctx, urlstr); err != nil {
+		return nil, err
+	}
+
+	go b.run(ctx)
+	return b, nil
+}
+
+// dial dials the websocket URL of the browser, or calls the dialer set up by
+// WithDialer with it, within the dial timeout, and
+// wraps the connection with the func of WithTransportWrapper, if any.
+func (b *Browser) dial(ctx context.Context, urlstr string) (Transport, error) {
 	if b.dialTimeout > 0 {
 		var cancel context.CancelFunc
-		dialCtx, cancel = context.WithTimeout(ctx, b.dialTimeout)
+		ctx, cancel = context.WithTimeout(ctx, b.dialTimeout)
 		defer cancel()
 	}
-
+	var conn Transport
 	var err error
-	b.conn, err = DialContext(dialCtx, urlstr, WithConnDebugf(b.dbgf))
+	if b.dialer != nil {
+		conn, err = b.dialer(ctx, urlstr)
+	} else {
+		conn, err = DialContext(ctx, urlstr, WithConnDebugf(b.dbgf))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not dial %q: %w", urlstr, err)
 	}
-
-	go b.run(ctx)
-	return b, nil
+	if b.wrapTransport != nil {
+		return b.wrapTransport(conn), nil
+	}
+	return conn, nil
 }
 
 // Process returns the process object of the browser.
@@ -135,7 +223,37 @@ func (b *Browser) Process() *os.Process {
 	return b.process
 }
 
-func (b *Browser) newExecutorForTarget(ctx context.Context, targetID target.ID, sessionID target.SessionID) (*Target, error) {
+// PID returns the process id of the browser, or 0 if the browser is not a
+// process started by the allocator, such as with RemoteAllocator.
+func (b *Browser) PID() int {
+	if b.process == nil {
+		return 0
+	}
+	return b.process.Pid
+}
+
+// StartTime returns the time the process of the browser was started at, or the
+// zero time if the browser is not a process started by the allocator.
+func (b *Browser) StartTime() time.Time {
+	return b.processStart
+}
+
+// CommandLine returns the command line the process of the browser was started
+// with, the executable path first, once all the flags were resolved. It's nil
+// if the browser is not a process started by the allocator.
+func (b *Browser) CommandLine() []string {
+	return slices.Clone(b.processArgs)
+}
+
+// ResidentMemory returns the resident memory in bytes of the process of the
+// browser and of its child processes, such as the renderers. It's only known
+// on Linux, and is 0 otherwise, or if the browser is not a process started by
+// the allocator.
+func (b *Browser) ResidentMemory() int64 {
+	return processTreeRSS(b.PID())
+}
+
+func (b *Browser) newExecutorForTarget(ctx context.Context, targetID target.ID, sessionID target.SessionID, queue *eventQueue) (*Target, error) {
 	if targetID == "" {
 		return nil, errors.New("empty target ID")
 	}
@@ -147,10 +265,12 @@ func (b *Browser) newExecutorForTarget(ctx context.Context, targetID target.ID,
 		TargetID:  targetID,
 		SessionID: sessionID,
 
-		messageQueue: make(chan *cdproto.Message, 1024),
-		frames:       make(map[cdp.FrameID]*cdp.Frame),
-		execContexts: make(map[cdp.FrameID]runtime.ExecutionContextID),
-		cur:          cdp.FrameID(targetID),
+		messageQueue:   queue,
+		syncEventQueue: make(chan eventValue, 4096),
+		frames:         make(map[cdp.FrameID]*cdp.Frame),
+		execContexts:   make(map[cdp.FrameID]runtime.ExecutionContextID),
+		contexts:       make(map[runtime.ExecutionContextID]*ExecutionContext),
+		cur:            cdp.FrameID(targetID),
 
 		logf: b.logf,
 		errf: b.errf,
@@ -171,9 +291,23 @@ func (b *Browser) Execute(ctx context.Context, method string, params easyjson.Ma
 	if method == browser.CommandClose {
 		return fmt.Errorf("to close the browser gracefully, use chromedp.Cancel")
 	}
+	if err := b.filterCommand(method); err != nil {
+		return err
+	}
 	return b.execute(ctx, method, params, res)
 }
 
+// filterCommand runs the filter set up by WithCommandFilter, if any.
+func (b *Browser) filterCommand(method string) error {
+	if b.cmdFilter == nil {
+		return nil
+	}
+	if err := b.cmdFilter(method); err != nil {
+		return fmt.Errorf("command %s not allowed: %w", method, err)
+	}
+	return nil
+}
+
 func (b *Browser) execute(ctx context.Context, method string, params easyjson.Marshaler, res easyjson.Unmarshaler) error {
 	id := atomic.AddInt64(&b.next, 1)
 	lctx, cancel := context.WithCancel(ctx)
@@ -205,9 +339,16 @@ func (b *Browser) execute(ctx context.Context, method string, params easyjson.Ma
 		Method: cdproto.MethodType(method),
 		Params: buf,
 	}
+	failed, done := b.addPending(id)
+	defer done()
+	timeout, stop := b.commandTimer()
+	defer stop()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
+	case <-timeout:
+		cancel()
+		return b.commandTimeoutError(method)
 	case b.cmdQueue <- cmd:
 	}
 
@@ -215,6 +356,12 @@ func (b *Browser) execute(ctx context.Context, method string, params easyjson.Ma
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
+	case <-timeout:
+		cancel()
+		return b.commandTimeoutError(method)
+	case err := <-failed:
+		cancel()
+		return err
 	case msg := <-ch:
 		switch {
 		case msg == nil:
@@ -228,8 +375,29 @@ func (b *Browser) execute(ctx context.Context, method string, params easyjson.Ma
 	return nil
 }
 
+// addCleanup registers the cleanup of a target, returning false if the
+// browser already waits for the cleanups to finish.
+func (b *Browser) addCleanup() bool {
+	b.cleanupsMu.Lock()
+	defer b.cleanupsMu.Unlock()
+	if b.cleanupsDone {
+		return false
+	}
+	b.cleanups.Add(1)
+	return true
+}
+
+// waitCleanups waits for the registered cleanups of the targets to finish.
+func (b *Browser) waitCleanups() {
+	b.cleanupsMu.Lock()
+	b.cleanupsDone = true
+	b.cleanupsMu.Unlock()
+	b.cleanups.Wait()
+}
+
 func (b *Browser) run(ctx context.Context) {
-	defer b.conn.Close()
+	// b.conn changes if the browser reconnects.
+	defer func() { b.conn.Close() }()
 
 	// incomingQueue is the queue of incoming target events, to be routed by
 	// their session ID.
@@ -237,6 +405,12 @@ func (b *Browser) run(ctx context.Context) {
 
 	delTabQueue := make(chan target.SessionID, 1)
 
+	// reconnectQueue is sent to by the reading goroutine once the
+	// connection is lost, to reconnect with WithReconnect; reconnected
+	// tells it whether the browser reconnected.
+	reconnectQueue := make(chan struct{})
+	reconnected := make(chan bool)
+
 	// This goroutine continuously reads events from the websocket
 	// connection. The separate goroutine is needed since a websocket read
 	// is blocking, so it cannot be used in a select statement.
@@ -247,7 +421,26 @@ func (b *Browser) run(ctx context.Context) {
 		for {
 			msg := new(cdproto.Message)
 			if err := b.conn.Read(ctx, msg); err != nil {
-				return
+				if !b.shouldReconnect(ctx) {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case reconnectQueue <- struct{}{}:
+				}
+				if !<-reconnected {
+					return
+				}
+				continue
+			}
+			if msg.ID != 0 {
+				b.replied(msg.ID)
+			}
+			if b.msgInterceptor != nil {
+				if msg = b.msgInterceptor(DirectionIncoming, msg); msg == nil {
+					continue
+				}
 			}
 
 			switch {
@@ -260,6 +453,11 @@ func (b *Browser) run(ctx context.Context) {
 
 			case msg.Method != "":
 				ev, err := cdproto.UnmarshalMessage(msg)
+				if _, ok := err.(cdp.ErrUnknownCommandOrEvent); ok {
+					if cev, custom, cerr := decodeCustomEvent(msg); custom {
+						ev, err = cev, cerr
+					}
+				}
 				if err != nil {
 					b.errf("%s", err)
 					continue
@@ -283,17 +481,84 @@ func (b *Browser) run(ctx context.Context) {
 		}
 	}()
 
+	// keepAlive and pongTimeout are nil unless WithKeepAlive is used; when
+	// pongTimeout fires, no frames were read since the ping was sent.
+	var keepAlive, pongTimeout <-chan time.Time
+	var pingSent time.Time
+	conn, _ := b.conn.(*Conn)
+	if b.keepAliveInterval > 0 {
+		if conn == nil {
+			b.errf("keepalive is not supported by the transport %T", b.conn)
+		} else {
+			ticker := time.NewTicker(b.keepAliveInterval)
+			defer ticker.Stop()
+			keepAlive = ticker.C
+		}
+	}
+
+	write := func(msg *cdproto.Message) {
+		if b.msgInterceptor != nil {
+			if msg = b.msgInterceptor(DirectionOutgoing, msg); msg == nil {
+				return
+			}
+		}
+		if err := b.conn.Write(ctx, msg); err != nil {
+			b.errf("%s", err)
+		}
+		b.sent(msg.ID)
+	}
+
 	b.pages = make(map[target.SessionID]*Target, 32)
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
-		case msg := <-b.cmdQueue:
-			if err := b.conn.Write(ctx, msg); err != nil {
+		case now := <-keepAlive:
+			// only ping idle connections, and wait for a reply to the
+			// previous ping before sending another one.
+			lastRead := conn.lastReadTime()
+			if pongTimeout != nil && lastRead.After(pingSent) {
+				pongTimeout = nil
+			}
+			if pongTimeout != nil || now.Sub(lastRead) < b.keepAliveInterval {
+				continue
+			}
+			if err := conn.ping(); err != nil {
 				b.errf("%s", err)
 				continue
 			}
+			pingSent = now
+			pongTimeout = time.After(b.keepAliveTimeout)
+
+		case <-pongTimeout:
+			pongTimeout = nil
+			if conn.lastReadTime().Before(pingSent) {
+				b.errf("no reply to the websocket ping in %s, closing the connection", b.keepAliveTimeout)
+				// Closing the connection makes the read fail, which
+				// closes LostConnection.
+				b.conn.Close()
+			}
+
+		case msg := <-b.cmdQueue:
+			if sessionID, ok := b.sessionAliases[msg.SessionID]; ok {
+				msg.SessionID = sessionID
+			}
+			if held, ok := b.held[msg.SessionID]; ok {
+				b.held[msg.SessionID] = append(held, msg)
+				continue
+			}
+			write(msg)
+
+		case msg := <-b.resumeQueue:
+			write(msg)
+
+		case sessionID := <-b.resumedQueue:
+			held := b.held[sessionID]
+			delete(b.held, sessionID)
+			for _, msg := range held {
+				write(msg)
+			}
 
 		case t := <-b.newTabQueue:
 			if _, ok := b.pages[t.SessionID]; ok {
@@ -314,11 +579,22 @@ func (b *Browser) run(ctx context.Context) {
 				continue
 			}
 
-			select {
-			case <-ctx.Done():
+			if !page.messageQueue.push(ctx, m) {
+				return
+			}
+
+		case <-reconnectQueue:
+			// the commands are queued while reconnecting.
+			err := b.reconnectTargets(ctx)
+			if err != nil {
+				b.errf("could not reconnect to the browser: %v", err)
+			}
+			reconnected <- err == nil
+			if err != nil {
 				return
-			case page.messageQueue <- m:
 			}
+			conn, _ = b.conn.(*Conn)
+			pongTimeout = nil
 
 		case <-b.LostConnection:
 			return // to avoid "write: broken pipe" errors
@@ -358,3 +634,121 @@ func WithConsolef(f func(string, ...interface{})) BrowserOption {
 func WithDialTimeout(d time.Duration) BrowserOption {
 	return func(b *Browser) { b.dialTimeout = d }
 }
+
+// WithKeepAlive is a browser option to send a websocket ping to the browser
+// when no message was received for interval, so that the proxies, NATs and
+// load balancers between chromedp and a remote browser don't close idle
+// connections, such as during long waits.
+//
+// If no reply to a ping is received within timeout, the connection is
+// considered dead and is closed, which closes LostConnection and cancels the
+// browser context when allocated with RemoteAllocator, unless it reconnects
+// with WithReconnect. A zero or negative timeout waits for interval.
+func WithKeepAlive(interval, timeout time.Duration) BrowserOption {
+	if timeout <= 0 {
+		timeout = interval
+	}
+	return func(b *Browser) {
+		b.keepAliveInterval = interval
+		b.keepAliveTimeout = timeout
+	}
+}
+
+// WithCommandFilter is a browser option to specify a func which is called
+// with the method name of every command before it's sent to the browser or to
+// any of its targets, such as "Page.navigate". If f returns a non-nil error,
+// the command isn't sent, and the error is returned by the action executing
+// it.
+//
+// This is useful to forbid certain commands when running actions built from
+// untrusted input. Note that the commands sent internally by chromedp, such
+// as Target.attachToTarget and the domain enable commands when creating a new
+// tab, go through the filter as well.
+func WithCommandFilter(f func(method string) error) BrowserOption {
+	return func(b *Browser) { b.cmdFilter = f }
+}
+
+// WithCommandTimeout is a browser option to bound the round-trip of every
+// command sent to the browser or to any of its targets to d. A command which
+// isn't replied to in time fails with ErrCommandTimeout naming its method,
+// such as a Page.captureScreenshot on a crashed renderer, instead of blocking
+// the action until the deadline of its context.
+//
+// Note that d should allow for the commands which take long by design, such
+// as Page.navigate on a slow server, or Page.printToPDF of a long document.
+func WithCommandTimeout(d time.Duration) BrowserOption {
+	return func(b *Browser) { b.cmdTimeout = d }
+}
+
+// Direction is the direction of a message intercepted by the func set up by
+// WithMessageInterceptor.
+type Direction int
+
+// Direction values.
+const (
+	// DirectionOutgoing is the direction of the commands sent to the
+	// browser.
+	DirectionOutgoing Direction = iota
+	// DirectionIncoming is the direction of the command responses and of
+	// the events received from the browser.
+	DirectionIncoming
+)
+
+// String satisfies fmt.Stringer.
+func (d Direction) String() string {
+	switch d {
+	case DirectionOutgoing:
+		return "outgoing"
+	case DirectionIncoming:
+		return "incoming"
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
+
+// WithMessageInterceptor is a browser option to specify a func which is called
+// with every raw protocol message sent to or received from the browser and its
+// targets, before it's written to the connection or handled by chromedp. The
+// message f returns is the one written or handled instead, which can be msg
+// after f modified it, or another message; the message is dropped if f
+// returns nil.
+//
+// This is useful to log the protocol traffic, to inject faults in tests, such
+// as failing some commands by replacing their responses with errors, or to
+// work around the protocol bugs of some browsers. Note that dropping a command
+// leaves the action executing it waiting for its response, until its context
+// is done or the timeout of WithCommandTimeout; dropping the events that
+// chromedp relies on, such as those of the Target domain, breaks it.
+//
+// f is called synchronously, by a single goroutine per direction, so it should
+// avoid blocking, and must not execute actions.
+func WithMessageInterceptor(f func(dir Direction, msg *cdproto.Message) *cdproto.Message) BrowserOption {
+	return func(b *Browser) { b.msgInterceptor = f }
+}
+
+// WithTransportWrapper is a browser option to specify a func which wraps the
+// connection to the browser once it's dialed, so that chromedp reads and
+// writes the protocol messages through the Transport wrap returns instead.
+//
+// This is useful to record the raw traffic, or to break the connection in
+// tests, as the Transport's Read failing is handled as a lost connection:
+// LostConnection is closed, which cancels the browser context. Note that
+// WithKeepAlive needs the connection dialed by chromedp, so it's not
+// supported with a wrapped connection.
+func WithTransportWrapper(wrap func(Transport) Transport) BrowserOption {
+	return func(b *Browser) { b.wrapTransport = wrap }
+}
+
+// commandTimer starts the timer of the timeout set up by WithCommandTimeout,
+// returning a nil channel if there's none. The returned func stops the timer.
+func (b *Browser) commandTimer() (<-chan time.Time, func()) {
+	if b.cmdTimeout <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(b.cmdTimeout)
+	return timer.C, func() { timer.Stop() }
+}
+
+// commandTimeoutError returns the error of the command method which timed out.
+func (b *Browser) commandTimeoutError(method string) error {
+	return fmt.Errorf("%w: %s after %v", ErrCommandTimeout, method, b.cmdTimeout)
+}
@@ -0,0 +1,266 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+)
+
+func TestKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithKeepAlive(10*time.Millisecond, time.Second)))
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pongs are received while the connection is idle.
+	time.Sleep(300 * time.Millisecond)
+	conn := FromContext(ctx).Browser.conn.(*Conn)
+	if d := time.Since(conn.lastReadTime()); d > 200*time.Millisecond {
+		t.Errorf("want a recent pong, got the last frame %s ago", d)
+	}
+	if err := Run(ctx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeepAliveZeroTimeout(t *testing.T) {
+	t.Parallel()
+
+	// a zero timeout waits for the interval, instead of closing the
+	// connection once the first ping is sent.
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithKeepAlive(20*time.Millisecond, 0)))
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	b := FromContext(ctx).Browser
+	if b.keepAliveTimeout != 20*time.Millisecond {
+		t.Errorf("want the timeout of the interval, got %v", b.keepAliveTimeout)
+	}
+	time.Sleep(300 * time.Millisecond)
+	select {
+	case <-b.LostConnection:
+		t.Fatal("want the connection kept alive")
+	default:
+	}
+	if err := Run(ctx, Evaluate(`1`, nil)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeepAliveDeadConnection(t *testing.T) {
+	t.Parallel()
+
+	// the server accepts the websocket connection, but never replies.
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b, err := NewBrowser(ctx, strings.Replace(s.URL, "http://", "ws://", 1),
+		WithKeepAlive(10*time.Millisecond, 50*time.Millisecond),
+		WithBrowserErrorf(t.Logf),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-b.LostConnection:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the dead connection to be detected")
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	t.Parallel()
+
+	// the server accepts the websocket connection, but never replies.
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b, err := NewBrowser(ctx, strings.Replace(s.URL, "http://", "ws://", 1),
+		WithCommandTimeout(50*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, _, _, err = browser.GetVersion().Do(cdp.WithExecutor(ctx, b))
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("want error %v, got %v", ErrCommandTimeout, err)
+	}
+	if want := browser.CommandGetVersion; !strings.Contains(err.Error(), want) {
+		t.Errorf("want the error to name %q, got %q", want, err)
+	}
+}
+
+func TestCommandTimeoutTarget(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithCommandTimeout(200*time.Millisecond)))
+	defer cancel()
+	if err := Run(ctx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the promise never settles, so the browser never replies.
+	err := Run(ctx, Evaluate(`new Promise(() => {})`, nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}))
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("want error %v, got %v", ErrCommandTimeout, err)
+	}
+	if want := runtime.CommandEvaluate; !strings.Contains(err.Error(), want) {
+		t.Errorf("want the error to name %q, got %q", want, err)
+	}
+
+	// the target is still usable.
+	var title string
+	if err := Run(ctx, Title(&title)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMessageInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var methods []string
+	screenshots := make(map[int64]bool)
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithMessageInterceptor(func(dir Direction, msg *cdproto.Message) *cdproto.Message {
+		mu.Lock()
+		defer mu.Unlock()
+		switch dir {
+		case DirectionOutgoing:
+			methods = append(methods, string(msg.Method))
+			switch msg.Method {
+			case page.CommandNavigate:
+				msg.Params = []byte(strings.Replace(string(msg.Params), "/form.html", "/image.html", 1))
+			case page.CommandCaptureScreenshot:
+				screenshots[msg.ID] = true
+			}
+		case DirectionIncoming:
+			switch {
+			case msg.Method == cdproto.EventRuntimeConsoleAPICalled:
+				return nil
+			case screenshots[msg.ID]:
+				return &cdproto.Message{
+					ID:        msg.ID,
+					SessionID: msg.SessionID,
+					Error:     &cdproto.Error{Code: -32000, Message: "injected"},
+				}
+			}
+		}
+		return msg
+	})))
+	defer cancel()
+
+	consoleCalled := make(chan struct{}, 1)
+	ListenTarget(ctx, func(ev interface{}) {
+		if _, ok := ev.(*runtime.EventConsoleAPICalled); ok {
+			consoleCalled <- struct{}{}
+		}
+	})
+	var title string
+	if err := Run(ctx,
+		Navigate(testdataDir+"/form.html"),
+		Title(&title),
+		Evaluate(`console.log('dropped')`, nil),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if title != "this is title" {
+		t.Errorf("want the title of the rewritten URL, got %q", title)
+	}
+	var buf []byte
+	if err := Run(ctx, CaptureScreenshot(&buf)); err == nil || !strings.Contains(err.Error(), "injected") {
+		t.Errorf("want the injected error, got %v", err)
+	}
+	select {
+	case <-consoleCalled:
+		t.Error("want the console event to be dropped")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Contains(methods, page.CommandNavigate) || !slices.Contains(methods, target.CommandAttachToTarget) {
+		t.Errorf("want the commands sent to the browser and its targets, got %v", methods)
+	}
+}
+
+// countingTransport is a Transport counting the messages it reads.
+type countingTransport struct {
+	Transport
+	reads atomic.Int64
+}
+
+func (t *countingTransport) Read(ctx context.Context, msg *cdproto.Message) error {
+	if err := t.Transport.Read(ctx, msg); err != nil {
+		return err
+	}
+	t.reads.Add(1)
+	return nil
+}
+
+func TestTransportWrapper(t *testing.T) {
+	t.Parallel()
+
+	var conn *countingTransport
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithTransportWrapper(func(c Transport) Transport {
+		conn = &countingTransport{Transport: c}
+		return conn
+	})))
+	defer cancel()
+	if err := Run(ctx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+	if conn == nil || conn.reads.Load() == 0 {
+		t.Fatal("want the messages to be read through the wrapper")
+	}
+
+	// closing the wrapped connection is handled as a lost connection.
+	conn.Close()
+	select {
+	case <-FromContext(ctx).Browser.LostConnection:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the connection to be lost")
+	}
+}
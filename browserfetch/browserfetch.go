@@ -0,0 +1,295 @@
+// Package browserfetch downloads pinned builds of Chrome for Testing and
+// chrome-headless-shell into a cache dir, to run chromedp against a
+// reproducible browser, such as in CI environments without Docker.
+//
+// See https://github.com/GoogleChromeLabs/chrome-for-testing.
+package browserfetch
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultBaseURL is the base URL of the Chrome for Testing downloads.
+const DefaultBaseURL = "https://storage.googleapis.com/chrome-for-testing-public"
+
+// Product is a browser product of Chrome for Testing.
+type Product string
+
+// Product values.
+const (
+	// HeadlessShell is chrome-headless-shell, the old headless mode of
+	// Chrome as a standalone binary.
+	HeadlessShell Product = "chrome-headless-shell"
+	// Chrome is Chrome for Testing, which can run headful or in the new
+	// headless mode.
+	Chrome Product = "chrome"
+)
+
+// Fetcher downloads the builds of a product.
+type Fetcher struct {
+	product    Product
+	platform   string
+	cacheDir   string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Option is a Fetcher option.
+type Option = func(*Fetcher)
+
+// WithProduct sets the product to download, which defaults to HeadlessShell.
+func WithProduct(p Product) Option {
+	return func(f *Fetcher) {
+		f.product = p
+	}
+}
+
+// WithPlatform sets the platform of the builds, such as "linux64" or
+// "mac-arm64", which defaults to the platform the program runs on.
+func WithPlatform(platform string) Option {
+	return func(f *Fetcher) {
+		f.platform = platform
+	}
+}
+
+// WithCacheDir sets the dir the builds are extracted into, which defaults to
+// the "chromedp/browserfetch" dir of os.UserCacheDir.
+func WithCacheDir(dir string) Option {
+	return func(f *Fetcher) {
+		f.cacheDir = dir
+	}
+}
+
+// WithBaseURL sets the base URL the builds are downloaded from, which defaults
+// to DefaultBaseURL. It's useful to download them from a mirror.
+func WithBaseURL(urlstr string) Option {
+	return func(f *Fetcher) {
+		f.baseURL = strings.TrimSuffix(urlstr, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to download the builds, which
+// defaults to http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(f *Fetcher) {
+		f.httpClient = c
+	}
+}
+
+// New creates a Fetcher.
+func New(opts ...Option) *Fetcher {
+	f := &Fetcher{
+		product:    HeadlessShell,
+		platform:   Platform(runtime.GOOS, runtime.GOARCH),
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// Platform returns the Chrome for Testing platform of goos and goarch, or the
+// empty string if there are no builds for them.
+func Platform(goos, goarch string) string {
+	switch {
+	case goos == "linux" && goarch == "amd64":
+		return "linux64"
+	case goos == "darwin" && goarch == "arm64":
+		return "mac-arm64"
+	case goos == "darwin" && goarch == "amd64":
+		return "mac-x64"
+	case goos == "windows" && goarch == "amd64":
+		return "win64"
+	case goos == "windows" && goarch == "386":
+		return "win32"
+	}
+	return ""
+}
+
+// Fetch downloads the build of version, such as "130.0.6723.58", unless it's
+// already in the cache dir, and returns the path of its executable.
+func (f *Fetcher) Fetch(ctx context.Context, version string) (string, error) {
+	if f.platform == "" {
+		return "", fmt.Errorf("no %s builds for %s/%s", f.product, runtime.GOOS, runtime.GOARCH)
+	}
+	if version == "" || strings.ContainsAny(version, `/\`) || strings.Contains(version, "..") {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	cacheDir := f.cacheDir
+	if cacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = filepath.Join(dir, "chromedp", "browserfetch")
+	}
+	dir := filepath.Join(cacheDir, string(f.product), version, f.platform)
+	execPath := filepath.Join(dir, f.execPath())
+	if _, err := os.Stat(execPath); err == nil {
+		return execPath, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", err
+	}
+	// extract into a temporary dir first, so that a partial build is
+	// never used, and concurrent fetches don't conflict.
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "fetch")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	if err := f.download(ctx, version, tmp); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(tmp, f.execPath())); err != nil {
+		return "", fmt.Errorf("invalid %s build %s: %w", f.product, version, err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, serr := os.Stat(execPath); serr == nil {
+			// fetched concurrently.
+			return execPath, nil
+		}
+		return "", err
+	}
+	return execPath, nil
+}
+
+// ExecPath downloads the build of version as Fetch, and returns the
+// chromedp.ExecPath option to run it.
+func (f *Fetcher) ExecPath(ctx context.Context, version string) (chromedp.ExecAllocatorOption, error) {
+	execPath, err := f.Fetch(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	return chromedp.ExecPath(execPath), nil
+}
+
+// ExecPath downloads the build of version with a Fetcher set up with opts, and
+// returns the chromedp.ExecPath option to run it.
+func ExecPath(ctx context.Context, version string, opts ...Option) (chromedp.ExecAllocatorOption, error) {
+	return New(opts...).ExecPath(ctx, version)
+}
+
+// execPath returns the path of the executable of the build, relative to the
+// dir it's extracted into.
+func (f *Fetcher) execPath() string {
+	name := string(f.product)
+	dir := name + "-" + f.platform
+	switch {
+	case f.product == Chrome && strings.HasPrefix(f.platform, "mac-"):
+		return filepath.Join(dir, "Google Chrome for Testing.app", "Contents", "MacOS", "Google Chrome for Testing")
+	case strings.HasPrefix(f.platform, "win"):
+		return filepath.Join(dir, name+".exe")
+	}
+	return filepath.Join(dir, name)
+}
+
+// download downloads the zip archive of the build of version, and extracts it
+// into dir.
+func (f *Fetcher) download(ctx context.Context, version, dir string) error {
+	name := fmt.Sprintf("%s-%s.zip", f.product, f.platform)
+	u := f.baseURL + "/" + path.Join(version, f.platform, name)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not download %s: %s", u, resp.Status)
+	}
+
+	// zip archives need random access, so save it first.
+	archive, err := os.CreateTemp(dir, "*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	size, err := io.Copy(archive, resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not download %s: %w", u, err)
+	}
+	zr, err := zip.NewReader(archive, size)
+	if err != nil {
+		return fmt.Errorf("invalid archive %s: %w", u, err)
+	}
+	// the symlinks extracted, so that no file is extracted through them.
+	links := make(map[string]bool)
+	for _, file := range zr.File {
+		if err := extract(dir, file, links); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extract extracts the file of a zip archive into dir. links are the names of
+// the symlinks already extracted, which the file may not be written through,
+// and to which it's added if it's one.
+func extract(dir string, file *zip.File, links map[string]bool) error {
+	name := filepath.FromSlash(file.Name)
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("invalid file name %q in archive", file.Name)
+	}
+	name = filepath.Clean(name)
+	for p := name; p != "."; p = filepath.Dir(p) {
+		if links[p] {
+			return fmt.Errorf("invalid file %q through a symlink in archive", file.Name)
+		}
+	}
+	target := filepath.Join(dir, name)
+	mode := file.Mode()
+	if mode.IsDir() {
+		return os.MkdirAll(target, 0o755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if mode&os.ModeSymlink != 0 {
+		// the macOS app bundles hold symlinks, whose content is their
+		// target.
+		link, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		dest := filepath.FromSlash(string(link))
+		if filepath.IsAbs(dest) || !filepath.IsLocal(filepath.Join(filepath.Dir(name), dest)) {
+			return fmt.Errorf("invalid symlink %q in archive", file.Name)
+		}
+		links[name] = true
+		return os.Symlink(dest, target)
+	}
+	perm := mode.Perm() | 0o600
+	w, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
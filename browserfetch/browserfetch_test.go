@@ -0,0 +1,204 @@
+package browserfetch
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var entries []zipEntry
+	for name, content := range files {
+		entries = append(entries, zipEntry{name, content, 0o755})
+	}
+	return makeZipEntries(t, entries)
+}
+
+type zipEntry struct {
+	name    string
+	content string
+	mode    fs.FileMode
+}
+
+func makeZipEntries(t *testing.T, entries []zipEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		h.SetMode(e.mode)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	archive := makeZip(t, map[string]string{
+		"chrome-headless-shell-linux64/chrome-headless-shell": "#!/bin/sh\n",
+		"chrome-headless-shell-linux64/libEGL.so":             "lib",
+	})
+	var requests atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/130.0.6723.58/linux64/chrome-headless-shell-linux64.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer s.Close()
+
+	cacheDir := t.TempDir()
+	f := New(WithBaseURL(s.URL+"/"), WithCacheDir(cacheDir), WithPlatform("linux64"))
+	for i := 0; i < 2; i++ {
+		execPath, err := f.Fetch(context.Background(), "130.0.6723.58")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := filepath.Join(cacheDir, "chrome-headless-shell", "130.0.6723.58", "linux64",
+			"chrome-headless-shell-linux64", "chrome-headless-shell")
+		if execPath != want {
+			t.Fatalf("want exec path %q, got %q", want, execPath)
+		}
+		info, err := os.Stat(execPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm()&0o100 == 0 {
+			t.Errorf("want an executable, got mode %v", info.Mode())
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("want the build to be downloaded once, got %d requests", n)
+	}
+
+	if opt, err := f.ExecPath(context.Background(), "130.0.6723.58"); err != nil || opt == nil {
+		t.Errorf("want an ExecPath option, got %v", err)
+	}
+
+	_, err := f.Fetch(context.Background(), "1.2.3.4")
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("want a download error, got %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), "../130.0.6723.58"); err == nil {
+		t.Error("want an error for an invalid version")
+	}
+}
+
+func TestFetchInvalidArchive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"Traversal", map[string]string{"../evil": "x"}, "invalid file name"},
+		{"NoExecutable", map[string]string{"chrome-headless-shell-linux64/README": "x"}, "invalid chrome-headless-shell build"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			archive := makeZip(t, test.files)
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(archive)
+			}))
+			defer s.Close()
+
+			cacheDir := t.TempDir()
+			_, err := fetch(t, s.URL, cacheDir)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("want error %q, got %v", test.want, err)
+			}
+			// nothing is left in the cache.
+			entries, _ := os.ReadDir(filepath.Join(cacheDir, "chrome-headless-shell", "1.0.0.0"))
+			if len(entries) != 0 {
+				t.Errorf("want an empty cache, got %v", entries)
+			}
+		})
+	}
+}
+
+func TestFetchInvalidSymlink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		want    string
+	}{
+		{"Absolute", []zipEntry{
+			{"chrome-headless-shell-linux64/etc", "/etc", fs.ModeSymlink | 0o777},
+		}, "invalid symlink"},
+		{"Traversal", []zipEntry{
+			{"chrome-headless-shell-linux64/up", "../..", fs.ModeSymlink | 0o777},
+		}, "invalid symlink"},
+		{"Through", []zipEntry{
+			{"chrome-headless-shell-linux64/lib", "Versions", fs.ModeSymlink | 0o777},
+			{"chrome-headless-shell-linux64/lib/evil", "x", 0o644},
+		}, "through a symlink"},
+		{"Onto", []zipEntry{
+			{"chrome-headless-shell-linux64/lib", "Versions", fs.ModeSymlink | 0o777},
+			{"chrome-headless-shell-linux64/lib", "x", 0o644},
+		}, "through a symlink"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			archive := makeZipEntries(t, test.entries)
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(archive)
+			}))
+			defer s.Close()
+
+			_, err := fetch(t, s.URL, t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("want error %q, got %v", test.want, err)
+			}
+		})
+	}
+}
+
+func fetch(t *testing.T, baseURL, cacheDir string) (string, error) {
+	t.Helper()
+	return New(WithBaseURL(baseURL), WithCacheDir(cacheDir), WithPlatform("linux64")).
+		Fetch(context.Background(), "1.0.0.0")
+}
+
+func TestExecPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		product  Product
+		platform string
+		want     string
+	}{
+		{HeadlessShell, "linux64", "chrome-headless-shell-linux64/chrome-headless-shell"},
+		{HeadlessShell, "win64", "chrome-headless-shell-win64/chrome-headless-shell.exe"},
+		{Chrome, "linux64", "chrome-linux64/chrome"},
+		{Chrome, "mac-arm64", "chrome-mac-arm64/Google Chrome for Testing.app/Contents/MacOS/Google Chrome for Testing"},
+		{Chrome, "win32", "chrome-win32/chrome.exe"},
+	}
+	for _, test := range tests {
+		f := New(WithProduct(test.product), WithPlatform(test.platform))
+		if got := filepath.ToSlash(f.execPath()); got != test.want {
+			t.Errorf("%s %s: want %q, got %q", test.product, test.platform, test.want, got)
+		}
+	}
+}
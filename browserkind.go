@@ -0,0 +1,164 @@
+package chromedp
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mailru/easyjson"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+)
+
+// BrowserKind is the kind of a browser, which determines the features it
+// supports.
+type BrowserKind int
+
+// BrowserKind values.
+const (
+	// BrowserUnknown is the kind of a browser which could not be detected.
+	BrowserUnknown BrowserKind = iota
+	// BrowserHeadlessShell is the kind of chrome-headless-shell, and of the
+	// old headless mode of Chrome, which lack the resources of Chrome, such
+	// as its default PDF templates, and the window.chrome object.
+	BrowserHeadlessShell
+	// BrowserHeadlessNew is the kind of Chrome in the new headless mode,
+	// which is the full browser without a window.
+	BrowserHeadlessNew
+	// BrowserHeadful is the kind of Chrome with windows.
+	BrowserHeadful
+)
+
+// String satisfies the fmt.Stringer interface.
+func (k BrowserKind) String() string {
+	switch k {
+	case BrowserHeadlessShell:
+		return "headless-shell"
+	case BrowserHeadlessNew:
+		return "headless=new"
+	case BrowserHeadful:
+		return "headful"
+	}
+	return "unknown"
+}
+
+// Kind returns the kind of the browser, detected when it was allocated.
+func (b *Browser) Kind() BrowserKind {
+	return b.kind
+}
+
+// WithFeatureWarning is a browser option to call fn when an action uses a
+// feature which is not available in the browser, with the name of the feature
+// and the reason, such as the kind of the browser. fn is called once per
+// feature.
+//
+// When it's possible, chromedp works around the missing feature, for example
+// by providing the default PDF header and footer templates missing from
+// headless-shell.
+func WithFeatureWarning(fn func(feature, reason string)) BrowserOption {
+	return func(b *Browser) { b.featureWarning = fn }
+}
+
+// warnFeature calls the WithFeatureWarning func with feature, if it wasn't
+// already called with it.
+func (b *Browser) warnFeature(feature, reason string) {
+	if b.featureWarning == nil {
+		return
+	}
+	b.warnedMu.Lock()
+	if b.warned == nil {
+		b.warned = make(map[string]bool)
+	}
+	warned := b.warned[feature]
+	b.warned[feature] = true
+	b.warnedMu.Unlock()
+	if !warned {
+		b.featureWarning(feature, reason)
+	}
+}
+
+// detectBrowserKind detects the kind of the browser b from its product and its
+// command line. The command line is only available when the browser runs with
+// --enable-automation, as by DefaultExecAllocatorOptions.
+//
+// The detection doesn't block for longer than the dial timeout of b, so that
+// it doesn't hang the allocation of a browser which doesn't reply.
+func detectBrowserKind(ctx context.Context, b *Browser) BrowserKind {
+	if b.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.dialTimeout)
+		defer cancel()
+	}
+	ctx = cdp.WithExecutor(ctx, b)
+	_, product, _, _, _, err := browser.GetVersion().Do(ctx)
+	if err != nil {
+		return BrowserUnknown
+	}
+	args, _ := browser.GetBrowserCommandLine().Do(ctx)
+	return browserKindOf(product, args)
+}
+
+// browserKindOf returns the kind of the browser with product, such as
+// "HeadlessChrome/140.0.7339.207", started with the command line args, which
+// may be nil if it's not known.
+func browserKindOf(product string, args []string) BrowserKind {
+	name, version, _ := strings.Cut(product, "/")
+	if name != "HeadlessChrome" {
+		return BrowserHeadful
+	}
+	if len(args) == 0 {
+		// the old headless mode and headless-shell are the most
+		// limited, so assume them.
+		return BrowserHeadlessShell
+	}
+	if strings.Contains(strings.ToLower(filepath.Base(args[0])), "headless") {
+		// headless_shell, chrome-headless-shell
+		return BrowserHeadlessShell
+	}
+	for _, arg := range args[1:] {
+		switch arg {
+		case "--headless=new":
+			return BrowserHeadlessNew
+		case "--headless=old":
+			return BrowserHeadlessShell
+		}
+	}
+	// the old headless mode was the default one until it was moved out of
+	// Chrome 132 into headless-shell.
+	major, _ := strconv.Atoi(strings.Split(version, ".")[0])
+	if major >= 132 {
+		return BrowserHeadlessNew
+	}
+	return BrowserHeadlessShell
+}
+
+// Default PDF header and footer templates, as those of Chrome are not part of
+// headless-shell.
+const (
+	defaultPDFHeaderTemplate = `<div style="font-size: 8px; width: 100%; display: flex; justify-content: space-between; margin: 0 0.4cm;"><span class="date"></span><span class="title"></span></div>`
+	defaultPDFFooterTemplate = `<div style="font-size: 8px; width: 100%; display: flex; justify-content: space-between; margin: 0 0.4cm;"><span class="url"></span><span><span class="pageNumber"></span>/<span class="totalPages"></span></span></div>`
+)
+
+// adjustParams returns the params of the command method, adjusted to work
+// around the features missing from the browser.
+func (b *Browser) adjustParams(method string, params easyjson.Marshaler) easyjson.Marshaler {
+	if b.kind != BrowserHeadlessShell || method != page.CommandPrintToPDF {
+		return params
+	}
+	p, ok := params.(*page.PrintToPDFParams)
+	if !ok || !p.DisplayHeaderFooter || p.HeaderTemplate != "" && p.FooterTemplate != "" {
+		return params
+	}
+	b.warnFeature("default PDF header and footer templates", "not part of "+b.kind.String())
+	adjusted := *p
+	if adjusted.HeaderTemplate == "" {
+		adjusted.HeaderTemplate = defaultPDFHeaderTemplate
+	}
+	if adjusted.FooterTemplate == "" {
+		adjusted.FooterTemplate = defaultPDFFooterTemplate
+	}
+	return &adjusted
+}
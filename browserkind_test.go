@@ -0,0 +1,80 @@
+package chromedp
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+func TestBrowserKind(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	kind := FromContext(ctx).Browser.Kind()
+	if os.Getenv("HEADLESS_SHELL") != "" {
+		if kind != BrowserHeadlessShell {
+			t.Errorf("want kind %v, got %v", BrowserHeadlessShell, kind)
+		}
+	} else if kind == BrowserUnknown || kind == BrowserHeadlessShell {
+		t.Errorf("want the kind of Chrome, got %v", kind)
+	}
+}
+
+func TestBrowserKindOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		product string
+		args    []string
+		want    BrowserKind
+	}{
+		{"Chrome/130.0.6723.58", []string{"chrome"}, BrowserHeadful},
+		{"HeadlessChrome/140.0.7339.207", []string{"/opt/chrome-headless-shell", "--headless"}, BrowserHeadlessShell},
+		{"HeadlessChrome/130.0.6723.58", []string{"/usr/bin/headless_shell"}, BrowserHeadlessShell},
+		{"HeadlessChrome/130.0.6723.58", []string{"google-chrome", "--headless=new"}, BrowserHeadlessNew},
+		{"HeadlessChrome/130.0.6723.58", []string{"google-chrome", "--headless"}, BrowserHeadlessShell},
+		{"HeadlessChrome/131.0.6778.85", []string{"google-chrome", "--headless=old"}, BrowserHeadlessShell},
+		{"HeadlessChrome/132.0.6834.57", []string{"google-chrome", "--headless"}, BrowserHeadlessNew},
+		{"HeadlessChrome/132.0.6834.57", nil, BrowserHeadlessShell},
+	}
+	for _, test := range tests {
+		if got := browserKindOf(test.product, test.args); got != test.want {
+			t.Errorf("browserKindOf(%q, %q): want %v, got %v", test.product, test.args, test.want, got)
+		}
+	}
+}
+
+func TestAdjustParams(t *testing.T) {
+	t.Parallel()
+
+	var warnings []string
+	b := &Browser{kind: BrowserHeadlessShell}
+	WithFeatureWarning(func(feature, reason string) {
+		warnings = append(warnings, feature+": "+reason)
+	})(b)
+
+	params := page.PrintToPDF().WithDisplayHeaderFooter(true).WithFooterTemplate("<span>footer</span>")
+	for i := 0; i < 2; i++ {
+		got, ok := b.adjustParams(page.CommandPrintToPDF, params).(*page.PrintToPDFParams)
+		if !ok {
+			t.Fatal("want PrintToPDF params")
+		}
+		if got.HeaderTemplate != defaultPDFHeaderTemplate || got.FooterTemplate != "<span>footer</span>" {
+			t.Errorf("want the default header template only, got %q and %q", got.HeaderTemplate, got.FooterTemplate)
+		}
+	}
+	if params.HeaderTemplate != "" {
+		t.Error("want the params to be left unchanged")
+	}
+	if want := "default PDF header and footer templates: not part of headless-shell"; len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("want a single warning %q, got %q", want, warnings)
+	}
+
+	b.kind = BrowserHeadlessNew
+	if got := b.adjustParams(page.CommandPrintToPDF, params); got != params {
+		t.Error("want the params to be unchanged for Chrome")
+	}
+}
@@ -3,6 +3,7 @@ package chromedp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/chromedp/cdproto/runtime"
 )
@@ -20,7 +21,7 @@ type CallAction Action
 // - WithReturnByValue: it will be set depending on the type of res;
 // - WithArguments: pass the arguments with args instead.
 //
-// Note: any exception encountered will be returned as an error.
+// Note: any exception encountered will be returned as a [*JSError].
 func CallFunctionOn(functionDeclaration string, res interface{}, opt CallOption, args ...interface{}) CallAction {
 	return ActionFunc(func(ctx context.Context) error {
 		_, err := callFunctionOn(ctx, functionDeclaration, res, opt, args...)
@@ -29,6 +30,10 @@ func CallFunctionOn(functionDeclaration string, res interface{}, opt CallOption,
 }
 
 func callFunctionOn(ctx context.Context, functionDeclaration string, res interface{}, opt CallOption, args ...interface{}) (*runtime.RemoteObject, error) {
+	if x, ok := res.(*limitedResult); ok {
+		return callFunctionLimited(ctx, functionDeclaration, x, opt, args...)
+	}
+
 	// set up parameters
 	p := runtime.CallFunctionOn(functionDeclaration).
 		WithSilent(true)
@@ -62,12 +67,37 @@ func callFunctionOn(ctx context.Context, functionDeclaration string, res interfa
 		return nil, err
 	}
 	if exp != nil {
-		return nil, exp
+		return nil, newJSError(ctx, exp)
 	}
 
 	return v, parseRemoteObject(v, res)
 }
 
+// callFunctionLimited calls the function with its result wrapped in an object
+// kept in the browser, as with evaluateWrapped, so that its size is checked
+// there by limitResult.
+func callFunctionLimited(ctx context.Context, functionDeclaration string, x *limitedResult, opt CallOption, args ...interface{}) (*runtime.RemoteObject, error) {
+	p := runtime.CallFunctionOn("")
+	if opt != nil {
+		p = opt(p)
+	}
+	wrapper := fmt.Sprintf(`function(...args) { return {value: (%s).apply(this, args)}; }`, functionDeclaration)
+	if p.AwaitPromise {
+		wrapper = fmt.Sprintf(`function(...args) { return Promise.resolve((%s).apply(this, args)).then(value => ({value})); }`, functionDeclaration)
+	}
+	byRef := func(p *runtime.CallFunctionOnParams) *runtime.CallFunctionOnParams {
+		if opt != nil {
+			p = opt(p)
+		}
+		return p.WithReturnByValue(false)
+	}
+	var v *runtime.RemoteObject
+	if _, err := callFunctionOn(ctx, wrapper, &v, byRef, args...); err != nil {
+		return nil, err
+	}
+	return limitResult(ctx, v, x)
+}
+
 // CallOption is a function to modify the runtime.CallFunctionOnParams
 // to provide more information.
 type CallOption = func(params *runtime.CallFunctionOnParams) *runtime.CallFunctionOnParams
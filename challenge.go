@@ -0,0 +1,62 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChallengeKind is the kind of a challenge of a bot protection, as detected by
+// DetectChallenge.
+type ChallengeKind string
+
+// ChallengeKind values.
+const (
+	// ChallengeCloudflare is the challenge of Cloudflare, either its "Just a
+	// moment..." interstitial, or a Turnstile widget.
+	ChallengeCloudflare ChallengeKind = "cloudflare"
+	// ChallengeRecaptcha is a reCAPTCHA widget, or the interstitial of
+	// Google when it detects unusual traffic.
+	ChallengeRecaptcha ChallengeKind = "recaptcha"
+	// ChallengeHCaptcha is an hCaptcha widget.
+	ChallengeHCaptcha ChallengeKind = "hcaptcha"
+)
+
+// ChallengeInfo is the challenge of the current page, as detected by
+// DetectChallenge.
+type ChallengeInfo struct {
+	// Kind is the kind of the challenge, or empty when none was detected.
+	Kind ChallengeKind `json:"kind,omitempty"`
+	// Interstitial reports whether the page is the challenge itself, which
+	// stands in for the requested page, rather than a page embedding a
+	// challenge widget, such as a form protected by a reCAPTCHA.
+	Interstitial bool `json:"interstitial,omitempty"`
+	// Evidence describes the signals the challenge was detected from, such
+	// as the title of the page, or the URL of a challenge iframe.
+	Evidence []string `json:"evidence,omitempty"`
+	// StatusCode is the HTTP status code of the page, such as 403 for the
+	// interstitials of Cloudflare, or 0 if it's not known.
+	StatusCode int64 `json:"status,omitempty"`
+}
+
+// Detected reports whether a challenge was detected.
+func (c *ChallengeInfo) Detected() bool {
+	return c.Kind != ""
+}
+
+// DetectChallenge is an action that detects the common challenges of the bot
+// protections on the current page, such as the Cloudflare interstitial, and
+// the reCAPTCHA and hCaptcha widgets, from the elements and the scripts of the
+// document, and from the status code of its response. It doesn't solve them;
+// it gives the crawlers a way to set aside the pages which need a human,
+// instead of waiting for the content of the page until a timeout.
+//
+// When no challenge is detected, the Kind of info is empty.
+func DetectChallenge(info *ChallengeInfo) Action {
+	if info == nil {
+		panic("info cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		*info = ChallengeInfo{}
+		return EvaluateAsDevTools(fmt.Sprintf(`(%s)()`, detectChallengeJS), info).Do(ctx)
+	})
+}
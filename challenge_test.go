@@ -0,0 +1,83 @@
+package chromedp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDetectChallenge(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cloudflare", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<html><head><title>Just a moment...</title></head><body>
+<form id="challenge-form" action="/"></form>
+<div class="cf-turnstile"></div>
+</body></html>`))
+	})
+	mux.HandleFunc("/recaptcha", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><form>
+<div class="g-recaptcha"><iframe src="/recaptcha/api2/anchor?k=key"></iframe></div>
+</form></body></html>`))
+	})
+	mux.HandleFunc("/hcaptcha", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><div class="h-captcha"></div></body></html>`))
+	})
+	mux.HandleFunc("/turnstile", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><div class="cf-turnstile"></div></body></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>plain</title></head><body><p>content</p></body></html>`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	tests := []struct {
+		path string
+		want ChallengeInfo
+	}{
+		{"/cloudflare", ChallengeInfo{
+			Kind:         ChallengeCloudflare,
+			Interstitial: true,
+			Evidence:     []string{`title "Just a moment..."`, "element #challenge-form", "element .cf-turnstile"},
+			StatusCode:   403,
+		}},
+		{"/recaptcha", ChallengeInfo{
+			Kind:       ChallengeRecaptcha,
+			Evidence:   []string{"iframe " + s.URL + "/recaptcha/api2/anchor?k=key", "element .g-recaptcha"},
+			StatusCode: 200,
+		}},
+		{"/hcaptcha", ChallengeInfo{
+			Kind:       ChallengeHCaptcha,
+			Evidence:   []string{"element .h-captcha"},
+			StatusCode: 200,
+		}},
+		{"/turnstile", ChallengeInfo{
+			Kind:       ChallengeCloudflare,
+			Evidence:   []string{"element .cf-turnstile"},
+			StatusCode: 200,
+		}},
+		{"/plain", ChallengeInfo{StatusCode: 200}},
+	}
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+	for _, test := range tests {
+		var info ChallengeInfo
+		if err := Run(ctx,
+			Navigate(s.URL+test.path),
+			DetectChallenge(&info),
+		); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(info, test.want) {
+			t.Errorf("%s: want %+v, got %+v", test.path, test.want, info)
+		}
+		if info.Detected() != (test.want.Kind != "") {
+			t.Errorf("%s: want Detected to be %t", test.path, test.want.Kind != "")
+		}
+	}
+}
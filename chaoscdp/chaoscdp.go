@@ -0,0 +1,221 @@
+// Package chaoscdp injects faults in the protocol traffic between chromedp and
+// the browser, such as lost command responses, late events, tab crashes and
+// lost connections, so that the programs using chromedp can test how they
+// recover from them. The faults are injected when asked for, and for a set
+// number of messages, so that the tests are deterministic.
+//
+// The faults apply to the messages of the browser set up with BrowserOptions,
+// and of all its targets; the tab crashes are caused with CrashTarget.
+//
+//	in := chaoscdp.New()
+//	ctx, cancel := chromedp.NewContext(context.Background(),
+//		chromedp.WithBrowserOption(in.BrowserOptions()...),
+//	)
+//	defer cancel()
+//	// the next Runtime.evaluate is never replied to.
+//	in.DropResponses(runtime.CommandEvaluate, 1)
+//	err := chromedp.Run(ctx, chromedp.Evaluate(`1+1`, nil))
+//
+// An Injector can set up several browsers at once, such as those of the tests
+// of a package.
+package chaoscdp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/page"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Injector injects the faults it's asked for in the traffic of the browsers
+// set up with its BrowserOptions. It's safe for concurrent use.
+type Injector struct {
+	mu       sync.Mutex
+	faults   []*fault
+	browsers []*browserState
+}
+
+// faultKind is the kind of a fault.
+type faultKind int
+
+const (
+	dropResponse faultKind = iota
+	delayEvent
+)
+
+// fault is a fault injected in the messages of method, for left messages, or
+// for all of them if left is negative.
+type fault struct {
+	kind   faultKind
+	method string
+	delay  time.Duration
+	left   int
+}
+
+// browserState is the state of a browser set up by an Injector.
+type browserState struct {
+	conn chromedp.Transport
+
+	// dropped holds the ids of the commands whose responses are dropped.
+	dropped map[int64]bool
+}
+
+// New creates an Injector, which injects no faults until asked to.
+func New() *Injector {
+	return &Injector{}
+}
+
+// BrowserOptions returns the browser options that set up a browser for the
+// faults of in, to be used with chromedp.WithBrowserOption, or with
+// chromedp.NewBrowser. They must be used for a single browser.
+func (in *Injector) BrowserOptions() []chromedp.BrowserOption {
+	b := &browserState{dropped: make(map[int64]bool)}
+	return []chromedp.BrowserOption{
+		chromedp.WithTransportWrapper(func(conn chromedp.Transport) chromedp.Transport {
+			in.mu.Lock()
+			defer in.mu.Unlock()
+			b.conn = conn
+			in.browsers = append(in.browsers, b)
+			return conn
+		}),
+		chromedp.WithMessageInterceptor(func(dir chromedp.Direction, msg *cdproto.Message) *cdproto.Message {
+			return in.intercept(b, dir, msg)
+		}),
+	}
+}
+
+// DropResponses drops the responses to the next n commands of method sent to
+// the browser or to any of its targets, such as runtime.CommandEvaluate, or to
+// all of them if n is negative. The browser still runs the commands, but the
+// actions executing them block until their context is done, or until the
+// timeout of chromedp.WithCommandTimeout.
+func (in *Injector) DropResponses(method string, n int) {
+	in.addFault(&fault{kind: dropResponse, method: method, left: n})
+}
+
+// DelayEvents delays the next n events of method, such as
+// "Page.loadEventFired", by d, or all of them if n is negative. The messages
+// received after a delayed event are delayed as well, so that they're still
+// handled in order.
+func (in *Injector) DelayEvents(method string, d time.Duration, n int) {
+	in.addFault(&fault{kind: delayEvent, method: method, delay: d, left: n})
+}
+
+// Reset removes the faults which are still to be injected. The responses to
+// the commands already sent are still dropped.
+func (in *Injector) Reset() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.faults = nil
+}
+
+// Disconnect closes the connections to the browsers set up by in, as if they
+// were lost; chromedp closes the LostConnection channel of the browsers, and
+// cancels their contexts.
+func (in *Injector) Disconnect() {
+	in.mu.Lock()
+	browsers := in.browsers
+	in.browsers = nil
+	in.mu.Unlock()
+	for _, b := range browsers {
+		b.conn.Close()
+	}
+}
+
+func (in *Injector) addFault(f *fault) {
+	if f.left == 0 {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.faults = append(in.faults, f)
+}
+
+// take returns the first pending fault of kind for method, and counts it as
+// injected.
+func (in *Injector) take(kind faultKind, method string) *fault {
+	for i, f := range in.faults {
+		if f.kind != kind || f.method != method {
+			continue
+		}
+		if f.left > 0 {
+			if f.left--; f.left == 0 {
+				in.faults = append(in.faults[:i:i], in.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// intercept is the message interceptor of the browser b.
+func (in *Injector) intercept(b *browserState, dir chromedp.Direction, msg *cdproto.Message) *cdproto.Message {
+	in.mu.Lock()
+	switch {
+	case dir == chromedp.DirectionOutgoing:
+		if in.take(dropResponse, string(msg.Method)) != nil {
+			b.dropped[msg.ID] = true
+		}
+
+	case msg.ID != 0:
+		if b.dropped[msg.ID] {
+			delete(b.dropped, msg.ID)
+			in.mu.Unlock()
+			return nil
+		}
+
+	case msg.Method != "":
+		if f := in.take(delayEvent, string(msg.Method)); f != nil {
+			in.mu.Unlock()
+			time.Sleep(f.delay)
+			return msg
+		}
+	}
+	in.mu.Unlock()
+	return msg
+}
+
+// CrashTarget crashes the renderer of the current tab of ctx, as if it ran out
+// of memory, and returns once chromedp received the Inspector.targetCrashed
+// event. The tab is left open, but it doesn't reply to the commands anymore,
+// until it's reloaded.
+func CrashTarget(ctx context.Context) error {
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		cctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		crashed := make(chan struct{})
+		var once sync.Once
+		chromedp.ListenTarget(cctx, func(ev interface{}) {
+			if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+				once.Do(func() { close(crashed) })
+			}
+		})
+
+		// the command is never replied to, as the renderer crashes.
+		errc := make(chan error, 1)
+		go func() { errc <- page.Crash().Do(cctx) }()
+		select {
+		case <-crashed:
+			return nil
+		case err := <-errc:
+			if err == nil {
+				// the browser replied, but the renderer may
+				// still crash.
+				select {
+				case <-crashed:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}))
+}
@@ -0,0 +1,148 @@
+package chaoscdp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/runtime"
+
+	"github.com/chromedp/chromedp"
+)
+
+var allocCtx context.Context
+
+func TestMain(m *testing.M) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.DisableGPU)
+	if execPath := os.Getenv("CHROMEDP_TEST_RUNNER"); execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+	if noSandbox := os.Getenv("CHROMEDP_NO_SANDBOX"); noSandbox != "false" {
+		opts = append(opts, chromedp.NoSandbox)
+	}
+	var cancel context.CancelFunc
+	allocCtx, cancel = chromedp.NewExecAllocator(context.Background(), opts...)
+
+	code := m.Run()
+	cancel()
+	os.Exit(code)
+}
+
+func newContext(t *testing.T, in *Injector) context.Context {
+	t.Helper()
+	opts := append(in.BrowserOptions(), chromedp.WithCommandTimeout(time.Second))
+	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithBrowserOption(opts...))
+	t.Cleanup(cancel)
+	if err := chromedp.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func TestDropResponses(t *testing.T) {
+	t.Parallel()
+
+	in := New()
+	ctx := newContext(t, in)
+
+	in.DropResponses(runtime.CommandEvaluate, 1)
+	var res int
+	err := chromedp.Run(ctx, chromedp.Evaluate(`1+1`, &res))
+	if !errors.Is(err, chromedp.ErrCommandTimeout) {
+		t.Fatalf("want error %v, got %v", chromedp.ErrCommandTimeout, err)
+	}
+	// the fault is only injected once.
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`1+1`, &res)); err != nil || res != 2 {
+		t.Fatalf("want 2, got %d, %v", res, err)
+	}
+
+	in.DropResponses(runtime.CommandEvaluate, -1)
+	in.Reset()
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`1+1`, &res)); err != nil {
+		t.Fatalf("want the faults to be reset, got %v", err)
+	}
+}
+
+func TestDelayEvents(t *testing.T) {
+	t.Parallel()
+
+	in := New()
+	ctx := newContext(t, in)
+
+	const delay = 500 * time.Millisecond
+	in.DelayEvents(string(cdproto.EventRuntimeConsoleAPICalled), delay, 1)
+	got := make(chan time.Time, 2)
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if _, ok := ev.(*runtime.EventConsoleAPICalled); ok {
+			got <- time.Now()
+		}
+	})
+	start := time.Now()
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`console.log("delayed"); console.log("next")`, nil)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case at := <-got:
+			if d := at.Sub(start); d < delay {
+				t.Errorf("want the event %d after %v, got it after %v", i, delay, d)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("want the console events")
+		}
+	}
+}
+
+func TestCrashTarget(t *testing.T) {
+	t.Parallel()
+
+	in := New()
+	ctx := newContext(t, in)
+
+	crashed := make(chan bool, 1)
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			select {
+			case crashed <- true:
+			default:
+			}
+		}
+	})
+	if err := CrashTarget(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-crashed:
+	default:
+		t.Fatal("want the crash to be reported")
+	}
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`1`, nil)); !errors.Is(err, chromedp.ErrCommandTimeout) {
+		t.Errorf("want the crashed tab not to reply, got %v", err)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	t.Parallel()
+
+	in := New()
+	ctx := newContext(t, in)
+
+	in.Disconnect()
+	select {
+	case <-chromedp.FromContext(ctx).Browser.LostConnection:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the connection to be lost")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the context to be canceled")
+	}
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`1`, nil)); err == nil {
+		t.Error("want an error once disconnected")
+	}
+}
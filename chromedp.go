@@ -13,17 +13,16 @@ package chromedp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/cdproto/cdp"
-	"github.com/chromedp/cdproto/css"
-	"github.com/chromedp/cdproto/dom"
-	"github.com/chromedp/cdproto/inspector"
-	"github.com/chromedp/cdproto/log"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
@@ -49,6 +48,13 @@ type Context struct {
 	// unused page target, or create a new one.
 	targetID target.ID
 
+	// targetMatcher is set up by WithTargetMatcher. If non-nil, Run
+	// attaches to the first existing page it matches.
+	targetMatcher func(*target.Info) bool
+
+	// keepAliveOnCancel is set up by KeepAliveOnCancel.
+	keepAliveOnCancel bool
+
 	// createBrowserContextParams is set up by WithNewBrowserContext. It is used
 	// to create a new BrowserContext.
 	createBrowserContextParams *target.CreateBrowserContextParams
@@ -74,6 +80,25 @@ type Context struct {
 	browserListeners []cancelableListener
 	targetListeners  []cancelableListener
 
+	// eventQueueSize and eventQueuePolicy are set up by WithEventQueue.
+	eventQueueSize   int
+	eventQueuePolicy EventQueuePolicy
+
+	// running is the semaphore held by Run while it runs actions, set up
+	// by WithSerializedActions and WithExclusiveActions. If nil, Run calls
+	// are not coordinated.
+	running chan struct{}
+	// exclusive is set up by WithExclusiveActions, to fail concurrent Run
+	// calls instead of queueing them.
+	exclusive bool
+
+	// persistEmulation is set up by WithPersistentEmulation, and
+	// emulationParent is the parent context with the option, if any.
+	persistEmulation bool
+	emulationParent  *Context
+	// isolateNetwork is set up by WithIsolatedNetworkConditions.
+	isolateNetwork bool
+
 	// browserOpts holds the browser options passed to NewContext via
 	// WithBrowserOption, so that they can later be used when allocating a
 	// browser in Run.
@@ -99,10 +124,25 @@ type Context struct {
 	// and remains nil.
 	allocated chan struct{}
 
-	// cancelErr is the first error encountered when cancelling this
-	// context, for example if a browser's temporary user data directory
-	// couldn't be deleted.
+	// cancelErr holds the errors encountered when cancelling this context,
+	// for example if a browser's temporary user data directory couldn't be
+	// deleted.
 	cancelErr error
+
+	// cleanupRegistered is set when the cleanup of Target is registered
+	// with Browser, which waits for it before closing the connection of a
+	// remote browser, or before recycling the browser.
+	cleanupRegistered bool
+
+	// recycler is set up when the browser is allocated by an ExecAllocator
+	// with WithRecycleAfter, and is inherited by the child contexts.
+	recycler *recycler
+
+	// uploadMu protects uploadDirs, the temporary directories holding the
+	// files written by SetUploadBytes, which are removed once the context is
+	// done.
+	uploadMu   sync.Mutex
+	uploadDirs []string
 }
 
 // NewContext creates a chromedp context from the parent context. The parent
@@ -127,7 +167,12 @@ func NewContext(parent context.Context, opts ...ContextOption) (context.Context,
 	if pc := FromContext(parent); pc != nil {
 		c.Allocator = pc.Allocator
 		c.Browser = pc.Browser
+		c.recycler = pc.recycler
 		parentBrowserContextID = pc.BrowserContextID
+		if pc.persistEmulation {
+			c.persistEmulation = true
+			c.emulationParent = pc
+		}
 		// don't inherit Target, so that NewContext can be used to
 		// create a new tab on the same browser.
 
@@ -150,17 +195,24 @@ func NewContext(parent context.Context, opts ...ContextOption) (context.Context,
 	if c.createBrowserContextParams != nil && c.BrowserContextID != "" {
 		panic("WithExistingBrowserContext can not be used when WithNewBrowserContext is specified")
 	}
-	if c.targetID == "" {
+	if c.targetID != "" && c.targetMatcher != nil {
+		panic("WithTargetMatcher can not be used when WithTargetID is specified")
+	}
+	if c.targetID == "" && c.targetMatcher == nil {
 		if c.BrowserContextID == "" {
 			// Inherit BrowserContextID from its parent context.
 			c.BrowserContextID = parentBrowserContextID
 		}
 	} else {
+		opt := "WithTargetID"
+		if c.targetMatcher != nil {
+			opt = "WithTargetMatcher"
+		}
 		if c.createBrowserContextParams != nil {
-			panic("WithNewBrowserContext can not be used when WithTargetID is specified")
+			panic("WithNewBrowserContext can not be used when " + opt + " is specified")
 		}
 		if c.BrowserContextID != "" {
-			panic("WithExistingBrowserContext can not be used when WithTargetID is specified")
+			panic("WithExistingBrowserContext can not be used when " + opt + " is specified")
 		}
 	}
 
@@ -173,6 +225,12 @@ func NewContext(parent context.Context, opts ...ContextOption) (context.Context,
 	go func() {
 		<-ctx.Done()
 		defer c.closedTarget.Done()
+		// remove the uploaded files once the page which could read them is
+		// closed.
+		defer c.removeUploadDirs()
+		if c.cleanupRegistered {
+			defer c.Browser.cleanups.Done()
+		}
 		if c.first {
 			// This is the original browser tab, so the entire
 			// browser will already be cleaned up elsewhere.
@@ -185,29 +243,7 @@ func NewContext(parent context.Context, opts ...ContextOption) (context.Context,
 			return
 		}
 
-		// Not the original browser tab; simply detach and close it.
-		// We need a new context, as ctx is cancelled; use a 1s timeout.
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		browserExecutor := cdp.WithExecutor(ctx, c.Browser)
-		if id := c.Target.SessionID; id != "" {
-			action := target.DetachFromTarget().WithSessionID(id)
-			if err := action.Do(browserExecutor); c.cancelErr == nil && err != nil {
-				c.cancelErr = err
-			}
-		}
-		if id := c.Target.TargetID; id != "" {
-			action := target.CloseTarget(id)
-			if err := action.Do(browserExecutor); c.cancelErr == nil && err != nil {
-				c.cancelErr = err
-			}
-		}
-		if c.browserContextOwner {
-			action := target.DisposeBrowserContext(c.BrowserContextID)
-			if err := action.Do(browserExecutor); c.cancelErr == nil && err != nil {
-				c.cancelErr = err
-			}
-		}
+		c.cleanupTarget()
 	}()
 	cancelWait := func() {
 		cancel()
@@ -220,6 +256,106 @@ func NewContext(parent context.Context, opts ...ContextOption) (context.Context,
 	return ctx, cancelWait
 }
 
+// targetCleanupTimeout is the timeout of each of the commands closing the
+// target of a cancelled context.
+const targetCleanupTimeout = time.Second
+
+// cleanupTarget detaches from the target of the cancelled context, closes its
+// page unless it's to be kept, and disposes the BrowserContext the context
+// owns. The errors are collected in cancelErr.
+func (c *Context) cleanupTarget() {
+	// We need new contexts, as ctx is cancelled; each command has its own
+	// timeout, so that a stuck one doesn't prevent the others.
+	do := func(action Action) error {
+		select {
+		case <-c.Browser.LostConnection:
+			return nil
+		default:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), targetCleanupTimeout)
+		defer cancel()
+		return action.Do(cdp.WithExecutor(ctx, c.Browser))
+	}
+	var errs []error
+	// detach first, so that the target is forgotten by the browser before
+	// its page is closed.
+	// a target lost once the browser reconnected is gone already.
+	sessionID, lost := c.Target.session()
+	if id := sessionID; id != "" && !lost {
+		if err := do(target.DetachFromTarget().WithSessionID(id)); err != nil {
+			errs = append(errs, fmt.Errorf("could not detach from session %s: %w", id, err))
+		}
+	}
+	if id := c.Target.TargetID; id != "" && !lost && !c.detachOnly() {
+		if err := do(target.CloseTarget(id)); err != nil {
+			errs = append(errs, fmt.Errorf("could not close target %s: %w", id, err))
+		}
+	}
+	if c.browserContextOwner {
+		if err := do(target.DisposeBrowserContext(c.BrowserContextID)); err != nil {
+			errs = append(errs, fmt.Errorf("could not dispose browser context %s: %w", c.BrowserContextID, err))
+		}
+	}
+	c.cancelErr = errors.Join(append([]error{c.cancelErr}, errs...)...)
+}
+
+// writeUploadFiles writes the files to a new temporary directory, removed
+// once the context is done, and returns their paths in the order of names.
+func (c *Context) writeUploadFiles(files map[string][]byte, names []string) ([]string, error) {
+	dir, err := os.MkdirTemp("", "chromedp-upload")
+	if err != nil {
+		return nil, err
+	}
+	c.uploadMu.Lock()
+	c.uploadDirs = append(c.uploadDirs, dir)
+	c.uploadMu.Unlock()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[i], files[name], 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// removeUploadDirs removes the temporary directories written by
+// SetUploadBytes. The errors are collected in cancelErr, except for the
+// context owning the browser, whose cancelErr is set up when the browser
+// stops.
+func (c *Context) removeUploadDirs() {
+	c.uploadMu.Lock()
+	dirs := c.uploadDirs
+	c.uploadDirs = nil
+	c.uploadMu.Unlock()
+	var errs []error
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !c.first {
+		c.cancelErr = errors.Join(append([]error{c.cancelErr}, errs...)...)
+	}
+}
+
+// detachOnly reports whether the context only detaches from its target when
+// it's cancelled, as set up by KeepAliveOnCancel or WithDetachOnly.
+func (c *Context) detachOnly() bool {
+	if c.keepAliveOnCancel {
+		return true
+	}
+	a, ok := c.Allocator.(*RemoteAllocator)
+	return ok && a.detachOnly
+}
+
+// inPageUploads reports whether the files to upload are created in the pages,
+// as set up by WithInPageUploads.
+func (c *Context) inPageUploads() bool {
+	a, ok := c.Allocator.(*RemoteAllocator)
+	return ok && a.inPageUploads
+}
+
 type contextKey struct{}
 
 // FromContext extracts the Context data stored inside a context.Context.
@@ -297,12 +433,28 @@ func initContextBrowser(ctx context.Context) (*Context, error) {
 		return nil, ErrInvalidContext
 	}
 	if c.Browser == nil {
+		if a, ok := c.Allocator.(*ExecAllocator); ok && a.recycles() {
+			if err := c.startRecycler(ctx, a); err != nil {
+				return nil, err
+			}
+			return c, nil
+		}
 		b, err := c.Allocator.Allocate(ctx, c.browserOpts...)
 		if err != nil {
 			return nil, err
 		}
 		c.Browser = b
+		c.Browser.listenersMu.Lock()
 		c.Browser.listeners = append(c.Browser.listeners, c.browserListeners...)
+		c.Browser.listenersMu.Unlock()
+	} else if c.recycler != nil && c.Target == nil && !c.cleanupRegistered {
+		// a new tab, in a new browser if the current one is recycled.
+		b, err := c.recycler.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.Browser = b
+		c.cleanupRegistered = true
 	}
 	return c, nil
 }
@@ -322,11 +474,36 @@ func initContextBrowser(ctx context.Context) (*Context, error) {
 //		id, err := target.CreateBrowserContext().Do(cdp.WithExecutor(ctx, c.Browser))
 //		return err
 //	}))
+//
+// Run may be called concurrently on the same context, for example to handle a
+// JavaScript dialog from a ListenTarget callback while a click is blocked on
+// it. The commands of the concurrent calls are then interleaved; see
+// WithSerializedActions and WithExclusiveActions to prevent it.
 func Run(ctx context.Context, actions ...Action) error {
 	c, err := initContextBrowser(ctx)
 	if err != nil {
 		return err
 	}
+	if c.running != nil {
+		if ctx.Value(runningKey{}) == c {
+			return ErrNestedRun
+		}
+		ctx = context.WithValue(ctx, runningKey{}, c)
+		if c.exclusive {
+			select {
+			case c.running <- struct{}{}:
+			default:
+				return ErrConcurrentRun
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case c.running <- struct{}{}:
+			}
+		}
+		defer func() { <-c.running }()
+	}
 	if c.Target == nil {
 		if err := c.newTarget(ctx); err != nil {
 			return err
@@ -335,7 +512,18 @@ func Run(ctx context.Context, actions ...Action) error {
 	return Tasks(actions).Do(cdp.WithExecutor(ctx, c.Target))
 }
 
+// runningKey is the context key of the context whose semaphore is held by the
+// Run call running the actions, to detect nested Run calls on it.
+type runningKey struct{}
+
 func (c *Context) newTarget(ctx context.Context) error {
+	if c.targetMatcher != nil && c.targetID == "" {
+		id, err := c.matchTarget(ctx)
+		if err != nil {
+			return err
+		}
+		c.targetID = id
+	}
 	if c.targetID != "" {
 		if err := c.attachTarget(ctx, c.targetID); err != nil {
 			return err
@@ -422,18 +610,38 @@ func (c *Context) newTarget(ctx context.Context) error {
 	return c.attachTarget(ctx, c.targetID)
 }
 
+// matchTarget returns the ID of the first existing page matched by the
+// WithTargetMatcher func.
+func (c *Context) matchTarget(ctx context.Context) (target.ID, error) {
+	infos, err := target.GetTargets().Do(cdp.WithExecutor(ctx, c.Browser))
+	if err != nil {
+		return "", err
+	}
+	for _, info := range infos {
+		if info.Type == "page" && c.targetMatcher(info) {
+			return info.TargetID, nil
+		}
+	}
+	return "", ErrNoMatchingTarget
+}
+
 func (c *Context) attachTarget(ctx context.Context, targetID target.ID) error {
 	sessionID, err := target.AttachToTarget(targetID).WithFlatten(true).Do(cdp.WithExecutor(ctx, c.Browser))
 	if err != nil {
 		return err
 	}
 
-	c.Target, err = c.Browser.newExecutorForTarget(ctx, targetID, sessionID)
+	c.Target, err = c.Browser.newExecutorForTarget(ctx, targetID, sessionID, newEventQueue(c.eventQueueSize, c.eventQueuePolicy))
 	if err != nil {
 		return err
 	}
 
 	c.Target.listeners = append(c.Target.listeners, c.targetListeners...)
+	c.Target.persistEmulation = c.persistEmulation
+	c.Target.isolateNetwork = c.isolateNetwork
+	if !c.first && !c.cleanupRegistered {
+		c.cleanupRegistered = c.Browser.addCleanup()
+	}
 	go c.Target.run(ctx)
 
 	// Check if this is a worker target. We cannot use Target.getTargetInfo or
@@ -448,27 +656,12 @@ func (c *Context) attachTarget(ctx context.Context, targetID target.ID) error {
 	}
 	c.Target.isWorker = strings.Contains(res.ClassName, "WorkerGlobalScope")
 
-	// Enable available domains and discover targets.
-	actions := []Action{
-		log.Enable(),
-		network.Enable(),
-	}
-	// These actions are not available on a worker target.
-	if !c.Target.isWorker {
-		actions = append(actions, []Action{
-			inspector.Enable(),
-			page.Enable(),
-			dom.Enable(),
-			css.Enable(),
-			target.SetDiscoverTargets(true),
-			target.SetAutoAttach(true, false).WithFlatten(true),
-			page.SetLifecycleEventsEnabled(true),
-		}...)
+	if err := c.Target.enableDomains(ctx); err != nil {
+		return err
 	}
-
-	for _, action := range actions {
-		if err := action.Do(cdp.WithExecutor(ctx, c.Target)); err != nil {
-			return fmt.Errorf("unable to execute %T: %w", action, err)
+	if p := c.emulationParent; p != nil && p.Target != nil && !c.Target.isWorker {
+		if err := c.Target.applyEmulation(ctx, p.Target.currentEmulation()); err != nil {
+			return fmt.Errorf("unable to apply the emulation: %w", err)
 		}
 	}
 	return nil
@@ -483,6 +676,34 @@ func WithTargetID(id target.ID) ContextOption {
 	return func(c *Context) { c.targetID = id }
 }
 
+// WithTargetMatcher sets up a context to be attached to the first existing
+// page matched by fn, instead of creating a new one. It's an alternative to
+// WithTargetID when the ID of the page isn't known ahead of time, such as
+// with a remote browser:
+//
+//	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithTargetMatcher(func(info *target.Info) bool {
+//		return strings.HasPrefix(info.URL, "https://example.com/")
+//	}))
+//
+// The first Run on the context fails with ErrNoMatchingTarget if no page
+// matches. See KeepAliveOnCancel to leave the page open once the context is
+// cancelled.
+func WithTargetMatcher(fn func(*target.Info) bool) ContextOption {
+	return func(c *Context) { c.targetMatcher = fn }
+}
+
+// KeepAliveOnCancel sets up a context to only detach from its target when it's
+// cancelled, instead of closing it. This is useful with WithTargetID, to
+// control an existing tab of a browser shared with other tools, without ever
+// closing it.
+//
+// It has no effect on the first context of a browser allocated by an
+// ExecAllocator, as cancelling it closes the entire browser. See WithDetachOnly
+// to apply it to all the contexts of a RemoteAllocator.
+func KeepAliveOnCancel() ContextOption {
+	return func(c *Context) { c.keepAliveOnCancel = true }
+}
+
 // CreateBrowserContextOption is a BrowserContext creation options.
 type CreateBrowserContextOption = func(*target.CreateBrowserContextParams) *target.CreateBrowserContextParams
 
@@ -542,6 +763,35 @@ func WithBrowserOption(opts ...BrowserOption) ContextOption {
 	}
 }
 
+// WithSerializedActions sets up a context so that concurrent Run calls on it
+// are queued, each one running its actions once the previous ones are done,
+// instead of interleaving their commands.
+//
+// Note that the actions of a Run call can then not wait on another Run call on
+// the same context, such as one handling a JavaScript dialog opened by a
+// click; use a separate goroutine with its own context for those, or don't use
+// this option. Likewise, calling Run on the same context from an action
+// deadlocks, as it waits for the Run call running the action. It returns
+// ErrNestedRun instead when the context given to the action, or one derived
+// from it, is passed to the nested call; the deadlock can't be detected when
+// the nested call is passed the context of the outer Run call instead.
+func WithSerializedActions() ContextOption {
+	return func(c *Context) {
+		c.running = make(chan struct{}, 1)
+		c.exclusive = false
+	}
+}
+
+// WithExclusiveActions sets up a context so that a Run call on it fails with
+// ErrConcurrentRun while another Run call is running actions on it. It is
+// useful to catch unintended concurrent use of a context.
+func WithExclusiveActions() ContextOption {
+	return func(c *Context) {
+		c.running = make(chan struct{}, 1)
+		c.exclusive = true
+	}
+}
+
 // RunResponse is an alternative to Run which can be used with a list of actions
 // that trigger a page navigation, such as clicking on a link or button.
 //
@@ -705,6 +955,16 @@ func Targets(ctx context.Context) ([]*target.Info, error) {
 	return target.GetTargets().Do(cdp.WithExecutor(ctx, c.Browser))
 }
 
+// TargetInfo returns the current info of the target of ctx, such as the title
+// and the URL of its tab. It allocates the browser and the tab if needed.
+func TargetInfo(ctx context.Context) (*target.Info, error) {
+	c := FromContext(ctx)
+	if err := Run(ctx); err != nil {
+		return nil, err
+	}
+	return target.GetTargetInfo().WithTargetID(c.Target.TargetID).Do(cdp.WithExecutor(ctx, c.Browser))
+}
+
 // Action is the common interface for an action that will be executed against a
 // context and frame handler.
 type Action interface {
@@ -827,6 +1087,116 @@ func ListenTarget(ctx context.Context, fn func(ev interface{})) {
 	}
 }
 
+// OnTargetCreated calls fn with the info of every target created in the browser
+// of ctx, such as a tab or a worker, until ctx is cancelled. It allocates the
+// browser if needed, and enables the discovery of its targets. The targets
+// existing at the time of the call are not reported, whether the discovery was
+// already enabled or not.
+//
+// As with ListenBrowser, fn is called synchronously and should avoid blocking.
+func OnTargetCreated(ctx context.Context, fn func(info *target.Info)) error {
+	return listenTargetLifecycle(ctx, fn, nil)
+}
+
+// OnTargetDestroyed calls fn with the last known info of every target of the
+// browser of ctx which is destroyed, such as a closed tab, until ctx is
+// cancelled. It allocates the browser if needed, and enables the discovery of
+// its targets.
+//
+// As with ListenBrowser, fn is called synchronously and should avoid blocking.
+func OnTargetDestroyed(ctx context.Context, fn func(info *target.Info)) error {
+	return listenTargetLifecycle(ctx, nil, fn)
+}
+
+// OnTargetInfoChanged calls fn with the new info of the target of ctx whenever
+// it changes, such as when its tab navigates to another URL, until ctx is
+// cancelled. It allocates the browser and the tab if needed, and enables the
+// discovery of the targets of the browser.
+//
+// As with ListenBrowser, fn is called synchronously and should avoid blocking.
+func OnTargetInfoChanged(ctx context.Context, fn func(info *target.Info)) error {
+	c := FromContext(ctx)
+	if err := Run(ctx); err != nil {
+		return err
+	}
+	id := c.Target.TargetID
+	ListenBrowser(ctx, func(ev interface{}) {
+		if ev, ok := ev.(*target.EventTargetInfoChanged); ok && ev.TargetInfo.TargetID == id {
+			fn(ev.TargetInfo)
+		}
+	})
+	return target.SetDiscoverTargets(true).Do(cdp.WithExecutor(ctx, c.Browser))
+}
+
+// listenTargetLifecycle listens for the targets of the browser of ctx being
+// created and destroyed, keeping track of their info.
+func listenTargetLifecycle(ctx context.Context, created, destroyed func(*target.Info)) error {
+	c, err := initContextBrowser(ctx)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	infos := make(map[target.ID]*target.Info)
+	// existing holds the targets existing at the time of the call, which
+	// are reported again once the discovery is enabled, unless it already
+	// was, such as by the context of a new tab; they're skipped either way.
+	existing := make(map[target.ID]bool)
+	ListenBrowser(ctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *target.EventTargetCreated:
+			mu.Lock()
+			infos[ev.TargetInfo.TargetID] = ev.TargetInfo
+			skip := existing[ev.TargetInfo.TargetID]
+			delete(existing, ev.TargetInfo.TargetID)
+			mu.Unlock()
+			if created != nil && !skip {
+				created(ev.TargetInfo)
+			}
+		case *target.EventTargetInfoChanged:
+			mu.Lock()
+			infos[ev.TargetInfo.TargetID] = ev.TargetInfo
+			mu.Unlock()
+		case *target.EventTargetDestroyed:
+			mu.Lock()
+			info, ok := infos[ev.TargetID]
+			delete(infos, ev.TargetID)
+			delete(existing, ev.TargetID)
+			mu.Unlock()
+			if !ok {
+				info = &target.Info{TargetID: ev.TargetID}
+			}
+			if destroyed != nil {
+				destroyed(info)
+			}
+		}
+	})
+	// get the info of the existing targets before enabling the discovery,
+	// to report it when they are destroyed, and to tell them apart from
+	// the targets created afterwards.
+	browserExecutor := cdp.WithExecutor(ctx, c.Browser)
+	targets, err := target.GetTargets().Do(browserExecutor)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	for _, info := range targets {
+		if _, ok := infos[info.TargetID]; !ok {
+			infos[info.TargetID] = info
+			existing[info.TargetID] = true
+		}
+	}
+	mu.Unlock()
+	if err := target.SetDiscoverTargets(true).Do(browserExecutor); err != nil {
+		return err
+	}
+	// the existing targets are only reported again if the discovery wasn't
+	// enabled yet, which the reply follows.
+	mu.Lock()
+	clear(existing)
+	mu.Unlock()
+	return nil
+}
+
 // WaitNewTarget can be used to wait for the current target to open a new
 // target. Once fn matches a new unattached target, its target ID is sent via
 // the returned channel.
@@ -353,6 +353,111 @@ func TestConcurrentCancel(t *testing.T) {
 	wg.Wait()
 }
 
+func TestSerializedActions(t *testing.T) {
+	t.Parallel()
+
+	tctx, tcancel := testAllocate(t, "")
+	defer tcancel()
+	ctx, cancel := NewContext(tctx, WithSerializedActions())
+	defer cancel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, ActionFunc(func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		}))
+	}()
+	<-started
+
+	var ran atomic.Bool
+	second := make(chan error, 1)
+	go func() {
+		second <- Run(ctx, ActionFunc(func(context.Context) error {
+			ran.Store(true)
+			return nil
+		}))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if ran.Load() {
+		t.Fatal("want the second Run to wait for the first one")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+	if !ran.Load() {
+		t.Fatal("want the second Run to run once the first one is done")
+	}
+}
+
+func TestSerializedActionsNested(t *testing.T) {
+	t.Parallel()
+
+	tctx, tcancel := testAllocate(t, "")
+	defer tcancel()
+	ctx, cancel := NewContext(tctx, WithSerializedActions())
+	defer cancel()
+
+	var nested error
+	if err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		nested = Run(ctx, Evaluate(`1`, nil))
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(nested, ErrNestedRun) {
+		t.Errorf("want error %v, got %v", ErrNestedRun, nested)
+	}
+	// the nested Run of a child context doesn't wait on the parent one.
+	if err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		cctx, ccancel := NewContext(ctx)
+		defer ccancel()
+		return Run(cctx, Evaluate(`1`, nil))
+	})); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExclusiveActions(t *testing.T) {
+	t.Parallel()
+
+	tctx, tcancel := testAllocate(t, "")
+	defer tcancel()
+	ctx, cancel := NewContext(tctx, WithExclusiveActions())
+	defer cancel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, ActionFunc(func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		}))
+	}()
+	<-started
+
+	if err := Run(ctx, Evaluate(`1`, nil)); !errors.Is(err, ErrConcurrentRun) {
+		t.Errorf("want error %v, got %v", ErrConcurrentRun, err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// once the first Run is done, the context can be used again.
+	if err := Run(ctx, Evaluate(`1`, nil)); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestListenBrowser(t *testing.T) {
 	t.Parallel()
 
@@ -595,6 +700,282 @@ func TestLogOptions(t *testing.T) {
 	}
 }
 
+func TestCommandFilter(t *testing.T) {
+	t.Parallel()
+
+	errForbidden := errors.New("forbidden")
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithCommandFilter(func(method string) error {
+		if method == page.CommandCaptureScreenshot {
+			return errForbidden
+		}
+		return nil
+	})))
+	defer cancel()
+
+	if err := Run(ctx, Navigate(testdataDir+"/image.html")); err != nil {
+		t.Fatal(err)
+	}
+	var buf []byte
+	err := Run(ctx, CaptureScreenshot(&buf))
+	if !errors.Is(err, errForbidden) {
+		t.Fatalf("want error %v, got %v", errForbidden, err)
+	}
+}
+
+func TestKeepAliveOnCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	// the tab to share, which is closed by the last iteration.
+	tabCtx, cancel := NewContext(ctx)
+	defer cancel()
+	if err := Run(tabCtx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+	id := FromContext(tabCtx).Target.TargetID
+	targetOpen := func() bool {
+		infos, err := Targets(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, info := range infos {
+			if info.TargetID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, keep := range []bool{true, false} {
+		opts := []ContextOption{WithTargetID(id)}
+		if keep {
+			opts = append(opts, KeepAliveOnCancel())
+		}
+		tctx, _ := NewContext(ctx, opts...)
+		var title string
+		if err := Run(tctx, Title(&title)); err != nil {
+			t.Fatal(err)
+		}
+		if err := Cancel(tctx); err != nil {
+			t.Fatal(err)
+		}
+		// closing a target is asynchronous.
+		got := targetOpen()
+		for start := time.Now(); got && !keep && time.Since(start) < time.Second; got = targetOpen() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got != keep {
+			t.Errorf("KeepAliveOnCancel=%v: want the target open=%v, got %v", keep, keep, got)
+		}
+	}
+}
+
+func TestTargetMatcher(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+	for _, name := range []string{"form.html", "image.html"} {
+		tabCtx, _ := NewContext(ctx)
+		if err := Run(tabCtx, Navigate(testdataDir+"/"+name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tctx, cancel := NewContext(ctx, WithTargetMatcher(func(info *target.Info) bool {
+		return strings.HasSuffix(info.URL, "/image.html")
+	}))
+	defer cancel()
+	var url string
+	if err := Run(tctx, Location(&url)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(url, "/image.html") {
+		t.Errorf("want the image.html page, got %q", url)
+	}
+
+	tctx, cancel = NewContext(ctx, WithTargetMatcher(func(info *target.Info) bool {
+		return info.Title == "no such title"
+	}))
+	defer cancel()
+	if err := Run(tctx); !errors.Is(err, ErrNoMatchingTarget) {
+		t.Errorf("want error %v, got %v", ErrNoMatchingTarget, err)
+	}
+}
+
+func TestTargetLifecycle(t *testing.T) {
+	t.Parallel()
+
+	// use a new browser, so that only the targets of the test are reported.
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the listeners are called synchronously, so they must not block.
+	listen := func(ch chan *target.Info) func(*target.Info) {
+		return func(info *target.Info) {
+			select {
+			case ch <- info:
+			default:
+			}
+		}
+	}
+
+	// the tab of ctx existed before the call, so it's not reported.
+	created := make(chan *target.Info, 16)
+	if err := OnTargetCreated(ctx, listen(created)); err != nil {
+		t.Fatal(err)
+	}
+	tabCtx, tabCancel := NewContext(ctx)
+	defer tabCancel()
+	if err := Run(tabCtx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+	id := FromContext(tabCtx).Target.TargetID
+	timeout := time.After(5 * time.Second)
+	for found := false; !found; {
+		select {
+		case info := <-created:
+			if info.TargetID == FromContext(ctx).Target.TargetID {
+				t.Errorf("want the existing tab not to be reported, got %q", info.TargetID)
+			}
+			if info.TargetID != id {
+				continue
+			}
+			if info.Type != "page" {
+				t.Errorf("want a page target, got %q", info.Type)
+			}
+			found = true
+		case <-timeout:
+			t.Fatal("want the tab to be reported created")
+		}
+	}
+
+	// the tab existed before the call, so its info is known.
+	destroyed := make(chan *target.Info, 16)
+	if err := OnTargetDestroyed(ctx, listen(destroyed)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Cancel(tabCtx); err != nil {
+		t.Fatal(err)
+	}
+	timeout = time.After(5 * time.Second)
+	for {
+		select {
+		case info := <-destroyed:
+			if info.TargetID != id {
+				continue
+			}
+			if !strings.HasSuffix(info.URL, "/form.html") {
+				t.Errorf("want the last URL of the tab, got %q", info.URL)
+			}
+			return
+		case <-timeout:
+			t.Fatal("want the tab to be reported destroyed")
+		}
+	}
+}
+
+func TestTargetCreatedExisting(t *testing.T) {
+	t.Parallel()
+
+	// attaching to an existing tab doesn't enable the discovery of the
+	// targets of the browser, which then reports the existing ones once it's
+	// enabled.
+	wsURL := startRemoteBrowser(t)
+	var existing target.ID
+	for id := range remoteTargets(t, wsURL) {
+		existing = id
+	}
+	allocCtx, cancel := NewRemoteAllocator(context.Background(), wsURL, WithDetachOnly())
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx, WithTargetID(existing))
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	created := make(chan target.ID, 16)
+	if err := OnTargetCreated(ctx, func(info *target.Info) {
+		select {
+		case created <- info.TargetID:
+		default:
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tabCtx, tabCancel := NewContext(ctx)
+	defer tabCancel()
+	if err := Run(tabCtx); err != nil {
+		t.Fatal(err)
+	}
+	id := FromContext(tabCtx).Target.TargetID
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-created:
+			if got == existing {
+				t.Fatalf("want the existing tab not to be reported, got %q", got)
+			}
+			if got == id {
+				return
+			}
+		case <-timeout:
+			t.Fatal("want the new tab to be reported created")
+		}
+	}
+}
+
+func TestTargetInfo(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	// the changes of the other tabs aren't reported.
+	otherCtx, otherCancel := NewContext(ctx)
+	defer otherCancel()
+
+	urls := make(chan string, 16)
+	if err := OnTargetInfoChanged(ctx, func(info *target.Info) {
+		if info.TargetID != FromContext(ctx).Target.TargetID {
+			t.Errorf("got the info of another target %q", info.TargetID)
+		}
+		select {
+		case urls <- info.URL:
+		default:
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(otherCtx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx, Navigate(testdataDir+"/image.html"), Evaluate(`document.title = 'changed'`, nil)); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for url := ""; !strings.HasSuffix(url, "/image.html"); {
+		select {
+		case url = <-urls:
+		case <-timeout:
+			t.Fatal("want the navigation to be reported")
+		}
+	}
+
+	info, err := TargetInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.TargetID != FromContext(ctx).Target.TargetID || info.Title != "changed" || !strings.HasSuffix(info.URL, "/image.html") {
+		t.Errorf("got the info %+v", info)
+	}
+}
+
 func TestBrowserContext(t *testing.T) {
 	ctx, cancel := testAllocate(t, "child1.html")
 	defer cancel()
@@ -786,6 +1167,19 @@ func TestBrowserContext(t *testing.T) {
 			wantDisposed: false,
 			wantPanic:    "WithExistingBrowserContext can not be used when WithTargetID is specified",
 		},
+		{
+			name: "WithNewBrowserContext when WithTargetMatcher is specified",
+			arrange: func(t *testing.T) (context.Context, context.CancelFunc, cdp.BrowserContextID) {
+				ctx, cancel := NewContext(browserCtx, WithTargetMatcher(func(*target.Info) bool { return true }), WithNewBrowserContext())
+				if err := Run(ctx); err != nil {
+					t.Fatal(err)
+				}
+
+				return ctx, cancel, rootBrowserContextID1
+			},
+			wantDisposed: false,
+			wantPanic:    "WithNewBrowserContext can not be used when WithTargetMatcher is specified",
+		},
 		{
 			name: "WithNewBrowserContext before Browser is initialized",
 			arrange: func(t *testing.T) (context.Context, context.CancelFunc, cdp.BrowserContextID) {
@@ -0,0 +1,193 @@
+// Package client provides a client for the HTTP endpoints of the DevTools
+// protocol, such as /json/version and /json/list, to orchestrate the targets
+// of a remote browser without a websocket connection.
+//
+// See https://chromedevtools.github.io/devtools-protocol/#endpoints.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Client is a client for the HTTP endpoints of a browser.
+type Client struct {
+	url         string
+	httpClient  *http.Client
+	versionPath string
+}
+
+// Option is a Client option.
+type Option = func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send the requests, which
+// defaults to http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.httpClient = c
+	}
+}
+
+// WithVersionPath sets the path of the version endpoint, which defaults to
+// "/json/version", for example when the browser is behind a proxy exposing it
+// at another path.
+func WithVersionPath(path string) Option {
+	return func(cl *Client) {
+		cl.versionPath = path
+	}
+}
+
+// New creates a client for the browser listening on the remote debugging
+// address urlstr, such as "http://127.0.0.1:9222". A "ws" or "wss" scheme is
+// replaced by "http" or "https", and the path of urlstr is ignored.
+func New(urlstr string, opts ...Option) *Client {
+	c := &Client{
+		url:         urlstr,
+		httpClient:  http.DefaultClient,
+		versionPath: "/json/version",
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Version is the version information of a browser, as returned by
+// /json/version.
+type Version struct {
+	Browser              string `json:"Browser"`
+	ProtocolVersion      string `json:"Protocol-Version"`
+	UserAgent            string `json:"User-Agent"`
+	V8Version            string `json:"V8-Version,omitempty"`
+	WebKitVersion        string `json:"WebKit-Version,omitempty"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// Target is a target of a browser, as returned by /json/list and /json/new.
+type Target struct {
+	ID                   string `json:"id"`
+	ParentID             string `json:"parentId,omitempty"`
+	Type                 string `json:"type"`
+	Title                string `json:"title"`
+	URL                  string `json:"url"`
+	Description          string `json:"description"`
+	FaviconURL           string `json:"faviconUrl,omitempty"`
+	DevtoolsFrontendURL  string `json:"devtoolsFrontendUrl,omitempty"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl,omitempty"`
+}
+
+// Version returns the version information of the browser, which includes
+// the websocket URL of the browser target.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	v := new(Version)
+	if err := c.do(ctx, "GET", c.versionPath, "", v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// List returns the targets of the browser, which are the pages, iframes,
+// workers and other targets users can attach to.
+func (c *Client) List(ctx context.Context) ([]*Target, error) {
+	var targets []*Target
+	if err := c.do(ctx, "GET", "/json/list", "", &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// New opens a new page target navigating to urlstr, or to "about:blank" if
+// urlstr is empty.
+func (c *Client) New(ctx context.Context, urlstr string) (*Target, error) {
+	t := new(Target)
+	// Chrome 111+ rejects GET requests to /json/new, and the URL to open
+	// is the whole query.
+	if err := c.do(ctx, "PUT", "/json/new", url.QueryEscape(urlstr), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Activate brings the page target with the given id to the front.
+func (c *Client) Activate(ctx context.Context, id string) error {
+	return c.do(ctx, "GET", "/json/activate/"+url.PathEscape(id), "", nil)
+}
+
+// Close closes the target with the given id.
+func (c *Client) Close(ctx context.Context, id string) error {
+	return c.do(ctx, "GET", "/json/close/"+url.PathEscape(id), "", nil)
+}
+
+// do sends a request to the endpoint at path, and decodes the JSON response
+// into v, unless v is nil.
+func (c *Client) do(ctx context.Context, method, path, rawQuery string, v interface{}) error {
+	u, err := c.endpoint(ctx, path)
+	if err != nil {
+		return err
+	}
+	// keep the query of the client URL, such as the token of a hosted
+	// browser.
+	switch {
+	case u.RawQuery == "":
+		u.RawQuery = rawQuery
+	case rawQuery != "":
+		u.RawQuery += "&" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, body)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// endpoint returns the URL of the endpoint at path.
+//
+// Since Chrome 66+, the "Host:" header of the requests must be either an IP
+// address, or "localhost", so the host of the client URL is resolved first.
+func (c *Client) endpoint(ctx context.Context, path string) (*url.URL, error) {
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if host != "localhost" && net.ParseIP(host) == nil {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		host = addrs[0].IP.String()
+	}
+	u.Host = net.JoinHostPort(host, port)
+	u.Path = path
+	return u, nil
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestServer returns a server faking the endpoints of a browser with a
+// single page target.
+func newTestServer(t *testing.T) *httptest.Server {
+	targets := []*Target{{ID: "PAGE", Type: "page", Title: "Blank", URL: "about:blank"}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /json/version", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&Version{
+			Browser:              "HeadlessChrome/130.0.0.0",
+			ProtocolVersion:      "1.3",
+			WebSocketDebuggerURL: "ws://" + r.Host + "/devtools/browser/ID",
+		})
+	})
+	mux.HandleFunc("GET /json/list", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(targets)
+	})
+	mux.HandleFunc("PUT /json/new", func(w http.ResponseWriter, r *http.Request) {
+		// as Chrome, unescape the whole query.
+		u, err := url.QueryUnescape(r.URL.RawQuery)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		t := &Target{ID: "NEW", Type: "page", URL: u}
+		if t.URL == "" {
+			t.URL = "about:blank"
+		}
+		targets = append(targets, t)
+		json.NewEncoder(w).Encode(t)
+	})
+	mux.HandleFunc("GET /json/activate/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "PAGE" {
+			http.Error(w, "No such target id: "+r.PathValue("id"), http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("Target activated"))
+	})
+	mux.HandleFunc("GET /json/close/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for i, t := range targets {
+			if t.ID == r.PathValue("id") {
+				targets = append(targets[:i], targets[i+1:]...)
+				w.Write([]byte("Target is closing"))
+				return
+			}
+		}
+		http.Error(w, "No such target id: "+r.PathValue("id"), http.StatusNotFound)
+	})
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+	ctx := context.Background()
+	// the scheme and the path are ignored.
+	c := New(strings.Replace(s.URL, "http://", "ws://", 1) + "/devtools/browser/ID")
+
+	v, err := c.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ws://" + strings.TrimPrefix(s.URL, "http://") + "/devtools/browser/ID"; v.WebSocketDebuggerURL != want {
+		t.Errorf("want websocket URL %q, got %q", want, v.WebSocketDebuggerURL)
+	}
+
+	target, err := c.New(ctx, "https://example.com/?q=a&b=c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/?q=a&b=c"; target.URL != want {
+		t.Errorf("want URL %q, got %q", want, target.URL)
+	}
+
+	if err := c.Activate(ctx, "PAGE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(ctx, "PAGE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(ctx, "PAGE"); err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("want a 404 error, got %v", err)
+	}
+
+	targets, err := c.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(targets, []*Target{target}) {
+		t.Errorf("want the new target only, got %v", targets)
+	}
+}
+
+func TestClientVersionPath(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /proxy/json/version", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&Version{
+			WebSocketDebuggerURL: "ws://" + r.Host + "/proxy/devtools/browser/ID",
+		})
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := New(s.URL).Version(ctx); err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("want a 404 error, got %v", err)
+	}
+	v, err := New(s.URL, WithVersionPath("/proxy/json/version")).Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ws://" + strings.TrimPrefix(s.URL, "http://") + "/proxy/devtools/browser/ID"; v.WebSocketDebuggerURL != want {
+		t.Errorf("want websocket URL %q, got %q", want, v.WebSocketDebuggerURL)
+	}
+}
+
+func TestClientQuery(t *testing.T) {
+	t.Parallel()
+
+	var queries []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /json/version", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		json.NewEncoder(w).Encode(&Version{WebSocketDebuggerURL: "ws://" + r.Host + "/devtools/browser/ID"})
+	})
+	mux.HandleFunc("PUT /json/new", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		json.NewEncoder(w).Encode(&Target{ID: "NEW", Type: "page"})
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+	ctx := context.Background()
+
+	c := New(s.URL + "/?token=secret")
+	if _, err := c.Version(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.New(ctx, "about:blank"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"token=secret", "token=secret&about%3Ablank"}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("want the queries %q, got %q", want, queries)
+	}
+}
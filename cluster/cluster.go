@@ -0,0 +1,378 @@
+// Package cluster runs tasks on a pool of browsers, which can be spread over
+// several machines via remote allocators. Each task runs in a new tab of one
+// of the browsers; the browsers are recycled after a number of tasks or once
+// they use too much memory, and the tasks are retried when their browser or
+// their tab crashes.
+//
+// Tasks are queued with Submit, which returns a Job to wait on, or run with
+// Do, which blocks until they're done. A task is given the context of its tab,
+// and returns its result, or an error which fails its job.
+//
+//	c, err := cluster.New(
+//		cluster.WithAllocator(func(ctx context.Context) (context.Context, context.CancelFunc) {
+//			return chromedp.NewExecAllocator(ctx, chromedp.DefaultExecAllocatorOptions[:]...)
+//		}, 4),
+//		cluster.WithRecycleAfterJobs(100),
+//		cluster.WithRetries(2),
+//	)
+//	if err != nil {
+//		// handle error
+//	}
+//	defer c.Close()
+//	title, err := c.Do(ctx, func(ctx context.Context) (interface{}, error) {
+//		var title string
+//		err := chromedp.Run(ctx, chromedp.Navigate("https://example.com"), chromedp.Title(&title))
+//		return title, err
+//	})
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/inspector"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrClosed is the error of the jobs submitted to a closed Cluster.
+var ErrClosed = errors.New("cluster closed")
+
+// ErrCrashed is the error of the jobs whose browser or tab crashed on their
+// last attempt.
+var ErrCrashed = errors.New("browser crashed")
+
+// defaultQueueSize is the default number of jobs queued before Submit blocks.
+const defaultQueueSize = 1024
+
+// AllocatorFunc creates an allocator context from ctx, such as with
+// chromedp.NewExecAllocator, or chromedp.NewRemoteAllocator to run the
+// browsers on another machine.
+type AllocatorFunc func(ctx context.Context) (context.Context, context.CancelFunc)
+
+// Task is a unit of work of a Cluster, run with the context of a new tab. The
+// tab is closed once the task returns.
+type Task func(ctx context.Context) (interface{}, error)
+
+// Cluster is a queue of tasks run by workers, each one with its own browser.
+type Cluster struct {
+	allocators    []workerAllocator
+	queueSize     int
+	retries       int
+	recycleJobs   int
+	recycleMemory int64
+	logf          func(string, ...interface{})
+
+	queue  chan *Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu guards closed, so that no job is queued once the queue is closed.
+	mu     sync.RWMutex
+	closed bool
+}
+
+type workerAllocator struct {
+	fn      AllocatorFunc
+	workers int
+}
+
+// Option is a Cluster option.
+type Option = func(*Cluster)
+
+// WithAllocator adds workers running browsers allocated by fn. It can be
+// used several times, for example with a remote allocator per machine.
+func WithAllocator(fn AllocatorFunc, workers int) Option {
+	return func(c *Cluster) {
+		c.allocators = append(c.allocators, workerAllocator{fn, workers})
+	}
+}
+
+// WithQueueSize sets the number of jobs queued before Submit blocks, which
+// defaults to 1024.
+func WithQueueSize(n int) Option {
+	return func(c *Cluster) {
+		c.queueSize = n
+	}
+}
+
+// WithRetries sets the number of times a job is retried when its browser or
+// its tab crashes, which defaults to 0. The errors returned by the tasks are
+// not retried.
+func WithRetries(n int) Option {
+	return func(c *Cluster) {
+		c.retries = n
+	}
+}
+
+// WithRecycleAfterJobs restarts the browser of a worker after it ran n jobs.
+func WithRecycleAfterJobs(n int) Option {
+	return func(c *Cluster) {
+		c.recycleJobs = n
+	}
+}
+
+// WithRecycleAfterMemory restarts the browser of a worker once the resident
+// memory of its processes exceeds bytes, checked after each job. It's only
+// supported for the local browsers on Linux.
+func WithRecycleAfterMemory(bytes int64) Option {
+	return func(c *Cluster) {
+		c.recycleMemory = bytes
+	}
+}
+
+// WithLogf sets the func used to log the browsers failing to start and being
+// recycled.
+func WithLogf(f func(string, ...interface{})) Option {
+	return func(c *Cluster) {
+		c.logf = f
+	}
+}
+
+// New creates a Cluster and starts its workers. Their browsers are started
+// on their first job.
+func New(opts ...Option) (*Cluster, error) {
+	c := &Cluster{
+		queueSize: defaultQueueSize,
+		logf:      func(string, ...interface{}) {},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	workers := 0
+	for _, a := range c.allocators {
+		if a.workers <= 0 {
+			return nil, fmt.Errorf("invalid number of workers %d", a.workers)
+		}
+		workers += a.workers
+	}
+	if workers == 0 {
+		return nil, errors.New("no workers; use WithAllocator")
+	}
+
+	c.queue = make(chan *Job, c.queueSize)
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	id := 0
+	for _, a := range c.allocators {
+		for i := 0; i < a.workers; i++ {
+			w := &worker{c: c, id: id, alloc: a.fn}
+			id++
+			c.wg.Add(1)
+			go w.run()
+		}
+	}
+	return c, nil
+}
+
+// Job is a task submitted to a Cluster.
+type Job struct {
+	ctx  context.Context
+	task Task
+
+	done     chan struct{}
+	value    interface{}
+	err      error
+	attempts int
+	worker   int
+}
+
+func (j *Job) finish(value interface{}, err error) {
+	j.value, j.err = value, err
+	close(j.done)
+}
+
+// Done returns a channel closed once the job is finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Result waits for the job to finish, and returns the result of its task.
+func (j *Job) Result() (interface{}, error) {
+	<-j.done
+	return j.value, j.err
+}
+
+// Attempts returns the number of times the task of the finished job was
+// run, which is more than 1 if it was retried.
+func (j *Job) Attempts() int {
+	<-j.done
+	return j.attempts
+}
+
+// Worker returns the index of the worker which ran the finished job, in the
+// order the workers are added by WithAllocator, or -1 if it didn't run.
+func (j *Job) Worker() int {
+	<-j.done
+	return j.worker
+}
+
+// Submit queues task to be run by a worker. The job fails with the error of
+// ctx if it's done before the task is run, and the tab of the task is closed
+// when ctx is done.
+func (c *Cluster) Submit(ctx context.Context, task Task) *Job {
+	j := &Job{ctx: ctx, task: task, done: make(chan struct{}), worker: -1}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		j.finish(nil, ErrClosed)
+		return j
+	}
+	select {
+	case <-ctx.Done():
+		j.finish(nil, ctx.Err())
+	case c.queue <- j:
+	}
+	return j
+}
+
+// Do runs task as Submit, and waits for its result.
+func (c *Cluster) Do(ctx context.Context, task Task) (interface{}, error) {
+	return c.Submit(ctx, task).Result()
+}
+
+// Close stops accepting jobs, waits for the queued ones to finish, and stops
+// the browsers.
+func (c *Cluster) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.closed = true
+	close(c.queue)
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	c.cancel()
+	return nil
+}
+
+// worker runs the jobs of a Cluster with its browser.
+type worker struct {
+	c     *Cluster
+	id    int
+	alloc AllocatorFunc
+
+	allocCancel   context.CancelFunc
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+	jobs          int
+}
+
+func (w *worker) run() {
+	defer w.c.wg.Done()
+	for j := range w.c.queue {
+		w.do(j)
+	}
+	w.stop()
+}
+
+// do runs the job j, retrying it if the browser crashes.
+func (w *worker) do(j *Job) {
+	for {
+		if err := j.ctx.Err(); err != nil {
+			j.finish(nil, err)
+			return
+		}
+		j.attempts++
+		if err := w.start(); err != nil {
+			w.c.logf("worker %d: could not start browser: %v", w.id, err)
+			if j.attempts > w.c.retries {
+				j.finish(nil, err)
+				return
+			}
+			continue
+		}
+		j.worker = w.id
+		value, err, crashed := w.runTask(j)
+		w.jobs++
+		if crashed {
+			w.stop()
+			if j.attempts > w.c.retries {
+				j.finish(value, fmt.Errorf("%w: %v", ErrCrashed, err))
+				return
+			}
+			continue
+		}
+		j.finish(value, err)
+		w.recycle()
+		return
+	}
+}
+
+// start starts the browser of the worker, if it's not running.
+func (w *worker) start() error {
+	if w.browserCtx != nil {
+		return nil
+	}
+	allocCtx, allocCancel := w.alloc(w.c.ctx)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return err
+	}
+	w.allocCancel, w.browserCtx, w.browserCancel = allocCancel, browserCtx, browserCancel
+	w.jobs = 0
+	return nil
+}
+
+// stop stops the browser of the worker, if it's running.
+func (w *worker) stop() {
+	if w.browserCtx == nil {
+		return
+	}
+	w.browserCancel()
+	w.allocCancel()
+	w.allocCancel, w.browserCtx, w.browserCancel = nil, nil, nil
+}
+
+// runTask runs the task of j in a new tab, reporting whether the tab or the
+// browser crashed.
+func (w *worker) runTask(j *Job) (value interface{}, err error, crashed bool) {
+	ctx, cancel := chromedp.NewContext(w.browserCtx)
+	defer cancel()
+	stop := context.AfterFunc(j.ctx, cancel)
+	defer stop()
+
+	var tabCrashed atomic.Bool
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			tabCrashed.Store(true)
+			// don't block the target with the cleanup of its tab.
+			go cancel()
+		}
+	})
+	value, err = j.task(ctx)
+
+	select {
+	case <-chromedp.FromContext(w.browserCtx).Browser.LostConnection:
+		return value, err, true
+	default:
+	}
+	return value, err, tabCrashed.Load()
+}
+
+// recycle stops the browser of the worker if it reached the limits set up by
+// WithRecycleAfterJobs and WithRecycleAfterMemory.
+func (w *worker) recycle() {
+	if w.browserCtx == nil {
+		return
+	}
+	if n := w.c.recycleJobs; n > 0 && w.jobs >= n {
+		w.c.logf("worker %d: recycling browser after %d jobs", w.id, w.jobs)
+		w.stop()
+		return
+	}
+	if max := w.c.recycleMemory; max > 0 {
+		b := chromedp.FromContext(w.browserCtx).Browser
+		if rss := b.ResidentMemory(); rss > max {
+			w.c.logf("worker %d: recycling browser using %d bytes", w.id, rss)
+			w.stop()
+		}
+	}
+}
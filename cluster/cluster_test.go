@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/chromedp/cdproto/page"
+
+	"github.com/chromedp/chromedp"
+)
+
+func testAllocator(ctx context.Context) (context.Context, context.CancelFunc) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.DisableGPU)
+	if execPath := os.Getenv("CHROMEDP_TEST_RUNNER"); execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+	if noSandbox := os.Getenv("CHROMEDP_NO_SANDBOX"); noSandbox != "false" {
+		opts = append(opts, chromedp.NoSandbox)
+	}
+	return chromedp.NewExecAllocator(ctx, opts...)
+}
+
+// browserPID is a task returning the PID of the browser it runs in.
+func browserPID(ctx context.Context) (interface{}, error) {
+	if err := chromedp.Run(ctx); err != nil {
+		return nil, err
+	}
+	return chromedp.FromContext(ctx).Browser.PID(), nil
+}
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(WithAllocator(testAllocator, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jobs []*Job
+	for i := 0; i < 6; i++ {
+		jobs = append(jobs, c.Submit(context.Background(), func(ctx context.Context) (interface{}, error) {
+			var title string
+			err := chromedp.Run(ctx,
+				chromedp.Navigate("data:text/html,<title>cluster</title>"),
+				chromedp.Title(&title),
+			)
+			return title, err
+		}))
+	}
+	workers := make(map[int]bool)
+	for _, j := range jobs {
+		title, err := j.Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if title != "cluster" {
+			t.Errorf("want title %q, got %q", "cluster", title)
+		}
+		workers[j.Worker()] = true
+	}
+	for w := range workers {
+		if w < 0 || w > 1 {
+			t.Errorf("unexpected worker %d", w)
+		}
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(context.Background(), browserPID); !errors.Is(err, ErrClosed) {
+		t.Errorf("want error %v, got %v", ErrClosed, err)
+	}
+}
+
+func TestClusterRecycle(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(WithAllocator(testAllocator, 1), WithRecycleAfterJobs(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var pids []interface{}
+	for i := 0; i < 3; i++ {
+		pid, err := c.Do(context.Background(), browserPID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pids = append(pids, pid)
+	}
+	if pids[0] != pids[1] {
+		t.Errorf("want the first 2 jobs in the same browser, got PIDs %v", pids)
+	}
+	if pids[1] == pids[2] {
+		t.Errorf("want the third job in a new browser, got PIDs %v", pids)
+	}
+}
+
+func TestClusterRecycleMemory(t *testing.T) {
+	if _, err := os.Stat("/proc/self/statm"); err != nil {
+		t.Skip("no /proc")
+	}
+	t.Parallel()
+
+	// any browser uses more than a byte.
+	c, err := New(WithAllocator(testAllocator, 1), WithRecycleAfterMemory(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	pid1, err := c.Do(context.Background(), browserPID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid2, err := c.Do(context.Background(), browserPID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid1 == pid2 {
+		t.Errorf("want the second job in a new browser, got PID %v twice", pid1)
+	}
+}
+
+func TestClusterRetry(t *testing.T) {
+	t.Parallel()
+
+	for _, retries := range []int{0, 1} {
+		c, err := New(WithAllocator(testAllocator, 1), WithRetries(retries))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var mu sync.Mutex
+		runs := 0
+		j := c.Submit(context.Background(), func(ctx context.Context) (interface{}, error) {
+			mu.Lock()
+			runs++
+			first := runs == 1
+			mu.Unlock()
+			if first {
+				// the tab crashes, so the command never replies.
+				err := chromedp.Run(ctx, page.Crash())
+				return nil, err
+			}
+			return "ok", chromedp.Run(ctx)
+		})
+		value, err := j.Result()
+		switch retries {
+		case 0:
+			if !errors.Is(err, ErrCrashed) {
+				t.Errorf("want error %v, got %v", ErrCrashed, err)
+			}
+		case 1:
+			if err != nil || value != "ok" {
+				t.Errorf("want the retried job to succeed, got %v, %v", value, err)
+			}
+			if n := j.Attempts(); n != 2 {
+				t.Errorf("want 2 attempts, got %d", n)
+			}
+		}
+		c.Close()
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chromedp/chromedp/kb"
+	"github.com/chromedp/chromedp/replay"
+)
+
+// generate generates a Go program running the steps of rec with chromedp.
+//
+// Steps which can't be expressed with chromedp actions are kept as comments
+// in the generated source, so that they can be completed by hand.
+func generate(rec *replay.Recording) ([]byte, error) {
+	g := &generator{imports: map[string]bool{
+		"context":                      true,
+		"log":                          true,
+		"github.com/chromedp/chromedp": true,
+	}}
+	for _, s := range rec.Steps {
+		g.step(s)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by chromedp-codegen.\n\n")
+	if rec.Title != "" {
+		fmt.Fprintf(&buf, "// The program replays the recording %q.\n", rec.Title)
+	}
+	buf.WriteString("package main\n\nimport (\n")
+	for _, imp := range []string{"context", "log", "", "github.com/chromedp/chromedp", "github.com/chromedp/chromedp/kb"} {
+		switch {
+		case imp == "":
+			buf.WriteString("\n")
+		case g.imports[imp]:
+			fmt.Fprintf(&buf, "%q\n", imp)
+		}
+	}
+	buf.WriteString(`)
+
+func main() {
+	ctx, cancel := chromedp.NewContext(context.Background())
+	defer cancel()
+
+	if err := chromedp.Run(ctx, tasks()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func tasks() chromedp.Tasks {
+	return chromedp.Tasks{
+`)
+	buf.Write(g.buf.Bytes())
+	buf.WriteString("}\n}\n")
+	return format.Source(buf.Bytes())
+}
+
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// action writes a chromedp action to the task list.
+func (g *generator) action(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, "chromedp."+format+",\n", args...)
+}
+
+// comment writes a comment to the task list. Its line breaks are replaced
+// with spaces, so that the values of the recording can't end the comment.
+func (g *generator) comment(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, "// %s\n", commentReplacer.Replace(fmt.Sprintf(format, args...)))
+}
+
+var commentReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+func (g *generator) step(s *replay.Step) {
+	switch s.Type {
+	case "setViewport":
+		opts := ""
+		if s.DeviceScaleFactor != 0 && s.DeviceScaleFactor != 1 {
+			opts += ", chromedp.EmulateScale(" + strconv.FormatFloat(s.DeviceScaleFactor, 'f', -1, 64) + ")"
+		}
+		if s.IsMobile {
+			opts += ", chromedp.EmulateMobile"
+		}
+		if s.HasTouch {
+			opts += ", chromedp.EmulateTouch"
+		}
+		if s.IsLandscape {
+			opts += ", chromedp.EmulateLandscape"
+		}
+		g.action("EmulateViewport(%d, %d%s)", s.Width, s.Height, opts)
+	case "navigate":
+		g.action("Navigate(%s)", quote(s.URL))
+	case "click", "doubleClick":
+		if s.Button != "" && s.Button != "primary" {
+			g.comment("unsupported %q step with the %q button", s.Type, s.Button)
+			return
+		}
+		name := "Click"
+		if s.Type == "doubleClick" {
+			name = "DoubleClick"
+		}
+		g.query(s, name+"(%s, %s)")
+	case "change":
+		g.query(s, "SetValue(%s, "+quote(s.Value)+", %s)")
+	case "keyDown":
+		key, ok := keyLiteral(s.Key)
+		if !ok {
+			g.comment("unsupported key %q", s.Key)
+			return
+		}
+		if strings.HasPrefix(key, "kb.") {
+			g.imports["github.com/chromedp/chromedp/kb"] = true
+		}
+		g.action("KeyEvent(%s)", key)
+	case "keyUp":
+		// The key is released by the KeyEvent of the keyDown step.
+	case "waitForElement":
+		if s.Visible != nil && !*s.Visible {
+			g.query(s, "WaitNotVisible(%s, %s)")
+		} else {
+			g.query(s, "WaitVisible(%s, %s)")
+		}
+	case "waitForExpression":
+		g.action("Poll(%s, nil)", quote(s.Expression))
+	case "scroll":
+		if len(s.Selectors) > 0 {
+			g.query(s, "ScrollIntoView(%s, %s)")
+			return
+		}
+		g.action("Evaluate(%s, nil)", quote(fmt.Sprintf("window.scrollTo(%s, %s)",
+			strconv.FormatFloat(s.X, 'f', -1, 64), strconv.FormatFloat(s.Y, 'f', -1, 64))))
+	case "close":
+		// The browser is closed when the program exits.
+	default:
+		g.comment("unsupported step %q", s.Type)
+	}
+}
+
+// query writes a query action in the format of action, with the selector and
+// its query option as arguments.
+func (g *generator) query(s *replay.Step, format string) {
+	sel, opt, ok := pickSelector(s.Selectors)
+	if !ok {
+		g.comment("no supported selector for the %q step: %q", s.Type, s.Selectors)
+		return
+	}
+	g.action(format, quote(sel), opt)
+}
+
+// pickSelector picks the selector which chromedp supports best among sels:
+// a CSS selector, then an XPath expression, and last a pierce selector,
+// which is used as a CSS selector. Chained selectors, and ARIA and text
+// selectors, are not supported.
+func pickSelector(sels []replay.Selector) (sel, opt string, ok bool) {
+	for _, kind := range []struct {
+		prefix, opt string
+	}{
+		{"", "chromedp.ByQuery"},
+		{"xpath/", "chromedp.BySearch"},
+		{"pierce/", "chromedp.ByQuery"},
+	} {
+		for _, s := range sels {
+			if len(s) != 1 {
+				continue
+			}
+			v := s[0]
+			if p := selectorPrefix(v); p != kind.prefix {
+				continue
+			}
+			return strings.TrimPrefix(v, kind.prefix), kind.opt, true
+		}
+	}
+	return "", "", false
+}
+
+// selectorPrefix returns the prefix of the DevTools Recorder selector s, or
+// "" for CSS selectors.
+func selectorPrefix(s string) string {
+	for _, p := range []string{"aria/", "text/", "xpath/", "pierce/"} {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// keyLiteral returns the Go expression of the DOM key value key, to pass to
+// chromedp.KeyEvent: a kb constant for named keys (such as "Enter"), and a
+// string literal for printable characters.
+func keyLiteral(key string) (string, bool) {
+	if utf8.RuneCountInString(key) == 1 {
+		return quote(key), true
+	}
+	for _, k := range kb.Keys {
+		if k.Key == key {
+			return "kb." + key, true
+		}
+	}
+	return "", false
+}
+
+// quote returns s as a Go string literal, using a raw string literal when
+// possible, as it's easier to read for selectors and expressions.
+func quote(s string) string {
+	if strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/chromedp/replay"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open(filepath.Join("testdata", "recording.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rec, err := replay.ReadRecording(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := generate(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(buf)
+	for _, want := range []string{
+		`// The program replays the recording "Search".`,
+		`"github.com/chromedp/chromedp/kb"`,
+		"chromedp.EmulateViewport(1280, 720, chromedp.EmulateScale(2)),",
+		"chromedp.Navigate(`https://example.com/`),",
+		"chromedp.Click(`#search`, chromedp.ByQuery),",
+		"chromedp.SetValue(`//input[@name=\"q\"]`, `chromedp`, chromedp.BySearch),",
+		"chromedp.KeyEvent(kb.Enter),",
+		"chromedp.WaitVisible(`#results`, chromedp.ByQuery),",
+		`// unsupported step "hover"`,
+		`// unsupported step "customStep"`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("want %q in the generated source:\n%s", want, src)
+		}
+	}
+	if n := strings.Count(src, "chromedp.KeyEvent("); n != 1 {
+		t.Errorf("want a single KeyEvent for the key press, got %d", n)
+	}
+}
+
+func TestGenerateComment(t *testing.T) {
+	t.Parallel()
+
+	// the values of a crafted recording can't end the comments they're
+	// written in.
+	rec := &replay.Recording{Steps: []*replay.Step{
+		{Type: "click", Button: "secondary\nos.RemoveAll(\"/\")", Selectors: []replay.Selector{{"#a"}}},
+		{Type: "hover\r\npanic(1)"},
+	}}
+	g := &generator{imports: map[string]bool{}}
+	for _, s := range rec.Steps {
+		g.step(s)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(g.buf.String()), "\n") {
+		if !strings.HasPrefix(line, "// ") {
+			t.Errorf("want only comments, got the line %q", line)
+		}
+	}
+}
+
+func TestKeyLiteral(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{"a", "`a`", true},
+		{"Enter", "kb.Enter", true},
+		{"ArrowDown", "kb.ArrowDown", true},
+		{"NotAKey", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := keyLiteral(tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("keyLiteral(%q): want (%q, %t), got (%q, %t)", tt.key, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestRunImport(t *testing.T) {
+	t.Parallel()
+
+	out := filepath.Join(t.TempDir(), "main.go")
+	if err := run(context.Background(), []string{"import", "-o", out, filepath.Join("testdata", "recording.json")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(buf), "// Code generated by chromedp-codegen.") {
+		t.Fatalf("unexpected output:\n%s", buf)
+	}
+}
@@ -0,0 +1,99 @@
+// Command chromedp-codegen generates Go programs running user flows with
+// chromedp. The flows are either recorded live in a browser, or imported from
+// the JSON exports of the Chrome DevTools Recorder panel.
+//
+// Usage:
+//
+//	chromedp-codegen record -url https://example.com [-o main.go] [-json]
+//	chromedp-codegen import [-o main.go] recording.json
+//
+// The record subcommand opens the URL in a new browser window, and records
+// the clicks, the changes of form values, and the special key presses (such
+// as Enter) until the window is closed or the command is interrupted.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/chromedp/chromedp/replay"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+const usage = `usage:
+	chromedp-codegen record -url URL [-o FILE] [-json]
+	chromedp-codegen import [-o FILE] RECORDING.json`
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) < 1 {
+		return errors.New(usage)
+	}
+	fs := flag.NewFlagSet("chromedp-codegen "+args[0], flag.ContinueOnError)
+	out := fs.String("o", "", "output file (defaults to stdout)")
+
+	var rec *replay.Recording
+	var asJSON bool
+	switch args[0] {
+	case "record":
+		urlstr := fs.String("url", "", "URL to start the recording at")
+		fs.BoolVar(&asJSON, "json", false, "output the recording in the DevTools Recorder JSON format")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *urlstr == "" || fs.NArg() != 0 {
+			return errors.New(usage)
+		}
+		var err error
+		if rec, err = record(ctx, *urlstr); err != nil {
+			return err
+		}
+	case "import":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New(usage)
+		}
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if rec, err = replay.ReadRecording(f); err != nil {
+			return fmt.Errorf("could not read recording %s: %w", fs.Arg(0), err)
+		}
+	default:
+		return errors.New(usage)
+	}
+
+	var buf []byte
+	var err error
+	if asJSON {
+		buf, err = json.MarshalIndent(rec, "", "  ")
+		buf = append(buf, '\n')
+	} else {
+		buf, err = generate(rec)
+	}
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		return os.WriteFile(*out, buf, 0o644)
+	}
+	_, err = stdout.Write(buf)
+	return err
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/replay"
+)
+
+// recordJS is the script injected in the recorded pages, which reports the
+// user actions as recording steps to the binding passed as argument.
+//
+//go:embed record.js
+var recordJS string
+
+// recordBinding is the name of the binding receiving the recorded steps.
+const recordBinding = "__chromedpRecord"
+
+// record opens urlstr in a new headful browser, and records the user actions
+// until the tab is closed or ctx is done.
+func record(ctx context.Context, urlstr string, opts ...chromedp.ExecAllocatorOption) (*replay.Recording, error) {
+	opts = append(append(chromedp.DefaultExecAllocatorOptions[:], chromedp.Flag("headless", false)), opts...)
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancel()
+	ctx, cancel = chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	// Start the browser to be able to listen to its events.
+	if err := chromedp.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	rec := &replay.Recording{Title: urlstr}
+	var mu sync.Mutex
+	closed := make(chan struct{})
+	targetID := chromedp.FromContext(ctx).Target.TargetID
+	chromedp.ListenBrowser(ctx, func(ev interface{}) {
+		if ev, ok := ev.(*target.EventTargetDestroyed); ok && ev.TargetID == targetID {
+			close(closed)
+		}
+	})
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		ev2, ok := ev.(*runtime.EventBindingCalled)
+		if !ok || ev2.Name != recordBinding {
+			return
+		}
+		s := new(replay.Step)
+		if err := json.Unmarshal([]byte(ev2.Payload), s); err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		addStep(rec, s)
+	})
+
+	var width, height int64
+	script := fmt.Sprintf("(%s)(%q);", recordJS, recordBinding)
+	if err := chromedp.Run(ctx,
+		runtime.AddBinding(recordBinding),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+			return err
+		}),
+		chromedp.Evaluate(`window.innerWidth`, &width),
+		chromedp.Evaluate(`window.innerHeight`, &height),
+		chromedp.Navigate(urlstr),
+	); err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	rec.Steps = append([]*replay.Step{
+		{Type: "setViewport", Width: width, Height: height, DeviceScaleFactor: 1},
+		{Type: "navigate", URL: urlstr},
+	}, rec.Steps...)
+	mu.Unlock()
+
+	select {
+	case <-closed:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return rec, nil
+}
+
+// addStep appends s to the steps of rec. A double click also reports the
+// clicks it's made of, which are then replaced by the doubleClick step.
+func addStep(rec *replay.Recording, s *replay.Step) {
+	if s.Type == "doubleClick" {
+		for i := 0; i < 2; i++ {
+			n := len(rec.Steps)
+			if n == 0 || rec.Steps[n-1].Type != "click" || !sameSelectors(rec.Steps[n-1].Selectors, s.Selectors) {
+				break
+			}
+			rec.Steps = rec.Steps[:n-1]
+		}
+	}
+	rec.Steps = append(rec.Steps, s)
+}
+
+func sameSelectors(a, b []replay.Selector) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+function record(binding) {
+    // The selectors of the steps are only valid in the top frame.
+    if (window !== window.top || window.__chromedpRecorder) {
+        return;
+    }
+    window.__chromedpRecorder = true;
+
+    const send = (step) => {
+        const fn = window[binding];
+        if (fn) {
+            fn(JSON.stringify(step));
+        }
+    };
+
+    const unique = (sel) => document.querySelectorAll(sel).length === 1;
+
+    const selectorFor = (el) => {
+        const parts = [];
+        for (; el && el.nodeType === Node.ELEMENT_NODE; el = el.parentElement) {
+            if (el.id && unique('#' + CSS.escape(el.id))) {
+                parts.unshift('#' + CSS.escape(el.id));
+                break;
+            }
+            let part = el.localName;
+            const parent = el.parentElement;
+            if (parent) {
+                const same = Array.from(parent.children).filter((c) => c.localName === el.localName);
+                if (same.length > 1) {
+                    part += `:nth-of-type(${same.indexOf(el) + 1})`;
+                }
+            }
+            parts.unshift(part);
+        }
+        return parts.join(' > ');
+    };
+
+    // Typed text is recorded as a single change step, which is sent when the
+    // element's value is committed, or before a key which might commit it.
+    const dirty = new Set();
+    const sent = new WeakMap();
+    const flush = (el) => {
+        dirty.delete(el);
+        if (el.value !== sent.get(el)) {
+            sent.set(el, el.value);
+            send({type: 'change', value: el.value, selectors: [[selectorFor(el)]]});
+        }
+    };
+
+    document.addEventListener('input', (e) => {
+        if (e.isTrusted && 'value' in e.target) {
+            dirty.add(e.target);
+        }
+    }, true);
+    document.addEventListener('change', (e) => {
+        if (e.isTrusted && 'value' in e.target) {
+            flush(e.target);
+        }
+    }, true);
+    document.addEventListener('click', (e) => {
+        if (e.isTrusted && e.button === 0) {
+            send({type: 'click', selectors: [[selectorFor(e.target)]]});
+        }
+    }, true);
+    document.addEventListener('dblclick', (e) => {
+        if (e.isTrusted && e.button === 0) {
+            send({type: 'doubleClick', selectors: [[selectorFor(e.target)]]});
+        }
+    }, true);
+
+    // Printable keys are part of the typed text, so only the other keys
+    // (Enter, Tab, arrows, and so on) are recorded.
+    const recorded = (e) => e.isTrusted && e.key.length > 1 && !['Shift', 'Control', 'Alt', 'Meta', 'CapsLock'].includes(e.key);
+    document.addEventListener('keydown', (e) => {
+        if (recorded(e)) {
+            if (dirty.has(e.target)) {
+                flush(e.target);
+            }
+            send({type: 'keyDown', key: e.key});
+        }
+    }, true);
+    document.addEventListener('keyup', (e) => {
+        if (recorded(e)) {
+            send({type: 'keyUp', key: e.key});
+        }
+    }, true);
+}
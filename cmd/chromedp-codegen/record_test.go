@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/chromedp/replay"
+)
+
+func TestRecordingAddStep(t *testing.T) {
+	t.Parallel()
+
+	button := []replay.Selector{{"#button"}}
+	rec := &replay.Recording{}
+	for _, s := range []*replay.Step{
+		{Type: "click", Selectors: []replay.Selector{{"#other"}}},
+		{Type: "click", Selectors: button},
+		{Type: "click", Selectors: button},
+		{Type: "doubleClick", Selectors: button},
+	} {
+		addStep(rec, s)
+	}
+	var got []string
+	for _, s := range rec.Steps {
+		got = append(got, s.Type+" "+s.Selectors[0][0])
+	}
+	want := []string{"click #other", "doubleClick #button"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want steps %q, got %q", want, got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"io"
 	"net"
+	"sync/atomic"
+	"time"
 
 	"github.com/gobwas/ws"
 	"github.com/gobwas/ws/wsutil"
@@ -16,8 +18,8 @@ import (
 
 // Transport is the common interface to send/receive messages to a target.
 //
-// This interface is currently used internally by Browser, but it is exposed as
-// it will be useful as part of the public API in the future.
+// This interface is used internally by Browser, and WithTransportWrapper can
+// wrap the connection of a Browser with another implementation.
 type Transport interface {
 	Read(context.Context, *cdproto.Message) error
 	Write(context.Context, *cdproto.Message) error
@@ -38,6 +40,10 @@ type Conn struct {
 	encoder jwriter.Writer
 
 	dbgf func(string, ...interface{})
+
+	// lastRead is the time in Unix nanoseconds of the last frame read,
+	// which may be a pong frame.
+	lastRead atomic.Int64
 }
 
 // DialContext dials the specified websocket URL using gobwas/ws.
@@ -58,6 +64,7 @@ func DialContext(ctx context.Context, urlstr string, opts ...DialOption) (*Conn,
 		// github.com/gobwas/ws will grow the buffer size if needed.
 		writer: *wsutil.NewWriterBufferSize(conn, ws.StateClientSide, ws.OpText, 0),
 	}
+	c.lastRead.Store(time.Now().UnixNano())
 	for _, o := range opts {
 		o(c)
 	}
@@ -74,12 +81,24 @@ func (c *Conn) Close() error {
 func (c *Conn) Read(_ context.Context, msg *cdproto.Message) error {
 	// get websocket reader
 	c.reader = wsutil.Reader{Source: c.conn, State: ws.StateClientSide}
-	h, err := c.reader.NextFrame()
-	if err != nil {
-		return err
-	}
-	if h.OpCode != ws.OpText {
-		return ErrInvalidWebsocketMessage
+	for {
+		h, err := c.reader.NextFrame()
+		if err != nil {
+			return err
+		}
+		c.lastRead.Store(time.Now().UnixNano())
+		// pong frames are the replies to Ping; Chrome doesn't send
+		// ping frames, but ignore them too.
+		if h.OpCode == ws.OpPong || h.OpCode == ws.OpPing {
+			if err := c.reader.Discard(); err != nil {
+				return err
+			}
+			continue
+		}
+		if h.OpCode != ws.OpText {
+			return ErrInvalidWebsocketMessage
+		}
+		break
 	}
 
 	var b bytes.Buffer
@@ -137,6 +156,17 @@ func (c *Conn) Write(_ context.Context, msg *cdproto.Message) error {
 	return c.writer.Flush()
 }
 
+// ping writes a ping frame, whose pong reply is read by Read. It must not be
+// called concurrently with Write.
+func (c *Conn) ping() error {
+	return wsutil.WriteClientMessage(c.conn, ws.OpPing, nil)
+}
+
+// lastReadTime returns the time of the last frame read.
+func (c *Conn) lastReadTime() time.Time {
+	return time.Unix(0, c.lastRead.Load())
+}
+
 // DialOption is a dial option.
 type DialOption = func(*Conn)
 
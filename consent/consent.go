@@ -0,0 +1,218 @@
+// Package consent dismisses the cookie consent banners of the pages, such as
+// the GDPR banners, by accepting them. The banners are found with a list of
+// rules, which covers the common consent management platforms and can be
+// extended, and then with heuristics matching the accept buttons of the other
+// banners.
+//
+// Dismiss reports the rule which matched the banner, if any, while Accept is
+// an action to use within chromedp.Run, which fails when no banner was found.
+//
+//	err := chromedp.Run(ctx, chromedp.Navigate("https://example.com"))
+//	if err != nil {
+//		// handle error
+//	}
+//	name, err := consent.Dismiss(ctx,
+//		consent.WithRules(consent.Rule{
+//			Name:     "example",
+//			Domains:  []string{"example.com"},
+//			Selector: "#cookie-bar .ok",
+//		}),
+//		consent.WithWait(2*time.Second),
+//	)
+//
+// The banners in cross-origin iframes and in shadow roots are not found.
+package consent
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrNotDismissed is the error of the Accept action when no banner was found.
+var ErrNotDismissed = errors.New("no consent banner found")
+
+// dismissJS is a JavaScript snippet that clicks the accept button of the
+// consent banner of the page, and returns the name of the rule it matched.
+//
+//go:embed js/dismiss.js
+var dismissJS string
+
+// pollInterval is how often Dismiss looks for a banner with WithWait.
+const pollInterval = 100 * time.Millisecond
+
+// HeuristicRule is the name returned by Dismiss when the banner was found by
+// the heuristics, rather than by a rule.
+const HeuristicRule = "heuristic"
+
+// Rule is a way to find the accept button of the consent banners of some
+// sites. At least one of Selector and Texts must be set.
+type Rule struct {
+	// Name identifies the rule, such as the consent management platform
+	// it's for.
+	Name string `json:"name"`
+	// Domains are the domains of the sites the rule applies to, including
+	// their subdomains. The rule applies to all the sites if it's empty.
+	Domains []string `json:"domains,omitempty"`
+	// Selector is the CSS selector of the accept button.
+	Selector string `json:"selector,omitempty"`
+	// Texts are the texts of the accept button, such as "Accept all",
+	// matched case-insensitively against its whole text. With Selector,
+	// they only match the elements it selects; otherwise, they match all
+	// the buttons and links.
+	Texts []string `json:"texts,omitempty"`
+}
+
+// DefaultRules are the rules for the common consent management platforms.
+var DefaultRules = []Rule{
+	{Name: "onetrust", Selector: "#onetrust-accept-btn-handler"},
+	{Name: "cookiebot", Selector: "#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll, #CybotCookiebotDialogBodyButtonAccept"},
+	{Name: "didomi", Selector: "#didomi-notice-agree-button"},
+	{Name: "quantcast", Selector: `.qc-cmp2-summary-buttons button[mode="primary"]`},
+	{Name: "trustarc", Selector: "#truste-consent-button"},
+	{Name: "funding-choices", Selector: ".fc-cta-consent"},
+	{Name: "osano", Selector: ".osano-cm-accept-all"},
+	{Name: "cookieyes", Selector: ".cky-btn-accept"},
+	{Name: "complianz", Selector: ".cmplz-btn.cmplz-accept"},
+	{Name: "iubenda", Selector: ".iubenda-cs-accept-btn"},
+	{Name: "cookie-notice", Selector: "#cn-accept-cookie"},
+}
+
+// DefaultAcceptTexts are the texts of the accept buttons matched by the
+// heuristics, in a few languages.
+var DefaultAcceptTexts = []string{
+	"accept", "accept all", "accept all cookies", "accept cookies",
+	"allow all", "allow all cookies", "allow cookies", "agree", "i agree",
+	"i accept", "got it", "ok", "okay",
+	"alle akzeptieren", "akzeptieren", "zustimmen", "alle zulassen",
+	"tout accepter", "accepter", "j'accepte", "accepter et fermer",
+	"aceptar", "aceptar todo", "aceptar todas", "accetta", "accetta tutto",
+	"aceitar", "aceitar todos", "alles accepteren", "accepteren",
+}
+
+// Option is a Dismiss option.
+type Option = func(*dismisser)
+
+// dismisser is the set up of Dismiss.
+type dismisser struct {
+	rules       []Rule
+	defaults    bool
+	heuristics  bool
+	acceptTexts []string
+	wait        time.Duration
+}
+
+// WithRules adds rules, which are tried in order before DefaultRules.
+func WithRules(rules ...Rule) Option {
+	return func(d *dismisser) {
+		d.rules = append(d.rules, rules...)
+	}
+}
+
+// WithoutDefaultRules leaves out DefaultRules.
+func WithoutDefaultRules() Option {
+	return func(d *dismisser) {
+		d.defaults = false
+	}
+}
+
+// WithoutHeuristics leaves out the heuristics, so that only the banners
+// matched by the rules are dismissed.
+func WithoutHeuristics() Option {
+	return func(d *dismisser) {
+		d.heuristics = false
+	}
+}
+
+// WithAcceptTexts adds texts of the accept buttons matched by the heuristics,
+// such as those of other languages, to DefaultAcceptTexts.
+func WithAcceptTexts(texts ...string) Option {
+	return func(d *dismisser) {
+		d.acceptTexts = append(d.acceptTexts[:len(d.acceptTexts):len(d.acceptTexts)], texts...)
+	}
+}
+
+// WithWait sets how long Dismiss waits for a banner to show up, as they're
+// often shown a while after the page is loaded. By default, Dismiss only looks
+// for the banner once.
+func WithWait(d time.Duration) Option {
+	return func(ds *dismisser) {
+		ds.wait = d
+	}
+}
+
+// Dismiss clicks the accept button of the consent banner of the current page
+// of ctx. It returns the name of the rule which matched the banner, or
+// HeuristicRule, and an empty name when no banner was found.
+func Dismiss(ctx context.Context, opts ...Option) (string, error) {
+	var name string
+	if err := chromedp.Run(ctx, dismiss(&name, opts)); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Accept is an action like Dismiss, which fails with ErrNotDismissed when no
+// banner was found.
+func Accept(opts ...Option) chromedp.Action {
+	var name string
+	return chromedp.Tasks{
+		dismiss(&name, opts),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if name == "" {
+				return ErrNotDismissed
+			}
+			return nil
+		}),
+	}
+}
+
+// dismiss is an action that clicks the accept button of the consent banner of
+// the current page, as set up by opts, and stores the name of the rule which
+// matched it in name.
+func dismiss(name *string, opts []Option) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		d := &dismisser{
+			defaults:    true,
+			heuristics:  true,
+			acceptTexts: DefaultAcceptTexts,
+		}
+		for _, o := range opts {
+			o(d)
+		}
+		rules := d.rules
+		if d.defaults {
+			rules = append(rules[:len(rules):len(rules)], DefaultRules...)
+		}
+		for i, r := range rules {
+			if r.Selector == "" && len(r.Texts) == 0 {
+				return fmt.Errorf("rule %d (%q) has neither a selector nor texts", i, r.Name)
+			}
+		}
+		args, err := json.Marshal([]interface{}{rules, d.heuristics, d.acceptTexts})
+		if err != nil {
+			return err
+		}
+		expr := fmt.Sprintf("(%s)(...%s)", dismissJS, args)
+
+		deadline := time.Now().Add(d.wait)
+		for {
+			if err := chromedp.Evaluate(expr, name).Do(ctx); err != nil {
+				return err
+			}
+			if *name != "" || !time.Now().Before(deadline) {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(pollInterval):
+			}
+		}
+	})
+}
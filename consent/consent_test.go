@@ -0,0 +1,123 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+var allocCtx context.Context
+
+func TestMain(m *testing.M) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.DisableGPU)
+	if execPath := os.Getenv("CHROMEDP_TEST_RUNNER"); execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+	if noSandbox := os.Getenv("CHROMEDP_NO_SANDBOX"); noSandbox != "false" {
+		opts = append(opts, chromedp.NoSandbox)
+	}
+	var cancel context.CancelFunc
+	allocCtx, cancel = chromedp.NewExecAllocator(context.Background(), opts...)
+
+	code := m.Run()
+	cancel()
+	os.Exit(code)
+}
+
+var testPages = map[string]string{
+	"/onetrust": `<div id="onetrust-banner-sdk">
+  <button id="onetrust-accept-btn-handler" onclick="document.title = 'accepted'">Accept All Cookies</button>
+</div>`,
+	"/heuristic": `<button onclick="document.title = 'wrong'">OK</button>
+<div class="site-cookie-banner">
+  <p>We use cookies.</p>
+  <button onclick="document.title = 'wrong'">Settings</button>
+  <button onclick="document.title = 'accepted'">  Alle
+    akzeptieren </button>
+</div>`,
+	"/custom": `<div id="bar">
+  <a href="#" onclick="document.title = 'wrong'">Fine</a>
+  <span role="button" onclick="document.title = 'accepted'">Sure, why not</span>
+</div>`,
+	"/late": `<script>
+  setTimeout(() => {
+    document.body.innerHTML = '<div role="dialog"><button onclick="document.title = \'accepted\'">Got it</button></div>';
+  }, 300);
+</script>`,
+	"/none": `<button onclick="document.title = 'wrong'">Accept</button>`,
+}
+
+func TestDismiss(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>" + testPages[r.URL.Path] + "</body></html>"))
+	}))
+	defer s.Close()
+
+	custom := WithRules(
+		Rule{Name: "elsewhere", Domains: []string{"example.com"}, Texts: []string{"Fine"}},
+		Rule{Name: "local", Domains: []string{"127.0.0.1"}, Selector: "#bar [role=button]", Texts: []string{"sure, why not"}},
+	)
+	tests := []struct {
+		path string
+		opts []Option
+		want string
+	}{
+		{"/onetrust", nil, "onetrust"},
+		{"/heuristic", nil, HeuristicRule},
+		{"/heuristic", []Option{WithoutHeuristics()}, ""},
+		{"/custom", []Option{custom}, "local"},
+		{"/custom", nil, ""},
+		{"/late", nil, ""},
+		{"/late", []Option{WithWait(2 * time.Second)}, HeuristicRule},
+		{"/none", nil, ""},
+	}
+	for _, test := range tests {
+		ctx, cancel := chromedp.NewContext(allocCtx)
+		var title string
+		if err := chromedp.Run(ctx, chromedp.Navigate(s.URL+test.path)); err != nil {
+			t.Fatal(err)
+		}
+		name, err := Dismiss(ctx, test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chromedp.Run(ctx, chromedp.Title(&title)); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if name != test.want {
+			t.Errorf("%s: want rule %q, got %q", test.path, test.want, name)
+		}
+		wantTitle := ""
+		if test.want != "" {
+			wantTitle = "accepted"
+		}
+		if title != wantTitle {
+			t.Errorf("%s: want title %q, got %q", test.path, wantTitle, title)
+		}
+	}
+}
+
+func TestAccept(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	if err := chromedp.Run(ctx, chromedp.Navigate("data:text/html,<p>no banner</p>")); err != nil {
+		t.Fatal(err)
+	}
+	if err := chromedp.Run(ctx, Accept()); !errors.Is(err, ErrNotDismissed) {
+		t.Errorf("want ErrNotDismissed, got %v", err)
+	}
+	if err := chromedp.Run(ctx, Accept(WithRules(Rule{Name: "empty"}))); err == nil {
+		t.Error("want an error for a rule without a selector nor texts")
+	}
+}
@@ -0,0 +1,53 @@
+function dismiss(rules, heuristics, acceptTexts) {
+    const host = location.hostname;
+    const normalize = (s) => (s || '').replace(/\s+/g, ' ').trim().toLowerCase();
+    const textOf = (el) => normalize(el.innerText || el.value || el.getAttribute('aria-label'));
+    const visible = (el) => {
+        const rect = el.getBoundingClientRect();
+        const style = getComputedStyle(el);
+        return rect.width > 0 && rect.height > 0 && style.visibility !== 'hidden' && style.display !== 'none';
+    };
+    const clickables = (root) => root.querySelectorAll('button, a, [role="button"], input[type="button"], input[type="submit"]');
+    const applies = (rule) => !rule.domains || rule.domains.length === 0 ||
+        rule.domains.some((d) => host === d || host.endsWith('.' + d));
+    const matches = (el, texts) => !texts || texts.length === 0 || texts.includes(textOf(el));
+
+    for (const rule of rules) {
+        if (!applies(rule)) {
+            continue;
+        }
+        const texts = (rule.texts || []).map(normalize);
+        const candidates = rule.selector ? document.querySelectorAll(rule.selector) : clickables(document);
+        for (const el of candidates) {
+            if (visible(el) && matches(el, texts)) {
+                el.click();
+                return rule.name;
+            }
+        }
+    }
+    if (!heuristics) {
+        return '';
+    }
+
+    // the accept buttons of the banners, which are dialogs, or whose ids or
+    // classes tell they're about cookies or consent.
+    const banner = /cookie|consent|gdpr|privacy|cmp/i;
+    const inBanner = (el) => {
+        for (let p = el.parentElement; p; p = p.parentElement) {
+            const role = p.getAttribute('role');
+            if (role === 'dialog' || role === 'alertdialog' || p.getAttribute('aria-modal') === 'true' ||
+                banner.test(p.id) || banner.test(p.getAttribute('class'))) {
+                return true;
+            }
+        }
+        return false;
+    };
+    const texts = acceptTexts.map(normalize);
+    for (const el of clickables(document)) {
+        if (visible(el) && texts.includes(textOf(el)) && inBanner(el)) {
+            el.click();
+            return 'heuristic';
+        }
+    }
+    return '';
+}
@@ -0,0 +1,270 @@
+package chromedp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+)
+
+// CookieOption is a SetCookie option.
+type CookieOption = func(*network.SetCookieParams) *network.SetCookieParams
+
+// CookieDomain is a SetCookie option to set the domain of the cookie. A
+// leading dot, as in ".example.com", sends the cookie to the subdomains of
+// domain too.
+func CookieDomain(domain string) CookieOption {
+	return func(p *network.SetCookieParams) *network.SetCookieParams {
+		return p.WithDomain(domain)
+	}
+}
+
+// CookiePath is a SetCookie option to set the path of the cookie.
+func CookiePath(path string) CookieOption {
+	return func(p *network.SetCookieParams) *network.SetCookieParams {
+		return p.WithPath(path)
+	}
+}
+
+// CookieExpires is a SetCookie option to set the expiration time of the
+// cookie, instead of a session cookie.
+func CookieExpires(t time.Time) CookieOption {
+	return func(p *network.SetCookieParams) *network.SetCookieParams {
+		expires := cdp.TimeSinceEpoch(t)
+		return p.WithExpires(&expires)
+	}
+}
+
+// CookieSecure is a SetCookie option to only send the cookie over secure
+// connections.
+func CookieSecure(p *network.SetCookieParams) *network.SetCookieParams {
+	return p.WithSecure(true)
+}
+
+// CookieHTTPOnly is a SetCookie option to hide the cookie from the scripts of
+// the pages.
+func CookieHTTPOnly(p *network.SetCookieParams) *network.SetCookieParams {
+	return p.WithHTTPOnly(true)
+}
+
+// CookieSameSite is a SetCookie option to set the SameSite attribute of the
+// cookie, such as network.CookieSameSiteStrict.
+func CookieSameSite(sameSite network.CookieSameSite) CookieOption {
+	return func(p *network.SetCookieParams) *network.SetCookieParams {
+		return p.WithSameSite(sameSite)
+	}
+}
+
+// SetCookie is an action that sets the cookie name to value in the current
+// BrowserContext, as set up by opts. The cookie is for the URL of the current
+// page, unless the CookieDomain option is set. Example:
+//
+//	chromedp.SetCookie("session", "secret",
+//		chromedp.CookieDomain(".example.com"),
+//		chromedp.CookieExpires(time.Now().Add(24*time.Hour)),
+//		chromedp.CookieHTTPOnly,
+//	)
+func SetCookie(name, value string, opts ...CookieOption) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		p := network.SetCookie(name, value)
+		for _, o := range opts {
+			p = o(p)
+		}
+		if p.Domain == "" && p.URL == "" {
+			var urlstr string
+			if err := Location(&urlstr).Do(ctx); err != nil {
+				return err
+			}
+			p = p.WithURL(urlstr)
+		}
+		return p.Do(ctx)
+	})
+}
+
+// Cookies is an action that retrieves the cookies sent to the URL of the
+// current page, and to the URLs of its frames, or to urls if any, and stores
+// them in cookies.
+func Cookies(cookies *[]*network.Cookie, urls ...string) Action {
+	if cookies == nil {
+		panic("cookies cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		p := network.GetCookies()
+		if len(urls) > 0 {
+			p = p.WithUrls(urls)
+		}
+		var err error
+		*cookies, err = p.Do(ctx)
+		return err
+	})
+}
+
+// ClearCookies is an action that deletes all the cookies of the current
+// BrowserContext.
+func ClearCookies() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		c := FromContext(ctx)
+		return storage.ClearCookies().
+			WithBrowserContextID(c.BrowserContextID).
+			Do(cdp.WithExecutor(ctx, c.Browser))
+	})
+}
+
+// CookieFormat is a format of the files of cookies written by ExportCookies
+// and read by ImportCookies.
+type CookieFormat int
+
+// CookieFormat values.
+const (
+	// CookieFormatJSON is a JSON array of the cookies, as network.Cookie
+	// values.
+	CookieFormatJSON CookieFormat = iota
+	// CookieFormatNetscape is the cookies.txt format of Netscape, as
+	// read and written by curl and wget, among others. It leaves out the
+	// SameSite attribute of the cookies.
+	CookieFormatNetscape
+)
+
+// netscapeHTTPOnlyPrefix is the prefix of the domains of the http-only cookies
+// in the cookies.txt files, as written by curl.
+const netscapeHTTPOnlyPrefix = "#HttpOnly_"
+
+// ExportCookies is an action that writes all the cookies of the current
+// BrowserContext to w, in format, so that they can be restored with
+// ImportCookies, such as to reuse a session across runs.
+func ExportCookies(w io.Writer, format CookieFormat) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		c := FromContext(ctx)
+		cookies, err := storage.GetCookies().
+			WithBrowserContextID(c.BrowserContextID).
+			Do(cdp.WithExecutor(ctx, c.Browser))
+		if err != nil {
+			return err
+		}
+		return writeCookies(w, format, cookies)
+	})
+}
+
+// writeCookies writes cookies to w, in format.
+func writeCookies(w io.Writer, format CookieFormat, cookies []*network.Cookie) error {
+	switch format {
+	case CookieFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cookies)
+	case CookieFormatNetscape:
+		bw := bufio.NewWriter(w)
+		fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+		for _, cookie := range cookies {
+			domain := cookie.Domain
+			if cookie.HTTPOnly {
+				domain = netscapeHTTPOnlyPrefix + domain
+			}
+			var expires int64
+			if !cookie.Session {
+				expires = int64(cookie.Expires)
+			}
+			fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				domain, netscapeBool(strings.HasPrefix(cookie.Domain, ".")),
+				cookie.Path, netscapeBool(cookie.Secure),
+				expires, cookie.Name, cookie.Value)
+		}
+		return bw.Flush()
+	}
+	return fmt.Errorf("unknown cookie format %d", format)
+}
+
+// ImportCookies is an action that sets the cookies read from r, in format, in
+// the current BrowserContext, such as those written by ExportCookies.
+func ImportCookies(r io.Reader, format CookieFormat) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		params, err := readCookies(r, format)
+		if err != nil || len(params) == 0 {
+			return err
+		}
+		c := FromContext(ctx)
+		return storage.SetCookies(params).
+			WithBrowserContextID(c.BrowserContextID).
+			Do(cdp.WithExecutor(ctx, c.Browser))
+	})
+}
+
+// readCookies reads the cookies of r, in format.
+func readCookies(r io.Reader, format CookieFormat) ([]*network.CookieParam, error) {
+	switch format {
+	case CookieFormatJSON:
+		var cookies []*network.Cookie
+		if err := json.NewDecoder(r).Decode(&cookies); err != nil {
+			return nil, err
+		}
+		var params []*network.CookieParam
+		for _, cookie := range cookies {
+			params = append(params, cookieParam(cookie))
+		}
+		return params, nil
+	case CookieFormatNetscape:
+		return parseNetscapeCookies(r)
+	}
+	return nil, fmt.Errorf("unknown cookie format %d", format)
+}
+
+// parseNetscapeCookies parses the cookies.txt file r.
+func parseNetscapeCookies(r io.Reader) ([]*network.CookieParam, error) {
+	var params []*network.CookieParam
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		httpOnly := strings.HasPrefix(line, netscapeHTTPOnlyPrefix)
+		line = strings.TrimPrefix(line, netscapeHTTPOnlyPrefix)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			// the cookies without a value.
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: want 7 fields, got %d", n, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiration time: %w", n, err)
+		}
+		p := &network.CookieParam{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   fields[3] == "TRUE",
+			HTTPOnly: httpOnly,
+		}
+		domain := fields[0]
+		if fields[1] == "TRUE" {
+			p.Domain = "." + strings.TrimPrefix(domain, ".")
+		} else {
+			p.URL = hostOnlyCookieURL(domain, p.Path, p.Secure)
+		}
+		if expires > 0 {
+			t := cdp.TimeSinceEpoch(time.Unix(expires, 0))
+			p.Expires = &t
+		}
+		params = append(params, p)
+	}
+	return params, sc.Err()
+}
+
+// netscapeBool returns the boolean v as written in the cookies.txt files.
+func netscapeBool(v bool) string {
+	if v {
+		return "TRUE"
+	}
+	return "FALSE"
+}
@@ -0,0 +1,182 @@
+package chromedp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func newCookieServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var names []string
+		for _, cookie := range r.Cookies() {
+			names = append(names, cookie.Name)
+		}
+		fmt.Fprintf(w, `<html><body id="cookies">%s</body></html>`, strings.Join(names, ","))
+	}))
+}
+
+func TestCookies(t *testing.T) {
+	t.Parallel()
+
+	s := newCookieServer()
+	defer s.Close()
+
+	tctx, tcancel := testAllocate(t, "")
+	defer tcancel()
+	ctx, cancel := NewContext(tctx, WithNewBrowserContext())
+	defer cancel()
+
+	expires := time.Now().Add(time.Hour)
+	var docCookie, sent string
+	var cookies []*network.Cookie
+	if err := Run(ctx,
+		Navigate(s.URL),
+		SetCookie("plain", "1"),
+		SetCookie("hidden", "2", CookieHTTPOnly, CookieExpires(expires)),
+		SetCookie("other", "3", CookieDomain(".example.com"), CookiePath("/sub")),
+		Cookies(&cookies),
+		Evaluate(`document.cookie`, &docCookie),
+		Reload(),
+		Text("#cookies", &sent, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 2 {
+		t.Fatalf("want the 2 cookies of the page, got %d", len(cookies))
+	}
+	byName := make(map[string]*network.Cookie)
+	for _, cookie := range cookies {
+		byName[cookie.Name] = cookie
+	}
+	if c := byName["plain"]; c == nil || c.Value != "1" || !c.Session || c.HTTPOnly {
+		t.Errorf("want a session cookie plain=1, got %+v", c)
+	}
+	if c := byName["hidden"]; c == nil || c.Value != "2" || c.Session || !c.HTTPOnly ||
+		int64(c.Expires) != expires.Unix() {
+		t.Errorf("want an http-only cookie hidden=2 expiring at %d, got %+v", expires.Unix(), c)
+	}
+	if want := "plain=1"; docCookie != want {
+		t.Errorf("want document.cookie %q, got %q", want, docCookie)
+	}
+	if want := "plain,hidden"; sent != want {
+		t.Errorf("want the cookies %q sent, got %q", want, sent)
+	}
+
+	if err := Run(ctx, Cookies(&cookies, "https://example.com/sub/page")); err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 1 || cookies[0].Name != "other" || cookies[0].Domain != ".example.com" {
+		t.Errorf("want the cookie other of example.com, got %v", cookies)
+	}
+
+	if err := Run(ctx, ClearCookies(), Cookies(&cookies, s.URL, "https://example.com/sub")); err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 0 {
+		t.Errorf("want no cookies, got %v", cookies)
+	}
+}
+
+func TestExportImportCookies(t *testing.T) {
+	t.Parallel()
+
+	s := newCookieServer()
+	defer s.Close()
+
+	tctx, tcancel := testAllocate(t, "")
+	defer tcancel()
+
+	expires := time.Now().Add(time.Hour)
+	for _, format := range []CookieFormat{CookieFormatJSON, CookieFormatNetscape} {
+		ctx1, cancel1 := NewContext(tctx, WithNewBrowserContext())
+		if err := Run(ctx1,
+			Navigate(s.URL),
+			SetCookie("plain", "1"),
+			SetCookie("hidden", "2", CookieHTTPOnly, CookieExpires(expires), CookiePath("/")),
+			SetCookie("other", "3", CookieDomain(".example.com"), CookieSecure),
+			network.SetCookie("host", "4").WithURL("https://example.com/").WithSecure(true),
+		); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		err := Run(ctx1, ExportCookies(&buf, format))
+		cancel1()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == CookieFormatNetscape && !strings.HasPrefix(buf.String(), "# Netscape HTTP Cookie File\n") {
+			t.Errorf("want the Netscape header, got %q", buf.String())
+		}
+
+		ctx2, cancel2 := NewContext(tctx, WithNewBrowserContext())
+		defer cancel2()
+		var sent string
+		var cookies []*network.Cookie
+		if err := Run(ctx2,
+			ImportCookies(&buf, format),
+			Navigate(s.URL),
+			Text("#cookies", &sent, ByQuery),
+			Cookies(&cookies, s.URL, "https://example.com"),
+		); err != nil {
+			t.Fatal(err)
+		}
+		if want := "plain,hidden"; sent != want {
+			t.Errorf("format %d: want the cookies %q sent, got %q", format, want, sent)
+		}
+		if len(cookies) != 4 {
+			t.Fatalf("format %d: want 4 cookies, got %d", format, len(cookies))
+		}
+		for _, c := range cookies {
+			switch c.Name {
+			case "plain":
+				if c.Value != "1" || !c.Session || c.HTTPOnly || c.Domain != "127.0.0.1" {
+					t.Errorf("format %d: got the cookie %+v", format, c)
+				}
+			case "hidden":
+				if c.Value != "2" || c.Session || !c.HTTPOnly || int64(c.Expires) != expires.Unix() {
+					t.Errorf("format %d: got the cookie %+v", format, c)
+				}
+			case "other":
+				if c.Value != "3" || !c.Secure || c.Domain != ".example.com" {
+					t.Errorf("format %d: got the cookie %+v", format, c)
+				}
+			case "host":
+				// the host-only cookie isn't sent to the subdomains.
+				if c.Value != "4" || c.Domain != "example.com" {
+					t.Errorf("format %d: got the cookie %+v", format, c)
+				}
+			}
+		}
+	}
+}
+
+func TestImportCookiesErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	tests := []struct {
+		format CookieFormat
+		input  string
+		want   string
+	}{
+		{CookieFormatJSON, `{`, "unexpected EOF"},
+		{CookieFormatNetscape, "# comment\nexample.com\tFALSE\t/\n", "line 2: want 7 fields, got 3"},
+		{CookieFormatNetscape, "example.com\tFALSE\t/\tFALSE\tsoon\tname\tvalue\n", "line 1: invalid expiration time"},
+		{CookieFormat(9), "", "unknown cookie format 9"},
+	}
+	for _, test := range tests {
+		err := Run(ctx, ImportCookies(strings.NewReader(test.input), test.format))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: want error %q, got %v", test.input, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package chromedp
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/chromedp/cdproto/css"
+)
+
+// CSSCoverage is the CSS coverage of a navigation, as reported by UnusedCSS.
+type CSSCoverage struct {
+	StyleSheets []*StyleSheetCoverage `json:"styleSheets"`
+}
+
+// StyleSheetCoverage is the coverage of the rules of a stylesheet.
+//
+// The byte counts are the sizes of the rules in the UTF-8 encoding of the
+// stylesheet text, the selectors are listed in the order of the stylesheet.
+type StyleSheetCoverage struct {
+	StyleSheetID css.StyleSheetID `json:"styleSheetId"`
+	// SourceURL is the URL of the stylesheet, which is the URL of the
+	// document for inline stylesheets.
+	SourceURL       string   `json:"sourceURL"`
+	IsInline        bool     `json:"isInline"`
+	TotalBytes      int      `json:"totalBytes"`
+	UsedBytes       int      `json:"usedBytes"`
+	UnusedBytes     int      `json:"unusedBytes"`
+	UsedSelectors   []string `json:"usedSelectors"`
+	UnusedSelectors []string `json:"unusedSelectors"`
+}
+
+// UnusedCSS runs nav, which should navigate to a page, and reports which
+// rules of the stylesheets of the page were used while the actions ran.
+//
+// The rules of the stylesheets added to the page during nav only are reported,
+// in the order the stylesheets were added. A rule is used when it matched an
+// element at any time, even if the element was later removed.
+func UnusedCSS(ctx context.Context, nav Action) (*CSSCoverage, error) {
+	cov := new(CSSCoverage)
+	if err := Run(ctx, cssCoverageAction(cov, nav)); err != nil {
+		return nil, err
+	}
+	return cov, nil
+}
+
+func cssCoverageAction(cov *CSSCoverage, nav Action) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		var mu sync.Mutex
+		var headers []*css.StyleSheetHeader
+
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			if ev, ok := ev.(*css.EventStyleSheetAdded); ok {
+				mu.Lock()
+				headers = append(headers, ev.Header)
+				mu.Unlock()
+			}
+		})
+
+		if err := css.StartRuleUsageTracking().Do(ctx); err != nil {
+			return err
+		}
+		if err := nav.Do(ctx); err != nil {
+			_, _ = css.StopRuleUsageTracking().Do(ctx)
+			return err
+		}
+		usages, err := css.StopRuleUsageTracking().Do(ctx)
+		if err != nil {
+			return err
+		}
+		cancel()
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, h := range headers {
+			var rules []*css.RuleUsage
+			for _, u := range usages {
+				if u.StyleSheetID == h.StyleSheetID {
+					rules = append(rules, u)
+				}
+			}
+			text, err := css.GetStyleSheetText(h.StyleSheetID).Do(ctx)
+			if err != nil {
+				// the stylesheet was removed, such as by a
+				// later navigation.
+				continue
+			}
+			cov.StyleSheets = append(cov.StyleSheets, styleSheetCoverage(h, text, rules))
+		}
+		return nil
+	})
+}
+
+// styleSheetCoverage computes the coverage of the stylesheet of header h from
+// its text and the usage of its rules.
+//
+// Chrome only reports the rules which were used, so the style rules are found
+// by scanning the text, and those not reported are the unused ones.
+func styleSheetCoverage(h *css.StyleSheetHeader, text string, usages []*css.RuleUsage) *StyleSheetCoverage {
+	sc := &StyleSheetCoverage{
+		StyleSheetID:    h.StyleSheetID,
+		SourceURL:       h.SourceURL,
+		IsInline:        h.IsInline,
+		TotalBytes:      len(text),
+		UsedSelectors:   []string{},
+		UnusedSelectors: []string{},
+	}
+
+	// the offsets are in UTF-16 code units.
+	units := utf16.Encode([]rune(text))
+	for _, r := range styleRules(units) {
+		used := false
+		for _, u := range usages {
+			if off := int(u.StartOffset); u.Used && off >= r[0] && off < r[1] {
+				used = true
+				break
+			}
+		}
+		rule := string(utf16.Decode(units[r[0]:r[1]]))
+		sel := rule
+		if i := strings.IndexByte(rule, '{'); i >= 0 {
+			sel = rule[:i]
+		}
+		sel = strings.TrimSpace(sel)
+		if used {
+			sc.UsedBytes += len(rule)
+			sc.UsedSelectors = append(sc.UsedSelectors, sel)
+		} else {
+			sc.UnusedBytes += len(rule)
+			sc.UnusedSelectors = append(sc.UnusedSelectors, sel)
+		}
+	}
+	return sc
+}
+
+// groupingAtRules are the at-rules whose block holds style rules.
+var groupingAtRules = []string{"@media", "@supports", "@layer", "@container", "@scope", "@starting-style", "@document"}
+
+// styleRules returns the ranges of the style rules of the stylesheet text
+// units, from the start of their selector to the end of their block, in the
+// order of the text. The rules nested in grouping at-rules, such as @media,
+// are included; the blocks of other at-rules, such as @font-face, are skipped.
+func styleRules(units []uint16) [][2]int {
+	type block struct {
+		start  int
+		style  bool // a style rule to report
+		nested bool // the block may hold style rules
+	}
+	var rules [][2]int
+	stack := []block{{nested: true}}
+	start := -1 // start of the current rule prelude
+	for i := 0; i < len(units); i++ {
+		switch c := units[i]; {
+		case c == '/' && i+1 < len(units) && units[i+1] == '*':
+			i += 2
+			for i+1 < len(units) && (units[i] != '*' || units[i+1] != '/') {
+				i++
+			}
+			i++
+		case c == '"' || c == '\'':
+			if start < 0 {
+				start = i
+			}
+			for i++; i < len(units) && units[i] != c; i++ {
+				if units[i] == '\\' {
+					i++
+				}
+			}
+		case c == '\\':
+			if start < 0 {
+				start = i
+			}
+			i++
+		case c == '{':
+			if start < 0 {
+				start = i
+			}
+			b := block{start: start}
+			if stack[len(stack)-1].nested {
+				prelude := string(utf16.Decode(units[start:i]))
+				if !strings.HasPrefix(prelude, "@") {
+					b.style = true
+				} else {
+					b.nested = slices.ContainsFunc(groupingAtRules, func(r string) bool {
+						return strings.HasPrefix(prelude, r) && (len(prelude) == len(r) || !isIdentChar(prelude[len(r)]))
+					})
+				}
+			}
+			stack = append(stack, b)
+			start = -1
+		case c == '}':
+			if len(stack) > 1 {
+				b := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if b.style {
+					rules = append(rules, [2]int{b.start, i + 1})
+				}
+			}
+			start = -1
+		case c == ';':
+			start = -1
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != '\f':
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	return rules
+}
+
+func isIdentChar(c byte) bool {
+	return c == '-' || c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,84 @@
+package chromedp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestUnusedCSS(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	cov, err := UnusedCSS(ctx, Navigate(testdataDir+"/coverage.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cov.StyleSheets) != 2 {
+		t.Fatalf("want 2 stylesheets, got %d", len(cov.StyleSheets))
+	}
+
+	tests := []struct {
+		url    string
+		inline bool
+		used   []string
+		unused []string
+	}{
+		{"/coverage.css", false, []string{".used"}, []string{".unused"}},
+		{"/coverage.html", true, []string{"#inline"}, []string{"#missing"}},
+	}
+	for _, test := range tests {
+		var sc *StyleSheetCoverage
+		for _, s := range cov.StyleSheets {
+			if strings.HasSuffix(s.SourceURL, test.url) {
+				sc = s
+			}
+		}
+		if sc == nil {
+			t.Errorf("no coverage for %s", test.url)
+			continue
+		}
+		if sc.IsInline != test.inline {
+			t.Errorf("%s: want inline %t, got %t", test.url, test.inline, sc.IsInline)
+		}
+		if !reflect.DeepEqual(sc.UsedSelectors, test.used) {
+			t.Errorf("%s: want used selectors %q, got %q", test.url, test.used, sc.UsedSelectors)
+		}
+		if !reflect.DeepEqual(sc.UnusedSelectors, test.unused) {
+			t.Errorf("%s: want unused selectors %q, got %q", test.url, test.unused, sc.UnusedSelectors)
+		}
+		if sc.UsedBytes == 0 || sc.UnusedBytes == 0 || sc.UsedBytes+sc.UnusedBytes > sc.TotalBytes {
+			t.Errorf("%s: invalid byte counts %d+%d of %d", test.url, sc.UsedBytes, sc.UnusedBytes, sc.TotalBytes)
+		}
+	}
+}
+
+func TestStyleRules(t *testing.T) {
+	t.Parallel()
+
+	text := `@import "a.css";
+/* .comment { } */
+a[title="}"] { color: red; }
+@media (min-width: 1px) {
+  .in-media { color: blue; }
+}
+@font-face { font-family: x; src: url(x.woff); }
+@keyframes spin { from { opacity: 0; } to { opacity: 1; } }
+.é, .b { content: "{"; }`
+	units := utf16.Encode([]rune(text))
+	var got []string
+	for _, r := range styleRules(units) {
+		got = append(got, string(utf16.Decode(units[r[0]:r[1]])))
+	}
+	want := []string{
+		`a[title="}"] { color: red; }`,
+		`.in-media { color: blue; }`,
+		`.é, .b { content: "{"; }`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
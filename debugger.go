@@ -0,0 +1,139 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/chromedp/cdproto/debugger"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// PausedState is the state of the page when it paused on an uncaught
+// exception, as passed to the handler of DebugOnException.
+type PausedState struct {
+	// Reason is debugger.PausedReasonException for the thrown exceptions,
+	// and debugger.PausedReasonPromiseRejection for the rejected promises.
+	Reason debugger.PausedReason
+	// Exception is the thrown value, as formatted by FormatRemoteObject,
+	// such as "TypeError: Cannot read properties of null" and its stack
+	// for an Error.
+	Exception string
+	// Frames are the call frames, the innermost first.
+	Frames []*PausedFrame
+}
+
+// PausedFrame is a call frame of a PausedState.
+type PausedFrame struct {
+	// FunctionName is the name of the function, or empty for the anonymous
+	// functions and the top-level code of the scripts.
+	FunctionName string
+	// URL is the URL of the script, or empty for the evaluated scripts.
+	URL string
+	// LineNumber and ColumnNumber are the 0-based position in the script.
+	LineNumber   int64
+	ColumnNumber int64
+	// Scopes are the scopes of the frame, the innermost first.
+	Scopes []*PausedScope
+}
+
+// PausedScope is a scope of a PausedFrame.
+type PausedScope struct {
+	Type debugger.ScopeType
+	// Name is the name of the function of the closure scopes, if any.
+	Name string
+	// Variables are the variables of the scope, as decoded by
+	// DecodeRemoteObject, or formatted by FormatRemoteObject when they
+	// can't be decoded. The variables of the global scope, which are the
+	// properties of the window object, are left out, so that it's nil.
+	Variables map[string]interface{}
+}
+
+// DebugOnException is an action that enables the debugger of the page, so
+// that it pauses on the uncaught exceptions and promise rejections, and calls
+// handler with the state of the page, before resuming it; the page is
+// otherwise debugged as long as ctx isn't cancelled. It's useful to report the
+// local variables leading to a page error, such as in the logs of CI runs.
+//
+// The page doesn't run while handler does, which is called in its own
+// goroutine, so it must not run any actions on the page. Example:
+//
+//	err := chromedp.Run(ctx,
+//		chromedp.DebugOnException(func(st chromedp.PausedState) {
+//			log.Printf("uncaught %s", st.Exception)
+//			for _, f := range st.Frames {
+//				log.Printf("  at %s (%s:%d)", f.FunctionName, f.URL, f.LineNumber+1)
+//				for _, s := range f.Scopes {
+//					log.Printf("    %s: %v", s.Type, s.Variables)
+//				}
+//			}
+//		}),
+//		chromedp.Navigate("https://example.com"),
+//	)
+func DebugOnException(handler func(PausedState)) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		d := &exceptionDebugger{handler: handler, scripts: make(map[runtime.ScriptID]string)}
+		ListenTarget(ctx, func(ev interface{}) {
+			switch ev := ev.(type) {
+			case *debugger.EventScriptParsed:
+				d.mu.Lock()
+				d.scripts[ev.ScriptID] = ev.URL
+				d.mu.Unlock()
+			case *debugger.EventPaused:
+				// the state is fetched with commands, which can't
+				// be sent while the events are handled.
+				go d.paused(ctx, ev)
+			}
+		})
+		if _, err := debugger.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return debugger.SetPauseOnExceptions(debugger.ExceptionsStateUncaught).Do(ctx)
+	})
+}
+
+// exceptionDebugger handles the pauses of a DebugOnException action.
+type exceptionDebugger struct {
+	handler func(PausedState)
+
+	mu sync.Mutex
+	// scripts are the URLs of the parsed scripts.
+	scripts map[runtime.ScriptID]string
+}
+
+// paused calls the handler with the state of the pause ev, and resumes the
+// page.
+func (d *exceptionDebugger) paused(ctx context.Context, ev *debugger.EventPaused) {
+	defer func() {
+		_ = debugger.Resume().Do(ctx)
+	}()
+	if ev.Reason != debugger.PausedReasonException && ev.Reason != debugger.PausedReasonPromiseRejection {
+		// such as a breakpoint set by the user.
+		return
+	}
+	st := PausedState{Reason: ev.Reason}
+	var exp runtime.RemoteObject
+	if len(ev.Data) > 0 && json.Unmarshal(ev.Data, &exp) == nil {
+		st.Exception = FormatRemoteObject(&exp)
+	}
+	for _, cf := range ev.CallFrames {
+		f := &PausedFrame{FunctionName: cf.FunctionName}
+		if cf.Location != nil {
+			d.mu.Lock()
+			f.URL = d.scripts[cf.Location.ScriptID]
+			d.mu.Unlock()
+			f.LineNumber, f.ColumnNumber = cf.Location.LineNumber, cf.Location.ColumnNumber
+		}
+		for _, sc := range cf.ScopeChain {
+			s := &PausedScope{Type: sc.Type, Name: sc.Name}
+			if sc.Type != debugger.ScopeTypeGlobal && sc.Object != nil && sc.Object.ObjectID != "" {
+				// the scope is left without its variables if they
+				// couldn't be fetched.
+				s.Variables, _ = objectProperties(ctx, sc.Object.ObjectID)
+			}
+			f.Scopes = append(f.Scopes, s)
+		}
+		st.Frames = append(st.Frames, f)
+	}
+	d.handler(st)
+}
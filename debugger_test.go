@@ -0,0 +1,87 @@
+package chromedp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/debugger"
+)
+
+func TestDebugOnException(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	states := make(chan PausedState, 1)
+	if err := Run(ctx,
+		DebugOnException(func(st PausedState) {
+			states <- st
+		}),
+		Evaluate(`try {
+			null.caught;
+		} catch (e) {}
+		setTimeout(function failing() {
+			const answer = 42;
+			const name = 'chromedp';
+			null.uncaught;
+		}, 0)`, nil),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var st PausedState
+	select {
+	case st = <-states:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the page didn't pause on the exception")
+	}
+	if st.Reason != debugger.PausedReasonException {
+		t.Errorf("want the reason %q, got %q", debugger.PausedReasonException, st.Reason)
+	}
+	if !strings.Contains(st.Exception, "uncaught") {
+		t.Errorf("want the uncaught exception, got %q", st.Exception)
+	}
+	if len(st.Frames) == 0 {
+		t.Fatal("want some frames")
+	}
+	f := st.Frames[0]
+	if f.FunctionName != "failing" {
+		t.Errorf("want the failing frame first, got %q", f.FunctionName)
+	}
+	if f.LineNumber != 6 {
+		t.Errorf("want the line 6, got %d", f.LineNumber)
+	}
+	var local *PausedScope
+	for _, s := range f.Scopes {
+		switch s.Type {
+		case debugger.ScopeTypeLocal:
+			local = s
+		case debugger.ScopeTypeGlobal:
+			if s.Variables != nil {
+				t.Errorf("want no variables of the global scope, got %d", len(s.Variables))
+			}
+		}
+	}
+	if local == nil {
+		t.Fatal("want a local scope")
+	}
+	if local.Variables["answer"] != float64(42) || local.Variables["name"] != "chromedp" {
+		t.Errorf("want the local variables, got %v", local.Variables)
+	}
+
+	// the page was resumed.
+	var res int
+	if err := Run(ctx, Evaluate(`1 + 2`, &res)); err != nil {
+		t.Fatal(err)
+	}
+	if res != 3 {
+		t.Errorf("want 3, got %d", res)
+	}
+	select {
+	case st := <-states:
+		t.Errorf("want a single pause, got %+v", st)
+	default:
+	}
+}
@@ -0,0 +1,152 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+// defaultDialogTimeout is how long ExpectDialog waits for the dialog by
+// default.
+const defaultDialogTimeout = 5 * time.Second
+
+// DialogExpectation is the JavaScript dialog expected by ExpectDialog, and how
+// to respond to it.
+type DialogExpectation struct {
+	// Type is the expected type of the dialog, such as page.DialogTypeConfirm,
+	// or empty for any type.
+	Type page.DialogType
+	// MessageContains is a substring of the expected message of the dialog.
+	MessageContains string
+
+	// Dismiss dismisses the dialog instead of accepting it.
+	Dismiss bool
+	// PromptText is the text entered in a prompt dialog before it's
+	// accepted.
+	PromptText string
+
+	// Timeout is how long to wait for the dialog, which defaults to 5
+	// seconds.
+	Timeout time.Duration
+}
+
+// DialogInfo is a JavaScript dialog opened by a page, as returned by
+// ExpectDialog.
+type DialogInfo struct {
+	Type          page.DialogType
+	Message       string
+	DefaultPrompt string
+	URL           string
+}
+
+// ExpectDialog runs the trigger action, such as a click, and waits for the
+// JavaScript dialog it opens, such as an alert. The dialog is responded to as
+// set up by exp, and its info is returned.
+//
+// It returns an [ErrUnexpectedDialog] error when the dialog doesn't match exp,
+// once it was responded to, and an [ErrNoDialog] error when no dialog was
+// opened before the timeout of exp. Otherwise, it returns the error of the
+// trigger action, if any. Only the first dialog is responded to, and the
+// trigger action is cancelled if it's still running once ExpectDialog returns.
+//
+// As the trigger action could be blocked by the dialog until it's responded
+// to, it's run concurrently, with another Run call on ctx; this doesn't work
+// with the contexts set up with WithSerializedActions or WithExclusiveActions.
+//
+// Example:
+//
+//	info, err := chromedp.ExpectDialog(ctx, chromedp.DialogExpectation{
+//		Type:            page.DialogTypeConfirm,
+//		MessageContains: "Delete",
+//	}, chromedp.Click(`#delete`, chromedp.ByQuery))
+func ExpectDialog(ctx context.Context, exp DialogExpectation, trigger Action) (DialogInfo, error) {
+	timeout := exp.Timeout
+	if timeout <= 0 {
+		timeout = defaultDialogTimeout
+	}
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	dialogs := make(chan *page.EventJavascriptDialogOpening, 1)
+	ListenTarget(lctx, func(ev interface{}) {
+		if ev, ok := ev.(*page.EventJavascriptDialogOpening); ok {
+			select {
+			case dialogs <- ev:
+			default:
+			}
+		}
+	})
+
+	// the trigger is stopped once ExpectDialog returns, such as when no
+	// dialog was opened, and waited for.
+	tctx, tcancel := context.WithCancel(ctx)
+	errc := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		errc <- Run(tctx, trigger)
+	}()
+	defer func() {
+		tcancel()
+		<-done
+	}()
+
+	var info DialogInfo
+	var ev *page.EventJavascriptDialogOpening
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for wait := true; wait; {
+		select {
+		case ev = <-dialogs:
+			wait = false
+		case <-timer.C:
+			wait = false
+		case <-ctx.Done():
+			return info, ctx.Err()
+		case err := <-errc:
+			// the trigger failed before opening any dialog.
+			if err != nil {
+				return info, err
+			}
+			// keep waiting for a dialog opened once the trigger is done.
+			errc = nil
+		}
+	}
+	if ev == nil {
+		// the trigger could be what failed to open the dialog.
+		select {
+		case err := <-errc:
+			if err != nil {
+				return info, err
+			}
+		default:
+		}
+		return info, fmt.Errorf("%w within %v", ErrNoDialog, timeout)
+	}
+	info = DialogInfo{
+		Type:          ev.Type,
+		Message:       ev.Message,
+		DefaultPrompt: ev.DefaultPrompt,
+		URL:           ev.URL,
+	}
+
+	p := page.HandleJavaScriptDialog(!exp.Dismiss)
+	if exp.PromptText != "" {
+		p = p.WithPromptText(exp.PromptText)
+	}
+	if err := Run(ctx, p); err != nil {
+		return info, err
+	}
+	if err := <-errc; err != nil {
+		return info, err
+	}
+	if exp.Type != "" && info.Type != exp.Type {
+		return info, fmt.Errorf("%w: got %s dialog %q, want %s", ErrUnexpectedDialog, info.Type, info.Message, exp.Type)
+	}
+	if !strings.Contains(info.Message, exp.MessageContains) {
+		return info, fmt.Errorf("%w: got %s dialog %q, want a message containing %q", ErrUnexpectedDialog, info.Type, info.Message, exp.MessageContains)
+	}
+	return info, nil
+}
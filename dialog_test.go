@@ -0,0 +1,140 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+func TestExpectDialog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		trigger Action
+		exp     DialogExpectation
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "Alert",
+			trigger: Click(`#alert`, ByQuery),
+			exp:     DialogExpectation{Type: page.DialogTypeAlert, MessageContains: "alert"},
+		},
+		{
+			name:    "Confirm",
+			trigger: Evaluate(`window.result = String(confirm("confirm text"))`, nil),
+			exp:     DialogExpectation{Type: page.DialogTypeConfirm},
+			want:    "true",
+		},
+		{
+			name:    "Dismiss",
+			trigger: Evaluate(`window.result = String(confirm("confirm text"))`, nil),
+			exp:     DialogExpectation{Dismiss: true},
+			want:    "false",
+		},
+		{
+			name:    "Prompt",
+			trigger: Evaluate(`window.result = prompt("prompt text", "default text")`, nil),
+			exp:     DialogExpectation{Type: page.DialogTypePrompt, PromptText: "entered"},
+			want:    "entered",
+		},
+		{
+			name:    "UnexpectedType",
+			trigger: Click(`#alert`, ByQuery),
+			exp:     DialogExpectation{Type: page.DialogTypeConfirm},
+			wantErr: ErrUnexpectedDialog,
+		},
+		{
+			name:    "UnexpectedMessage",
+			trigger: Click(`#alert`, ByQuery),
+			exp:     DialogExpectation{MessageContains: "other"},
+			wantErr: ErrUnexpectedDialog,
+		},
+		{
+			name:    "NoDialog",
+			trigger: Evaluate(`1`, nil),
+			exp:     DialogExpectation{Timeout: 200 * time.Millisecond},
+			wantErr: ErrNoDialog,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := testAllocate(t, "dialog.html")
+			defer cancel()
+
+			info, err := ExpectDialog(ctx, test.exp, test.trigger)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("want error %v, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Message == "" || info.URL == "" {
+				t.Errorf("want the info of the dialog, got %+v", info)
+			}
+			if test.want != "" {
+				var result string
+				if err := Run(ctx, Evaluate(`window.result`, &result)); err != nil {
+					t.Fatal(err)
+				}
+				if result != test.want {
+					t.Errorf("want the result %q, got %q", test.want, result)
+				}
+			}
+		})
+	}
+}
+
+func TestExpectDialogTriggerError(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "dialog.html")
+	defer cancel()
+
+	// the error of the trigger is returned as soon as it fails, rather than
+	// once no dialog was opened within the timeout.
+	errTrigger := errors.New("trigger failed")
+	start := time.Now()
+	_, err := ExpectDialog(ctx, DialogExpectation{Timeout: 10 * time.Second}, ActionFunc(func(context.Context) error {
+		return errTrigger
+	}))
+	if !errors.Is(err, errTrigger) {
+		t.Fatalf("want error %v, got %v", errTrigger, err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("want the error before the timeout, took %v", d)
+	}
+}
+
+func TestExpectDialogStopsTrigger(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "dialog.html")
+	defer cancel()
+
+	// a trigger which keeps running without opening a dialog is stopped
+	// once ExpectDialog returns.
+	var stopped atomic.Bool
+	trigger := ActionFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped.Store(true)
+		return ctx.Err()
+	})
+	_, err := ExpectDialog(ctx, DialogExpectation{Timeout: 100 * time.Millisecond}, trigger)
+	if !errors.Is(err, ErrNoDialog) {
+		t.Fatalf("want error %v, got %v", ErrNoDialog, err)
+	}
+	if !stopped.Load() {
+		t.Fatal("want the trigger stopped once ExpectDialog returned")
+	}
+}
@@ -0,0 +1,67 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// domEventWorldName is the name of the isolated worlds WaitDOMEvent sets up
+// its listeners in.
+const domEventWorldName = "chromedp_wait_dom_event"
+
+// WaitDOMEvent is an element query action that waits for a DOM event of the
+// given type, such as "transitionend", "change" or a custom event, to be
+// dispatched to the first element node matching the selector, or to one of
+// its descendants. It returns [ErrDOMEventTimeout] when timeout is reached
+// first; a timeout of 0 waits until ctx is done.
+//
+// The one-shot listener is added in an isolated world, so that the scripts of
+// the page can neither see nor remove it. As with [WaitForMutation], it's
+// added once the node was queried, so that the events dispatched before are
+// missed.
+//
+// Example:
+//
+//	err := chromedp.Run(ctx,
+//		chromedp.Click(`#toggle`, chromedp.ByQuery),
+//		chromedp.WaitDOMEvent(`#panel`, "transitionend", 5*time.Second, chromedp.ByQuery),
+//	)
+func WaitDOMEvent(sel interface{}, eventType string, timeout time.Duration, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return errors.New("expected at least one element")
+		}
+		t := cdp.ExecutorFromContext(ctx).(*Target)
+		frameID := t.enclosingFrame(nodes[0])
+		if frameID == "" {
+			return errors.New("could not find the frame of the element")
+		}
+		worldCtx, err := page.CreateIsolatedWorld(frameID).WithWorldName(domEventWorldName).Do(ctx)
+		if err != nil {
+			return err
+		}
+		obj, err := dom.ResolveNode().WithNodeID(nodes[0].NodeID).WithExecutionContextID(worldCtx).Do(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+		}()
+		var dispatched bool
+		if err := CallFunctionOn(waitForEventJS, &dispatched, func(p *runtime.CallFunctionOnParams) *runtime.CallFunctionOnParams {
+			return p.WithObjectID(obj.ObjectID).WithAwaitPromise(true)
+		}, eventType, timeout.Milliseconds()).Do(ctx); err != nil {
+			return err
+		}
+		if !dispatched {
+			return ErrDOMEventTimeout
+		}
+		return nil
+	}, opts...)
+}
@@ -0,0 +1,55 @@
+package chromedp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitDOMEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		eventType string
+		dispatch  string
+	}{
+		{"Change", "change", `const input = document.querySelector("#keyword"); input.value = "x"; input.dispatchEvent(new Event("change", {bubbles: true}))`},
+		{"Custom", "my-event", `document.querySelector("#form").dispatchEvent(new CustomEvent("my-event"))`},
+		// focus doesn't bubble.
+		{"Descendant", "focus", `document.querySelector("#keyword").focus()`},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := testAllocate(t, "form.html")
+			defer cancel()
+
+			if err := Run(ctx,
+				Evaluate(`setTimeout(() => { `+test.dispatch+` }, 100)`, nil),
+				WaitDOMEvent(`#form`, test.eventType, 10*time.Second, ByQuery),
+			); err != nil {
+				t.Fatal(err)
+			}
+			// the following scripts still run in the main world.
+			if err := Run(ctx,
+				Evaluate(`window.mainWorld = true`, nil),
+				Poll(`window.mainWorld`, nil, WithPollingTimeout(time.Second)),
+			); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testAllocate(t, "form.html")
+		defer cancel()
+
+		err := Run(ctx, WaitDOMEvent(`#form`, "my-event", 200*time.Millisecond, ByQuery))
+		if !errors.Is(err, ErrDOMEventTimeout) {
+			t.Errorf("want error %v, got %v", ErrDOMEventTimeout, err)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/page"
+)
+
+// Download is a file downloaded by a page, as reported by WaitDownload.
+type Download struct {
+	// GUID is the id of the download, which is the name of its file until
+	// it's completed.
+	GUID string
+	// URL is the URL the file was downloaded from.
+	URL string
+	// SuggestedFilename is the name of the file, as suggested by the server
+	// or by the download attribute of the link.
+	SuggestedFilename string
+	// Path is the path of the downloaded file.
+	Path string
+	// Err is the error of the download, such as ErrDownloadCanceled, or the
+	// error renaming its file.
+	Err error
+}
+
+// WaitDownload sets up the current page of ctx to download its files into dir,
+// and waits for the next download of the page. Once the download is completed,
+// its file is renamed to its suggested filename, replacing any file with the
+// same name, and the download is sent via the returned channel. It's sent with
+// ErrDownloadCanceled if the download is canceled. Example:
+//
+//	ch, err := chromedp.WaitDownload(ctx, dir)
+//	if err != nil {
+//		// handle error
+//	}
+//	if err := chromedp.Run(ctx, chromedp.Click("#export", chromedp.ByQuery)); err != nil {
+//		// handle error
+//	}
+//	d := <-ch
+//	if d.Err != nil {
+//		// handle error
+//	}
+//	fmt.Println("downloaded", d.Path)
+//
+// The page keeps downloading its files into dir afterwards, named after their
+// GUID.
+func WaitDownload(ctx context.Context, dir string) (<-chan *Download, error) {
+	var ch <-chan *Download
+	if err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		var err error
+		ch, err = waitDownload(ctx, dir, func(d *Download) string {
+			name := filepath.Base(d.SuggestedFilename)
+			if name == "." || name == string(filepath.Separator) {
+				name = d.GUID
+			}
+			return filepath.Join(filepath.Dir(d.Path), name)
+		})
+		return err
+	})); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// DownloadFile is an action that downloads a file of the current page into
+// destPath, replacing any existing file. src is either the http, https or file
+// URL of the file, which the page navigates to, or the selector of the element
+// to click to download it, queried with opts. Example:
+//
+//	chromedp.DownloadFile("#export", "/tmp/report.csv", chromedp.ByQuery)
+//
+// An URL loaded as a page, rather than downloaded, fails with ErrNoDownload.
+func DownloadFile(src, destPath string, opts ...QueryOption) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		destPath, err := filepath.Abs(destPath)
+		if err != nil {
+			return err
+		}
+		dctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch, err := waitDownload(dctx, filepath.Dir(destPath), func(*Download) string {
+			return destPath
+		})
+		if err != nil {
+			return err
+		}
+
+		if isDownloadURL(src) {
+			_, _, errorText, err := page.Navigate(src).Do(ctx)
+			if err != nil {
+				return err
+			}
+			switch errorText {
+			case "net::ERR_ABORTED":
+				// the navigation was turned into a download.
+			case "":
+				return fmt.Errorf("%s: %w", src, ErrNoDownload)
+			default:
+				return fmt.Errorf("page load error %s", errorText)
+			}
+		} else if err := Click(src, opts...).Do(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case d := <-ch:
+			return d.Err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// isDownloadURL reports whether the src of DownloadFile is an URL, rather than
+// a selector.
+func isDownloadURL(src string) bool {
+	for _, scheme := range []string{"http://", "https://", "file://"} {
+		if strings.HasPrefix(src, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitDownload sets up the current page of ctx to download its files into dir,
+// and sends the next download of the page via the returned channel once it's
+// done, with its file renamed to the path returned by name.
+func waitDownload(ctx context.Context, dir string, name func(*Download) string) (<-chan *Download, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *Download, 1)
+	lctx, cancel := context.WithCancel(ctx)
+	// d is only used by the listener, which is called sequentially.
+	var d *Download
+	ListenTarget(lctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *browser.EventDownloadWillBegin:
+			if d == nil {
+				d = &Download{
+					GUID:              ev.GUID,
+					URL:               ev.URL,
+					SuggestedFilename: ev.SuggestedFilename,
+					Path:              filepath.Join(dir, ev.GUID),
+				}
+			}
+		case *browser.EventDownloadProgress:
+			if d == nil || ev.GUID != d.GUID {
+				return
+			}
+			switch ev.State {
+			case browser.DownloadProgressStateCompleted:
+				if path := name(d); path != d.Path {
+					if err := os.Rename(d.Path, path); err != nil {
+						d.Err = err
+					} else {
+						d.Path = path
+					}
+				}
+			case browser.DownloadProgressStateCanceled:
+				d.Path, d.Err = "", ErrDownloadCanceled
+			default:
+				return
+			}
+			ch <- d
+			close(ch)
+			cancel()
+		}
+	})
+	if err := browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
+		WithDownloadPath(dir).
+		WithEventsEnabled(true).
+		Do(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+	return ch, nil
+}
@@ -0,0 +1,108 @@
+package chromedp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newDownloadServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.bin":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			fmt.Fprint(w, "some binary data")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<a id="download" href="/data.bin" download="report.bin">download</a>`)
+		}
+	}))
+}
+
+func TestWaitDownload(t *testing.T) {
+	t.Parallel()
+
+	s := newDownloadServer()
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	dir := t.TempDir()
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := WaitDownload(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx, Click("#download", ByQuery)); err != nil {
+		t.Fatal(err)
+	}
+	var d *Download
+	select {
+	case d = <-ch:
+	case <-time.After(10 * time.Second):
+		t.Fatal("want the download to complete")
+	}
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if want := filepath.Join(dir, "report.bin"); d.Path != want || d.URL != s.URL+"/data.bin" || d.SuggestedFilename != "report.bin" {
+		t.Errorf("want the path %q, got the download %+v", want, d)
+	}
+	buf, err := os.ReadFile(d.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "some binary data" {
+		t.Errorf("got the content %q", buf)
+	}
+	if _, err := os.Stat(filepath.Join(dir, d.GUID)); !os.IsNotExist(err) {
+		t.Errorf("want the file of the GUID to be renamed, got %v", err)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	t.Parallel()
+
+	s := newDownloadServer()
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	dir := t.TempDir()
+	tests := []struct {
+		src  string
+		opts []QueryOption
+	}{
+		{"#download", []QueryOption{ByQuery}},
+		{s.URL + "/data.bin", nil},
+	}
+	for i, test := range tests {
+		dest := filepath.Join(dir, fmt.Sprintf("file%d.bin", i))
+		if err := Run(ctx, Navigate(s.URL), DownloadFile(test.src, dest, test.opts...)); err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		buf, err := os.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "some binary data" {
+			t.Errorf("%s: got the content %q", test.src, buf)
+		}
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 2 {
+		t.Errorf("want only the two files, got %v, %v", entries, err)
+	}
+
+	if err := Run(ctx, DownloadFile(s.URL+"/page", filepath.Join(dir, "page.html"))); !errors.Is(err, ErrNoDownload) {
+		t.Errorf("want ErrNoDownload, got %v", err)
+	}
+}
@@ -1,7 +1,19 @@
 package chromedp
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mailru/easyjson"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp/device"
 )
 
@@ -72,6 +84,174 @@ func EmulateTouch(p1 *emulation.SetDeviceMetricsOverrideParams, p2 *emulation.Se
 	p2.Enabled = true
 }
 
+// EmulateSize is an emulate viewport option to set the device viewport width
+// and height, as with UpdateViewport.
+func EmulateSize(width, height int64) EmulateViewportOption {
+	return func(p1 *emulation.SetDeviceMetricsOverrideParams, p2 *emulation.SetTouchEmulationEnabledParams) {
+		p1.Width, p1.Height = width, height
+	}
+}
+
+// UpdateViewport is an action to change only some settings of the browser
+// viewport, such as its size with EmulateSize, keeping the other settings
+// currently applied by EmulateViewport, Emulate or the emulation commands.
+//
+// The applied settings are tracked by chromedp per target, from the
+// emulation.SetDeviceMetricsOverride and emulation.SetTouchEmulationEnabled
+// commands; the settings which were never applied are the ones the browser was
+// started with.
+//
+// Example:
+//
+//	err := chromedp.Run(ctx,
+//		chromedp.Emulate(device.IPhone7),
+//		chromedp.UpdateViewport(chromedp.EmulateLandscape),
+//	)
+func UpdateViewport(opts ...EmulateViewportOption) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		t, _ := cdp.ExecutorFromContext(ctx).(*Target)
+		if t == nil {
+			return ErrInvalidTarget
+		}
+		p1, p2 := t.currentViewport()
+		for _, o := range opts {
+			o(p1, p2)
+		}
+		return Tasks{p1, p2}.Do(ctx)
+	})
+}
+
+// WithPersistentEmulation sets up a context to keep the emulation of its tab
+// across the tabs it opens: the contexts created from it with NewContext
+// inherit the option, and apply the emulation of its tab to their own tabs on
+// their first Run, instead of starting with the default desktop settings. The
+// emulation is also applied again when the tab is reloaded after its renderer
+// crashed.
+//
+// The emulation is the one set up by Emulate, EmulateViewport, UpdateViewport
+// and the emulation commands overriding the device metrics, the touch
+// emulation and the user agent.
+func WithPersistentEmulation() ContextOption {
+	return func(c *Context) { c.persistEmulation = true }
+}
+
+// WithIsolatedNetworkConditions sets up a context to keep the network
+// conditions emulated in its tab with network.EmulateNetworkConditions, such
+// as its latency and throughput, applied across the navigations of the tab.
+// The pages of a tab can otherwise see the network conditions of the browser,
+// through navigator.onLine and navigator.connection, once it navigated,
+// especially to another origin in another renderer process.
+//
+// The last conditions emulated in the tab are applied again once each document
+// of its top-level frame is parsed, and when it's reloaded after its renderer
+// crashed; the scripts run while the document is parsed can still see the
+// conditions of the browser. The conditions of the other tabs of the browser
+// are left as is.
+func WithIsolatedNetworkConditions() ContextOption {
+	return func(c *Context) { c.isolateNetwork = true }
+}
+
+// emulationState is the emulation applied to a target, as tracked from the
+// emulation commands it executed.
+type emulationState struct {
+	deviceMetrics *emulation.SetDeviceMetricsOverrideParams
+	touch         *emulation.SetTouchEmulationEnabledParams
+	userAgent     *emulation.SetUserAgentOverrideParams
+}
+
+// trackEmulation tracks the emulation applied by the command method with
+// params, which was executed by t.
+func (t *Target) trackEmulation(method string, params easyjson.Marshaler) {
+	t.emulationMu.Lock()
+	defer t.emulationMu.Unlock()
+	switch p := params.(type) {
+	case *emulation.SetDeviceMetricsOverrideParams:
+		cp := *p
+		t.emulation.deviceMetrics = &cp
+	case *emulation.SetTouchEmulationEnabledParams:
+		cp := *p
+		t.emulation.touch = &cp
+	case *emulation.SetUserAgentOverrideParams:
+		cp := *p
+		t.emulation.userAgent = &cp
+	case *network.EmulateNetworkConditionsParams:
+		cp := *p
+		t.networkConditions = &cp
+	case *emulation.SetEmulatedMediaParams:
+		cp := *p
+		t.emulatedMedia = &cp
+	case nil:
+		if method == emulation.CommandClearDeviceMetricsOverride {
+			t.emulation.deviceMetrics = nil
+		}
+	}
+}
+
+// applyEmulation applies the emulation st to t, as tracked by another target,
+// or by t before its renderer crashed.
+func (t *Target) applyEmulation(ctx context.Context, st emulationState) error {
+	var actions Tasks
+	if st.userAgent != nil {
+		actions = append(actions, st.userAgent)
+	}
+	if st.deviceMetrics != nil {
+		actions = append(actions, st.deviceMetrics)
+	}
+	if st.touch != nil {
+		actions = append(actions, st.touch)
+	}
+	return actions.Do(cdp.WithExecutor(ctx, t))
+}
+
+// applyNetworkConditions applies the network conditions last emulated by t
+// again, if any, as set up by WithIsolatedNetworkConditions.
+func (t *Target) applyNetworkConditions(ctx context.Context) error {
+	t.emulationMu.Lock()
+	p := t.networkConditions
+	t.emulationMu.Unlock()
+	if p == nil {
+		return nil
+	}
+	return p.Do(cdp.WithExecutor(ctx, t))
+}
+
+// currentEmulation returns the emulation applied to t.
+func (t *Target) currentEmulation() emulationState {
+	t.emulationMu.Lock()
+	defer t.emulationMu.Unlock()
+	return t.emulation
+}
+
+// currentMedia returns the params to apply the media emulation of t again,
+// which clear it if it wasn't emulated.
+func (t *Target) currentMedia() *emulation.SetEmulatedMediaParams {
+	t.emulationMu.Lock()
+	defer t.emulationMu.Unlock()
+	if p := t.emulatedMedia; p != nil {
+		cp := *p
+		return &cp
+	}
+	return emulation.SetEmulatedMedia()
+}
+
+// currentViewport returns the params to apply the viewport emulation of t
+// again, which are those of the browser when it wasn't emulated.
+func (t *Target) currentViewport() (*emulation.SetDeviceMetricsOverrideParams, *emulation.SetTouchEmulationEnabledParams) {
+	t.emulationMu.Lock()
+	defer t.emulationMu.Unlock()
+	p1 := emulation.SetDeviceMetricsOverride(0, 0, 0, false)
+	if p := t.emulation.deviceMetrics; p != nil {
+		cp := *p
+		p1 = &cp
+	}
+	p2 := emulation.SetTouchEmulationEnabled(false)
+	if p := t.emulation.touch; p != nil {
+		cp := *p
+		p2 = &cp
+	}
+	return p1, p2
+}
+
 // ResetViewport is an action to reset the browser viewport to the default
 // values the browser was started with.
 //
@@ -119,3 +299,203 @@ func Emulate(device Device) EmulateAction {
 func EmulateReset() EmulateAction {
 	return Emulate(device.Reset)
 }
+
+// SetLanguage is an action to consistently emulate the preferred languages of
+// the user, given as BCP 47 language tags in the order of preference (such as
+// "fr-CH", "fr", "en").
+//
+// It sets the Accept-Language header sent with every request, the locale of
+// the browser (which affects Intl and Date formatting) to the first tag, and
+// the values of navigator.language and navigator.languages, which are
+// overridden in the current document and in any document loaded afterwards.
+//
+// Note: the header is set with network.SetExtraHTTPHeaders, which replaces any
+// extra HTTP headers previously set on the target.
+func SetLanguage(tags ...string) EmulateAction {
+	if len(tags) == 0 {
+		panic("tags cannot be empty")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		languages, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		script := fmt.Sprintf("(%s)(%s);", overrideLanguagesJS, languages)
+		if _, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx); err != nil {
+			return err
+		}
+		return Tasks{
+			network.SetExtraHTTPHeaders(network.Headers{"Accept-Language": acceptLanguage(tags)}),
+			emulation.SetLocaleOverride().WithLocale(strings.ReplaceAll(tags[0], "-", "_")),
+			Evaluate(script, nil),
+		}.Do(ctx)
+	})
+}
+
+// acceptLanguage builds the value of an Accept-Language header from the
+// language tags, like "fr-CH,fr;q=0.9,en;q=0.8".
+func acceptLanguage(tags []string) string {
+	var b strings.Builder
+	for i, tag := range tags {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(tag)
+		if i > 0 {
+			q := 10 - i
+			if q < 1 {
+				q = 1
+			}
+			fmt.Fprintf(&b, ";q=0.%d", q)
+		}
+	}
+	return b.String()
+}
+
+// SpoofTime is an action to make the current time of the page start at base,
+// for deterministic results of time-dependent pages (such as in screenshot
+// tests).
+//
+// It injects a shim overriding Date in the current document and in any
+// document loaded afterwards, so that Date.now() and new Date() start at base
+// when each document is created. When tick is true, the time then keeps going
+// forward as usual; otherwise, both Date.now() and performance.now() are
+// frozen.
+//
+// Unless the location of base is time.Local, the timezone of the browser is
+// also set to the location of base (e.g., "America/New_York"), so that the
+// local time seen by the page is consistent with base. The locations created
+// with time.FixedZone, whose names aren't IANA timezones, are emulated with
+// the Etc/GMT±N timezone of their offset; it returns an error for those whose
+// offset isn't a whole number of hours.
+func SpoofTime(base time.Time, tick bool) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		if loc := base.Location(); loc != time.Local {
+			id, err := timezoneID(base)
+			if err != nil {
+				return err
+			}
+			if err := emulation.SetTimezoneOverride(id).Do(ctx); err != nil {
+				return err
+			}
+		}
+		script := fmt.Sprintf("(%s)(%d, %t);", spoofTimeJS, base.UnixMilli(), tick)
+		if _, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx); err != nil {
+			return err
+		}
+		return Evaluate(script, nil).Do(ctx)
+	})
+}
+
+// timezoneID returns the IANA timezone of the location of t, or the Etc/GMT±N
+// timezone of its offset at t for a fixed zone.
+func timezoneID(t time.Time) (string, error) {
+	name := t.Location().String()
+	_, offset := t.Zone()
+	// the names of the fixed zones, such as "" or "EDT", could also be the
+	// names of other timezones.
+	if loc, err := time.LoadLocation(name); err == nil && name != "" && name != "Local" {
+		if _, locOffset := t.In(loc).Zone(); locOffset == offset {
+			return name, nil
+		}
+	}
+	hours := offset / 3600
+	if offset%3600 != 0 || hours < -12 || hours > 14 {
+		return "", fmt.Errorf("cannot emulate the timezone %q: it's not an IANA timezone, and its offset %s isn't a whole number of hours between -12 and +14", name, time.Duration(offset)*time.Second)
+	}
+	if hours == 0 {
+		return "Etc/GMT", nil
+	}
+	// the signs of the Etc/GMT±N timezones are inverted: Etc/GMT-2 is two
+	// hours ahead of UTC.
+	return fmt.Sprintf("Etc/GMT%+d", -hours), nil
+}
+
+// DarkMode is an action to emulate the preferred color scheme of the user, as
+// a dark color scheme when enabled, or a light one otherwise, as seen by the
+// prefers-color-scheme media queries of the pages.
+//
+// When the browser was started with the forced dark mode, which darkens the
+// pages that don't support a dark color scheme themselves (such as with
+// --blink-settings=forceDarkModeEnabled=true), it's turned off for a light
+// color scheme. It's only detected when the browser runs with
+// --enable-automation, as by DefaultExecAllocatorOptions. As headless-shell
+// can't turn it off, the WithFeatureWarning func of the browser is called
+// instead there.
+//
+// This overrides any previous emulation of the media type and features, such
+// as the print media type.
+func DarkMode(enabled bool) EmulateAction {
+	scheme := "light"
+	if enabled {
+		scheme = "dark"
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		if err := emulation.SetEmulatedMedia().
+			WithFeatures([]*emulation.MediaFeature{{Name: "prefers-color-scheme", Value: scheme}}).
+			Do(ctx); err != nil {
+			return err
+		}
+		c := FromContext(ctx)
+		if c == nil || c.Browser == nil {
+			return nil
+		}
+		if enabled {
+			return nil
+		}
+		args, err := browser.GetBrowserCommandLine().Do(cdp.WithExecutor(ctx, c.Browser))
+		if err != nil || !forcedDarkMode(args) {
+			return nil
+		}
+		if c.Browser.Kind() == BrowserHeadlessShell {
+			c.Browser.warnFeature("light color scheme with the forced dark mode", "not supported by "+BrowserHeadlessShell.String())
+			return nil
+		}
+		return emulation.SetAutoDarkModeOverride().WithEnabled(false).Do(ctx)
+	})
+}
+
+// EmulateMediaType is an action that runs the actions with the CSS media type
+// emulated, such as "print" or "screen", keeping the media features emulated
+// by the target, such as the prefers-color-scheme of DarkMode. The previous
+// media emulation is restored afterwards.
+func EmulateMediaType(media string, actions ...Action) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		prev := emulation.SetEmulatedMedia()
+		if t, _ := cdp.ExecutorFromContext(ctx).(*Target); t != nil {
+			prev = t.currentMedia()
+		}
+		if err := emulation.SetEmulatedMedia().WithMedia(media).WithFeatures(prev.Features).Do(ctx); err != nil {
+			return err
+		}
+		err := Tasks(actions).Do(ctx)
+		if rerr := prev.Do(ctx); err == nil {
+			err = rerr
+		}
+		return err
+	})
+}
+
+// forcedDarkMode reports whether the browser started with the command line
+// args darkens the pages which don't support a dark color scheme.
+func forcedDarkMode(args []string) bool {
+	for _, arg := range args {
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "enable-features":
+			for _, feature := range strings.Split(value, ",") {
+				// features may have parameters, as in "Name:param/value".
+				if feature, _, _ = strings.Cut(feature, ":"); feature == "WebContentsForceDark" {
+					return true
+				}
+			}
+		case "blink-settings":
+			for _, setting := range strings.Split(value, ",") {
+				if strings.EqualFold(setting, "forceDarkModeEnabled=true") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -2,9 +2,18 @@ package chromedp
 
 import (
 	"bytes"
+	"context"
 	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp/device"
 )
 
@@ -31,3 +40,421 @@ func TestEmulate(t *testing.T) {
 		t.Errorf("expected size 400x400, got: %dx%d", size.X, size.Y)
 	}
 }
+
+func TestSetLanguage(t *testing.T) {
+	t.Parallel()
+
+	var header string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Accept-Language")
+		w.Write([]byte(`<html><body>language</body></html>`))
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var languages []string
+	var current, formatted string
+	if err := Run(ctx,
+		SetLanguage("de-DE", "de", "en"),
+		Evaluate(`navigator.language`, &current),
+		Navigate(s.URL),
+		Evaluate(`navigator.languages`, &languages),
+		Evaluate(`new Intl.NumberFormat().format(1234.5)`, &formatted),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "de-DE,de;q=0.9,en;q=0.8"; header != want {
+		t.Errorf("want Accept-Language %q, got %q", want, header)
+	}
+	if want := "de-DE"; current != want {
+		t.Errorf("want navigator.language %q in the current document, got %q", want, current)
+	}
+	if want := []string{"de-DE", "de", "en"}; !reflect.DeepEqual(languages, want) {
+		t.Errorf("want navigator.languages %q, got %q", want, languages)
+	}
+	if want := "1.234,5"; formatted != want {
+		t.Errorf("want formatted number %q, got %q", want, formatted)
+	}
+}
+
+func TestTimezoneID(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		loc     *time.Location
+		want    string
+		wantErr bool
+	}{
+		{time.UTC, "UTC", false},
+		{time.FixedZone("", 2*3600), "Etc/GMT-2", false},
+		{time.FixedZone("EST", -4*3600), "Etc/GMT+4", false},
+		{time.FixedZone("+00:00", 0), "Etc/GMT", false},
+		{time.FixedZone("IST", 5*3600+1800), "", true},
+	}
+	for _, tt := range tests {
+		got, err := timezoneID(base.In(tt.loc))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%v: want %q (error %t), got %q (%v)", tt.loc, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
+
+func TestSpoofTimeFixedZone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	base := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("", 2*3600))
+	var offset int
+	if err := Run(ctx,
+		SpoofTime(base, false),
+		Evaluate(`new Date().getTimezoneOffset()`, &offset),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if offset != -120 {
+		t.Errorf("want the offset of the fixed zone, got %d", offset)
+	}
+	err := Run(ctx, SpoofTime(base.In(time.FixedZone("", 5*3600+1800)), false))
+	if err == nil || !strings.Contains(err.Error(), "whole number of hours") {
+		t.Errorf("want the offset rejected, got %v", err)
+	}
+}
+
+func TestSpoofTime(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	base := time.Date(2020, 1, 2, 3, 4, 5, 0, loc)
+
+	tests := []struct {
+		name string
+		tick bool
+	}{
+		{"frozen", false},
+		{"tick", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := testAllocate(t, "")
+			defer cancel()
+
+			var now, created int64
+			var perf1, perf2 float64
+			var hours int
+			var str string
+			if err := Run(ctx,
+				SpoofTime(base, tt.tick),
+				Navigate(testdataDir+"/image.html"),
+				Evaluate(`performance.now()`, &perf1),
+				Sleep(50*time.Millisecond),
+				Evaluate(`Date.now()`, &now),
+				Evaluate(`new Date().getTime()`, &created),
+				Evaluate(`new Date().getHours()`, &hours),
+				Evaluate(`typeof Date()`, &str),
+				Evaluate(`performance.now()`, &perf2),
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			want := base.UnixMilli()
+			if tt.tick {
+				if now < want+50 || now > want+60000 {
+					t.Errorf("want Date.now() shortly after %d, got %d", want, now)
+				}
+				if perf2 <= perf1 {
+					t.Errorf("want performance.now() to tick, got %v then %v", perf1, perf2)
+				}
+			} else {
+				if now != want || created != want {
+					t.Errorf("want Date.now() and new Date() at %d, got %d and %d", want, now, created)
+				}
+				if perf2 != perf1 {
+					t.Errorf("want performance.now() frozen, got %v then %v", perf1, perf2)
+				}
+			}
+			if hours != base.Hour() {
+				t.Errorf("want local hours %d, got %d", base.Hour(), hours)
+			}
+			if str != "string" {
+				t.Errorf("want Date() to return a string, got %q", str)
+			}
+		})
+	}
+}
+
+func TestDarkMode(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "colorscheme.html")
+	defer cancel()
+
+	for _, test := range []struct {
+		enabled bool
+		want    uint32
+	}{
+		{true, 0},
+		{false, 0xff},
+		{true, 0},
+	} {
+		var buf []byte
+		var dark bool
+		if err := Run(ctx,
+			DarkMode(test.enabled),
+			Evaluate(`matchMedia('(prefers-color-scheme: dark)').matches`, &dark),
+			Screenshot(`#scheme`, &buf, ByQuery),
+		); err != nil {
+			t.Fatal(err)
+		}
+		if dark != test.enabled {
+			t.Errorf("enabled=%t: want the dark color scheme to match %t", test.enabled, test.enabled)
+		}
+		img, err := png.Decode(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, g, b, _ := img.At(50, 50).RGBA(); r>>8 != test.want || g>>8 != test.want || b>>8 != test.want {
+			t.Errorf("enabled=%t: want rgb(%d, %d, %d), got rgb(%d, %d, %d)", test.enabled,
+				test.want, test.want, test.want, r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func TestForcedDarkMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"chrome", "--force-dark-mode"}, false},
+		{[]string{"chrome", "--enable-features=Foo,WebContentsForceDark:inversion_method/cielab_based"}, true},
+		{[]string{"chrome", "--enable-features=WebContentsForceDarkFoo"}, false},
+		{[]string{"chrome", "--blink-settings=imagesEnabled=false,forceDarkModeEnabled=true"}, true},
+	}
+	for i, test := range tests {
+		if got := forcedDarkMode(test.args); got != test.want {
+			t.Errorf("test %d: want %t for %q, got %t", i, test.want, test.args, got)
+		}
+	}
+
+	var warnings []string
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], Flag("blink-settings", "forceDarkModeEnabled=true"))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx, WithBrowserOption(WithFeatureWarning(func(feature, reason string) {
+		warnings = append(warnings, feature)
+	})))
+	defer cancel()
+
+	var buf []byte
+	if err := Run(ctx,
+		Navigate(`data:text/html,<body style="margin: 0"><div id="plain" style="width: 100px; height: 100px; background: white"></div></body>`),
+		DarkMode(false),
+		Screenshot(`#plain`, &buf, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _, _, _ := img.At(50, 50).RGBA()
+	if FromContext(ctx).Browser.Kind() == BrowserHeadlessShell {
+		// the page stays darkened.
+		if r>>8 >= 0x80 || len(warnings) != 1 {
+			t.Errorf("want the page darkened with a warning, got red %d and %q", r>>8, warnings)
+		}
+	} else if r>>8 != 0xff || len(warnings) != 0 {
+		t.Errorf("want the page not darkened, got red %d and %q", r>>8, warnings)
+	}
+}
+
+func TestUpdateViewport(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	type viewport struct {
+		Width  int64   `json:"width"`
+		Height int64   `json:"height"`
+		Ratio  float64 `json:"ratio"`
+		Touch  bool    `json:"touch"`
+	}
+	const expr = `({width: innerWidth, height: innerHeight, ratio: devicePixelRatio, touch: navigator.maxTouchPoints > 0})`
+	var initial viewport
+	if err := Run(ctx, Evaluate(expr, &initial)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		action Action
+		want   viewport
+	}{
+		{
+			"nothing applied",
+			UpdateViewport(EmulateScale(3)),
+			viewport{initial.Width, initial.Height, 3, false},
+		},
+		{
+			"applied",
+			EmulateViewport(400, 300, EmulateScale(2), EmulateTouch),
+			viewport{400, 300, 2, true},
+		},
+		{
+			"size",
+			UpdateViewport(EmulateSize(500, 320)),
+			viewport{500, 320, 2, true},
+		},
+		{
+			"scale",
+			UpdateViewport(EmulateScale(1)),
+			viewport{500, 320, 1, true},
+		},
+	}
+	for _, test := range tests {
+		var got viewport
+		if err := Run(ctx, test.action, Evaluate(expr, &got)); err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: want %+v, got %+v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestPersistentEmulation(t *testing.T) {
+	t.Parallel()
+
+	bctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	ctx, cancel := NewContext(bctx, WithPersistentEmulation())
+	defer cancel()
+	crashed := make(chan struct{}, 1)
+	reloaded := make(chan struct{}, 1)
+	ListenTarget(ctx, func(ev interface{}) {
+		switch ev.(type) {
+		case *inspector.EventTargetCrashed:
+			crashed <- struct{}{}
+		case *inspector.EventTargetReloadedAfterCrash:
+			reloaded <- struct{}{}
+		}
+	})
+	if err := Run(ctx,
+		Navigate(testdataDir+"/image.html"),
+		Emulate(device.IPhone7),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(ctx context.Context, name string, want bool) {
+		t.Helper()
+		var ua string
+		var touch bool
+		if err := Run(ctx,
+			Evaluate(`navigator.userAgent`, &ua),
+			Evaluate(`navigator.maxTouchPoints > 0`, &touch),
+		); err != nil {
+			t.Fatal(err)
+		}
+		wantUA := device.IPhone7.Device().UserAgent
+		if got := ua == wantUA && touch; got != want {
+			t.Errorf("%s: want the emulation applied=%t, got user agent %q and touch %t", name, want, ua, touch)
+		}
+	}
+
+	tabCtx, cancel := NewContext(ctx)
+	defer cancel()
+	if err := Run(tabCtx, Navigate(testdataDir+"/image.html")); err != nil {
+		t.Fatal(err)
+	}
+	check(tabCtx, "new tab", true)
+
+	otherCtx, cancel := NewContext(bctx)
+	defer cancel()
+	if err := Run(otherCtx, Navigate(testdataDir+"/image.html")); err != nil {
+		t.Fatal(err)
+	}
+	check(otherCtx, "unrelated tab", false)
+
+	// the crash command doesn't return.
+	go Run(ctx, page.Crash())
+	<-crashed
+	if err := Run(ctx, page.Reload()); err != nil {
+		t.Fatal(err)
+	}
+	<-reloaded
+	if err := Run(ctx, WaitReady(`body`, ByQuery)); err != nil {
+		t.Fatal(err)
+	}
+	check(ctx, "reloaded after a crash", true)
+}
+
+func TestIsolatedNetworkConditions(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>network</body></html>`))
+	}))
+	defer s.Close()
+	// another origin, on the same server.
+	otherURL := strings.Replace(s.URL, "127.0.0.1", "localhost", 1)
+
+	bctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	ctx, cancel := NewContext(bctx, WithIsolatedNetworkConditions())
+	defer cancel()
+	otherCtx, cancel := NewContext(bctx)
+	defer cancel()
+
+	rtt := func(ctx context.Context, urlstr string, want bool) float64 {
+		t.Helper()
+		var rtt float64
+		if err := Run(ctx, Navigate(urlstr)); err != nil {
+			t.Fatal(err)
+		}
+		// the conditions are applied again asynchronously.
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			if err := Run(ctx, Evaluate(`navigator.connection.rtt`, &rtt)); err != nil {
+				t.Fatal(err)
+			}
+			if (rtt > 0) == want {
+				break
+			}
+		}
+		return rtt
+	}
+	for _, c := range []context.Context{ctx, otherCtx} {
+		if err := Run(c,
+			Navigate(s.URL),
+			network.EmulateNetworkConditions(false, 400, 1e6, 1e6),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the rtt is rounded, with some noise.
+	if got := rtt(ctx, otherURL, true); got == 0 {
+		t.Errorf("want the latency once navigated to another origin, got the rtt %v", got)
+	}
+	if got := rtt(ctx, s.URL+"/same", true); got == 0 {
+		t.Errorf("want the latency once navigated, got the rtt %v", got)
+	}
+
+	// the tabs without the option are left as is.
+	if got := rtt(otherCtx, otherURL, false); got != 0 {
+		t.Errorf("want the conditions of the other tab not to be applied again, got the rtt %v", got)
+	}
+}
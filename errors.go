@@ -31,6 +31,17 @@ const (
 	// ErrDisabled is the disabled error.
 	ErrDisabled Error = "disabled"
 
+	// ErrNotStable is the not stable error.
+	ErrNotStable Error = "not stable"
+
+	// ErrObscured is the error that an element does not receive the events at
+	// its center point, as it's covered by another element.
+	ErrObscured Error = "obscured"
+
+	// ErrClickIntercepted is the error that a click would be received by
+	// another element than the clicked one, such as an overlay.
+	ErrClickIntercepted Error = "click intercepted"
+
 	// ErrNotSelected is the not selected error.
 	ErrNotSelected Error = "not selected"
 
@@ -49,9 +60,93 @@ const (
 	// ErrPollingTimeout is the error that the timeout reached before the pageFunction returns a truthy value.
 	ErrPollingTimeout Error = "waiting for function failed: timeout"
 
+	// ErrMutationTimeout is the error that the timeout of WaitForMutation was
+	// reached before the node was mutated.
+	ErrMutationTimeout Error = "waiting for mutation failed: timeout"
+
+	// ErrDOMEventTimeout is the error that the timeout of WaitDOMEvent was
+	// reached before the event was dispatched.
+	ErrDOMEventTimeout Error = "waiting for event failed: timeout"
+
 	// ErrJSUndefined is the error that the type of RemoteObject is "undefined".
 	ErrJSUndefined Error = "encountered an undefined value"
 
 	// ErrJSNull is the error that the value of RemoteObject is null.
 	ErrJSNull Error = "encountered a null value"
+
+	// ErrEvalTimeout is the error that the evaluation of a JavaScript
+	// expression was terminated because it exceeded its timeout.
+	ErrEvalTimeout Error = "evaluation timed out"
+
+	// ErrResultTooLarge is the error that the JSON-encoded result of a
+	// JavaScript evaluation exceeded the maximum allowed size.
+	ErrResultTooLarge Error = "result too large"
+
+	// ErrConcurrentRun is the error that Run was called on a context set up
+	// with WithExclusiveActions while another Run call was running on it.
+	ErrConcurrentRun Error = "concurrent run on the same context"
+
+	// ErrNestedRun is the error that Run was called on a context set up with
+	// WithSerializedActions from the actions of another Run call on it,
+	// which would wait for itself forever.
+	ErrNestedRun Error = "nested run on the same serialized context"
+
+	// ErrNoSpace is the error that there was no disk space left to set up
+	// the user data dir of a browser.
+	ErrNoSpace Error = "no space left for the user data dir"
+
+	// ErrInvalidFlag is the error that a flag set up with Flag would be
+	// ignored by Chrome, or prevent it from starting, such as a flag which
+	// conflicts with another one.
+	ErrInvalidFlag Error = "invalid browser flag"
+
+	// ErrOldHeadlessRemoved is the error that Chrome was started in the old
+	// headless mode, which was removed from Chrome 132. See HeadlessNew.
+	ErrOldHeadlessRemoved Error = "old headless mode removed"
+
+	// ErrCommandTimeout is the error that the browser didn't reply to a
+	// command within the timeout set up by WithCommandTimeout.
+	ErrCommandTimeout Error = "command timed out"
+
+	// ErrNoDialog is the error that no JavaScript dialog was opened within
+	// the timeout of ExpectDialog.
+	ErrNoDialog Error = "no dialog opened"
+
+	// ErrUnexpectedDialog is the error that the JavaScript dialog opened
+	// didn't match the expectation of ExpectDialog.
+	ErrUnexpectedDialog Error = "unexpected dialog"
+
+	// ErrNoMatchingTarget is the error that no existing page was matched by
+	// the func set up by WithTargetMatcher.
+	ErrNoMatchingTarget Error = "no matching target"
+
+	// ErrTooManyRedirects is the error that a navigation of NavigateFollow
+	// was redirected more than its maximum number of redirects.
+	ErrTooManyRedirects Error = "too many redirects"
+
+	// ErrRedirectTimeout is the error that a hop of a navigation of
+	// NavigateFollow didn't get its response within its timeout.
+	ErrRedirectTimeout Error = "redirect hop timed out"
+
+	// ErrDownloadCanceled is the error that a download of the page was
+	// canceled before it completed, such as by the browser.
+	ErrDownloadCanceled Error = "download canceled"
+
+	// ErrNoDownload is the error that the URL given to DownloadFile was
+	// loaded as a page, rather than downloaded.
+	ErrNoDownload Error = "no file downloaded"
+
+	// ErrPoolClosed is the error that a job was given to a Pool which was
+	// closed.
+	ErrPoolClosed Error = "pool closed"
+
+	// ErrTargetLost is the error that the target of a context couldn't be
+	// attached to again once its browser reconnected with WithReconnect,
+	// such as because it was closed meanwhile.
+	ErrTargetLost Error = "target lost"
+
+	// ErrConnectionLost is the error that the connection to the browser was
+	// lost before the reply to a command sent over it, once the browser
+	// reconnected with WithReconnect. The command may have been run or not.
+	ErrConnectionLost Error = "connection lost before the reply"
 )
@@ -3,7 +3,15 @@ package chromedp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"reflect"
+	"time"
+
+	"github.com/mailru/easyjson"
 
 	"github.com/chromedp/cdproto/runtime"
 )
@@ -32,8 +40,24 @@ type EvaluateAction Action
 // and the value that res points to can not be nil (only the value of a chan,
 // func, interface, map, pointer, or slice can be nil), it returns [ErrJSUndefined]
 // or [ErrJSNull] respectively.
+//
+// When the script throws an exception, or the promise it returns is rejected
+// with the [runtime.EvaluateParams.WithAwaitPromise] option, it returns a
+// [*JSError].
+//
+// To evaluate untrusted scripts, see [EvalTimeout] to bound the execution time,
+// and [LimitResultSize] to bound the size of the result.
 func Evaluate(expression string, res interface{}, opts ...EvaluateOption) EvaluateAction {
 	return ActionFunc(func(ctx context.Context) error {
+		if x, ok := res.(*limitedResult); ok {
+			v, err := evaluateWrapped(ctx, expression, opts...)
+			if err != nil {
+				return err
+			}
+			_, err = limitResult(ctx, v, x)
+			return err
+		}
+
 		// set up parameters
 		p := runtime.Evaluate(expression)
 		switch res.(type) {
@@ -50,10 +74,13 @@ func Evaluate(expression string, res interface{}, opts ...EvaluateOption) Evalua
 		// evaluate
 		v, exp, err := p.Do(ctx)
 		if err != nil {
+			if p.Timeout > 0 && isExecutionTerminatedError(err) {
+				return ErrEvalTimeout
+			}
 			return err
 		}
 		if exp != nil {
-			return exp
+			return newJSError(ctx, exp)
 		}
 
 		return parseRemoteObject(v, res)
@@ -66,6 +93,14 @@ func parseRemoteObject(v *runtime.RemoteObject, res interface{}) (err error) {
 	}
 
 	switch x := res.(type) {
+	case *limitedResult:
+		// the results of Evaluate and CallFunctionOn are limited in the
+		// browser instead, by limitResult.
+		if n := len(v.Value); n > x.max {
+			return fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrResultTooLarge, n, x.max)
+		}
+		return parseRemoteObject(v, x.res)
+
 	case **runtime.RemoteObject:
 		*x = v
 		return
@@ -101,6 +136,472 @@ func parseRemoteObject(v *runtime.RemoteObject, res interface{}) (err error) {
 	return json.Unmarshal(value, res)
 }
 
+// limitedResult is the result wrapper returned by LimitResultSize.
+type limitedResult struct {
+	res interface{}
+	max int
+}
+
+// LimitResultSize wraps res so that, when the JSON-encoded result of a script
+// is larger than max bytes, [ErrResultTooLarge] is returned instead of
+// decoding the result into res.
+//
+// The size is checked in the browser, where the result is JSON-encoded to be
+// measured, so that the results over the limit are never sent to the client.
+//
+// The wrapped value can be passed as the res parameter of [Evaluate],
+// [CallFunctionOn], [Poll] and [PollFunction]. Note that res must not be a
+// **runtime.RemoteObject, as those results are not returned by value.
+func LimitResultSize(res interface{}, max int) interface{} {
+	return &limitedResult{res: res, max: max}
+}
+
+// limitResult returns the value of the wrapper object v, as returned by
+// evaluateWrapped, decoded into the result of x, unless its JSON encoding is
+// larger than the limit of x. The wrapper object is released.
+func limitResult(ctx context.Context, v *runtime.RemoteObject, x *limitedResult) (*runtime.RemoteObject, error) {
+	defer func() {
+		_ = runtime.ReleaseObject(v.ObjectID).Do(ctx)
+	}()
+	var res struct {
+		Size  int             `json:"size"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := CallFunctionOn(limitResultJS, &res, withObjectID(v.ObjectID), x.max).Do(ctx); err != nil {
+		return nil, err
+	}
+	if res.Size > x.max {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrResultTooLarge, res.Size, x.max)
+	}
+	obj := &runtime.RemoteObject{Type: runtime.TypeUndefined}
+	switch {
+	case res.Value == nil:
+	case string(res.Value) == "null":
+		obj = &runtime.RemoteObject{Type: runtime.TypeObject, Subtype: runtime.SubtypeNull}
+	default:
+		obj = &runtime.RemoteObject{Value: easyjson.RawMessage(res.Value)}
+	}
+	return obj, parseRemoteObject(obj, x.res)
+}
+
+// evaluateStreamChunkSize is the maximum number of UTF-16 code units that
+// EvaluateStream retrieves from the browser at once.
+const evaluateStreamChunkSize = 1 << 20
+
+// EvaluateStream is an action to evaluate the JavaScript expression, writing
+// the JSON encoding of the script result to w.
+//
+// Unlike [Evaluate], the result is never marshaled as a whole in a single
+// protocol message nor decoded in memory: it's JSON-encoded in the browser
+// with JSON.stringify, and then retrieved in chunks which are written to w
+// as they arrive. This is useful to extract very large datasets from a page.
+//
+// The script result is wrapped in an object in the browser, so that even the
+// primitive results, such as very long strings, are retrieved in chunks. Note
+// that the expression is evaluated with an indirect eval for that, so that the
+// Command Line API (such as $ and $$) isn't available to it, and its top-level
+// let and const declarations don't outlive it. When the script result is
+// "undefined", or JSON.stringify returns undefined for it (e.g., for a
+// function), it returns [ErrJSUndefined].
+func EvaluateStream(expression string, w io.Writer, opts ...EvaluateOption) EvaluateAction {
+	if w == nil {
+		panic("w cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		v, err := evaluateWrapped(ctx, expression, opts...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = runtime.ReleaseObject(v.ObjectID).Do(ctx)
+		}()
+
+		var s *runtime.RemoteObject
+		if err := CallFunctionOn(jsonStringifyJS, &s, withObjectID(v.ObjectID)).Do(ctx); err != nil {
+			return err
+		}
+		if s.ObjectID == "" {
+			return ErrJSUndefined
+		}
+		defer func() {
+			_ = runtime.ReleaseObject(s.ObjectID).Do(ctx)
+		}()
+
+		for start := int64(0); ; {
+			var res struct {
+				Chunk  string `json:"chunk"`
+				End    int64  `json:"end"`
+				Length int64  `json:"length"`
+			}
+			if err := CallFunctionOn(stringChunkJS, &res, withObjectID(s.ObjectID), start, evaluateStreamChunkSize).Do(ctx); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, res.Chunk); err != nil {
+				return err
+			}
+			if res.End >= res.Length {
+				return nil
+			}
+			start = res.End
+		}
+	})
+}
+
+// evaluateWrapped evaluates the expression, returning the remote object of an
+// object holding the script result in its value property. The object is
+// resolved from the promise returned by the script when opts await it.
+func evaluateWrapped(ctx context.Context, expression string, opts ...EvaluateOption) (*runtime.RemoteObject, error) {
+	// the expression is passed as a JSON string literal, which is also a
+	// valid JavaScript one.
+	quoted, err := json.Marshal(expression)
+	if err != nil {
+		return nil, err
+	}
+	wrapper := fmt.Sprintf(`({value: (0, eval)(%s)})`, quoted)
+	p := runtime.Evaluate("")
+	for _, o := range opts {
+		p = o(p)
+	}
+	if p.AwaitPromise {
+		wrapper = fmt.Sprintf(`Promise.resolve((0, eval)(%s)).then(value => ({value}))`, quoted)
+	}
+	// the wrapper object is kept in the browser, even with EvalAsValue.
+	byRef := func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithReturnByValue(false)
+	}
+	var v *runtime.RemoteObject
+	if err := Evaluate(wrapper, &v, append(opts, byRef)...).Do(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// withObjectID is a call option to call the function on the remote object
+// with the given ID.
+func withObjectID(id runtime.RemoteObjectID) CallOption {
+	return func(p *runtime.CallFunctionOnParams) *runtime.CallFunctionOnParams {
+		return p.WithObjectID(id)
+	}
+}
+
+// DeepEvaluate is an action to evaluate the JavaScript expression, using the
+// deep serialization of the protocol to convert the script result to a
+// structured Go value, which is placed in res.
+//
+// Unlike [Evaluate], which relies on JSON, the deep serialization preserves
+// values like Maps, Sets, Dates and BigInts. The JavaScript types are
+// converted as follows:
+//
+//   - undefined and null to nil;
+//   - strings, booleans and numbers to string, bool and float64 (including
+//     NaN, -0, and ±Infinity);
+//   - BigInts to *big.Int;
+//   - Dates to time.Time;
+//   - RegExps to DeepRegExp;
+//   - Arrays and Sets to []interface{};
+//   - Maps to []DeepMapEntry, which preserves the insertion order and allows
+//     non-string keys;
+//   - plain objects (and DOM nodes) to map[string]interface{};
+//   - ArrayBuffers to []byte, and typed arrays to []interface{} of float64,
+//     or of *big.Int for the BigInt64Array and BigUint64Array, which are
+//     retrieved with another call each, as they aren't serialized.
+//
+// The values without a serialized representation in the protocol, such as
+// functions, symbols, promises or DataViews, are set to the low-level
+// *runtime.DeepSerializedValue, as are the typed arrays and ArrayBuffers held
+// by the properties with a symbol key.
+func DeepEvaluate(expression string, res *interface{}, opts ...EvaluateOption) EvaluateAction {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		deep := func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithSerializationOptions(&runtime.SerializationOptions{
+				Serialization: runtime.SerializationOptionsSerializationDeep,
+			})
+		}
+		var v *runtime.RemoteObject
+		if err := Evaluate(expression, &v, append([]EvaluateOption{deep}, opts...)...).Do(ctx); err != nil {
+			return err
+		}
+		if v.ObjectID != "" {
+			defer func() {
+				_ = runtime.ReleaseObject(v.ObjectID).Do(ctx)
+			}()
+		}
+		if v.DeepSerializedValue == nil {
+			return errors.New("the browser did not return a deep serialized value")
+		}
+		d := &deepDecoder{ctx: ctx, root: v.ObjectID, refs: make(map[int64]interface{})}
+		var err error
+		*res, err = d.value(v.DeepSerializedValue, []deepPathStep{})
+		return err
+	})
+}
+
+// DeepMapEntry is an entry of a JavaScript Map, as converted by DeepEvaluate.
+type DeepMapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// DeepRegExp is a JavaScript RegExp, as converted by DeepEvaluate.
+type DeepRegExp struct {
+	Pattern string `json:"pattern"`
+	Flags   string `json:"flags,omitempty"`
+}
+
+// deepDecoder converts the deep serialized values to Go values, as described in
+// DeepEvaluate.
+type deepDecoder struct {
+	// ctx and root are the context and the remote object of the value
+	// which is converted, to retrieve the contents of its typed arrays and
+	// ArrayBuffers, which aren't serialized. They're left as is without a
+	// root.
+	ctx  context.Context
+	root runtime.RemoteObjectID
+
+	// refs keeps the values seen so far by their weak local object
+	// reference, as the browser only serializes the first occurrence of
+	// values which are referenced more than once.
+	refs map[int64]interface{}
+}
+
+// deepPathStep is a step of the path from the root of a deep serialized value
+// to one of its values, as followed by binaryValueJS: the kind of step, such
+// as "index" or "property", and the index or the name of the property.
+type deepPathStep [2]interface{}
+
+// fromDeepSerializedValue converts v to a Go value, as described in
+// DeepEvaluate, without a root to retrieve the contents of its typed arrays.
+func fromDeepSerializedValue(v *runtime.DeepSerializedValue) (interface{}, error) {
+	d := &deepDecoder{refs: make(map[int64]interface{})}
+	return d.value(v, nil)
+}
+
+// value converts v, found at path from the root.
+func (d *deepDecoder) value(v *runtime.DeepSerializedValue, path []deepPathStep) (interface{}, error) {
+	if v.Value == nil && v.WeakLocalObjectReference != 0 {
+		if x, ok := d.refs[v.WeakLocalObjectReference]; ok {
+			return x, nil
+		}
+	}
+	var x interface{}
+	var err error
+	switch v.Type {
+	case runtime.DeepSerializedValueTypeUndefined, runtime.DeepSerializedValueTypeNull:
+		return nil, nil
+
+	case runtime.DeepSerializedValueTypeString:
+		var s string
+		err = json.Unmarshal(v.Value, &s)
+		x = s
+
+	case runtime.DeepSerializedValueTypeBoolean:
+		var b bool
+		err = json.Unmarshal(v.Value, &b)
+		x = b
+
+	case runtime.DeepSerializedValueTypeNumber:
+		x, err = parseDeepNumber(json.RawMessage(v.Value))
+
+	case runtime.DeepSerializedValueTypeBigint:
+		var s string
+		if err = json.Unmarshal(v.Value, &s); err != nil {
+			break
+		}
+		x, err = parseDeepBigint(s)
+
+	case runtime.DeepSerializedValueTypeDate:
+		var t time.Time
+		err = json.Unmarshal(v.Value, &t)
+		x = t
+
+	case runtime.DeepSerializedValueTypeRegexp:
+		var r DeepRegExp
+		err = json.Unmarshal(v.Value, &r)
+		x = r
+
+	case runtime.DeepSerializedValueTypeArray, runtime.DeepSerializedValueTypeSet:
+		var list []*runtime.DeepSerializedValue
+		if err = json.Unmarshal(v.Value, &list); err != nil {
+			break
+		}
+		a := make([]interface{}, len(list))
+		for i, e := range list {
+			if a[i], err = d.value(e, step(path, "index", i)); err != nil {
+				break
+			}
+		}
+		x = a
+
+	case runtime.DeepSerializedValueTypeMap:
+		var entries [][2]json.RawMessage
+		if err = json.Unmarshal(v.Value, &entries); err != nil {
+			break
+		}
+		m := make([]DeepMapEntry, len(entries))
+		for i, e := range entries {
+			if m[i].Key, err = d.key(e[0], step(path, "mapKey", i)); err != nil {
+				break
+			}
+			if m[i].Value, err = d.raw(e[1], step(path, "mapValue", i)); err != nil {
+				break
+			}
+		}
+		x = m
+
+	case runtime.DeepSerializedValueTypeObject:
+		var entries [][2]json.RawMessage
+		if err = json.Unmarshal(v.Value, &entries); err != nil {
+			break
+		}
+		m := make(map[string]interface{}, len(entries))
+		// Maps are references, so record the object before converting its
+		// properties, to support cycles.
+		if v.WeakLocalObjectReference != 0 {
+			d.refs[v.WeakLocalObjectReference] = m
+		}
+		for _, e := range entries {
+			var k, val interface{}
+			if k, err = d.key(e[0], nil); err != nil {
+				break
+			}
+			// the values of the properties with a non-string key,
+			// such as a symbol, can't be found from the root.
+			var valPath []deepPathStep
+			if name, ok := k.(string); ok {
+				valPath = step(path, "property", name)
+			}
+			if val, err = d.raw(e[1], valPath); err != nil {
+				break
+			}
+			m[fmt.Sprint(k)] = val
+		}
+		x = m
+
+	case runtime.DeepSerializedValueTypeNode:
+		var m map[string]interface{}
+		err = json.Unmarshal(v.Value, &m)
+		x = m
+
+	case runtime.DeepSerializedValueTypeTypedarray, runtime.DeepSerializedValueTypeArraybuffer:
+		x, err = d.binary(v, path)
+
+	default:
+		x = v
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not convert deep serialized %s: %w", v.Type, err)
+	}
+	if v.WeakLocalObjectReference != 0 {
+		d.refs[v.WeakLocalObjectReference] = x
+	}
+	return x, nil
+}
+
+// key converts the key of a deep serialized object or map, which is either a
+// string, or a deep serialized value found at path.
+func (d *deepDecoder) key(raw json.RawMessage, path []deepPathStep) (interface{}, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	return d.raw(raw, path)
+}
+
+func (d *deepDecoder) raw(raw json.RawMessage, path []deepPathStep) (interface{}, error) {
+	v := new(runtime.DeepSerializedValue)
+	if err := json.Unmarshal(raw, v); err != nil {
+		return nil, err
+	}
+	return d.value(v, path)
+}
+
+// binary retrieves the contents of the ArrayBuffer or the typed array v, found
+// at path from the root, as a []byte or a []interface{} respectively. v is
+// left as is when it can't be found from the root.
+func (d *deepDecoder) binary(v *runtime.DeepSerializedValue, path []deepPathStep) (interface{}, error) {
+	if d.root == "" || path == nil {
+		return v, nil
+	}
+	var res *struct {
+		Bytes    []byte            `json:"bytes"`
+		Elements []json.RawMessage `json:"elements"`
+		Bigint   bool              `json:"bigint"`
+	}
+	if err := CallFunctionOn(binaryValueJS, &res, withObjectID(d.root), path).Do(d.ctx); err != nil {
+		return nil, err
+	}
+	switch {
+	case res == nil:
+		return v, nil
+	case v.Type == runtime.DeepSerializedValueTypeArraybuffer:
+		if res.Bytes == nil {
+			return []byte{}, nil
+		}
+		return res.Bytes, nil
+	}
+	a := make([]interface{}, len(res.Elements))
+	for i, e := range res.Elements {
+		var err error
+		if !res.Bigint {
+			a[i], err = parseDeepNumber(e)
+		} else {
+			var s string
+			if err = json.Unmarshal(e, &s); err == nil {
+				a[i], err = parseDeepBigint(s)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// step returns path followed by the step of kind to key.
+func step(path []deepPathStep, kind string, key interface{}) []deepPathStep {
+	if path == nil {
+		return nil
+	}
+	return append(path[:len(path):len(path)], deepPathStep{kind, key})
+}
+
+// parseDeepNumber parses a deep serialized number, where the special values
+// are serialized as strings.
+func parseDeepNumber(raw json.RawMessage) (float64, error) {
+	var f float64
+	err := json.Unmarshal(raw, &f)
+	if err == nil {
+		return f, nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return 0, err
+	}
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "-0":
+		return math.Copysign(0, -1), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
+	return 0, fmt.Errorf("invalid number %q", s)
+}
+
+// parseDeepBigint parses the decimal digits of a deep serialized BigInt.
+func parseDeepBigint(s string) (*big.Int, error) {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid bigint %q", s)
+	}
+	return i, nil
+}
+
 // EvaluateAsDevTools is an action that evaluates a JavaScript expression as
 // Chrome DevTools would, evaluating the expression in the "console" context,
 // and making the Command Line API available to the script.
@@ -138,6 +639,18 @@ func EvalIgnoreExceptions(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 	return p.WithSilent(true)
 }
 
+// EvalTimeout is an evaluate option to terminate the execution of the script
+// once it has run for longer than d, in which case [ErrEvalTimeout] is
+// returned.
+//
+// Note that the timeout only bounds the synchronous execution of the script;
+// it doesn't apply to the time spent waiting for a promise to resolve.
+func EvalTimeout(d time.Duration) EvaluateOption {
+	return func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithTimeout(runtime.TimeDelta(d.Milliseconds()))
+	}
+}
+
 // EvalAsValue is an evaluate option that will cause the evaluated JavaScript
 // expression to encode the result of the expression as a JSON-encoded value.
 func EvalAsValue(p *runtime.EvaluateParams) *runtime.EvaluateParams {
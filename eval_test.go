@@ -1,8 +1,17 @@
 package chromedp
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/chromedp/cdproto/runtime"
 )
@@ -204,3 +213,304 @@ func TestEvaluateNil(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	err := Run(ctx,
+		Evaluate(`while (true) {}`, nil, EvalTimeout(100*time.Millisecond)),
+	)
+	if !errors.Is(err, ErrEvalTimeout) {
+		t.Fatalf("want error %v, got: %v", ErrEvalTimeout, err)
+	}
+
+	// The page must still be usable after the termination.
+	var res int
+	if err := Run(ctx, Evaluate(`1 + 2`, &res, EvalTimeout(time.Second))); err != nil {
+		t.Fatal(err)
+	}
+	if res != 3 {
+		t.Fatalf("want: 3, got: %d", res)
+	}
+}
+
+func TestLimitResultSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		expression string
+		max        int
+		wantErr    error
+	}{
+		{
+			name:       "under",
+			expression: "'a'.repeat(8)",
+			max:        10,
+		},
+		{
+			name:       "over",
+			expression: "'a'.repeat(9)",
+			max:        10,
+			wantErr:    ErrResultTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := testAllocate(t, "")
+			defer cancel()
+
+			var res string
+			err := Run(ctx,
+				Evaluate(tt.expression, LimitResultSize(&res, tt.max)),
+			)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error: %v, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && res == "" {
+				t.Fatal("want a non-empty result")
+			}
+		})
+	}
+
+	ctx, cancel := testAllocate(t, "form.html")
+	defer cancel()
+
+	// the results of the functions and of the promises are limited too,
+	// and the undefined and null results are reported as without a limit.
+	var res string
+	if err := Run(ctx, PollFunction(`(n) => 'a'.repeat(n)`, LimitResultSize(&res, 10), WithPollingArgs(9))); !errors.Is(err, ErrResultTooLarge) {
+		t.Errorf("want ErrResultTooLarge for the function, got %v", err)
+	}
+	if err := Run(ctx, Evaluate(`Promise.resolve('a'.repeat(8))`, LimitResultSize(&res, 10), func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	})); err != nil || res != "aaaaaaaa" {
+		t.Errorf("want the result of the promise, got %q, %v", res, err)
+	}
+	if err := Run(ctx, Evaluate(`undefined`, LimitResultSize(&res, 10))); !errors.Is(err, ErrJSUndefined) {
+		t.Errorf("want ErrJSUndefined, got %v", err)
+	}
+	if err := Run(ctx, Evaluate(`null`, LimitResultSize(&res, 10))); !errors.Is(err, ErrJSNull) {
+		t.Errorf("want ErrJSNull, got %v", err)
+	}
+}
+
+func TestEvaluateStream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "number",
+			expression: "123",
+			want:       "123",
+		},
+		{
+			name:       "object",
+			expression: "({a: [1, 2], b: 'ü😀'})",
+			want:       `{"a":[1,2],"b":"ü😀"}`,
+		},
+		{
+			name:       "string",
+			expression: "'a\"b'",
+			want:       `"a\"b"`,
+		},
+		{
+			name:       "NaN",
+			expression: "NaN",
+			want:       "null",
+		},
+		{
+			name:       "undefined",
+			expression: "",
+			wantErr:    ErrJSUndefined,
+		},
+		{
+			name:       "function",
+			expression: "(function() {})",
+			wantErr:    ErrJSUndefined,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := testAllocate(t, "")
+			defer cancel()
+
+			var buf bytes.Buffer
+			err := Run(ctx, EvaluateStream(tt.expression, &buf))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error: %v, got: %v", tt.wantErr, err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("want: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEvaluateStreamLarge(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	// Larger than evaluateStreamChunkSize, with a surrogate pair in each
+	// element, so that some of them end up at the chunk boundaries.
+	const n = 200000
+	var buf bytes.Buffer
+	if err := Run(ctx,
+		EvaluateStream(fmt.Sprintf(`Array.from({length: %d}, (_, i) => i + '😀')`, n), &buf),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n {
+		t.Fatalf("want %d elements, got %d", n, len(got))
+	}
+	for i, s := range got {
+		if want := strconv.Itoa(i) + "😀"; s != want {
+			t.Fatalf("element %d: want %q, got %q", i, want, s)
+		}
+	}
+}
+
+func TestEvaluateStreamLargeString(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	// a primitive result larger than evaluateStreamChunkSize is also
+	// retrieved in chunks, even when awaited.
+	const n = evaluateStreamChunkSize + 1000
+	var buf bytes.Buffer
+	if err := Run(ctx,
+		EvaluateStream(fmt.Sprintf(`Promise.resolve('😀'.repeat(%d))`, n), &buf, EvalAsValue, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Repeat("😀", n); got != want {
+		t.Fatalf("want %d characters, got %d", len(want), len(got))
+	}
+}
+
+func TestDeepEvaluate(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res interface{}
+	if err := Run(ctx, DeepEvaluate(`({
+		date: new Date(Date.UTC(2020, 0, 2, 3, 4, 5)),
+		map: new Map([[1, 'one'], ['two', 2]]),
+		set: new Set(['a', 'b']),
+		big: 12345678901234567890n,
+		nan: NaN,
+		re: /ab+c/gi,
+	})`, &res)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"date": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		"map": []DeepMapEntry{
+			{Key: float64(1), Value: "one"},
+			{Key: "two", Value: float64(2)},
+		},
+		"set": []interface{}{"a", "b"},
+		"re":  DeepRegExp{Pattern: "ab+c", Flags: "gi"},
+	}
+	m, ok := res.(map[string]interface{})
+	if !ok {
+		t.Fatalf("want a map, got: %#v", res)
+	}
+	if bi, _ := m["big"].(*big.Int); bi == nil || bi.String() != "12345678901234567890" {
+		t.Errorf("want a big.Int, got: %#v", m["big"])
+	}
+	if nan, _ := m["nan"].(float64); !math.IsNaN(nan) {
+		t.Errorf("want NaN, got: %#v", m["nan"])
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(m[k], v) {
+			t.Errorf("%s: want: %#v, got: %#v", k, v, m[k])
+		}
+	}
+}
+
+func TestDeepEvaluateBinary(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res interface{}
+	if err := Run(ctx, DeepEvaluate(`({
+		buf: new Uint8Array([1, 2, 255]).buffer,
+		floats: new Float64Array([1.5, NaN]),
+		bigs: new BigInt64Array([-12345678901234567n]),
+		nested: new Map([['ints', [new Int16Array([-1, 7])]]]),
+	})`, &res)); err != nil {
+		t.Fatal(err)
+	}
+	m, ok := res.(map[string]interface{})
+	if !ok {
+		t.Fatalf("want a map, got: %#v", res)
+	}
+	if want := []byte{1, 2, 255}; !reflect.DeepEqual(m["buf"], want) {
+		t.Errorf("buf: want: %#v, got: %#v", want, m["buf"])
+	}
+	if floats, _ := m["floats"].([]interface{}); len(floats) != 2 || floats[0] != 1.5 || !math.IsNaN(floats[1].(float64)) {
+		t.Errorf("floats: got: %#v", m["floats"])
+	}
+	if bigs, _ := m["bigs"].([]interface{}); len(bigs) != 1 || fmt.Sprint(bigs[0]) != "-12345678901234567" {
+		t.Errorf("bigs: got: %#v", m["bigs"])
+	}
+	want := []DeepMapEntry{{Key: "ints", Value: []interface{}{[]interface{}{float64(-1), float64(7)}}}}
+	if !reflect.DeepEqual(m["nested"], want) {
+		t.Errorf("nested: want: %#v, got: %#v", want, m["nested"])
+	}
+}
+
+func TestFromDeepSerializedValue(t *testing.T) {
+	t.Parallel()
+
+	// A serialized cyclic object, like the one returned for:
+	//
+	//	const a = {x: -0}; a.self = a; a
+	raw := `{"type":"object","weakLocalObjectReference":1,"value":[
+		["x",{"type":"number","value":"-0"}],
+		["self",{"type":"object","weakLocalObjectReference":1}]
+	]}`
+	v := new(runtime.DeepSerializedValue)
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		t.Fatal(err)
+	}
+	res, err := fromDeepSerializedValue(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := res.(map[string]interface{})
+	if x := m["x"].(float64); x != 0 || !math.Signbit(x) {
+		t.Errorf("want -0, got: %v", x)
+	}
+	if self, ok := m["self"].(map[string]interface{}); !ok || reflect.ValueOf(self).Pointer() != reflect.ValueOf(m).Pointer() {
+		t.Errorf("want self to reference the object, got: %#v", m["self"])
+	}
+}
@@ -0,0 +1,53 @@
+package chromedp
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/chromedp/cdproto"
+)
+
+// EventDecoder is a func that decodes the raw params of a CDP event into a
+// value which is then passed to the listeners.
+type EventDecoder = func(json.RawMessage) (interface{}, error)
+
+var (
+	eventDecodersMu sync.RWMutex
+	eventDecoders   = make(map[cdproto.MethodType]EventDecoder)
+)
+
+// RegisterEventDecoder registers fn to decode the events with the given method
+// name (such as "Page.someNewEvent") which aren't known to cdproto. Once
+// registered, the values returned by fn are passed to the functions added via
+// ListenBrowser and ListenTarget, just like any other event.
+//
+// This is useful to consume events introduced by a newer Chrome before cdproto
+// is updated. fn may return the json.RawMessage itself to consume the event
+// as raw JSON, or unmarshal it into a user-defined type.
+//
+// Decoders are only used for events that cdproto fails to recognize; they
+// can't replace the decoding of known events. Passing a nil fn unregisters
+// the decoder for method.
+func RegisterEventDecoder(method string, fn EventDecoder) {
+	eventDecodersMu.Lock()
+	defer eventDecodersMu.Unlock()
+	if fn == nil {
+		delete(eventDecoders, cdproto.MethodType(method))
+		return
+	}
+	eventDecoders[cdproto.MethodType(method)] = fn
+}
+
+// decodeCustomEvent decodes msg with the decoder registered via
+// RegisterEventDecoder, if any. It returns false when no decoder was
+// registered for the message's method.
+func decodeCustomEvent(msg *cdproto.Message) (interface{}, bool, error) {
+	eventDecodersMu.RLock()
+	fn := eventDecoders[msg.Method]
+	eventDecodersMu.RUnlock()
+	if fn == nil {
+		return nil, false, nil
+	}
+	ev, err := fn(json.RawMessage(msg.Params))
+	return ev, true, err
+}
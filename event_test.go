@@ -1,9 +1,11 @@
 package chromedp
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
+	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/target"
 )
@@ -184,3 +186,36 @@ func TestWaitNewTarget(t *testing.T) {
 		t.Errorf("want to be on form.html, at %q", urlstr)
 	}
 }
+
+func TestRegisterEventDecoder(t *testing.T) {
+	t.Parallel()
+
+	type fooEvent struct {
+		Bar string `json:"bar"`
+	}
+	const method = "Test.fooHappened"
+	RegisterEventDecoder(method, func(params json.RawMessage) (interface{}, error) {
+		ev := new(fooEvent)
+		if err := json.Unmarshal(params, ev); err != nil {
+			return nil, err
+		}
+		return ev, nil
+	})
+	defer RegisterEventDecoder(method, nil)
+
+	msg := &cdproto.Message{Method: method, Params: []byte(`{"bar":"baz"}`)}
+	ev, ok, err := decodeCustomEvent(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected the registered decoder to be used")
+	}
+	if foo, _ := ev.(*fooEvent); foo == nil || foo.Bar != "baz" {
+		t.Fatalf("unexpected event: %#v", ev)
+	}
+
+	if _, ok, _ := decodeCustomEvent(&cdproto.Message{Method: "Test.unknown"}); ok {
+		t.Fatal("expected no decoder for an unregistered method")
+	}
+}
@@ -0,0 +1,233 @@
+package chromedp
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/chromedp/cdproto"
+)
+
+// defaultEventQueueSize is the default size of the event queue of targets.
+const defaultEventQueueSize = 1024
+
+// EventQueuePolicy is the policy applied when the event queue of a target is
+// full, because its listeners are slower than the browser sending events.
+//
+// The responses to commands, and the events of the Page, DOM and Runtime
+// domains, which chromedp needs to track the state of the target, are never
+// dropped.
+type EventQueuePolicy struct {
+	dropOldest  bool
+	dropDomains []string
+}
+
+var (
+	// EventQueueBlock is the default policy, which stops reading messages
+	// from the browser until the queue has room again. Note that this also
+	// blocks the messages of the other targets of the browser.
+	EventQueueBlock = EventQueuePolicy{}
+
+	// EventQueueDropOldest is the policy dropping the oldest event of the
+	// queue to make room for a new message.
+	EventQueueDropOldest = EventQueuePolicy{dropOldest: true}
+)
+
+// EventQueueDropDomains returns the policy dropping the new events of the
+// given domains, such as "Network", and blocking as EventQueueBlock for the
+// other messages.
+func EventQueueDropDomains(domains ...string) EventQueuePolicy {
+	return EventQueuePolicy{dropDomains: domains}
+}
+
+// EventQueueStats are the statistics of the event queue of a target.
+type EventQueueStats struct {
+	// Len is the number of messages in the queue.
+	Len int
+	// Dropped is the number of events dropped.
+	Dropped uint64
+	// DroppedByDomain is the number of events dropped, by domain.
+	DroppedByDomain map[string]uint64
+}
+
+// WithEventQueue is a context option to limit the queue of the incoming
+// messages of the target to size messages, applying policy when it's full.
+//
+// The default is a queue of 1024 messages with the EventQueueBlock policy.
+// Use Target.EventQueueStats to know how many events were dropped.
+func WithEventQueue(size int, policy EventQueuePolicy) ContextOption {
+	if size <= 0 {
+		panic("size must be positive")
+	}
+	return func(c *Context) {
+		c.eventQueueSize = size
+		c.eventQueuePolicy = policy
+	}
+}
+
+// eventQueue is the queue of the incoming messages of a target, pushed by the
+// browser and popped by the target.
+type eventQueue struct {
+	size   int
+	policy EventQueuePolicy
+
+	mu    sync.Mutex
+	msgs  []*cdproto.Message
+	stats EventQueueStats
+
+	// ready and room are signaled when the queue is not empty, and when it
+	// has room.
+	ready chan struct{}
+	room  chan struct{}
+
+	// closed is closed once the target stops popping messages, so that the
+	// browser doesn't block on a full queue.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newEventQueue(size int, policy EventQueuePolicy) *eventQueue {
+	if size <= 0 {
+		size = defaultEventQueueSize
+	}
+	return &eventQueue{
+		size:   size,
+		policy: policy,
+		ready:  make(chan struct{}, 1),
+		room:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+// close makes the pending and future pushes discard their messages.
+func (q *eventQueue) close() {
+	q.closeOnce.Do(func() { close(q.closed) })
+}
+
+// droppable reports whether msg is an event which can be dropped.
+func droppable(msg *cdproto.Message) bool {
+	if msg.ID != 0 || msg.Method == "" {
+		return false
+	}
+	switch msg.Method.Domain() {
+	case "Page", "DOM", "Runtime":
+		return false
+	}
+	return true
+}
+
+// push pushes msg to the queue, applying the policy of the queue if it's full.
+// It returns false if ctx is done before msg can be pushed, and discards msg
+// if the queue is closed.
+func (q *eventQueue) push(ctx context.Context, msg *cdproto.Message) bool {
+	for {
+		q.mu.Lock()
+		if len(q.msgs) >= q.size {
+			q.drop()
+		}
+		switch {
+		case len(q.msgs) < q.size:
+			q.msgs = append(q.msgs, msg)
+			q.mu.Unlock()
+			signal(q.ready)
+			return true
+		case q.dropped(msg):
+			q.mu.Unlock()
+			return true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-q.closed:
+			return true
+		case <-q.room:
+		}
+	}
+}
+
+// drop drops the oldest droppable event of the full queue, if the policy is
+// EventQueueDropOldest.
+func (q *eventQueue) drop() {
+	if !q.policy.dropOldest {
+		return
+	}
+	i := slices.IndexFunc(q.msgs, droppable)
+	if i < 0 {
+		return
+	}
+	q.count(q.msgs[i])
+	q.msgs = slices.Delete(q.msgs, i, i+1)
+}
+
+// dropped reports whether msg is dropped, as the queue is full and no event of
+// the queue was dropped.
+func (q *eventQueue) dropped(msg *cdproto.Message) bool {
+	if !droppable(msg) {
+		return false
+	}
+	if !q.policy.dropOldest && !slices.Contains(q.policy.dropDomains, msg.Method.Domain()) {
+		return false
+	}
+	q.count(msg)
+	return true
+}
+
+func (q *eventQueue) count(msg *cdproto.Message) {
+	q.stats.Dropped++
+	if q.stats.DroppedByDomain == nil {
+		q.stats.DroppedByDomain = make(map[string]uint64)
+	}
+	q.stats.DroppedByDomain[msg.Method.Domain()]++
+}
+
+// pop pops the oldest message of the queue, waiting for one if it's empty. It
+// returns false if ctx is done first.
+func (q *eventQueue) pop(ctx context.Context) (*cdproto.Message, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.msgs) > 0 {
+			msg := q.msgs[0]
+			q.msgs[0] = nil
+			q.msgs = q.msgs[1:]
+			more := len(q.msgs) > 0
+			q.mu.Unlock()
+			signal(q.room)
+			if more {
+				signal(q.ready)
+			}
+			return msg, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-q.ready:
+		}
+	}
+}
+
+// signal signals ch without blocking.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// EventQueueStats returns the statistics of the queue of the incoming
+// messages of the target, set up by WithEventQueue.
+func (t *Target) EventQueueStats() EventQueueStats {
+	q := t.messageQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := q.stats
+	stats.Len = len(q.msgs)
+	stats.DroppedByDomain = make(map[string]uint64, len(q.stats.DroppedByDomain))
+	for d, n := range q.stats.DroppedByDomain {
+		stats.DroppedByDomain[d] = n
+	}
+	return stats
+}
@@ -0,0 +1,149 @@
+package chromedp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto"
+)
+
+func TestEventQueue(t *testing.T) {
+	t.Parallel()
+
+	resp := &cdproto.Message{ID: 1}
+	page := &cdproto.Message{Method: "Page.loadEventFired"}
+	net1 := &cdproto.Message{Method: "Network.requestWillBeSent"}
+	net2 := &cdproto.Message{Method: "Network.responseReceived"}
+	log := &cdproto.Message{Method: "Log.entryAdded"}
+
+	tests := []struct {
+		name    string
+		policy  EventQueuePolicy
+		push    []*cdproto.Message
+		want    []*cdproto.Message
+		dropped map[string]uint64
+	}{
+		{
+			name:   "DropOldest",
+			policy: EventQueueDropOldest,
+			push:   []*cdproto.Message{resp, net1, page, net2, log},
+			want:   []*cdproto.Message{resp, page, log},
+			dropped: map[string]uint64{
+				"Network": 2,
+			},
+		},
+		{
+			name:   "DropOldestNotDroppable",
+			policy: EventQueueDropOldest,
+			push:   []*cdproto.Message{resp, page, page, net1},
+			want:   []*cdproto.Message{resp, page, page},
+			dropped: map[string]uint64{
+				"Network": 1,
+			},
+		},
+		{
+			name:   "DropDomains",
+			policy: EventQueueDropDomains("Network"),
+			push:   []*cdproto.Message{log, page, net1, net2, resp},
+			want:   []*cdproto.Message{log, page, net1, resp},
+			dropped: map[string]uint64{
+				"Network": 1,
+			},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			q := newEventQueue(3, test.policy)
+			pushed := make(chan bool)
+			go func() {
+				for _, msg := range test.push {
+					if !q.push(ctx, msg) {
+						pushed <- false
+						return
+					}
+				}
+				pushed <- true
+			}()
+
+			// the last message blocks in the DropDomains test, until the
+			// queue has room.
+			if test.name == "DropDomains" {
+				time.Sleep(10 * time.Millisecond)
+			} else if !<-pushed {
+				t.Fatal("push blocked")
+			}
+			var got []*cdproto.Message
+			for range test.want {
+				msg, ok := q.pop(ctx)
+				if !ok {
+					t.Fatal("pop blocked")
+				}
+				got = append(got, msg)
+			}
+			if test.name == "DropDomains" && !<-pushed {
+				t.Fatal("push blocked")
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want messages %v, got %v", test.want, got)
+			}
+			stats := q.stats
+			if !reflect.DeepEqual(stats.DroppedByDomain, test.dropped) {
+				t.Errorf("want dropped %v, got %v", test.dropped, stats.DroppedByDomain)
+			}
+		})
+	}
+}
+
+func TestEventQueueBlock(t *testing.T) {
+	t.Parallel()
+
+	q := newEventQueue(1, EventQueueBlock)
+	ctx := context.Background()
+	if !q.push(ctx, &cdproto.Message{Method: "Network.requestWillBeSent"}) {
+		t.Fatal("push blocked")
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if q.push(tctx, &cdproto.Message{Method: "Network.responseReceived"}) {
+		t.Fatal("want push to block on a full queue")
+	}
+	if q.stats.Dropped != 0 {
+		t.Errorf("want no dropped events, got %d", q.stats.Dropped)
+	}
+
+	// the messages are discarded once the target is done.
+	q.close()
+	if !q.push(ctx, &cdproto.Message{Method: "Network.responseReceived"}) {
+		t.Fatal("want push to discard the message on a closed queue")
+	}
+}
+
+func TestWithEventQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+	ctx, cancel = NewContext(ctx, WithEventQueue(2, EventQueueDropDomains("Network")))
+	defer cancel()
+
+	// a slow listener fills the queue with network events.
+	ListenTarget(ctx, func(ev interface{}) {
+		time.Sleep(5 * time.Millisecond)
+	})
+	if err := Run(ctx, Navigate(testdataDir+"/image.html")); err != nil {
+		t.Fatal(err)
+	}
+	stats := FromContext(ctx).Target.EventQueueStats()
+	if stats.Dropped != stats.DroppedByDomain["Network"] {
+		t.Errorf("want only network events dropped, got %v", stats.DroppedByDomain)
+	}
+	t.Logf("dropped %d events", stats.Dropped)
+}
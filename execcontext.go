@@ -0,0 +1,65 @@
+package chromedp
+
+import (
+	"context"
+	"slices"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// ExecutionContext is an execution context of a page, in which its scripts
+// run, as listed by ExecutionContexts. Each frame has a default one, the main
+// world of its document, and may have isolated worlds, such as those of the
+// content scripts of extensions, which share its DOM but not its JavaScript
+// objects.
+type ExecutionContext struct {
+	ID runtime.ExecutionContextID
+	// UniqueID is unique across the processes of the browser, unlike ID.
+	UniqueID string
+	// FrameID is the frame of the context, which is empty for the contexts
+	// without a frame.
+	FrameID cdp.FrameID
+	// Name is the name of the world, such as the name of an extension for
+	// its content scripts, which is empty for the default contexts.
+	Name   string
+	Origin string
+	// Type is the type of the context, such as "default", "isolated" or
+	// "worker".
+	Type string
+	// Default is whether it's the default context of its frame.
+	Default bool
+}
+
+// ExecutionContexts returns the execution contexts of the page of ctx,
+// including the isolated worlds, in the order they were created. The page
+// must have been allocated, such as by a previous Run.
+//
+// The id of a context can be passed to Evaluate with the InContext option.
+func ExecutionContexts(ctx context.Context) ([]*ExecutionContext, error) {
+	c := FromContext(ctx)
+	if c == nil || c.Target == nil {
+		return nil, ErrInvalidContext
+	}
+	t := c.Target
+	t.frameMu.RLock()
+	defer t.frameMu.RUnlock()
+	res := make([]*ExecutionContext, 0, len(t.contexts))
+	for _, ec := range t.contexts {
+		cp := *ec
+		res = append(res, &cp)
+	}
+	slices.SortFunc(res, func(a, b *ExecutionContext) int {
+		return int(a.ID - b.ID)
+	})
+	return res, nil
+}
+
+// InContext is an evaluate option to evaluate the expression in the execution
+// context id, such as an isolated world listed by ExecutionContexts, rather
+// than in the default one of the current frame.
+func InContext(id runtime.ExecutionContextID) EvaluateOption {
+	return func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithContextID(id)
+	}
+}
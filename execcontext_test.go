@@ -0,0 +1,79 @@
+package chromedp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestExecutionContexts(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "iframe.html")
+	defer cancel()
+
+	var frameID string
+	var worldID runtime.ExecutionContextID
+	if err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		frameID = string(tree.Frame.ID)
+		worldID, err = page.CreateIsolatedWorld(tree.Frame.ID).WithWorldName("chromedp-test").Do(ctx)
+		return err
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	// the contexts are tracked from the events, which may be handled after
+	// the command replied.
+	var world *ExecutionContext
+	var defaults int
+	deadline := time.Now().Add(5 * time.Second)
+	for world == nil && time.Now().Before(deadline) {
+		contexts, err := ExecutionContexts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defaults = 0
+		for _, ec := range contexts {
+			if ec.ID == worldID {
+				world = ec
+			}
+			if ec.Default {
+				defaults++
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if world == nil {
+		t.Fatal("want the isolated world to be listed")
+	}
+	if world.Name != "chromedp-test" || world.Default || string(world.FrameID) != frameID || world.Type != "isolated" {
+		t.Errorf("got the isolated world %+v", world)
+	}
+	if defaults < 2 {
+		t.Errorf("want the default contexts of the page and its iframe, got %d", defaults)
+	}
+
+	// the isolated world shares the DOM, but not the globals.
+	var main, isolated string
+	if err := Run(ctx,
+		Evaluate(`window.chromedpTest = 'main'; document.title = 'shared'`, nil),
+		Evaluate(`typeof window.chromedpTest`, &main),
+		Evaluate(`typeof window.chromedpTest + ' ' + document.title`, &isolated, InContext(worldID)),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if main != "string" || isolated != "undefined shared" {
+		t.Errorf("got %q and %q", main, isolated)
+	}
+
+	if _, err := ExecutionContexts(context.Background()); err != ErrInvalidContext {
+		t.Errorf("want ErrInvalidContext, got %v", err)
+	}
+}
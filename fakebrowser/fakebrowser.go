@@ -0,0 +1,293 @@
+// Package fakebrowser is a fake browser, which answers a set of the protocol
+// methods in process, so that the programs using chromedp can unit test their
+// actions in milliseconds, without Chrome. It answers the methods chromedp
+// needs to open and close tabs, navigates to any URL without loading it,
+// evaluates the JavaScript expressions it's given canned results for, and
+// captures its fixture as the screenshots. The other methods fail, unless
+// a Handler is set up for them. It's served by the test process itself, over
+// an in-process chromedp.Transport, so that no network connection is made.
+//
+// Each connection gets its own instance of the browser, while the calls of all
+// of them are recorded by the Browser, in order, to be checked with Calls.
+//
+//	b := fakebrowser.New(
+//		fakebrowser.WithPage("https://example.com", "Example Domain"),
+//		fakebrowser.WithEvaluate(`document.querySelectorAll("a").length`, 1),
+//	)
+//	defer b.Close()
+//	allocCtx, cancel := b.NewAllocator(context.Background())
+//	defer cancel()
+//	ctx, cancel := chromedp.NewContext(allocCtx)
+//	defer cancel()
+//	var title string
+//	err := chromedp.Run(ctx,
+//		chromedp.Navigate("https://example.com"),
+//		chromedp.Title(&title),
+//	)
+//
+// The fake browser doesn't render pages, so that the actions relying on the
+// DOM, such as the queries by selector, need a Handler for the DOM methods
+// they use.
+package fakebrowser
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Call is a protocol method called on the fake browser.
+type Call struct {
+	// Method is the method, such as "Page.navigate".
+	Method string
+	// SessionID is the session of the target the method was called on, or
+	// empty for the browser.
+	SessionID target.SessionID
+	// Params are the JSON-encoded parameters of the call.
+	Params json.RawMessage
+}
+
+// Handler answers a call, with a result which is encoded as JSON, such as a
+// map or a struct of the cdproto package. An error of type *cdproto.Error is
+// sent as is; the other errors are sent as server errors.
+type Handler func(call *Call) (interface{}, error)
+
+// Option is a fake browser option.
+type Option = func(*Browser)
+
+// Browser is a fake browser. It's safe for concurrent use.
+type Browser struct {
+	// closed is closed by Close.
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	handlers   map[string]Handler
+	evals      map[string]interface{}
+	titles     map[string]string
+	navErrors  map[string]string
+	screenshot []byte
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// WithHandler answers the calls of method with h, instead of the default
+// answer, if any.
+func WithHandler(method string, h Handler) Option {
+	return func(b *Browser) { b.handlers[method] = h }
+}
+
+// WithEvaluate answers the evaluations of the JavaScript expression with value,
+// encoded as JSON. A value of type error is thrown as an exception instead.
+// The expressions without a canned result evaluate to undefined, except for
+// those chromedp.Title and chromedp.Location evaluate, which return the title
+// and the URL of the current page.
+func WithEvaluate(expression string, value interface{}) Option {
+	return func(b *Browser) { b.evals[expression] = value }
+}
+
+// WithPage sets the title of the page at urlstr, once the fake browser
+// navigated to it. The pages are untitled by default.
+func WithPage(urlstr, title string) Option {
+	return func(b *Browser) { b.titles[urlstr] = title }
+}
+
+// WithNavigateError fails the navigations to urlstr with errorText, such as
+// "net::ERR_NAME_NOT_RESOLVED".
+func WithNavigateError(urlstr, errorText string) Option {
+	return func(b *Browser) { b.navErrors[urlstr] = errorText }
+}
+
+// WithScreenshot sets the PNG image captured as the screenshots of the pages.
+// It's an image of a white pixel by default.
+func WithScreenshot(buf []byte) Option {
+	return func(b *Browser) { b.screenshot = buf }
+}
+
+// New starts a fake browser, set up by opts.
+func New(opts ...Option) *Browser {
+	b := &Browser{
+		closed:    make(chan struct{}),
+		handlers:  make(map[string]Handler),
+		evals:     make(map[string]interface{}),
+		titles:    make(map[string]string),
+		navErrors: make(map[string]string),
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	if b.screenshot == nil {
+		b.screenshot = whitePixel()
+	}
+	return b
+}
+
+// wsURL is the websocket URL the allocators of the fake browsers connect to,
+// which is never dialed.
+const wsURL = "ws://fakebrowser/devtools/browser/fake"
+
+// NewAllocator creates an allocator context from parent which connects to the
+// fake browser in process, with chromedp.NewRemoteAllocator. Each root context
+// created from it connects to a new instance of the browser, with a blank tab.
+func (b *Browser) NewAllocator(parent context.Context) (context.Context, context.CancelFunc) {
+	return chromedp.NewRemoteAllocator(parent, wsURL, chromedp.NoModifyURL, chromedp.WithDialer(b.dial))
+}
+
+// dial connects to a new instance of the fake browser.
+func (b *Browser) dial(ctx context.Context, urlstr string) (chromedp.Transport, error) {
+	select {
+	case <-b.closed:
+		return nil, errors.New("fake browser is closed")
+	default:
+	}
+	t := newTransport(b.closed)
+	go b.serve(t)
+	return t, nil
+}
+
+// Close stops the fake browser, closing its connections.
+func (b *Browser) Close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+}
+
+// Calls returns the calls of method received so far, or all the calls if
+// method is empty, in order.
+func (b *Browser) Calls(method string) []Call {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var calls []Call
+	for _, call := range b.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// serve serves an instance of the fake browser over t, until the connection
+// or the browser is closed.
+func (b *Browser) serve(t *transport) {
+	s := &session{
+		b:        b,
+		conn:     t,
+		targets:  make(map[target.ID]*fakeTarget),
+		sessions: make(map[target.SessionID]*fakeTarget),
+	}
+	defer t.end()
+	s.newTarget("")
+	for {
+		msg, ok := t.receive()
+		if !ok || !s.handle(msg) {
+			return
+		}
+	}
+}
+
+// whitePixel returns the PNG image of a white pixel.
+func whitePixel() []byte {
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	img.Pix[0] = 0xff
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// errNotFound is the error of the methods the fake browser doesn't answer, as
+// sent by Chrome.
+func errNotFound(method string) *cdproto.Error {
+	return &cdproto.Error{Code: -32601, Message: fmt.Sprintf("'%s' wasn't found", method)}
+}
+
+// errorMessage returns the error message sent for err.
+func errorMessage(err error) *cdproto.Error {
+	var cerr *cdproto.Error
+	if errors.As(err, &cerr) {
+		return cerr
+	}
+	return &cdproto.Error{Code: -32000, Message: err.Error()}
+}
+
+// evalResult returns the result of the evaluation of an expression to value.
+func evalResult(value interface{}) (*runtime.EvaluateReturns, error) {
+	if err, ok := value.(error); ok {
+		return &runtime.EvaluateReturns{
+			Result: &runtime.RemoteObject{Type: runtime.TypeObject, Subtype: runtime.SubtypeError, Description: err.Error()},
+			ExceptionDetails: &runtime.ExceptionDetails{
+				Text: "Uncaught",
+				Exception: &runtime.RemoteObject{
+					Type:        runtime.TypeObject,
+					Subtype:     runtime.SubtypeError,
+					ClassName:   "Error",
+					Description: "Error: " + err.Error(),
+				},
+			},
+		}, nil
+	}
+	if value == nil {
+		return &runtime.EvaluateReturns{Result: &runtime.RemoteObject{Type: runtime.TypeUndefined}}, nil
+	}
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	obj := &runtime.RemoteObject{Value: buf}
+	switch buf[0] {
+	case '"':
+		obj.Type = runtime.TypeString
+	case 't', 'f':
+		obj.Type = runtime.TypeBoolean
+	case 'n':
+		obj.Type, obj.Subtype = runtime.TypeObject, runtime.SubtypeNull
+	case '{':
+		obj.Type = runtime.TypeObject
+	case '[':
+		obj.Type, obj.Subtype = runtime.TypeObject, runtime.SubtypeArray
+	default:
+		obj.Type = runtime.TypeNumber
+	}
+	obj.Description = string(buf)
+	return &runtime.EvaluateReturns{Result: obj}, nil
+}
+
+// screenshotResult returns the result of Page.captureScreenshot.
+func (b *Browser) screenshotResult() interface{} {
+	return map[string]string{"data": base64.StdEncoding.EncodeToString(b.screenshot)}
+}
+
+// frame returns the frame of the target t.
+func (t *fakeTarget) frame() *cdp.Frame {
+	return &cdp.Frame{
+		ID:             cdp.FrameID(t.info.TargetID),
+		LoaderID:       t.loaderID,
+		URL:            t.info.URL,
+		SecurityOrigin: securityOrigin(t.info.URL),
+		MimeType:       "text/html",
+		// the fake pages are as secure as the pages of an https server.
+		SecureContextType:              cdp.SecureContextTypeSecure,
+		CrossOriginIsolatedContextType: cdp.CrossOriginIsolatedContextTypeNotIsolated,
+	}
+}
+
+// securityOrigin returns the security origin of urlstr.
+func securityOrigin(urlstr string) string {
+	scheme, rest, ok := strings.Cut(urlstr, "://")
+	if !ok {
+		return "null"
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	return scheme + "://" + host
+}
@@ -0,0 +1,165 @@
+package fakebrowser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestBrowser(t *testing.T) {
+	t.Parallel()
+
+	fixture := []byte("\x89PNG fixture")
+	b := New(
+		WithPage("https://example.com/", "Example Domain"),
+		WithEvaluate(`document.querySelectorAll("a").length`, 3),
+		WithEvaluate(`window.config`, map[string]interface{}{"debug": true}),
+		WithEvaluate(`fail()`, errors.New("boom")),
+		WithNavigateError("https://unknown.invalid/", "net::ERR_NAME_NOT_RESOLVED"),
+		WithScreenshot(fixture),
+	)
+	defer b.Close()
+
+	allocCtx, cancel := b.NewAllocator(context.Background())
+	defer cancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	start := time.Now()
+	var title, location string
+	var links int
+	var config struct{ Debug bool }
+	var buf []byte
+	if err := chromedp.Run(ctx,
+		chromedp.Navigate("https://example.com/"),
+		chromedp.Title(&title),
+		chromedp.Location(&location),
+		chromedp.Evaluate(`document.querySelectorAll("a").length`, &links),
+		chromedp.Evaluate(`window.config`, &config),
+		chromedp.CaptureScreenshot(&buf),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if title != "Example Domain" || location != "https://example.com/" || links != 3 || !config.Debug {
+		t.Errorf("got the title %q, the location %q, %d links and the config %+v", title, location, links, config)
+	}
+	if !bytes.Equal(buf, fixture) {
+		t.Errorf("want the screenshot fixture, got %q", buf)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("want the actions to run in milliseconds, took %v", d)
+	}
+
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`fail()`, nil)); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("want the thrown exception, got %v", err)
+	}
+	if err := chromedp.Run(ctx, chromedp.Navigate("https://unknown.invalid/")); err == nil || !strings.Contains(err.Error(), "net::ERR_NAME_NOT_RESOLVED") {
+		t.Errorf("want the navigation error, got %v", err)
+	}
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, err := page.PrintToPDF().Do(ctx)
+		return err
+	})); err == nil || !strings.Contains(err.Error(), "'Page.printToPDF' wasn't found") {
+		t.Errorf("want the unknown method to fail, got %v", err)
+	}
+
+	calls := b.Calls(page.CommandNavigate)
+	if len(calls) != 2 || !strings.Contains(string(calls[0].Params), "https://example.com/") {
+		t.Errorf("want the 2 navigations to be recorded, got %v", calls)
+	}
+}
+
+func TestBrowserTabs(t *testing.T) {
+	t.Parallel()
+
+	b := New(WithHandler(page.CommandPrintToPDF, func(call *Call) (interface{}, error) {
+		if call.SessionID == "" {
+			return nil, &cdproto.Error{Code: -32601, Message: "want a target"}
+		}
+		return map[string]string{"data": "cGRm"}, nil
+	}))
+	defer b.Close()
+
+	allocCtx, cancel := b.NewAllocator(context.Background())
+	defer cancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	if err := chromedp.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new tab in a new BrowserContext.
+	tctx, tcancel := chromedp.NewContext(ctx, chromedp.WithNewBrowserContext())
+	var buf []byte
+	if err := chromedp.Run(tctx,
+		chromedp.Navigate("https://example.com/report"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			buf, _, err = page.PrintToPDF().Do(ctx)
+			return err
+		}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "pdf" {
+		t.Errorf("want the result of the handler, got %q", buf)
+	}
+	infos, err := chromedp.Targets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, info := range infos {
+		found = found || info.URL == "https://example.com/report" && info.BrowserContextID != ""
+	}
+	if !found {
+		t.Errorf("want the new tab in its BrowserContext, got %v", infos)
+	}
+	if err := chromedp.Cancel(tctx); err != nil {
+		t.Fatal(err)
+	}
+	tcancel()
+	if calls := b.Calls(target.CommandDisposeBrowserContext); len(calls) != 1 {
+		t.Errorf("want the BrowserContext to be disposed, got %v", calls)
+	}
+
+	if err := chromedp.Cancel(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBrowserClose(t *testing.T) {
+	t.Parallel()
+
+	b := New()
+	allocCtx, cancel := b.NewAllocator(context.Background())
+	defer cancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	if err := chromedp.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// closing the fake browser drops its connections, which cancels the
+	// contexts connected to it.
+	b.Close()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("want the context cancelled once the browser is closed")
+	}
+	rctx, rcancel := chromedp.NewContext(allocCtx)
+	defer rcancel()
+	if err := chromedp.Run(rctx); err == nil || !strings.Contains(err.Error(), "fake browser is closed") {
+		t.Errorf("want the closed browser to fail the new connections, got %v", err)
+	}
+}
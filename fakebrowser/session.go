@@ -0,0 +1,358 @@
+package fakebrowser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+)
+
+// session is a connection to the fake browser, which is an instance of the
+// browser with its own targets.
+type session struct {
+	b    *Browser
+	conn *transport
+
+	// targets and sessions are only used by the goroutine serving the
+	// connection.
+	targets  map[target.ID]*fakeTarget
+	sessions map[target.SessionID]*fakeTarget
+	next     int
+
+	// events are the events to send once the response to the call being
+	// handled is sent.
+	events []*cdproto.Message
+}
+
+// fakeTarget is a tab of the fake browser.
+type fakeTarget struct {
+	info      *target.Info
+	sessionID target.SessionID
+	loaderID  cdp.LoaderID
+}
+
+// nextID returns a new id with prefix.
+func (s *session) nextID(prefix string) string {
+	s.next++
+	return fmt.Sprintf("%s%032d", prefix, s.next)
+}
+
+// newTarget opens a blank tab in the BrowserContext id.
+func (s *session) newTarget(id cdp.BrowserContextID) *fakeTarget {
+	t := &fakeTarget{
+		info: &target.Info{
+			TargetID:         target.ID(s.nextID("T")),
+			Type:             "page",
+			URL:              "about:blank",
+			BrowserContextID: id,
+		},
+		loaderID: cdp.LoaderID(s.nextID("L")),
+	}
+	s.targets[t.info.TargetID] = t
+	return t
+}
+
+// handle answers msg, and reports whether the connection is still open.
+func (s *session) handle(msg *cdproto.Message) bool {
+	call := &Call{
+		Method:    string(msg.Method),
+		SessionID: msg.SessionID,
+		Params:    json.RawMessage(msg.Params),
+	}
+	s.b.mu.Lock()
+	s.b.calls = append(s.b.calls, *call)
+	s.b.mu.Unlock()
+
+	var res interface{}
+	var err error
+	if h := s.b.handlers[call.Method]; h != nil {
+		res, err = h(call)
+	} else {
+		res, err = s.answer(call)
+	}
+	resp := &cdproto.Message{ID: msg.ID, SessionID: msg.SessionID}
+	if err != nil {
+		resp.Error = errorMessage(err)
+	} else {
+		if res == nil {
+			res = struct{}{}
+		}
+		if resp.Result, err = json.Marshal(res); err != nil {
+			resp.Result, resp.Error = nil, errorMessage(err)
+		}
+	}
+	if err := s.write(resp); err != nil {
+		return false
+	}
+	events := s.events
+	s.events = nil
+	for _, ev := range events {
+		if err := s.write(ev); err != nil {
+			return false
+		}
+	}
+	// the browser closes the connection once it's closed.
+	return call.Method != browser.CommandClose
+}
+
+func (s *session) write(msg *cdproto.Message) error {
+	return s.conn.send(msg)
+}
+
+// emit queues the event method, to the target of sessionID, or to the browser
+// if it's empty.
+func (s *session) emit(sessionID target.SessionID, method cdproto.MethodType, params interface{}) {
+	buf, _ := json.Marshal(params)
+	s.events = append(s.events, &cdproto.Message{
+		SessionID: sessionID,
+		Method:    method,
+		Params:    buf,
+	})
+}
+
+// answer answers call by default.
+func (s *session) answer(call *Call) (interface{}, error) {
+	var t *fakeTarget
+	if call.SessionID != "" {
+		if t = s.sessions[call.SessionID]; t == nil {
+			return nil, &cdproto.Error{Code: -32001, Message: "Session with given id not found."}
+		}
+	}
+
+	switch call.Method {
+	case browser.CommandGetVersion:
+		return map[string]string{
+			"protocolVersion": "1.3",
+			"product":         "Chrome/140.0.0.0",
+			"userAgent":       "Mozilla/5.0 (fakebrowser) Chrome/140.0.0.0",
+		}, nil
+	case browser.CommandClose:
+		return nil, nil
+
+	case target.CommandSetDiscoverTargets:
+		for _, t := range s.targets {
+			s.emit(call.SessionID, cdproto.EventTargetTargetCreated, map[string]interface{}{"targetInfo": t.info})
+		}
+		return nil, nil
+	case target.CommandSetAutoAttach:
+		return nil, nil
+	case target.CommandCreateBrowserContext:
+		return map[string]string{"browserContextId": s.nextID("C")}, nil
+	case target.CommandDisposeBrowserContext:
+		var p target.DisposeBrowserContextParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		for _, t := range s.targets {
+			if t.info.BrowserContextID == p.BrowserContextID {
+				s.closeTarget(t)
+			}
+		}
+		return nil, nil
+	case target.CommandCreateTarget:
+		var p target.CreateTargetParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		t := s.newTarget(p.BrowserContextID)
+		s.emit("", cdproto.EventTargetTargetCreated, map[string]interface{}{"targetInfo": t.info})
+		return map[string]interface{}{"targetId": t.info.TargetID}, nil
+	case target.CommandAttachToTarget:
+		var p target.AttachToTargetParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		t := s.targets[p.TargetID]
+		if t == nil {
+			return nil, &cdproto.Error{Code: -32602, Message: "No target with given id found"}
+		}
+		t.sessionID = target.SessionID(s.nextID("S"))
+		t.info.Attached = true
+		s.sessions[t.sessionID] = t
+		s.emit("", cdproto.EventTargetAttachedToTarget, map[string]interface{}{
+			"sessionId":          t.sessionID,
+			"targetInfo":         t.info,
+			"waitingForDebugger": false,
+		})
+		return map[string]interface{}{"sessionId": t.sessionID}, nil
+	case target.CommandDetachFromTarget:
+		var p target.DetachFromTargetParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		if t := s.sessions[p.SessionID]; t != nil {
+			s.detach(t)
+		}
+		return nil, nil
+	case target.CommandCloseTarget:
+		var p target.CloseTargetParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		t := s.targets[p.TargetID]
+		if t == nil {
+			return nil, &cdproto.Error{Code: -32602, Message: "No target with given id found"}
+		}
+		s.closeTarget(t)
+		return map[string]bool{"success": true}, nil
+	case target.CommandGetTargets:
+		infos := make([]*target.Info, 0, len(s.targets))
+		for _, t := range s.targets {
+			infos = append(infos, t.info)
+		}
+		return map[string]interface{}{"targetInfos": infos}, nil
+	case target.CommandGetTargetInfo:
+		var p target.GetTargetInfoParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.TargetID != "" {
+			t = s.targets[p.TargetID]
+		}
+		if t == nil {
+			return nil, &cdproto.Error{Code: -32602, Message: "No target with given id found"}
+		}
+		return map[string]interface{}{"targetInfo": t.info}, nil
+	}
+
+	if t == nil {
+		return nil, errNotFound(call.Method)
+	}
+	switch method := call.Method; {
+	case strings.HasSuffix(method, ".enable"), strings.HasSuffix(method, ".disable"),
+		method == page.CommandSetLifecycleEventsEnabled:
+		return nil, nil
+	case method == runtime.CommandEvaluate:
+		var p runtime.EvaluateParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.evaluate(t, p.Expression)
+	case method == page.CommandNavigate:
+		var p page.NavigateParams
+		if err := json.Unmarshal(call.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.navigate(t, p.URL), nil
+	case method == page.CommandReload:
+		s.navigate(t, t.info.URL)
+		return nil, nil
+	case method == page.CommandGetFrameTree:
+		return map[string]interface{}{"frameTree": map[string]interface{}{"frame": t.frame()}}, nil
+	case method == dom.CommandGetDocument:
+		return map[string]interface{}{"root": document(t)}, nil
+	case method == page.CommandCaptureScreenshot:
+		return s.b.screenshotResult(), nil
+	}
+	return nil, errNotFound(call.Method)
+}
+
+// evaluate evaluates expression in the page of t.
+func (s *session) evaluate(t *fakeTarget, expression string) (interface{}, error) {
+	if value, ok := s.b.evals[expression]; ok {
+		return evalResult(value)
+	}
+	switch expression {
+	case `document.title`:
+		return evalResult(t.info.Title)
+	case `document.location.toString()`:
+		return evalResult(t.info.URL)
+	}
+	return evalResult(nil)
+}
+
+// navigate navigates the page of t to urlstr, and returns the result of
+// Page.navigate.
+func (s *session) navigate(t *fakeTarget, urlstr string) interface{} {
+	frameID := cdp.FrameID(t.info.TargetID)
+	if errorText, ok := s.b.navErrors[urlstr]; ok {
+		return map[string]interface{}{
+			"frameId":   frameID,
+			"loaderId":  s.nextID("L"),
+			"errorText": errorText,
+		}
+	}
+	t.loaderID = cdp.LoaderID(s.nextID("L"))
+	t.info.URL = urlstr
+	t.info.Title = s.b.titles[urlstr]
+
+	sid := t.sessionID
+	lifecycle := func(name string) {
+		s.emit(sid, cdproto.EventPageLifecycleEvent, map[string]interface{}{
+			"frameId":   frameID,
+			"loaderId":  t.loaderID,
+			"name":      name,
+			"timestamp": 0,
+		})
+	}
+	s.emit(sid, cdproto.EventPageFrameStartedLoading, map[string]interface{}{"frameId": frameID})
+	s.emit(sid, cdproto.EventRuntimeExecutionContextsCleared, struct{}{})
+	s.emit(sid, cdproto.EventPageFrameNavigated, map[string]interface{}{"frame": t.frame(), "type": "Navigation"})
+	lifecycle("init")
+	s.emit("", cdproto.EventTargetTargetInfoChanged, map[string]interface{}{"targetInfo": t.info})
+	s.emit(sid, cdproto.EventRuntimeExecutionContextCreated, map[string]interface{}{
+		"context": map[string]interface{}{
+			"id":       s.next,
+			"origin":   securityOrigin(urlstr),
+			"name":     "",
+			"uniqueId": s.nextID("E"),
+			"auxData":  map[string]interface{}{"isDefault": true, "type": "default", "frameId": frameID},
+		},
+	})
+	s.emit(sid, cdproto.EventDOMDocumentUpdated, struct{}{})
+	s.emit(sid, cdproto.EventPageDomContentEventFired, map[string]interface{}{"timestamp": 0})
+	lifecycle("DOMContentLoaded")
+	s.emit(sid, cdproto.EventPageLoadEventFired, map[string]interface{}{"timestamp": 0})
+	lifecycle("load")
+	s.emit(sid, cdproto.EventPageFrameStoppedLoading, map[string]interface{}{"frameId": frameID})
+	return map[string]interface{}{"frameId": frameID, "loaderId": t.loaderID}
+}
+
+// detach detaches the session of t.
+func (s *session) detach(t *fakeTarget) {
+	if t.sessionID == "" {
+		return
+	}
+	s.emit("", cdproto.EventTargetDetachedFromTarget, map[string]interface{}{
+		"sessionId": t.sessionID,
+		"targetId":  t.info.TargetID,
+	})
+	delete(s.sessions, t.sessionID)
+	t.sessionID = ""
+	t.info.Attached = false
+}
+
+// closeTarget closes the tab t.
+func (s *session) closeTarget(t *fakeTarget) {
+	s.detach(t)
+	s.emit("", cdproto.EventTargetTargetDestroyed, map[string]interface{}{"targetId": t.info.TargetID})
+	delete(s.targets, t.info.TargetID)
+}
+
+// document returns the root node of the empty document of the page of t.
+func document(t *fakeTarget) *cdp.Node {
+	node := func(id cdp.NodeID, name string, children ...*cdp.Node) *cdp.Node {
+		return &cdp.Node{
+			NodeID:         id,
+			BackendNodeID:  cdp.BackendNodeID(id),
+			NodeType:       cdp.NodeTypeElement,
+			NodeName:       strings.ToUpper(name),
+			LocalName:      name,
+			ChildNodeCount: int64(len(children)),
+			Children:       children,
+		}
+	}
+	root := node(1, "", node(2, "html", node(3, "head"), node(4, "body")))
+	root.NodeType, root.NodeName = cdp.NodeTypeDocument, "#document"
+	root.FrameID = cdp.FrameID(t.info.TargetID)
+	root.DocumentURL, root.BaseURL = t.info.URL, t.info.URL
+	root.CompatibilityMode = cdp.CompatibilityModeNoQuirksMode
+	return root
+}
@@ -0,0 +1,167 @@
+package fakebrowser
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/mailru/easyjson"
+
+	"github.com/chromedp/cdproto"
+)
+
+// transport is the in-process connection of chromedp to a session of the fake
+// browser, which implements chromedp.Transport. The messages are encoded as
+// JSON both ways, as over a websocket, so that chromedp and the session never
+// share them.
+type transport struct {
+	// in are the messages sent by chromedp, and out are those sent by the
+	// session, which ends it once it's done.
+	in  *queue
+	out *queue
+
+	// closed is closed by Close, and done by the browser once it's closed.
+	closed    chan struct{}
+	closeOnce sync.Once
+	done      <-chan struct{}
+}
+
+func newTransport(done <-chan struct{}) *transport {
+	return &transport{
+		in:     newQueue(),
+		out:    newQueue(),
+		closed: make(chan struct{}),
+		done:   done,
+	}
+}
+
+// Read satisfies the chromedp.Transport interface.
+func (t *transport) Read(ctx context.Context, msg *cdproto.Message) error {
+	buf, err := t.out.pop(ctx.Done(), t.closed)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return easyjson.Unmarshal(buf, msg)
+}
+
+// Write satisfies the chromedp.Transport interface.
+func (t *transport) Write(ctx context.Context, msg *cdproto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-t.closed:
+		return net.ErrClosed
+	case <-t.done:
+		return io.EOF
+	default:
+	}
+	buf, err := easyjson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.in.push(buf)
+	return nil
+}
+
+// Close satisfies the chromedp.Transport interface.
+func (t *transport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
+
+// receive returns the next message sent by chromedp, and false once the
+// connection or the browser is closed.
+func (t *transport) receive() (*cdproto.Message, bool) {
+	buf, err := t.in.pop(t.closed, t.done)
+	if err != nil {
+		return nil, false
+	}
+	msg := new(cdproto.Message)
+	if err := easyjson.Unmarshal(buf, msg); err != nil {
+		return nil, false
+	}
+	return msg, true
+}
+
+// send sends msg to chromedp.
+func (t *transport) send(msg *cdproto.Message) error {
+	buf, err := easyjson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.out.push(buf)
+	return nil
+}
+
+// end ends the messages sent to chromedp once the queued ones are read, which
+// chromedp reads as a lost connection.
+func (t *transport) end() {
+	t.out.end()
+}
+
+// queue is an unbounded queue of encoded messages, so that, as with the
+// buffers of a websocket connection, the writers don't wait for the readers.
+type queue struct {
+	mu    sync.Mutex
+	msgs  [][]byte
+	ended bool
+	// ready is signaled when a message is pushed, or the queue is ended.
+	ready chan struct{}
+}
+
+func newQueue() *queue {
+	return &queue{ready: make(chan struct{}, 1)}
+}
+
+func (q *queue) push(buf []byte) {
+	q.mu.Lock()
+	q.msgs = append(q.msgs, buf)
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *queue) end() {
+	q.mu.Lock()
+	q.ended = true
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the next message, waiting for it until the queue is ended, or
+// until stop or done is closed.
+func (q *queue) pop(stop, done <-chan struct{}) ([]byte, error) {
+	for {
+		q.mu.Lock()
+		if len(q.msgs) > 0 {
+			buf := q.msgs[0]
+			q.msgs[0] = nil
+			q.msgs = q.msgs[1:]
+			q.mu.Unlock()
+			return buf, nil
+		}
+		ended := q.ended
+		q.mu.Unlock()
+		if ended {
+			return nil, io.EOF
+		}
+		select {
+		case <-q.ready:
+		case <-stop:
+			return nil, net.ErrClosed
+		case <-done:
+			return nil, net.ErrClosed
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// Fingerprint is the fingerprint of the browser, as seen by the current page,
+// and gathered by FingerprintReport.
+type Fingerprint struct {
+	// UserAgent, AppVersion, Platform and Vendor are the ones of navigator.
+	UserAgent  string `json:"userAgent"`
+	AppVersion string `json:"appVersion"`
+	Platform   string `json:"platform"`
+	Vendor     string `json:"vendor"`
+	// ClientHints are the user agent client hints, which are sent in the
+	// Sec-CH-UA-* headers. They're nil when navigator.userAgentData is
+	// missing, such as in the pages which are not secure contexts.
+	ClientHints *ClientHints `json:"clientHints,omitempty"`
+
+	// Language and Languages are the ones of navigator, and Locale is the
+	// default locale of Intl.
+	Language  string   `json:"language"`
+	Languages []string `json:"languages"`
+	Locale    string   `json:"locale"`
+	// Timezone is the IANA timezone of Intl, such as "Europe/Paris", and
+	// TimezoneOffset the offset of the current time from UTC, in minutes,
+	// as returned by Date.getTimezoneOffset.
+	Timezone       string `json:"timezone"`
+	TimezoneOffset int64  `json:"timezoneOffset"`
+
+	HardwareConcurrency int64   `json:"hardwareConcurrency"`
+	DeviceMemory        float64 `json:"deviceMemory"`
+	MaxTouchPoints      int64   `json:"maxTouchPoints"`
+	Webdriver           bool    `json:"webdriver"`
+	ScreenWidth         int64   `json:"screenWidth"`
+	ScreenHeight        int64   `json:"screenHeight"`
+	DevicePixelRatio    float64 `json:"devicePixelRatio"`
+
+	// WebGLVendor and WebGLRenderer are the unmasked ones of WebGL, or empty
+	// if WebGL isn't available.
+	WebGLVendor   string `json:"webGLVendor,omitempty"`
+	WebGLRenderer string `json:"webGLRenderer,omitempty"`
+
+	// Inconsistencies are the properties which don't match each other, such
+	// as a user agent overridden without its client hints, or the signs of
+	// an automated browser.
+	Inconsistencies []string `json:"-"`
+}
+
+// ClientHints are the user agent client hints of a Fingerprint.
+type ClientHints struct {
+	Brands          []ClientHintsBrand `json:"brands"`
+	Mobile          bool               `json:"mobile"`
+	Platform        string             `json:"platform"`
+	PlatformVersion string             `json:"platformVersion,omitempty"`
+	Architecture    string             `json:"architecture,omitempty"`
+	Model           string             `json:"model,omitempty"`
+	FullVersion     string             `json:"fullVersion,omitempty"`
+}
+
+// ClientHintsBrand is a brand of ClientHints, such as "Chromium".
+type ClientHintsBrand struct {
+	Brand   string `json:"brand"`
+	Version string `json:"version"`
+}
+
+// FingerprintReport is an action that gathers the fingerprint of the browser,
+// as seen by the current page, and reports the inconsistencies between its
+// properties. It's a diagnostic to find out why a site detects the browser
+// as automated, as the partial overrides, such as of the user agent alone,
+// leave the other properties revealing the real browser.
+func FingerprintReport(report *Fingerprint) Action {
+	if report == nil {
+		panic("report cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		var f Fingerprint
+		if err := Evaluate(fmt.Sprintf(`(%s)()`, fingerprintJS), &f, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}).Do(ctx); err != nil {
+			return err
+		}
+		f.Inconsistencies = f.inconsistencies()
+		*report = f
+		return nil
+	})
+}
+
+// chromeVersionRE matches the major version of Chrome in a user agent.
+var chromeVersionRE = regexp.MustCompile(`(?:Chrome|CriOS|HeadlessChrome)/(\d+)`)
+
+// uaOS returns the operating system of the user agent ua, as the platform of
+// its client hints, and the prefix of its navigator.platform.
+func uaOS(ua string) (platform, navPlatform string) {
+	switch {
+	case strings.Contains(ua, "Android"):
+		return "Android", "Linux"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		return "iOS", "i"
+	case strings.Contains(ua, "Windows"):
+		return "Windows", "Win"
+	case strings.Contains(ua, "Macintosh"):
+		return "macOS", "Mac"
+	case strings.Contains(ua, "CrOS"):
+		return "Chrome OS", "Linux"
+	case strings.Contains(ua, "Linux"):
+		return "Linux", "Linux"
+	}
+	return "", ""
+}
+
+// inconsistencies returns the descriptions of the inconsistencies of f.
+func (f *Fingerprint) inconsistencies() []string {
+	var res []string
+	add := func(format string, args ...interface{}) {
+		res = append(res, fmt.Sprintf(format, args...))
+	}
+
+	if strings.Contains(f.UserAgent, "HeadlessChrome") {
+		add("the user agent %q tells it's headless", f.UserAgent)
+	}
+	if f.Webdriver {
+		add("navigator.webdriver is true")
+	}
+	if strings.Contains(f.WebGLRenderer, "SwiftShader") {
+		add("the WebGL renderer %q is a software one", f.WebGLRenderer)
+	}
+
+	mobile := strings.Contains(f.UserAgent, "Mobile") || strings.Contains(f.UserAgent, "Android")
+	if mobile && f.MaxTouchPoints == 0 {
+		add("the user agent is of a mobile device, without touch points")
+	}
+	platform, navPlatform := uaOS(f.UserAgent)
+	if navPlatform != "" && !strings.HasPrefix(f.Platform, navPlatform) {
+		add("the user agent is of %s, but navigator.platform is %q", platform, f.Platform)
+	}
+
+	if h := f.ClientHints; h != nil {
+		chrome := chromeVersionRE.FindStringSubmatch(f.UserAgent)
+		if chrome == nil {
+			add("the user agent %q isn't of a Chromium browser, unlike its client hints", f.UserAgent)
+		} else {
+			for _, b := range h.Brands {
+				if (b.Brand == "Chromium" || b.Brand == "Google Chrome") && b.Version != chrome[1] {
+					add("the user agent is of Chrome %s, but the client hints are of %s %s", chrome[1], b.Brand, b.Version)
+					break
+				}
+			}
+		}
+		for _, b := range h.Brands {
+			if b.Brand == "HeadlessChrome" {
+				add("the client hints tell it's headless")
+			}
+		}
+		switch {
+		case mobile && !h.Mobile:
+			add("the user agent is of a mobile device, but not the client hints")
+		case !mobile && h.Mobile:
+			add("the client hints are of a mobile device, but not the user agent")
+		}
+		if platform != "" && h.Platform != platform {
+			add("the user agent is of %s, but the client hints are of %q", platform, h.Platform)
+		}
+	}
+
+	if len(f.Languages) > 0 && f.Language != f.Languages[0] {
+		add("navigator.language %q isn't the first of navigator.languages %q", f.Language, f.Languages)
+	}
+	lang, _, _ := strings.Cut(f.Language, "-")
+	locale, _, _ := strings.Cut(f.Locale, "-")
+	if lang != "" && locale != "" && !strings.EqualFold(lang, locale) {
+		add("navigator.language %q doesn't match the locale %q", f.Language, f.Locale)
+	}
+	return res
+}
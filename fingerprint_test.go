@@ -0,0 +1,115 @@
+package chromedp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/emulation"
+)
+
+func TestFingerprintReport(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var f Fingerprint
+	if err := Run(ctx, FingerprintReport(&f)); err != nil {
+		t.Fatal(err)
+	}
+	if f.UserAgent == "" || f.Platform == "" || len(f.Languages) == 0 || f.Timezone == "" {
+		t.Fatalf("want the navigator properties, got %+v", f)
+	}
+	if f.ClientHints == nil || len(f.ClientHints.Brands) == 0 {
+		t.Fatalf("want the client hints, got %+v", f.ClientHints)
+	}
+	if !f.Webdriver {
+		t.Error("want navigator.webdriver to be true")
+	}
+
+	// only override the user agent, so that the navigator platform and the
+	// client hints still reveal the browser.
+	const ua = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.0.0 Safari/537.36"
+	if err := Run(ctx,
+		emulation.SetUserAgentOverride(ua),
+		Navigate(testdataDir+"/image.html"),
+		FingerprintReport(&f),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if f.UserAgent != ua {
+		t.Fatalf("want the user agent %q, got %q", ua, f.UserAgent)
+	}
+	found := strings.Join(f.Inconsistencies, "\n")
+	if !strings.Contains(found, "navigator.platform") {
+		t.Errorf("want an inconsistency of navigator.platform, got %q", f.Inconsistencies)
+	}
+}
+
+func TestFingerprintInconsistencies(t *testing.T) {
+	t.Parallel()
+
+	const winUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	consistent := Fingerprint{
+		UserAgent: winUA,
+		Platform:  "Win32",
+		ClientHints: &ClientHints{
+			Brands:   []ClientHintsBrand{{"Not_A Brand", "8"}, {"Chromium", "120"}, {"Google Chrome", "120"}},
+			Platform: "Windows",
+		},
+		Language:  "fr-FR",
+		Languages: []string{"fr-FR", "fr"},
+		Locale:    "fr-FR",
+	}
+	tests := []struct {
+		name   string
+		modify func(f *Fingerprint)
+		want   []string
+	}{
+		{"consistent", func(f *Fingerprint) {}, nil},
+		{"version", func(f *Fingerprint) {
+			f.ClientHints.Brands = []ClientHintsBrand{{"Chromium", "140"}}
+		}, []string{"the user agent is of Chrome 120, but the client hints are of Chromium 140"}},
+		{"platform", func(f *Fingerprint) {
+			f.Platform = "Linux x86_64"
+			f.ClientHints.Platform = "Linux"
+		}, []string{
+			`the user agent is of Windows, but navigator.platform is "Linux x86_64"`,
+			`the user agent is of Windows, but the client hints are of "Linux"`,
+		}},
+		{"not chromium", func(f *Fingerprint) {
+			f.UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0"
+		}, []string{`the user agent "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0" isn't of a Chromium browser, unlike its client hints`}},
+		{"mobile", func(f *Fingerprint) {
+			f.UserAgent = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+			f.Platform = "Linux armv8l"
+			f.ClientHints.Platform = "Android"
+		}, []string{
+			"the user agent is of a mobile device, without touch points",
+			"the user agent is of a mobile device, but not the client hints",
+		}},
+		{"languages", func(f *Fingerprint) {
+			f.Language = "en-US"
+		}, []string{
+			`navigator.language "en-US" isn't the first of navigator.languages ["fr-FR" "fr"]`,
+			`navigator.language "en-US" doesn't match the locale "fr-FR"`,
+		}},
+		{"automation", func(f *Fingerprint) {
+			f.Webdriver = true
+			f.WebGLRenderer = "ANGLE (Google, SwiftShader driver)"
+		}, []string{
+			"navigator.webdriver is true",
+			`the WebGL renderer "ANGLE (Google, SwiftShader driver)" is a software one`,
+		}},
+	}
+	for _, test := range tests {
+		f := consistent
+		hints := *f.ClientHints
+		f.ClientHints = &hints
+		test.modify(&f)
+		if got := f.inconsistencies(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: want %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package chromedp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// conflictingFlags are the pairs of flags which can't be both passed to
+// Chrome, as one of them would be ignored.
+var conflictingFlags = [][2]string{
+	{"window-size", "start-maximized"},
+	{"window-size", "start-fullscreen"},
+	{"window-size", "kiosk"},
+	{"proxy-server", "no-proxy-server"},
+	{"proxy-server", "proxy-pac-url"},
+	{"proxy-server", "proxy-auto-detect"},
+}
+
+// validateFlags checks the flags set up by Flag before they're passed to
+// Chrome, which would otherwise ignore the invalid ones, or fail to start
+// without telling why. All the problems are returned, each wrapping
+// ErrInvalidFlag.
+func validateFlags(flags map[string]interface{}) error {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	invalid := func(name, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w %q: %s", ErrInvalidFlag, name, fmt.Sprintf(format, args...)))
+	}
+	for _, name := range names {
+		value := flags[name]
+		switch {
+		case name == "" || strings.TrimSpace(name) != name:
+			invalid(name, "the name must be non-empty, without spaces around it")
+			continue
+		case strings.HasPrefix(name, "-"):
+			invalid(name, "the name must not start with dashes, as in Flag(%q, ...)", strings.TrimLeft(name, "-"))
+			continue
+		case strings.Contains(name, "="):
+			n, v, _ := strings.Cut(name, "=")
+			invalid(name, "the value must be passed separately, as in Flag(%q, %q)", n, v)
+			continue
+		}
+		switch value := value.(type) {
+		case bool:
+		case string:
+			if err := validateFlagValue(name, value); err != nil {
+				invalid(name, "%v", err)
+			}
+		default:
+			invalid(name, "the value %v is a %T, not a string or a bool", value, value)
+		}
+	}
+	for _, pair := range conflictingFlags {
+		if flagSet(flags, pair[0]) && flagSet(flags, pair[1]) {
+			invalid(pair[0], "conflicts with the %q flag", pair[1])
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateFlagValue checks the value of the string flags Chrome would
+// otherwise ignore silently.
+func validateFlagValue(name, value string) error {
+	switch name {
+	case "headless":
+		if value != "new" && value != "old" {
+			return fmt.Errorf("the value %q is not a headless mode, such as \"new\"", value)
+		}
+	case "window-size":
+		w, h, ok := strings.Cut(value, ",")
+		width, werr := strconv.Atoi(w)
+		height, herr := strconv.Atoi(h)
+		if !ok || werr != nil || herr != nil || width <= 0 || height <= 0 {
+			return fmt.Errorf("the value %q is not a size such as \"1280,720\"", value)
+		}
+	case "remote-debugging-port":
+		if port, err := strconv.Atoi(value); err != nil || port < 0 || port > 65535 {
+			return fmt.Errorf("the value %q is not a port", value)
+		}
+	}
+	return nil
+}
+
+// flagSet reports whether the flag is passed to Chrome, that is if it's set
+// to a string or to true.
+func flagSet(flags map[string]interface{}, name string) bool {
+	switch value := flags[name].(type) {
+	case string:
+		return true
+	case bool:
+		return value
+	}
+	return false
+}
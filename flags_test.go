@@ -0,0 +1,86 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []ExecAllocatorOption
+		wantErr []string
+	}{
+		{"Defaults", DefaultExecAllocatorOptions[:], nil},
+		{"HeadlessNew", []ExecAllocatorOption{HeadlessNew, WindowSize(1280, 720)}, nil},
+		{"Dashes", []ExecAllocatorOption{Flag("--mute-audio", true)}, []string{
+			`invalid browser flag "--mute-audio": the name must not start with dashes, as in Flag("mute-audio", ...)`,
+		}},
+		{"Value", []ExecAllocatorOption{Headless, Flag("headless=new", true)}, []string{
+			`invalid browser flag "headless=new": the value must be passed separately, as in Flag("headless", "new")`,
+		}},
+		{"Type", []ExecAllocatorOption{Flag("remote-debugging-port", 9222)}, []string{
+			`invalid browser flag "remote-debugging-port": the value 9222 is a int, not a string or a bool`,
+		}},
+		{"Values", []ExecAllocatorOption{
+			Flag("headless", "yes"),
+			Flag("window-size", "1280x720"),
+			Flag("remote-debugging-port", "port"),
+		}, []string{
+			`invalid browser flag "headless": the value "yes" is not a headless mode`,
+			`invalid browser flag "remote-debugging-port": the value "port" is not a port`,
+			`invalid browser flag "window-size": the value "1280x720" is not a size`,
+		}},
+		{"Conflicts", []ExecAllocatorOption{
+			WindowSize(1280, 720),
+			Flag("start-maximized", true),
+			ProxyServer("localhost:8080"),
+			Flag("no-proxy-server", false),
+		}, []string{
+			`invalid browser flag "window-size": conflicts with the "start-maximized" flag`,
+		}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateFlags(setupExecAllocator(test.opts...).initFlags)
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatalf("want no error, got: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidFlag) {
+				t.Fatalf("want an ErrInvalidFlag error, got: %v", err)
+			}
+			got := strings.Split(err.Error(), "\n")
+			if len(got) != len(test.wantErr) {
+				t.Fatalf("want %d errors, got: %q", len(test.wantErr), got)
+			}
+			for i, want := range test.wantErr {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("want error %d to start with %q, got %q", i, want, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], Flag("--headless", true))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); !errors.Is(err, ErrInvalidFlag) {
+		t.Fatalf("want an ErrInvalidFlag error, got: %v", err)
+	}
+}
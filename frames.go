@@ -0,0 +1,120 @@
+package chromedp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// FrameInfo is the info of a frame of a page, as reported by OnFrameAttached
+// and OnFrameDetached.
+type FrameInfo struct {
+	ID cdp.FrameID
+	// ParentID is the id of the parent frame, which is empty for the main
+	// frame.
+	ParentID cdp.FrameID
+	// Name and URL are the ones of the last document of the frame, which
+	// are empty until the frame is first navigated, and thus when it's
+	// attached.
+	Name string
+	URL  string
+	// Stack is the stack trace of the script which attached the frame, if
+	// any.
+	Stack *runtime.StackTrace
+	// DetachReason is why the frame was detached, which is "swap" when the
+	// frame is moved to another process, as its own target, rather than
+	// removed.
+	DetachReason page.FrameDetachedReason
+}
+
+// OnFrameAttached calls fn with the info of every frame attached to the page
+// of ctx, such as an iframe added to the document, until ctx is cancelled. It
+// allocates the page if needed. The frames existing at the time of the call
+// are not reported.
+//
+// As with ListenTarget, fn is called synchronously and should avoid blocking.
+func OnFrameAttached(ctx context.Context, fn func(info *FrameInfo)) error {
+	return listenFrameLifecycle(ctx, fn, nil)
+}
+
+// OnFrameDetached calls fn with the last known info of every frame detached
+// from the page of ctx, including the ones existing at the time of the call,
+// until ctx is cancelled. It allocates the page if needed.
+//
+// As with ListenTarget, fn is called synchronously and should avoid blocking.
+func OnFrameDetached(ctx context.Context, fn func(info *FrameInfo)) error {
+	return listenFrameLifecycle(ctx, nil, fn)
+}
+
+// listenFrameLifecycle listens for the frames of the page of ctx being
+// attached and detached, keeping track of their info.
+func listenFrameLifecycle(ctx context.Context, attached, detached func(*FrameInfo)) error {
+	return Run(ctx, ActionFunc(func(ctx context.Context) error {
+		var mu sync.Mutex
+		infos := make(map[cdp.FrameID]*FrameInfo)
+		ListenTarget(ctx, func(ev interface{}) {
+			switch ev := ev.(type) {
+			case *page.EventFrameAttached:
+				info := &FrameInfo{ID: ev.FrameID, ParentID: ev.ParentFrameID, Stack: ev.Stack}
+				mu.Lock()
+				infos[ev.FrameID] = info
+				mu.Unlock()
+				if attached != nil {
+					cp := *info
+					attached(&cp)
+				}
+			case *page.EventFrameNavigated:
+				mu.Lock()
+				info := infos[ev.Frame.ID]
+				if info == nil {
+					info = &FrameInfo{ID: ev.Frame.ID}
+					infos[ev.Frame.ID] = info
+				}
+				info.ParentID, info.Name, info.URL = ev.Frame.ParentID, ev.Frame.Name, ev.Frame.URL
+				mu.Unlock()
+			case *page.EventFrameDetached:
+				mu.Lock()
+				info, ok := infos[ev.FrameID]
+				delete(infos, ev.FrameID)
+				mu.Unlock()
+				if !ok {
+					info = &FrameInfo{ID: ev.FrameID}
+				}
+				info.DetachReason = ev.Reason
+				if detached != nil {
+					detached(info)
+				}
+			}
+		})
+		if detached == nil {
+			return nil
+		}
+		// the existing frames are not reported, so get their info to
+		// report it when they are detached.
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		var walk func(*page.FrameTree)
+		walk = func(t *page.FrameTree) {
+			if _, ok := infos[t.Frame.ID]; !ok {
+				infos[t.Frame.ID] = &FrameInfo{
+					ID:       t.Frame.ID,
+					ParentID: t.Frame.ParentID,
+					Name:     t.Frame.Name,
+					URL:      t.Frame.URL,
+				}
+			}
+			for _, child := range t.ChildFrames {
+				walk(child)
+			}
+		}
+		walk(tree)
+		return nil
+	}))
+}
@@ -0,0 +1,84 @@
+package chromedp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+func TestFrameLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "iframe.html")
+	defer cancel()
+
+	var mainID string
+	if err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		mainID = string(tree.Frame.ID)
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	attached := make(chan *FrameInfo, 4)
+	detached := make(chan *FrameInfo, 4)
+	if err := OnFrameAttached(ctx, func(info *FrameInfo) { attached <- info }); err != nil {
+		t.Fatal(err)
+	}
+	if err := OnFrameDetached(ctx, func(info *FrameInfo) { detached <- info }); err != nil {
+		t.Fatal(err)
+	}
+
+	receive := func(ch chan *FrameInfo) *FrameInfo {
+		t.Helper()
+		select {
+		case info := <-ch:
+			return info
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a frame")
+			return nil
+		}
+	}
+
+	// the existing frame of the page is reported once detached, with its
+	// last known info.
+	if err := Run(ctx, Evaluate(`document.querySelector('iframe').remove()`, nil)); err != nil {
+		t.Fatal(err)
+	}
+	info := receive(detached)
+	if string(info.ParentID) != mainID || info.URL == "" || info.DetachReason != page.FrameDetachedReasonRemove {
+		t.Errorf("got the detached frame %+v", info)
+	}
+
+	if err := Run(ctx, Evaluate(`(() => {
+		const f = document.createElement('iframe');
+		f.name = 'added';
+		f.src = 'child1.html';
+		f.onload = () => f.dataset.loaded = 'true';
+		document.body.append(f);
+	})()`, nil)); err != nil {
+		t.Fatal(err)
+	}
+	added := receive(attached)
+	if string(added.ParentID) != mainID || added.Stack == nil {
+		t.Errorf("got the attached frame %+v", added)
+	}
+
+	// wait for the frame to load, to get its name and URL.
+	if err := Run(ctx, Poll(`document.querySelector('iframe[name=added]').dataset.loaded === 'true'`, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx, Evaluate(`document.querySelector('iframe[name=added]').remove()`, nil)); err != nil {
+		t.Fatal(err)
+	}
+	info = receive(detached)
+	if info.ID != added.ID || info.Name != "added" || info.URL != testdataDir+"/child1.html" {
+		t.Errorf("got the detached frame %+v", info)
+	}
+}
@@ -0,0 +1,429 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+)
+
+// HARRecorder records the requests of a page, until it's stopped into a HAR
+// archive, as started by RecordHAR.
+type HARRecorder struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	rec       *networkRecorder
+	mainFrame cdp.FrameID
+
+	mu    sync.Mutex
+	pages []*harPage
+	// pageRefs are the ids of the pages the requests were sent by.
+	pageRefs map[network.RequestID]string
+}
+
+// harPage is a page of a HARRecorder, with the timestamp its document was
+// requested at, which its timings are relative to.
+type harPage struct {
+	page  *har.Page
+	start time.Time
+}
+
+// RecordHAR starts recording the requests of the current page of ctx, and of
+// the pages it navigates to, as set up by opts, until the Stop method of the
+// returned recorder is called. The WithRequestBodies and WithResponseBodies
+// options are needed for the bodies to be in the archive. Example:
+//
+//	rec, err := chromedp.RecordHAR(ctx, chromedp.WithResponseBodies(1<<20))
+//	if err != nil {
+//		// handle error
+//	}
+//	if err := chromedp.Run(ctx, chromedp.Navigate("https://example.com")); err != nil {
+//		// handle error
+//	}
+//	h, err := rec.Stop()
+//	if err != nil {
+//		// handle error
+//	}
+//	buf, err := json.Marshal(h)
+//	if err != nil {
+//		// handle error
+//	}
+//	err = os.WriteFile("example.har", buf, 0o644)
+func RecordHAR(ctx context.Context, opts ...RecordNetworkOption) (*HARRecorder, error) {
+	r := &HARRecorder{
+		ctx:      ctx,
+		rec:      newNetworkRecorder(),
+		pageRefs: make(map[network.RequestID]string),
+	}
+	for _, o := range opts {
+		o(r.rec)
+	}
+	// allocate the target first, if needed.
+	if err := Run(ctx); err != nil {
+		return nil, err
+	}
+	t := FromContext(ctx).Target
+	r.rec.ctx = cdp.WithExecutor(ctx, t)
+	t.frameMu.RLock()
+	r.mainFrame = t.cur
+	t.frameMu.RUnlock()
+
+	var lctx context.Context
+	lctx, r.cancel = context.WithCancel(ctx)
+	ListenTarget(lctx, r.handle)
+	return r, nil
+}
+
+// handle updates the recorder with the event ev.
+func (r *HARRecorder) handle(ev interface{}) {
+	r.rec.handle(ev)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var cur *harPage
+	if len(r.pages) > 0 {
+		cur = r.pages[len(r.pages)-1]
+	}
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if ev.Type == network.ResourceTypeDocument && ev.FrameID == r.mainFrame && ev.RedirectResponse == nil &&
+			string(ev.RequestID) == string(ev.LoaderID) {
+			cur = &harPage{page: &har.Page{
+				ID:          fmt.Sprintf("page_%d", len(r.pages)+1),
+				Title:       ev.Request.URL,
+				PageTimings: &har.PageTimings{OnContentLoad: -1, OnLoad: -1},
+			}}
+			if ev.WallTime != nil {
+				cur.page.StartedDateTime = harTime(ev.WallTime.Time())
+			}
+			if ev.Timestamp != nil {
+				cur.start = ev.Timestamp.Time()
+			}
+			r.pages = append(r.pages, cur)
+		}
+		if _, ok := r.pageRefs[ev.RequestID]; !ok && cur != nil {
+			r.pageRefs[ev.RequestID] = cur.page.ID
+		}
+	case *page.EventDomContentEventFired:
+		if cur != nil && ev.Timestamp != nil && !cur.start.IsZero() {
+			cur.page.PageTimings.OnContentLoad = durationMillis(ev.Timestamp.Time().Sub(cur.start))
+		}
+	case *page.EventLoadEventFired:
+		if cur != nil && ev.Timestamp != nil && !cur.start.IsZero() {
+			cur.page.PageTimings.OnLoad = durationMillis(ev.Timestamp.Time().Sub(cur.start))
+		}
+	}
+}
+
+// Stop stops the recording, and returns the HAR 1.2 archive of the recorded
+// requests, in the order they were sent. The requests which haven't finished
+// yet are left out.
+func (r *HARRecorder) Stop() (*har.HAR, error) {
+	r.cancel()
+	r.rec.stop()
+	reqs := slices.DeleteFunc(r.rec.requests(), func(req *NetworkRequest) bool {
+		return !req.Finished
+	})
+	if err := Run(r.ctx, ActionFunc(func(ctx context.Context) error {
+		r.rec.fetchBodies(ctx, reqs)
+		return nil
+	})); err != nil {
+		return nil, err
+	}
+
+	log := &har.Log{
+		Version: "1.2",
+		Creator: &har.Creator{Name: "chromedp", Version: chromedpVersion()},
+		Entries: make([]*har.Entry, 0, len(reqs)),
+	}
+	if c := FromContext(r.ctx); c != nil && c.Browser != nil {
+		_, product, _, _, _, err := browser.GetVersion().Do(cdp.WithExecutor(r.ctx, c.Browser))
+		if err != nil {
+			return nil, err
+		}
+		name, version, _ := strings.Cut(product, "/")
+		log.Browser = &har.Creator{Name: name, Version: version}
+	}
+
+	r.mu.Lock()
+	for _, p := range r.pages {
+		cp := *p.page
+		timings := *p.page.PageTimings
+		cp.PageTimings = &timings
+		log.Pages = append(log.Pages, &cp)
+	}
+	for i, req := range reqs {
+		e := harEntry(req)
+		// the redirects have the same id, and the same page.
+		e.Pageref = r.pageRefs[req.RequestID]
+		// the other requests may have been sent between two hops.
+		if j := slices.IndexFunc(reqs[i+1:], func(next *NetworkRequest) bool {
+			return next.RequestID == req.RequestID
+		}); j >= 0 {
+			e.Response.RedirectURL = reqs[i+1+j].URL
+		}
+		log.Entries = append(log.Entries, e)
+	}
+	r.mu.Unlock()
+	return &har.HAR{Log: log}, nil
+}
+
+// chromedpVersion returns the version of the chromedp module, as built in the
+// binary.
+func chromedpVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, m := range append([]*debug.Module{&info.Main}, info.Deps...) {
+			if m.Path == "github.com/chromedp/chromedp" && m.Version != "" {
+				return m.Version
+			}
+		}
+	}
+	return "(devel)"
+}
+
+// harEntry returns the HAR entry of the request req, without its page.
+func harEntry(req *NetworkRequest) *har.Entry {
+	reqURL, _, _ := strings.Cut(req.URL, "#")
+	httpVersion := harHTTPVersion(req.Protocol)
+	e := &har.Entry{
+		StartedDateTime: harTime(req.Started),
+		Request: &har.Request{
+			Method:      req.Method,
+			URL:         reqURL,
+			HTTPVersion: httpVersion,
+			Cookies:     harRequestCookies(req.RequestHeaders),
+			Headers:     harHeaders(req.RequestHeaders),
+			QueryString: harQueryString(reqURL),
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: &har.Response{
+			Status:      req.Status,
+			StatusText:  req.StatusText,
+			HTTPVersion: httpVersion,
+			Cookies:     harResponseCookies(req.Headers),
+			Headers:     harHeaders(req.Headers),
+			Content:     harContent(req),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Cache:           &har.Cache{},
+		ServerIPAddress: strings.Trim(req.RemoteIPAddress, "[]"),
+		Comment:         req.ErrorText,
+	}
+	if len(req.PostData) > 0 || req.postDataMissing {
+		e.Request.PostData = &har.PostData{
+			MimeType: headerValue(req.RequestHeaders, "Content-Type"),
+			Params:   []*har.Param{},
+			Text:     string(req.PostData),
+		}
+		e.Request.BodySize = int64(len(req.PostData))
+		if req.PostDataTruncated || req.postDataMissing {
+			e.Request.BodySize = -1
+		}
+	}
+	if req.Cache != CacheNetwork && req.Cache != "" {
+		e.Response.BodySize = 0
+	}
+	if req.ConnectionID != 0 {
+		e.Connection = strconv.FormatFloat(req.ConnectionID, 'f', -1, 64)
+	}
+	e.Timings, e.Time = harTimings(req)
+	return e
+}
+
+// harTime formats the time t as in the HAR archives.
+func harTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// durationMillis returns d in milliseconds.
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harHTTPVersion returns the HTTP version of the protocol of a response, such
+// as "HTTP/2" for "h2".
+func harHTTPVersion(protocol string) string {
+	switch protocol {
+	case "h2":
+		return "HTTP/2"
+	case "h3":
+		return "HTTP/3"
+	}
+	return strings.ToUpper(protocol)
+}
+
+// headerValue returns the value of the header name of h, regardless of its
+// case.
+func headerValue(h network.Headers, name string) string {
+	for k, v := range h {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// harHeaders returns the headers h, sorted by their names, with a pair for
+// each of the values of a header, which the raw headers join with newlines.
+func harHeaders(h network.Headers) []*har.NameValuePair {
+	pairs := []*har.NameValuePair{}
+	for name, v := range h {
+		for _, s := range strings.Split(fmt.Sprint(v), "\n") {
+			pairs = append(pairs, &har.NameValuePair{Name: name, Value: s})
+		}
+	}
+	slices.SortStableFunc(pairs, func(a, b *har.NameValuePair) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return pairs
+}
+
+// harQueryString returns the query parameters of rawURL, in their order.
+func harQueryString(rawURL string) []*har.NameValuePair {
+	pairs := []*har.NameValuePair{}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return pairs
+	}
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		pairs = append(pairs, &har.NameValuePair{Name: k, Value: v})
+	}
+	return pairs
+}
+
+// harRequestCookies returns the cookies of the Cookie header of h.
+func harRequestCookies(h network.Headers) []*har.Cookie {
+	cookies := []*har.Cookie{}
+	line := headerValue(h, "Cookie")
+	if line == "" {
+		return cookies
+	}
+	parsed, _ := http.ParseCookie(line)
+	for _, c := range parsed {
+		cookies = append(cookies, &har.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return cookies
+}
+
+// harResponseCookies returns the cookies of the Set-Cookie headers of h.
+func harResponseCookies(h network.Headers) []*har.Cookie {
+	cookies := []*har.Cookie{}
+	line := headerValue(h, "Set-Cookie")
+	if line == "" {
+		return cookies
+	}
+	for _, s := range strings.Split(line, "\n") {
+		c, err := http.ParseSetCookie(s)
+		if err != nil {
+			continue
+		}
+		hc := &har.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			hc.Expires = harTime(c.Expires)
+		}
+		cookies = append(cookies, hc)
+	}
+	return cookies
+}
+
+// harContent returns the content of the response of req, with its body if it
+// was recorded, encoded in base64 unless it's text.
+func harContent(req *NetworkRequest) *har.Content {
+	c := &har.Content{
+		Size:     int64(len(req.Body)),
+		MimeType: headerValue(req.Headers, "Content-Type"),
+	}
+	if c.MimeType == "" {
+		c.MimeType = req.MimeType
+	}
+	if c.MimeType == "" {
+		c.MimeType = "x-unknown"
+	}
+	switch {
+	case len(req.Body) == 0:
+	case utf8.Valid(req.Body):
+		c.Text = string(req.Body)
+	default:
+		c.Text = base64.StdEncoding.EncodeToString(req.Body)
+		c.Encoding = "base64"
+	}
+	return c
+}
+
+// harTimings returns the timings of the phases of the request req, and their
+// total in milliseconds. The phases which don't apply, such as the DNS lookup
+// of a reused connection, are -1.
+func harTimings(req *NetworkRequest) (*har.Timings, float64) {
+	total := durationMillis(req.Duration)
+	t := req.Timing
+	if t == nil {
+		// such as for the cached responses.
+		return &har.Timings{Blocked: -1, DNS: -1, Connect: -1, Ssl: -1, Receive: total}, total
+	}
+	phase := func(start, end float64) float64 {
+		if start < 0 || end < 0 {
+			return -1
+		}
+		return end - start
+	}
+	timings := &har.Timings{
+		Blocked: -1,
+		DNS:     phase(t.DNSStart, t.DNSEnd),
+		Connect: phase(t.ConnectStart, t.ConnectEnd),
+		Ssl:     phase(t.SslStart, t.SslEnd),
+		// the send, wait and receive phases always apply.
+		Send: max(phase(t.SendStart, t.SendEnd), 0),
+		Wait: max(phase(t.SendEnd, t.ReceiveHeadersEnd), 0),
+	}
+	for _, start := range []float64{t.DNSStart, t.ConnectStart, t.SendStart} {
+		if start >= 0 {
+			timings.Blocked = start
+			break
+		}
+	}
+	timings.Receive = max(total-t.ReceiveHeadersEnd, 0)
+	var sum float64
+	// the TLS handshake is part of the connection.
+	for _, v := range []float64{timings.Blocked, timings.DNS, timings.Connect, timings.Send, timings.Wait, timings.Receive} {
+		if v > 0 {
+			sum += v
+		}
+	}
+	return timings, sum
+}
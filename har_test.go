@@ -0,0 +1,219 @@
+package chromedp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestRecordHAR(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><script src="/script.js?v=1&name=a%20b"></script></head><body>har</body></html>`)
+	})
+	mux.HandleFunc("/script.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		fmt.Fprint(w, `window.loaded = true;`)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/api", http.StatusFound)
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0xff, 0x00, 0xfe})
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	rec, err := RecordHAR(ctx, WithRequestBodies(0), WithResponseBodies(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx,
+		Navigate(s.URL+"/"),
+		Evaluate(`fetch('/old', {method: 'POST', body: 'data=1'}).then(r => r.arrayBuffer()).then(b => b.byteLength)`, nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	h, err := rec.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := h.Log
+	if l.Version != "1.2" || l.Creator.Name != "chromedp" || l.Browser == nil || l.Browser.Version == "" {
+		t.Errorf("got the log %+v, creator %+v, browser %+v", l, l.Creator, l.Browser)
+	}
+	if len(l.Pages) != 1 {
+		t.Fatalf("want 1 page, got %d", len(l.Pages))
+	}
+	if p := l.Pages[0]; p.ID != "page_1" || p.PageTimings.OnLoad <= 0 || p.PageTimings.OnContentLoad <= 0 || p.StartedDateTime == "" {
+		t.Errorf("got the page %+v, timings %+v", p, p.PageTimings)
+	}
+
+	entries := make(map[string]*har.Entry)
+	for _, e := range l.Entries {
+		if e.Pageref != "page_1" {
+			t.Errorf("%s: want the page page_1, got %q", e.Request.URL, e.Pageref)
+		}
+		if e.Time <= 0 || e.Timings.Wait < 0 || e.Timings.Receive < 0 {
+			t.Errorf("%s: got the time %v and timings %+v", e.Request.URL, e.Time, e.Timings)
+		}
+		if _, err := time.Parse(time.RFC3339, e.StartedDateTime); err != nil {
+			t.Errorf("%s: %v", e.Request.URL, err)
+		}
+		entries[strings.TrimPrefix(e.Request.URL, s.URL)] = e
+	}
+
+	doc := entries["/"]
+	if doc == nil {
+		t.Fatalf("no entry of the document in %d entries", len(l.Entries))
+	}
+	if doc.Response.Status != 200 || doc.Response.HTTPVersion != "HTTP/1.1" || !strings.Contains(doc.Response.Content.Text, "<body>har</body>") ||
+		doc.ServerIPAddress != "127.0.0.1" {
+		t.Errorf("got the document response %+v, content %+v", doc.Response, doc.Response.Content)
+	}
+
+	script := entries["/script.js?v=1&name=a%20b"]
+	if script == nil {
+		t.Fatal("no entry of the script")
+	}
+	want := []*har.NameValuePair{{Name: "v", Value: "1"}, {Name: "name", Value: "a b"}}
+	if !reflect.DeepEqual(script.Request.QueryString, want) {
+		t.Errorf("got the query string %+v", script.Request.QueryString)
+	}
+	if script.Response.Content.MimeType != "text/javascript" || script.Response.Content.Text != "window.loaded = true;" {
+		t.Errorf("got the script content %+v", script.Response.Content)
+	}
+
+	redirect, api := entries["/old"], entries["/api"]
+	if redirect == nil || api == nil {
+		t.Fatal("no entries of the redirect")
+	}
+	if redirect.Response.Status != 302 || redirect.Response.RedirectURL != s.URL+"/api" || redirect.Request.PostData == nil ||
+		redirect.Request.PostData.Text != "data=1" || redirect.Request.BodySize != 6 {
+		t.Errorf("got the redirect %+v, %+v", redirect.Request, redirect.Response)
+	}
+	if c := api.Response.Content; c.Encoding != "base64" || c.Text != "/wD+" || c.Size != 3 {
+		t.Errorf("got the binary content %+v", c)
+	}
+
+	buf, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		Log struct {
+			Entries []map[string]interface{} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range v.Log.Entries {
+		for _, k := range []string{"startedDateTime", "time", "request", "response", "cache", "timings"} {
+			if _, ok := e[k]; !ok {
+				t.Errorf("want the field %q in the entry %v", k, e)
+			}
+		}
+	}
+}
+
+func TestHAREntry(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	e := harEntry(&NetworkRequest{
+		URL:     "https://example.com/a?q=1#frag",
+		Method:  "POST",
+		Started: started,
+		RequestHeaders: network.Headers{
+			"Cookie":       "a=1; b=2",
+			"content-type": "text/plain",
+		},
+		Status:     200,
+		StatusText: "OK",
+		Headers: network.Headers{
+			"Set-Cookie": "s=x; Path=/; HttpOnly\nt=y; Secure",
+		},
+		Protocol:        "h2",
+		RemoteIPAddress: "[::1]",
+		ConnectionID:    12,
+		Cache:           CacheDisk,
+		PostData:        []byte("body"),
+		Body:            []byte("ok"),
+		Finished:        true,
+	})
+	if e.StartedDateTime != "2024-01-02T03:04:05.006Z" || e.Request.URL != "https://example.com/a?q=1" || e.Request.HTTPVersion != "HTTP/2" ||
+		e.ServerIPAddress != "::1" || e.Connection != "12" || e.Response.BodySize != 0 {
+		t.Errorf("got the entry %+v, request %+v, response %+v", e, e.Request, e.Response)
+	}
+	wantCookies := []*har.Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}
+	if !reflect.DeepEqual(e.Request.Cookies, wantCookies) {
+		t.Errorf("want the request cookies %+v, got %+v", wantCookies, e.Request.Cookies)
+	}
+	wantCookies = []*har.Cookie{{Name: "s", Value: "x", Path: "/", HTTPOnly: true}, {Name: "t", Value: "y", Secure: true}}
+	if !reflect.DeepEqual(e.Response.Cookies, wantCookies) {
+		t.Errorf("want the response cookies %+v, got %+v", wantCookies, e.Response.Cookies)
+	}
+	if h := e.Response.Headers; len(h) != 2 || h[0].Value != "s=x; Path=/; HttpOnly" || h[1].Value != "t=y; Secure" {
+		t.Errorf("want a header per value, got %+v", h)
+	}
+	if p := e.Request.PostData; p == nil || p.MimeType != "text/plain" || p.Text != "body" || e.Request.BodySize != 4 {
+		t.Errorf("got the post data %+v", p)
+	}
+	if c := e.Response.Content; c.Text != "ok" || c.Size != 2 || c.Encoding != "" {
+		t.Errorf("got the content %+v", c)
+	}
+}
+
+func TestHARTimings(t *testing.T) {
+	t.Parallel()
+
+	req := &NetworkRequest{
+		Duration: 100 * time.Millisecond,
+		Timing: &network.ResourceTiming{
+			ProxyStart: -1, ProxyEnd: -1,
+			DNSStart: 2, DNSEnd: 12,
+			ConnectStart: 12, ConnectEnd: 40,
+			SslStart: 20, SslEnd: 40,
+			SendStart: 41, SendEnd: 42,
+			ReceiveHeadersEnd: 80,
+		},
+	}
+	timings, total := harTimings(req)
+	want := &har.Timings{Blocked: 2, DNS: 10, Connect: 28, Ssl: 20, Send: 1, Wait: 38, Receive: 20}
+	if !reflect.DeepEqual(timings, want) {
+		t.Errorf("want %+v, got %+v", want, timings)
+	}
+	if total != 99 {
+		t.Errorf("want the total 99, got %v", total)
+	}
+
+	// a reused connection.
+	req.Timing = &network.ResourceTiming{
+		DNSStart: -1, DNSEnd: -1, ConnectStart: -1, ConnectEnd: -1, SslStart: -1, SslEnd: -1,
+		SendStart: 1, SendEnd: 2, ReceiveHeadersEnd: 50,
+	}
+	timings, total = harTimings(req)
+	want = &har.Timings{Blocked: 1, DNS: -1, Connect: -1, Ssl: -1, Send: 1, Wait: 48, Receive: 50}
+	if !reflect.DeepEqual(timings, want) || total != 100 {
+		t.Errorf("want %+v, got %+v and the total %v", want, timings, total)
+	}
+}
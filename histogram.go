@@ -0,0 +1,129 @@
+package chromedp
+
+import (
+	"context"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+)
+
+// Histogram is an internal histogram of the browser, such as the
+// "Memory.Browser.PrivateMemoryFootprint" or "PageLoad.PaintTiming.NavigationToFirstContentfulPaint"
+// one, as returned by Histograms.
+type Histogram struct {
+	Name string
+	// Sum is the sum of the samples, and Count their number.
+	Sum   int64
+	Count int64
+	// Buckets are the buckets with samples, in increasing order.
+	Buckets []HistogramBucket
+}
+
+// HistogramBucket is a bucket of a Histogram, with the samples from Low,
+// inclusive, to High, exclusive.
+type HistogramBucket struct {
+	Low   int64
+	High  int64
+	Count int64
+}
+
+// Histograms returns the internal histograms of the browser of the context
+// whose name contains filter, or all of them if filter is empty. They cover
+// the whole life of the browser; to compare the samples of a run, use Sub
+// with the histograms returned before it.
+//
+// Example:
+//
+//	hs, err := chromedp.Histograms(ctx, "PageLoad.")
+//	if err != nil {
+//		// handle error
+//	}
+//	for _, h := range hs {
+//		fmt.Printf("%s: %d samples, mean %.1f, p90 %.1f\n", h.Name, h.Count, h.Mean(), h.Percentile(0.9))
+//	}
+func Histograms(ctx context.Context, filter string) ([]*Histogram, error) {
+	c, err := initContextBrowser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p := browser.GetHistograms()
+	if filter != "" {
+		p = p.WithQuery(filter)
+	}
+	hs, err := p.Do(cdp.WithExecutor(ctx, c.Browser))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Histogram, 0, len(hs))
+	for _, h := range hs {
+		buckets := make([]HistogramBucket, 0, len(h.Buckets))
+		for _, b := range h.Buckets {
+			buckets = append(buckets, HistogramBucket{Low: b.Low, High: b.High, Count: b.Count})
+		}
+		res = append(res, &Histogram{Name: h.Name, Sum: h.Sum, Count: h.Count, Buckets: buckets})
+	}
+	return res, nil
+}
+
+// Mean returns the mean of the samples, or 0 if there are none.
+func (h *Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.Sum) / float64(h.Count)
+}
+
+// Percentile returns an estimate of the percentile p of the samples, from 0
+// to 1, such as 0.5 for the median, interpolated within its bucket. It
+// returns 0 if there are no samples.
+func (h *Histogram) Percentile(p float64) float64 {
+	var total int64
+	for _, b := range h.Buckets {
+		total += b.Count
+	}
+	if total == 0 {
+		return 0
+	}
+	if p < 0 {
+		p = 0
+	} else if p > 1 {
+		p = 1
+	}
+	rank := p * float64(total)
+	var seen int64
+	for _, b := range h.Buckets {
+		if b.Count > 0 && float64(seen+b.Count) >= rank {
+			frac := (rank - float64(seen)) / float64(b.Count)
+			return float64(b.Low) + frac*float64(b.High-b.Low)
+		}
+		seen += b.Count
+	}
+	last := h.Buckets[len(h.Buckets)-1]
+	return float64(last.High)
+}
+
+// Sub returns the histogram of the samples of h which are not in prev, an
+// earlier version of the same histogram, so that the samples of a run can be
+// compared across the browsers. The buckets left without samples are
+// removed.
+func (h *Histogram) Sub(prev *Histogram) *Histogram {
+	res := &Histogram{Name: h.Name, Sum: h.Sum, Count: h.Count}
+	if prev == nil {
+		res.Buckets = append(res.Buckets, h.Buckets...)
+		return res
+	}
+	res.Sum -= prev.Sum
+	res.Count -= prev.Count
+	type key struct{ low, high int64 }
+	counts := make(map[key]int64, len(prev.Buckets))
+	for _, b := range prev.Buckets {
+		counts[key{b.Low, b.High}] += b.Count
+	}
+	for _, b := range h.Buckets {
+		b.Count -= counts[key{b.Low, b.High}]
+		if b.Count > 0 {
+			res.Buckets = append(res.Buckets, b)
+		}
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+package chromedp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistograms(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	all, err := Histograms(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 {
+		t.Fatal("want some histograms")
+	}
+	name := all[0].Name
+	for _, h := range all {
+		if h.Count > 0 && len(h.Buckets) > 0 {
+			name = h.Name
+			break
+		}
+	}
+	filtered, err := Histograms(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) == 0 || len(filtered) >= len(all) {
+		t.Fatalf("got %d histograms for %q, of %d", len(filtered), name, len(all))
+	}
+	for _, h := range filtered {
+		if !strings.Contains(h.Name, name) {
+			t.Errorf("got the histogram %q for %q", h.Name, name)
+		}
+	}
+}
+
+func TestHistogramStats(t *testing.T) {
+	t.Parallel()
+
+	h := &Histogram{
+		Name:  "Test",
+		Sum:   100,
+		Count: 10,
+		Buckets: []HistogramBucket{
+			{Low: 0, High: 10, Count: 5},
+			{Low: 10, High: 20, Count: 0},
+			{Low: 20, High: 40, Count: 5},
+		},
+	}
+	if got := h.Mean(); got != 10 {
+		t.Errorf("got the mean %v, want 10", got)
+	}
+	for _, test := range []struct {
+		p, want float64
+	}{
+		{0, 0},
+		{0.2, 4},
+		{0.5, 10},
+		{0.7, 28},
+		{1, 40},
+		{2, 40},
+	} {
+		if got := h.Percentile(test.p); got != test.want {
+			t.Errorf("percentile %v: got %v, want %v", test.p, got, test.want)
+		}
+	}
+	if got := (&Histogram{}).Percentile(0.5); got != 0 {
+		t.Errorf("got the percentile %v of an empty histogram, want 0", got)
+	}
+
+	prev := &Histogram{
+		Name:    "Test",
+		Sum:     40,
+		Count:   4,
+		Buckets: []HistogramBucket{{Low: 0, High: 10, Count: 2}, {Low: 20, High: 40, Count: 2}},
+	}
+	want := &Histogram{
+		Name:    "Test",
+		Sum:     60,
+		Count:   6,
+		Buckets: []HistogramBucket{{Low: 0, High: 10, Count: 3}, {Low: 20, High: 40, Count: 3}},
+	}
+	if got := h.Sub(prev); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := h.Sub(h); got.Count != 0 || len(got.Buckets) != 0 {
+		t.Errorf("got %+v, want no samples", got)
+	}
+}
@@ -2,10 +2,13 @@ package chromedp
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp/kb"
 )
 
@@ -56,40 +59,149 @@ func MouseClickXY(x, y float64, opts ...MouseOption) MouseAction {
 // viewport.
 func MouseClickNode(n *cdp.Node, opts ...MouseOption) MouseAction {
 	return ActionFunc(func(ctx context.Context) error {
-		t := cdp.ExecutorFromContext(ctx).(*Target)
-		if t == nil {
-			return ErrInvalidTarget
-		}
-
-		if err := dom.ScrollIntoViewIfNeeded().WithNodeID(n.NodeID).Do(ctx); err != nil {
+		x, y, err := nodeCenter(ctx, n)
+		if err != nil {
 			return err
 		}
 
-		boxes, err := dom.GetContentQuads().WithNodeID(n.NodeID).Do(ctx)
-		if err != nil {
+		return MouseClickXY(x, y, opts...).Do(ctx)
+	})
+}
+
+// nodeCenter scrolls n into view if needed, and returns the coordinates of its
+// center.
+func nodeCenter(ctx context.Context, n *cdp.Node) (x, y float64, err error) {
+	t := cdp.ExecutorFromContext(ctx).(*Target)
+	if t == nil {
+		return 0, 0, ErrInvalidTarget
+	}
+
+	if err := dom.ScrollIntoViewIfNeeded().WithNodeID(n.NodeID).Do(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	boxes, err := dom.GetContentQuads().WithNodeID(n.NodeID).Do(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(boxes) == 0 {
+		return 0, 0, ErrInvalidDimensions
+	}
+
+	content := boxes[0]
+
+	c := len(content)
+	if c%2 != 0 || c < 1 {
+		return 0, 0, ErrInvalidDimensions
+	}
+
+	for i := 0; i < c; i += 2 {
+		x += content[i]
+		y += content[i+1]
+	}
+	x /= float64(c / 2)
+	y /= float64(c / 2)
+
+	return x, y, nil
+}
+
+// clickNode is the implementation of the Click and DoubleClick actions: it
+// clicks at the center of n, after checking that n would receive the click
+// when verify is true.
+func clickNode(ctx context.Context, n *cdp.Node, verify bool, opts ...MouseOption) error {
+	x, y, err := nodeCenter(ctx, n)
+	if err != nil {
+		return err
+	}
+
+	if verify {
+		if err := checkClickTarget(ctx, n, x, y); err != nil {
 			return err
 		}
+	}
 
-		if len(boxes) == 0 {
-			return ErrInvalidDimensions
-		}
+	return MouseClickXY(x, y, opts...).Do(ctx)
+}
 
-		content := boxes[0]
+// checkClickTarget returns ErrClickIntercepted, along with a description of
+// the covering element, if the element at x, y is neither n nor one of its
+// descendants.
+func checkClickTarget(ctx context.Context, n *cdp.Node, x, y float64) error {
+	backendID, _, _, err := dom.GetNodeForLocation(int64(x), int64(y)).
+		WithIgnorePointerEventsNone(true).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if backendID == n.BackendNodeID {
+		return nil
+	}
 
-		c := len(content)
-		if c%2 != 0 || c < 1 {
-			return ErrInvalidDimensions
-		}
+	hit, err := dom.ResolveNode().WithBackendNodeID(backendID).Do(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = runtime.ReleaseObject(hit.ObjectID).Do(ctx)
+	}()
 
-		var x, y float64
-		for i := 0; i < c; i += 2 {
-			x += content[i]
-			y += content[i+1]
-		}
-		x /= float64(c / 2)
-		y /= float64(c / 2)
+	obj, err := dom.ResolveNode().WithNodeID(n.NodeID).Do(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+	}()
+
+	res, exp, err := runtime.CallFunctionOn(clickTargetJS).
+		WithObjectID(obj.ObjectID).
+		WithArguments([]*runtime.CallArgument{{ObjectID: hit.ObjectID}}).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exp != nil {
+		return exp
+	}
+	var desc string
+	if err := json.Unmarshal(res.Value, &desc); err != nil {
+		return err
+	}
+	if desc != "" {
+		return fmt.Errorf("%w by %s", ErrClickIntercepted, desc)
+	}
+	return nil
+}
 
-		return MouseClickXY(x, y, opts...).Do(ctx)
+// ElementFromPoint is an action that retrieves the node ID of the topmost
+// element at the coordinates x, y of the viewport, that is, the element which
+// would receive a mouse event dispatched at that point.
+//
+// Like with document.elementFromPoint(), the elements with
+// "pointer-events: none" are ignored.
+func ElementFromPoint(x, y float64, nodeID *cdp.NodeID) Action {
+	if nodeID == nil {
+		panic("nodeID cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		backendID, _, id, err := dom.GetNodeForLocation(int64(x), int64(y)).
+			WithIgnorePointerEventsNone(true).
+			Do(ctx)
+		if err != nil {
+			return err
+		}
+		if id == 0 {
+			ids, err := dom.PushNodesByBackendIDsToFrontend([]cdp.BackendNodeID{backendID}).Do(ctx)
+			if err != nil {
+				return err
+			}
+			id = ids[0]
+		}
+		*nodeID = id
+		return nil
 	})
 }
 
@@ -286,3 +286,22 @@ func TestKeyEventNode(t *testing.T) {
 		})
 	}
 }
+
+func TestElementFromPoint(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "actionable.html")
+	defer cancel()
+
+	var ids []cdp.NodeID
+	var id cdp.NodeID
+	if err := Run(ctx,
+		NodeIDs("#overlay", &ids, ByQuery),
+		ElementFromPoint(10, 10, &id),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if id != ids[0] {
+		t.Fatalf("want the overlay node %d, got %d", ids[0], id)
+	}
+}
@@ -0,0 +1,252 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+// InterceptedRequest is a request of the page paused by InterceptRequests,
+// which its handler can modify, fail or fulfill. It's continued as is
+// otherwise.
+type InterceptedRequest struct {
+	// RequestID is the id of the paused request, and NetworkID the id of
+	// the request in the Network events, if any.
+	RequestID    fetch.RequestID
+	NetworkID    network.RequestID
+	FrameID      cdp.FrameID
+	ResourceType network.ResourceType
+	// Request is the request, as sent by the page.
+	Request *network.Request
+
+	url, method string
+	headers     []*fetch.HeaderEntry
+	postData    []byte
+	hasPostData bool
+
+	failReason network.ErrorReason
+	fulfill    *fetch.FulfillRequestParams
+}
+
+// SetURL changes the URL of the request, without the page noticing it, such
+// as to redirect it to a mock server. The scheme can't be changed.
+func (r *InterceptedRequest) SetURL(url string) {
+	r.url = url
+}
+
+// SetMethod changes the method of the request.
+func (r *InterceptedRequest) SetMethod(method string) {
+	r.method = method
+}
+
+// SetHeader sets the header name of the request to value, replacing its
+// values, if any.
+func (r *InterceptedRequest) SetHeader(name, value string) {
+	r.DelHeader(name)
+	r.headers = append(r.headers, &fetch.HeaderEntry{Name: name, Value: value})
+}
+
+// DelHeader removes the header name of the request.
+func (r *InterceptedRequest) DelHeader(name string) {
+	if r.headers == nil {
+		r.headers = requestHeaderEntries(r.Request.Headers)
+	}
+	r.headers = slices.DeleteFunc(r.headers, func(h *fetch.HeaderEntry) bool {
+		return strings.EqualFold(h.Name, name)
+	})
+}
+
+// SetPostData changes the body of the request, such as the data of a POST
+// request.
+func (r *InterceptedRequest) SetPostData(body []byte) {
+	r.postData, r.hasPostData = body, true
+}
+
+// Fail fails the request with reason, such as
+// network.ErrorReasonBlockedByClient to block it, instead of sending it.
+func (r *InterceptedRequest) Fail(reason network.ErrorReason) {
+	r.failReason = reason
+}
+
+// Fulfill responds to the request with the status code status, the headers
+// and body, instead of sending it, such as to mock the responses of a server.
+func (r *InterceptedRequest) Fulfill(status int64, headers http.Header, body []byte) {
+	var entries []*fetch.HeaderEntry
+	for name, values := range headers {
+		for _, v := range values {
+			entries = append(entries, &fetch.HeaderEntry{Name: name, Value: v})
+		}
+	}
+	r.fulfill = fetch.FulfillRequest(r.RequestID, status).
+		WithResponseHeaders(entries).
+		WithBody(base64.StdEncoding.EncodeToString(body))
+}
+
+// requestHeaderEntries returns the headers of a request as header entries,
+// sorted by their names.
+func requestHeaderEntries(headers network.Headers) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(headers))
+	for name, v := range headers {
+		entries = append(entries, &fetch.HeaderEntry{Name: name, Value: fmt.Sprint(v)})
+	}
+	slices.SortFunc(entries, func(a, b *fetch.HeaderEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return entries
+}
+
+// InterceptOption is an InterceptRequests option.
+type InterceptOption = func(*interceptor)
+
+// WithURLPatterns is an InterceptRequests option to only intercept the requests
+// whose URL matches one of patterns, instead of all of them. The wildcard '*'
+// of the patterns matches zero or more characters, '?' matches exactly one,
+// and '\' escapes them.
+func WithURLPatterns(patterns ...string) InterceptOption {
+	return func(i *interceptor) {
+		i.urlPatterns = append(i.urlPatterns, patterns...)
+	}
+}
+
+// WithResourceTypes is an InterceptRequests option to only intercept the
+// requests of one of types, such as network.ResourceTypeImage.
+func WithResourceTypes(types ...network.ResourceType) InterceptOption {
+	return func(i *interceptor) {
+		i.resourceTypes = append(i.resourceTypes, types...)
+	}
+}
+
+// interceptor handles the requests paused by InterceptRequests.
+type interceptor struct {
+	handler       func(*InterceptedRequest)
+	urlPatterns   []string
+	resourceTypes []network.ResourceType
+}
+
+// InterceptRequests intercepts the requests of the current page of ctx, as
+// filtered by opts, until ctx is cancelled. Each request is paused until
+// handler returns, after which it's sent, with the changes of handler, or
+// failed or fulfilled by it. handler is called in its own goroutine for each
+// request, so it can be called concurrently. Example:
+//
+//	err := chromedp.InterceptRequests(ctx, func(r *chromedp.InterceptedRequest) {
+//		switch {
+//		case strings.Contains(r.Request.URL, "/ads/"):
+//			r.Fail(network.ErrorReasonBlockedByClient)
+//		case strings.HasSuffix(r.Request.URL, "/api/user"):
+//			r.Fulfill(200, http.Header{"Content-Type": {"application/json"}}, []byte(`{"name":"test"}`))
+//		default:
+//			r.SetHeader("X-Test", "1")
+//		}
+//	}, chromedp.WithResourceTypes(network.ResourceTypeXHR, network.ResourceTypeFetch, network.ResourceTypeScript))
+//
+// It enables the Fetch domain, which only allows one set of patterns, so that
+// it can't be used along with other InterceptRequests calls, nor with
+// fetch.Enable, on the same page. The authentication challenges and the
+// responses aren't intercepted.
+func InterceptRequests(ctx context.Context, handler func(*InterceptedRequest), opts ...InterceptOption) error {
+	i := &interceptor{handler: handler}
+	for _, o := range opts {
+		o(i)
+	}
+	return Run(ctx, ActionFunc(i.enable))
+}
+
+// enable enables the interception of the requests, until ctx is cancelled.
+func (i *interceptor) enable(ctx context.Context) error {
+	// the requests paused after ctx is cancelled, until the Fetch domain is
+	// disabled, are still continued.
+	lctx, lcancel := context.WithCancel(context.WithoutCancel(ctx))
+	ListenTarget(lctx, func(ev interface{}) {
+		if ev, ok := ev.(*fetch.EventRequestPaused); ok {
+			go i.handle(ctx, lctx, ev)
+		}
+	})
+	if err := fetch.Enable().WithPatterns(i.patterns()).Do(ctx); err != nil {
+		lcancel()
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		// the requests would otherwise stay paused, such as for a context
+		// with a timeout. The requests being paused while the domain is
+		// disabled are never reported nor continued, so no requests are
+		// intercepted anymore first, with a pattern which can't match
+		// the escaped URLs.
+		dctx, cancel := context.WithTimeout(lctx, time.Second)
+		_ = fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: " "}}).Do(dctx)
+		_ = fetch.Disable().Do(dctx)
+		cancel()
+		lcancel()
+	}()
+	return nil
+}
+
+// patterns returns the request patterns of the Fetch domain, matching any of
+// the URL patterns and of the resource types.
+func (i *interceptor) patterns() []*fetch.RequestPattern {
+	urls := i.urlPatterns
+	if len(urls) == 0 {
+		urls = []string{"*"}
+	}
+	types := i.resourceTypes
+	if len(types) == 0 {
+		types = []network.ResourceType{""}
+	}
+	var patterns []*fetch.RequestPattern
+	for _, u := range urls {
+		for _, t := range types {
+			patterns = append(patterns, &fetch.RequestPattern{URLPattern: u, ResourceType: t})
+		}
+	}
+	return patterns
+}
+
+// handle calls the handler with the paused request ev, and continues, fails
+// or fulfills it as set up by the handler, with lctx, so that it isn't left
+// paused when ctx is cancelled meanwhile. The requests paused once ctx is
+// cancelled are continued as is.
+func (i *interceptor) handle(ctx, lctx context.Context, ev *fetch.EventRequestPaused) {
+	r := &InterceptedRequest{
+		RequestID:    ev.RequestID,
+		NetworkID:    ev.NetworkID,
+		FrameID:      ev.FrameID,
+		ResourceType: ev.ResourceType,
+		Request:      ev.Request,
+	}
+	if ctx.Err() == nil {
+		i.handler(r)
+	}
+
+	// the request is gone if these fail, such as after the page navigated
+	// or was closed.
+	switch {
+	case r.failReason != "":
+		_ = fetch.FailRequest(r.RequestID, r.failReason).Do(lctx)
+	case r.fulfill != nil:
+		_ = r.fulfill.Do(lctx)
+	default:
+		p := fetch.ContinueRequest(r.RequestID)
+		if r.url != "" {
+			p = p.WithURL(r.url)
+		}
+		if r.method != "" {
+			p = p.WithMethod(r.method)
+		}
+		if r.headers != nil {
+			p = p.WithHeaders(r.headers)
+		}
+		if r.hasPostData {
+			p = p.WithPostData(base64.StdEncoding.EncodeToString(r.postData))
+		}
+		_ = p.Do(lctx)
+	}
+}
@@ -0,0 +1,146 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestInterceptRequests(t *testing.T) {
+	t.Parallel()
+
+	var served atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>intercept</body></html>`)
+	})
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		fmt.Fprint(w, `{"name":"server"}`)
+	})
+	mux.HandleFunc("/ads/banner.js", func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("X-Test"), body)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	ictx, icancel := context.WithCancel(ctx)
+	defer icancel()
+	var types atomic.Value
+	if err := InterceptRequests(ictx, func(r *InterceptedRequest) {
+		types.Store(r.ResourceType)
+		switch {
+		case strings.Contains(r.Request.URL, "/ads/"):
+			r.Fail(network.ErrorReasonBlockedByClient)
+		case strings.HasSuffix(r.Request.URL, "/api/user"):
+			r.Fulfill(200, http.Header{"Content-Type": {"application/json"}}, []byte(`{"name":"mock"}`))
+		default:
+			r.SetMethod("PUT")
+			r.SetHeader("X-Test", "modified")
+			r.SetPostData([]byte("changed"))
+		}
+	}, WithURLPatterns("*/api/*", "*/ads/*", "*/echo")); err != nil {
+		t.Fatal(err)
+	}
+
+	fetchJS := func(url, init string) string {
+		return fmt.Sprintf(`fetch(%q, %s).then(r => r.text(), e => 'failed')`, url, init)
+	}
+	tests := []struct {
+		url, init string
+		want      string
+	}{
+		{"/api/user", "{}", `{"name":"mock"}`},
+		{"/ads/banner.js", "{}", "failed"},
+		{"/echo", `{method: 'POST', body: 'original', headers: {'X-Test': 'original'}}`, "PUT modified changed"},
+	}
+	for _, test := range tests {
+		var got string
+		if err := Run(ctx, Evaluate(fetchJS(test.url, test.init), &got, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		})); err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: want %q, got %q", test.url, test.want, got)
+		}
+	}
+	if n := served.Load(); n != 0 {
+		t.Errorf("want no requests to the server for the mocked and blocked ones, got %d", n)
+	}
+	// the requests of fetch are reported as XHR ones by some versions.
+	if got := types.Load(); got != network.ResourceTypeFetch && got != network.ResourceTypeXHR {
+		t.Errorf("want the resource type %q, got %q", network.ResourceTypeFetch, got)
+	}
+
+	// the requests aren't intercepted once the context is cancelled, and
+	// the Fetch domain disabled.
+	icancel()
+	want := `{"name":"server"}`
+	var got string
+	for deadline := time.Now().Add(5 * time.Second); got != want && time.Now().Before(deadline); {
+		if err := Run(ctx, Evaluate(fetchJS("/api/user", "{}"), &got, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got != want {
+		t.Errorf("want %q once cancelled, got %q", want, got)
+	}
+}
+
+func TestInterceptPatterns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		opts []InterceptOption
+		want []*fetch.RequestPattern
+	}{
+		{nil, []*fetch.RequestPattern{{URLPattern: "*"}}},
+		{
+			[]InterceptOption{WithURLPatterns("*.js", "*.css")},
+			[]*fetch.RequestPattern{{URLPattern: "*.js"}, {URLPattern: "*.css"}},
+		},
+		{
+			[]InterceptOption{
+				WithURLPatterns("*/api/*"),
+				WithResourceTypes(network.ResourceTypeXHR, network.ResourceTypeFetch),
+			},
+			[]*fetch.RequestPattern{
+				{URLPattern: "*/api/*", ResourceType: network.ResourceTypeXHR},
+				{URLPattern: "*/api/*", ResourceType: network.ResourceTypeFetch},
+			},
+		},
+	}
+	for i, test := range tests {
+		var ic interceptor
+		for _, o := range test.opts {
+			o(&ic)
+		}
+		if got := ic.patterns(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("test %d: want %v, got %v", i, test.want, got)
+		}
+	}
+}
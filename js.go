@@ -15,6 +15,16 @@ var (
 	//go:embed js/textContent.js
 	textContentJS string
 
+	// innerTextJS is a JavaScript snippet that returns the innerText of the
+	// specified element.
+	//go:embed js/innerText.js
+	innerTextJS string
+
+	// markdownJS is a JavaScript snippet that converts the specified element
+	// and its subtree to Markdown.
+	//go:embed js/markdown.js
+	markdownJS string
+
 	// blurJS is a JavaScript snippet that blurs the specified element.
 	//go:embed js/blur.js
 	blurJS string
@@ -29,6 +39,18 @@ var (
 	//go:embed js/reset.js
 	resetJS string
 
+	// cssPathJS is a JavaScript snippet that returns the shortest CSS selector
+	// path of the specified element, as copied by the DevTools.
+	//go:embed js/cssPath.js
+	cssPathJS string
+
+	// uploadFilesJS is a JavaScript snippet that creates files from their
+	// base64 encoded content, and sets them as the files of the specified
+	// file input, or drops them onto the specified element otherwise, as a
+	// drag and drop from outside of the page would.
+	//go:embed js/uploadFiles.js
+	uploadFilesJS string
+
 	// attributeJS is a JavaScript snippet that returns the attribute of a specified
 	// node.
 	//go:embed js/attribute.js
@@ -45,11 +67,117 @@ var (
 	//go:embed js/visible.js
 	visibleJS string
 
+	// actionableJS is a JavaScript snippet that returns the name of the first
+	// failed actionability check of the specified element (stable, hitTest or
+	// enabled), or an empty string if the element is actionable, once it's
+	// scrolled into view as the click would if scroll is true.
+	//go:embed js/actionable.js
+	actionableJS string
+
+	// clickTargetJS is a JavaScript snippet that returns an empty string if the
+	// element passed as argument is the specified element or one of its
+	// descendants, or a description of the passed element otherwise.
+	//go:embed js/clickTarget.js
+	clickTargetJS string
+
+	// scrollIntoViewJS is a JavaScript snippet that scrolls the specified
+	// element and its scroll containers to align the element as specified,
+	// keeping a margin around it.
+	//go:embed js/scrollIntoView.js
+	scrollIntoViewJS string
+
 	// getClientRectJS is a JavaScript snippet that returns the information about the
 	// size of the specified node and its position relative to its owner document.
 	//go:embed js/getClientRect.js
 	getClientRectJS string
 
+	// jsonStringifyJS is a JavaScript snippet that returns the JSON encoding
+	// of the value of the specified wrapper object as a String object, so that
+	// it can be kept in the browser and retrieved in chunks with stringChunkJS.
+	//go:embed js/jsonStringify.js
+	jsonStringifyJS string
+
+	// limitResultJS is a JavaScript snippet that returns the size of the JSON
+	// encoding of the value of the specified wrapper object, along with the
+	// value if it's not larger than the specified limit.
+	//go:embed js/limitResult.js
+	limitResultJS string
+
+	// binaryValueJS is a JavaScript snippet that returns the bytes of the
+	// ArrayBuffer, or the elements of the typed array, at the specified path
+	// from the specified object.
+	//go:embed js/binaryValue.js
+	binaryValueJS string
+
+	// stringChunkJS is a JavaScript snippet that returns a chunk of the
+	// specified String object, along with the end offset of the chunk and the
+	// total length of the string.
+	//go:embed js/stringChunk.js
+	stringChunkJS string
+
+	// metaTagsJS is a JavaScript snippet that returns the values of the
+	// document's meta tags, keyed by their name, property, http-equiv or
+	// itemprop attributes.
+	//go:embed js/metaTags.js
+	metaTagsJS string
+
+	// detectChallengeJS is a JavaScript snippet that detects the challenge
+	// page or widget of a bot protection, such as the interstitial of
+	// Cloudflare or a reCAPTCHA, from the document.
+	//go:embed js/detectChallenge.js
+	detectChallengeJS string
+
+	// fingerprintJS is a JavaScript snippet that gathers the properties of
+	// the browser which the pages commonly use to fingerprint it, such as
+	// the user agent, its client hints, the languages and the WebGL
+	// renderer.
+	//go:embed js/fingerprint.js
+	fingerprintJS string
+
+	// savePageJS is a JavaScript snippet that serializes the document, with
+	// the references to the saved resources rewritten to their local paths,
+	// and the stylesheets optionally inlined.
+	//go:embed js/savePage.js
+	savePageJS string
+
+	// linksJS is a JavaScript snippet that returns the http and https links
+	// of the specified element and its subtree, with their absolute URL
+	// without its fragment, and their text.
+	//go:embed js/links.js
+	linksJS string
+
+	// checkLinkJS is a JavaScript snippet that fetches the specified URL
+	// with a HEAD request, or a GET one when HEAD isn't supported, and
+	// returns the status code of the response, or the error of the fetch.
+	//go:embed js/checkLink.js
+	checkLinkJS string
+
+	// overrideLanguagesJS is a JavaScript snippet that overrides
+	// navigator.languages and navigator.language with the specified list of
+	// languages.
+	//go:embed js/overrideLanguages.js
+	overrideLanguagesJS string
+
+	// spoofTimeJS is a JavaScript snippet that overrides Date so that the
+	// current time starts at the specified base time, and optionally freezes
+	// both Date.now() and performance.now().
+	//go:embed js/spoofTime.js
+	spoofTimeJS string
+
+	// waitForEventJS is a JavaScript snippet that waits for an event of the
+	// specified type to be dispatched to the specified node or to its
+	// descendants, returning true, or false once the specified timeout in
+	// milliseconds is reached.
+	//go:embed js/waitForEvent.js
+	waitForEventJS string
+
+	// waitForMutationJS is a JavaScript snippet that waits for a mutation of
+	// the specified node observed by a MutationObserver with the specified
+	// options, returning the number of mutation records, or 0 once the
+	// specified timeout in milliseconds is reached.
+	//go:embed js/waitForMutation.js
+	waitForMutationJS string
+
 	// waitForPredicatePageFunction is a JavaScript snippet that runs the polling in the
 	// browser. It's copied from puppeteer. See
 	// https://github.com/puppeteer/puppeteer/blob/669f04a7a6e96cc8353a8cb152898edbc25e7c15/src/common/DOMWorld.ts#L870-L944
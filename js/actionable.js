@@ -0,0 +1,39 @@
+async function actionable(stable, hitTest, enabled, scroll) {
+    if (scroll) {
+        // Scroll like the click would, before the element is checked.
+        this.scrollIntoViewIfNeeded();
+    }
+    if (stable) {
+        // The element is stable when its bounding box is the same over two
+        // animation frames, or over a short while in the background tabs,
+        // where the animation frames don't fire.
+        const box = () => {
+            const r = this.getBoundingClientRect();
+            return [r.x, r.y, r.width, r.height].join();
+        };
+        const before = box();
+        await Promise.race([
+            new Promise((resolve) => requestAnimationFrame(() => requestAnimationFrame(resolve))),
+            new Promise((resolve) => setTimeout(resolve, 100)),
+        ]);
+        if (box() !== before) {
+            return 'stable';
+        }
+    }
+    if (hitTest) {
+        // Check that the element at the center point is the element itself
+        // or one of its descendants.
+        const r = this.getBoundingClientRect();
+        let hit = this.getRootNode().elementFromPoint(r.left + r.width / 2, r.top + r.height / 2);
+        while (hit && hit !== this) {
+            hit = hit.parentNode || hit.host;
+        }
+        if (!hit) {
+            return 'hitTest';
+        }
+    }
+    if (enabled && this.matches(':disabled')) {
+        return 'enabled';
+    }
+    return '';
+}
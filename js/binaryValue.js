@@ -0,0 +1,41 @@
+function binaryValue(path) {
+    let v = this;
+    for (const [kind, key] of path) {
+        switch (kind) {
+        case 'index':
+            // the elements of the arrays and sets.
+            v = Array.from(v)[key];
+            break;
+        case 'property':
+            v = v[key];
+            break;
+        case 'mapKey':
+            v = Array.from(v.keys())[key];
+            break;
+        case 'mapValue':
+            v = Array.from(v.values())[key];
+            break;
+        }
+    }
+    if (v instanceof ArrayBuffer) {
+        const bytes = new Uint8Array(v);
+        let s = '';
+        for (let i = 0; i < bytes.length; i++) {
+            s += String.fromCharCode(bytes[i]);
+        }
+        return {bytes: btoa(s)};
+    }
+    if (ArrayBuffer.isView(v) && !(v instanceof DataView)) {
+        // the values which JSON can't encode are sent as strings.
+        return {
+            elements: Array.from(v, (e) => {
+                if (typeof e === 'bigint' || !Number.isFinite(e) || Object.is(e, -0)) {
+                    return String(e);
+                }
+                return e;
+            }),
+            bigint: v instanceof BigInt64Array || v instanceof BigUint64Array,
+        };
+    }
+    return null;
+}
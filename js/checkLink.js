@@ -0,0 +1,13 @@
+async function checkLink(url) {
+    try {
+        let resp = await fetch(url, {method: 'HEAD', credentials: 'include', cache: 'no-store'});
+        if (resp.status === 405 || resp.status === 501) {
+            // the servers which don't support HEAD.
+            resp = await fetch(url, {credentials: 'include', cache: 'no-store'});
+            resp.body && resp.body.cancel();
+        }
+        return {status: resp.status};
+    } catch (e) {
+        return {error: String(e.message || e)};
+    }
+}
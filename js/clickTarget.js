@@ -0,0 +1,15 @@
+function clickTarget(hit) {
+    for (let n = hit; n; n = n.parentNode || n.host) {
+        if (n === this) {
+            return '';
+        }
+    }
+    let desc = hit.localName || hit.nodeName;
+    if (hit.id) {
+        desc += '#' + hit.id;
+    }
+    for (const c of hit.classList || []) {
+        desc += '.' + c;
+    }
+    return desc;
+}
@@ -0,0 +1,29 @@
+function cssPath() {
+    const unique = (node, selector) => node.getRootNode().querySelectorAll(selector).length === 1;
+    const steps = [];
+    for (let node = this; node && node.nodeType === Node.ELEMENT_NODE; node = node.parentElement) {
+        const name = node.localName;
+        if (node.id && unique(node, `#${CSS.escape(node.id)}`)) {
+            steps.unshift(`#${CSS.escape(node.id)}`);
+            break;
+        }
+        const parent = node.parentElement;
+        if (!parent || name === "html" || name === "body" || name === "head") {
+            steps.unshift(name);
+            break;
+        }
+        const siblings = Array.from(parent.children);
+        const sameName = siblings.filter((s) => s !== node && s.localName === name);
+        let step = CSS.escape(name);
+        if (sameName.length > 0) {
+            const cls = Array.from(node.classList).find((c) => !sameName.some((s) => s.classList.contains(c)));
+            if (cls) {
+                step += `.${CSS.escape(cls)}`;
+            } else {
+                step += `:nth-child(${siblings.indexOf(node) + 1})`;
+            }
+        }
+        steps.unshift(step);
+    }
+    return steps.join(" > ");
+}
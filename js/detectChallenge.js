@@ -0,0 +1,57 @@
+function detectChallenge() {
+    const found = [];
+    const add = (kind, interstitial, evidence) => found.push({kind, interstitial, evidence});
+    const has = (selector) => document.querySelector(selector) !== null;
+    const srcs = (selector) => Array.from(document.querySelectorAll(selector), (el) => el.src || '');
+
+    const title = document.title;
+    if (/^just a moment\.\.\.$/i.test(title) || /^attention required! \| cloudflare$/i.test(title)) {
+        add('cloudflare', true, 'title ' + JSON.stringify(title));
+    }
+    for (const selector of ['#challenge-form', '#challenge-running', '#cf-challenge-running', '.cf-browser-verification']) {
+        if (has(selector)) {
+            add('cloudflare', true, 'element ' + selector);
+        }
+    }
+    for (const src of srcs('script[src]')) {
+        if (src.includes('/cdn-cgi/challenge-platform/')) {
+            add('cloudflare', true, 'script ' + src);
+        }
+    }
+    for (const src of srcs('iframe[src]')) {
+        if (src.includes('challenges.cloudflare.com')) {
+            add('cloudflare', false, 'iframe ' + src);
+        } else if (/\/recaptcha\/(api2|enterprise)\//.test(src)) {
+            add('recaptcha', false, 'iframe ' + src);
+        } else if (/(^|\.)hcaptcha\.com\//.test(new URL(src, location.href).host + '/')) {
+            add('hcaptcha', false, 'iframe ' + src);
+        }
+    }
+    if (has('.cf-turnstile')) {
+        add('cloudflare', false, 'element .cf-turnstile');
+    }
+    if (has('.g-recaptcha')) {
+        add('recaptcha', false, 'element .g-recaptcha');
+    }
+    if (has('.h-captcha')) {
+        add('hcaptcha', false, 'element .h-captcha');
+    }
+    // the interstitial of Google when it detects unusual traffic.
+    if (location.pathname.startsWith('/sorry/') && has('#captcha-form')) {
+        add('recaptcha', true, 'element #captcha-form');
+    }
+
+    const nav = performance.getEntriesByType('navigation')[0];
+    const status = nav && nav.responseStatus ? nav.responseStatus : 0;
+    if (found.length === 0) {
+        return {status};
+    }
+    // an interstitial takes precedence over the widgets it embeds.
+    const first = found.find((f) => f.interstitial) || found[0];
+    return {
+        kind: first.kind,
+        interstitial: first.interstitial,
+        evidence: found.filter((f) => f.kind === first.kind).map((f) => f.evidence),
+        status,
+    };
+}
@@ -0,0 +1,50 @@
+async function fingerprint() {
+    const nav = navigator;
+    const res = {
+        userAgent: nav.userAgent,
+        appVersion: nav.appVersion,
+        platform: nav.platform,
+        vendor: nav.vendor,
+        language: nav.language,
+        languages: Array.from(nav.languages || []),
+        locale: Intl.DateTimeFormat().resolvedOptions().locale,
+        timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
+        timezoneOffset: new Date().getTimezoneOffset(),
+        hardwareConcurrency: nav.hardwareConcurrency || 0,
+        deviceMemory: nav.deviceMemory || 0,
+        maxTouchPoints: nav.maxTouchPoints || 0,
+        webdriver: !!nav.webdriver,
+        screenWidth: screen.width,
+        screenHeight: screen.height,
+        devicePixelRatio: devicePixelRatio,
+    };
+    if (nav.userAgentData) {
+        const data = nav.userAgentData;
+        const hints = {
+            brands: data.brands.map((b) => ({brand: b.brand, version: b.version})),
+            mobile: data.mobile,
+            platform: data.platform,
+        };
+        try {
+            const high = await data.getHighEntropyValues(['architecture', 'model', 'platformVersion', 'uaFullVersion']);
+            hints.architecture = high.architecture || '';
+            hints.model = high.model || '';
+            hints.platformVersion = high.platformVersion || '';
+            hints.fullVersion = high.uaFullVersion || '';
+        } catch (e) {
+            // the high entropy values are optional.
+        }
+        res.clientHints = hints;
+    }
+    try {
+        const gl = document.createElement('canvas').getContext('webgl');
+        if (gl) {
+            const ext = gl.getExtension('WEBGL_debug_renderer_info');
+            res.webGLVendor = gl.getParameter(ext ? ext.UNMASKED_VENDOR_WEBGL : gl.VENDOR);
+            res.webGLRenderer = gl.getParameter(ext ? ext.UNMASKED_RENDERER_WEBGL : gl.RENDERER);
+        }
+    } catch (e) {
+        // WebGL is optional.
+    }
+    return res;
+}
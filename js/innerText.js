@@ -0,0 +1,3 @@
+function innerText() {
+    return this.innerText;
+}
@@ -0,0 +1,7 @@
+function jsonStringify() {
+    const s = JSON.stringify(this.value);
+    if (s === undefined) {
+        return undefined;
+    }
+    return new String(s);
+}
@@ -0,0 +1,16 @@
+function limitResult(max) {
+    let size = 0;
+    try {
+        const s = JSON.stringify(this.value);
+        if (s !== undefined) {
+            size = new TextEncoder().encode(s).length;
+        }
+    } catch (e) {
+        // the value is returned as is, to fail as it would without the
+        // limit.
+    }
+    if (size > max) {
+        return {size};
+    }
+    return {size, value: this.value};
+}
@@ -0,0 +1,27 @@
+function links() {
+    const res = [];
+    const add = (a) => {
+        let u;
+        try {
+            u = new URL(a.href, document.baseURI);
+        } catch (e) {
+            return;
+        }
+        if (u.protocol !== 'http:' && u.protocol !== 'https:') {
+            return;
+        }
+        u.hash = '';
+        res.push({
+            url: u.href,
+            text: (a.innerText || a.textContent || a.getAttribute('alt') || '').trim(),
+            sameOrigin: u.origin === location.origin,
+        });
+    };
+    if (this.matches('a[href], area[href]')) {
+        add(this);
+    }
+    for (const a of this.querySelectorAll('a[href], area[href]')) {
+        add(a);
+    }
+    return res;
+}
@@ -0,0 +1,98 @@
+function markdown() {
+    const skip = new Set(['SCRIPT', 'STYLE', 'NOSCRIPT', 'TEMPLATE', 'HEAD', 'IFRAME', 'SVG', 'CANVAS']);
+    const escape = (s) => s.replace(/([\\`*_[\]])/g, '\\$1');
+    const inline = (n, pre) => {
+        if (n.nodeType === Node.TEXT_NODE) {
+            return pre ? n.nodeValue : escape(n.nodeValue.replace(/\s+/g, ' '));
+        }
+        if (n.nodeType !== Node.ELEMENT_NODE || skip.has(n.nodeName.toUpperCase())) {
+            return '';
+        }
+        const style = window.getComputedStyle(n);
+        if (style.display === 'none' || style.visibility === 'hidden') {
+            return '';
+        }
+        const children = () => Array.from(n.childNodes).map((c) => inline(c, pre)).join('');
+        switch (n.nodeName) {
+            case 'BR':
+                return '  \n';
+            case 'STRONG':
+            case 'B': {
+                const s = children().trim();
+                return s ? `**${s}**` : '';
+            }
+            case 'EM':
+            case 'I': {
+                const s = children().trim();
+                return s ? `_${s}_` : '';
+            }
+            case 'CODE':
+                return pre ? n.textContent : '`' + n.textContent + '`';
+            case 'A': {
+                const s = children().trim();
+                const href = n.getAttribute('href');
+                return href && s ? `[${s}](${n.href})` : s;
+            }
+            case 'IMG': {
+                const src = n.getAttribute('src');
+                return src ? `![${escape(n.getAttribute('alt') || '')}](${n.src})` : '';
+            }
+        }
+        return block(n, pre);
+    };
+    const block = (n, pre) => {
+        const children = () => Array.from(n.childNodes).map((c) => inline(c, pre)).join('').trim();
+        const lines = (s, prefix) => s.split('\n').map((l) => prefix + l).join('\n');
+        switch (n.nodeName) {
+            case 'H1':
+            case 'H2':
+            case 'H3':
+            case 'H4':
+            case 'H5':
+            case 'H6':
+                return `\n\n${'#'.repeat(Number(n.nodeName[1]))} ${children()}\n\n`;
+            case 'P':
+            case 'DIV':
+            case 'SECTION':
+            case 'ARTICLE':
+            case 'MAIN':
+            case 'HEADER':
+            case 'FOOTER':
+            case 'NAV':
+            case 'ASIDE':
+            case 'FIGURE':
+            case 'FORM':
+                return `\n\n${children()}\n\n`;
+            case 'HR':
+                return '\n\n---\n\n';
+            case 'PRE':
+                return '\n\n```\n' + n.textContent.replace(/\n$/, '') + '\n```\n\n';
+            case 'BLOCKQUOTE':
+                return '\n\n' + lines(children().replace(/\n{3,}/g, '\n\n'), '> ') + '\n\n';
+            case 'UL':
+            case 'OL': {
+                let i = 0;
+                const items = Array.from(n.children).filter((c) => c.nodeName === 'LI').map((li) => {
+                    i++;
+                    const marker = n.nodeName === 'OL' ? `${i}. ` : '- ';
+                    const s = Array.from(li.childNodes).map((c) => inline(c, pre)).join('').trim().replace(/\n{2,}/g, '\n');
+                    return marker + s.split('\n').join('\n' + ' '.repeat(marker.length));
+                });
+                return '\n\n' + items.join('\n') + '\n\n';
+            }
+            case 'TABLE': {
+                const rows = Array.from(n.querySelectorAll('tr')).map((tr) =>
+                    Array.from(tr.children).map((c) => Array.from(c.childNodes).map((x) => inline(x, pre)).join('').trim().replace(/\n+/g, ' ').replace(/\|/g, '\\|')));
+                if (rows.length === 0) {
+                    return '';
+                }
+                const width = Math.max(...rows.map((r) => r.length));
+                const row = (r) => '| ' + Array.from({ length: width }, (_, i) => r[i] || '').join(' | ') + ' |';
+                const out = [row(rows[0]), row(Array(width).fill('---')), ...rows.slice(1).map(row)];
+                return '\n\n' + out.join('\n') + '\n\n';
+            }
+        }
+        return children();
+    };
+    return inline(this, false).replace(/^[ \t]+$/gm, '').replace(/\n{3,}/g, '\n\n').trim();
+}
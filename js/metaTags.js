@@ -0,0 +1,15 @@
+function metaTags() {
+    const tags = {};
+    for (const e of document.querySelectorAll('meta')) {
+        if (e.hasAttribute('charset')) {
+            tags['charset'] = e.getAttribute('charset');
+            continue;
+        }
+        const name = e.getAttribute('name') || e.getAttribute('property') ||
+            e.getAttribute('http-equiv') || e.getAttribute('itemprop');
+        if (name && e.hasAttribute('content') && !(name in tags)) {
+            tags[name] = e.getAttribute('content');
+        }
+    }
+    return tags;
+}
@@ -0,0 +1,12 @@
+function overrideLanguages(languages) {
+    const frozen = Object.freeze(languages.slice());
+    Object.defineProperty(Navigator.prototype, 'languages', {
+        get: () => frozen,
+        configurable: true,
+    });
+    Object.defineProperty(Navigator.prototype, 'language', {
+        get: () => frozen[0],
+        configurable: true,
+    });
+    window.dispatchEvent(new Event('languagechange'));
+}
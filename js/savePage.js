@@ -0,0 +1,92 @@
+function savePage(resources, rewriteLinks, styles) {
+    const base = document.baseURI;
+    const resolve = (u) => {
+        try {
+            return new URL(u, base).href;
+        } catch (e) {
+            return null;
+        }
+    };
+    // local returns the local path of the resource u, or its absolute URL
+    // with rewriteLinks, or null to leave it as is.
+    const local = (u) => {
+        const trimmed = u.trim();
+        if (trimmed === '' || trimmed.startsWith('#') || /^(data|javascript|mailto|blob|about):/i.test(trimmed)) {
+            return null;
+        }
+        const abs = resolve(trimmed);
+        if (abs === null) {
+            return null;
+        }
+        if (abs in resources) {
+            return resources[abs];
+        }
+        const noHash = abs.replace(/#.*$/, '');
+        if (noHash in resources) {
+            return resources[noHash] + abs.slice(noHash.length);
+        }
+        return rewriteLinks ? abs : null;
+    };
+    const rewriteCSS = (css) => css.replace(/url\(\s*(['"]?)([^'")]+)\1\s*\)/g, (m, q, u) => {
+        const l = local(u);
+        return l === null ? m : `url("${l}")`;
+    });
+
+    const root = document.documentElement.cloneNode(true);
+    if (rewriteLinks) {
+        for (const b of root.querySelectorAll('base')) {
+            b.remove();
+        }
+    }
+    for (const el of root.querySelectorAll('[src], [href], [poster], object[data]')) {
+        for (const attr of ['src', 'href', 'poster', 'data']) {
+            const v = el.getAttribute(attr);
+            if (v === null || (attr === 'data' && el.localName !== 'object')) {
+                continue;
+            }
+            const l = local(v);
+            if (l !== null) {
+                el.setAttribute(attr, l);
+            }
+        }
+    }
+    for (const el of root.querySelectorAll('[srcset]')) {
+        el.setAttribute('srcset', el.getAttribute('srcset').split(',').map((c) => {
+            const [u, ...desc] = c.trim().split(/\s+/);
+            const l = local(u);
+            return [l === null ? u : l, ...desc].join(' ');
+        }).join(', '));
+    }
+    for (const el of root.querySelectorAll('[style]')) {
+        el.setAttribute('style', rewriteCSS(el.getAttribute('style')));
+    }
+    for (const el of root.querySelectorAll('style')) {
+        el.textContent = rewriteCSS(el.textContent);
+    }
+    const orig = Array.from(document.querySelectorAll('link'));
+    Array.from(root.querySelectorAll('link')).forEach((el, i) => {
+        const href = orig[i] && orig[i].href;
+        if (!/(^|\s)stylesheet(\s|$)/i.test(el.rel) || !(href in styles)) {
+            return;
+        }
+        const style = document.createElement('style');
+        if (el.media) {
+            style.media = el.media;
+        }
+        style.textContent = styles[href];
+        el.replaceWith(style);
+    });
+    if (document.characterSet !== 'UTF-8') {
+        for (const m of root.querySelectorAll('meta[charset], meta[http-equiv="content-type" i]')) {
+            m.remove();
+        }
+        const head = root.querySelector('head');
+        if (head) {
+            const m = document.createElement('meta');
+            m.setAttribute('charset', 'utf-8');
+            head.prepend(m);
+        }
+    }
+    const doctype = document.doctype ? new XMLSerializer().serializeToString(document.doctype) + '\n' : '';
+    return doctype + root.outerHTML;
+}
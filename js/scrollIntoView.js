@@ -0,0 +1,15 @@
+function scrollIntoView(block, inline, margin) {
+    // scroll-margin is taken into account by scrollIntoView, which also
+    // scrolls all the scroll containers of the element.
+    const hadStyle = this.hasAttribute('style');
+    const prev = this.style.scrollMargin;
+    this.style.scrollMargin = margin + 'px';
+    this.scrollIntoView({block, inline, behavior: 'instant'});
+    this.style.scrollMargin = prev;
+    if (!hadStyle) {
+        // Read the attribute first, so that the lazily updated inline style
+        // doesn't add it back once removed.
+        this.getAttribute('style');
+        this.removeAttribute('style');
+    }
+}
@@ -0,0 +1,23 @@
+function spoofTime(base, tick) {
+    const OrigDate = Date;
+    const start = OrigDate.now();
+    const now = () => (tick ? base + (OrigDate.now() - start) : base);
+    window.Date = new Proxy(OrigDate, {
+        construct(target, args, newTarget) {
+            return Reflect.construct(target, args.length ? args : [now()], newTarget);
+        },
+        apply() {
+            return new OrigDate(now()).toString();
+        },
+        get(target, prop, receiver) {
+            if (prop === 'now') {
+                return now;
+            }
+            return Reflect.get(target, prop, receiver);
+        },
+    });
+    if (!tick) {
+        const frozen = performance.now();
+        performance.now = () => frozen;
+    }
+}
@@ -0,0 +1,15 @@
+function stringChunk(start, size) {
+    let end = Math.min(start + size, this.length);
+    if (end < this.length) {
+        // Don't split a surrogate pair across two chunks.
+        const c = this.charCodeAt(end - 1);
+        if (c >= 0xd800 && c <= 0xdbff) {
+            end--;
+        }
+    }
+    return {
+        chunk: this.slice(start, end),
+        end: end,
+        length: this.length,
+    };
+}
@@ -0,0 +1,22 @@
+function uploadFiles(files) {
+    const dataTransfer = new DataTransfer();
+    for (const { name, type, data } of files) {
+        const bytes = Uint8Array.from(atob(data), (c) => c.charCodeAt(0));
+        dataTransfer.items.add(new File([bytes], name, { type }));
+    }
+    if (this.tagName === "INPUT" && this.type === "file") {
+        this.files = dataTransfer.files;
+        this.dispatchEvent(new Event("input", { bubbles: true, composed: true }));
+        this.dispatchEvent(new Event("change", { bubbles: true }));
+        return this.files.length;
+    }
+    for (const type of ["dragenter", "dragover", "drop"]) {
+        this.dispatchEvent(new DragEvent(type, {
+            bubbles: true,
+            cancelable: true,
+            composed: true,
+            dataTransfer,
+        }));
+    }
+    return dataTransfer.files.length;
+}
@@ -0,0 +1,16 @@
+function waitForEvent(type, timeout) {
+    return new Promise((resolve) => {
+        let timer;
+        const listener = () => {
+            clearTimeout(timer);
+            resolve(true);
+        };
+        this.addEventListener(type, listener, { once: true, capture: true });
+        if (timeout > 0) {
+            timer = setTimeout(() => {
+                this.removeEventListener(type, listener, { capture: true });
+                resolve(false);
+            }, timeout);
+        }
+    });
+}
@@ -0,0 +1,17 @@
+function waitForMutation(options, timeout) {
+    return new Promise((resolve) => {
+        let timer;
+        const observer = new MutationObserver((records) => {
+            observer.disconnect();
+            clearTimeout(timer);
+            resolve(records.length);
+        });
+        observer.observe(this, options);
+        if (timeout > 0) {
+            timer = setTimeout(() => {
+                observer.disconnect();
+                resolve(0);
+            }, timeout);
+        }
+    });
+}
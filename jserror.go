@@ -0,0 +1,110 @@
+package chromedp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// JSError is the error of a script which threw an exception, or of a promise
+// which was rejected, as returned by Evaluate and CallFunctionOn.
+//
+// It wraps the low-level *runtime.ExceptionDetails, so that errors.As can
+// still be used to retrieve them.
+type JSError struct {
+	// Name is the class name of the thrown Error object, such as
+	// "TypeError", or empty if the thrown value is not an object.
+	Name string
+	// Message is the message of the thrown Error object, or the thrown value
+	// as formatted by FormatRemoteObject otherwise.
+	Message string
+	// Stack is the stack trace of the exception, the innermost call first.
+	// It's empty when the browser didn't report it, such as for some
+	// rejected promises.
+	Stack []*runtime.CallFrame
+	// Properties are the own properties of the thrown object, such as the
+	// message, stack, cause or code of an Error, as decoded by
+	// DecodeRemoteObject, or formatted by FormatRemoteObject when they
+	// can't be decoded.
+	Properties map[string]interface{}
+	// Details are the low-level exception details.
+	Details *runtime.ExceptionDetails
+}
+
+// Error satisfies the error interface.
+func (e *JSError) Error() string {
+	return e.Details.Error()
+}
+
+// Unwrap returns the exception details.
+func (e *JSError) Unwrap() error {
+	return e.Details
+}
+
+// newJSError maps the exception details exp to a JSError, fetching the
+// properties of the thrown object, which is then released.
+func newJSError(ctx context.Context, exp *runtime.ExceptionDetails) *JSError {
+	e := &JSError{Message: exp.Text, Details: exp}
+	if exp.StackTrace != nil {
+		e.Stack = exp.StackTrace.CallFrames
+	}
+	obj := exp.Exception
+	if obj == nil {
+		return e
+	}
+	e.Message = FormatRemoteObject(obj)
+	if obj.ObjectID == "" {
+		return e
+	}
+	defer func() {
+		_ = runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+	}()
+	if obj.Type == runtime.TypeObject {
+		e.Name = obj.ClassName
+	}
+	props, err := objectProperties(ctx, obj.ObjectID)
+	if err != nil {
+		return e
+	}
+	e.Properties = props
+	if obj.Subtype == runtime.SubtypeError {
+		if msg, ok := e.Properties["message"].(string); ok {
+			e.Message = msg
+		} else {
+			// the description starts with the name and message of the
+			// error, followed by its stack.
+			e.Message, _, _ = strings.Cut(obj.Description, "\n")
+		}
+	}
+	return e
+}
+
+// objectProperties returns the own properties of the remote object id, as
+// decoded by DecodeRemoteObject, or formatted by FormatRemoteObject when they
+// can't be decoded. The accessor properties are left out.
+func objectProperties(ctx context.Context, id runtime.RemoteObjectID) (map[string]interface{}, error) {
+	props, _, _, exp, err := runtime.GetProperties(id).
+		WithOwnProperties(true).
+		WithGeneratePreview(true).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exp != nil {
+		return nil, exp
+	}
+	res := make(map[string]interface{}, len(props))
+	for _, prop := range props {
+		if prop.Value == nil {
+			// accessor properties.
+			continue
+		}
+		var v interface{}
+		if err := DecodeRemoteObject(prop.Value, &v); err != nil {
+			v = FormatRemoteObject(prop.Value)
+		}
+		res[prop.Name] = v
+	}
+	return res, nil
+}
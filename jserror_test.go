@@ -0,0 +1,94 @@
+package chromedp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestJSError(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	awaitPromise := func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}
+	tests := []struct {
+		name       string
+		expression string
+		opts       []EvaluateOption
+		wantName   string
+		wantMsg    string
+		wantProp   string
+		wantValue  interface{}
+		wantStack  bool
+	}{
+		{
+			name:       "Error",
+			expression: `(function f() { const e = new TypeError("boom"); e.code = 42; throw e })()`,
+			wantName:   "TypeError",
+			wantMsg:    "boom",
+			wantProp:   "code",
+			wantValue:  42.0,
+			wantStack:  true,
+		},
+		{
+			name:       "Rejected",
+			expression: `Promise.reject(new RangeError("bad", {cause: "why"}))`,
+			opts:       []EvaluateOption{awaitPromise},
+			wantName:   "RangeError",
+			wantMsg:    "bad",
+			wantProp:   "cause",
+			wantValue:  "why",
+		},
+		{
+			name:       "Value",
+			expression: `(function() { throw "boom" })()`,
+			wantMsg:    "boom",
+		},
+		{
+			name:       "Object",
+			expression: `(function() { throw {code: 1} })()`,
+			wantName:   "Object",
+			wantMsg:    "{code: 1}",
+			wantProp:   "code",
+			wantValue:  1.0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var res interface{}
+			err := Run(ctx, Evaluate(test.expression, &res, test.opts...))
+			var jsErr *JSError
+			if !errors.As(err, &jsErr) {
+				t.Fatalf("want a JSError, got %v", err)
+			}
+			var exp *runtime.ExceptionDetails
+			if !errors.As(err, &exp) {
+				t.Errorf("want the exception details to be wrapped, got %v", err)
+			}
+			if jsErr.Name != test.wantName || jsErr.Message != test.wantMsg {
+				t.Errorf("want %q %q, got %q %q", test.wantName, test.wantMsg, jsErr.Name, jsErr.Message)
+			}
+			if test.wantProp != "" && jsErr.Properties[test.wantProp] != test.wantValue {
+				t.Errorf("want property %s %v, got %v", test.wantProp, test.wantValue, jsErr.Properties)
+			}
+			if test.wantStack && (len(jsErr.Stack) == 0 || jsErr.Stack[0].FunctionName != "f") {
+				t.Errorf("want the stack of the exception, got %v", jsErr.Stack)
+			}
+		})
+	}
+
+	var window *runtime.RemoteObject
+	if err := Run(ctx, Evaluate(`window`, &window)); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(ctx, CallFunctionOn(`function() { throw new Error("call") }`, nil, withObjectID(window.ObjectID)))
+	var jsErr *JSError
+	if !errors.As(err, &jsErr) || jsErr.Message != "call" {
+		t.Errorf("want a JSError from CallFunctionOn, got %v", err)
+	}
+}
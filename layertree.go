@@ -0,0 +1,128 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/layertree"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// LayerTree is an action that retrieves the compositing layers of the page.
+//
+// The layers are listed in paint order, the root layer being first. A layer
+// owned by an element (for example, one with "will-change: transform") has
+// the BackendNodeID of this element set.
+//
+// The LayerTree domain is enabled during the action only, as keeping it
+// enabled slows down the rendering of the page.
+func LayerTree(layers *[]*layertree.Layer) Action {
+	if layers == nil {
+		panic("layers cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		defer layertree.Disable().Do(ctx)
+		var err error
+		*layers, err = enableLayerTree(ctx)
+		return err
+	})
+}
+
+// enableLayerTree enables the LayerTree domain, and waits for the layers of
+// the page to be sent. The domain should be disabled by the caller.
+func enableLayerTree(ctx context.Context) ([]*layertree.Layer, error) {
+	ch := make(chan []*layertree.Layer, 1)
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ListenTarget(lctx, func(ev interface{}) {
+		// the event is sent with no layers when the tree is
+		// invalidated, before the new tree is known.
+		if ev, ok := ev.(*layertree.EventLayerTreeDidChange); ok && ev.Layers != nil {
+			select {
+			case ch <- ev.Layers:
+			default:
+			}
+		}
+	})
+
+	if err := layertree.Enable().Do(ctx); err != nil {
+		return nil, err
+	}
+	// the layers are only sent once a frame is painted, so request one.
+	if err := waitFrame().Do(ctx); err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case layers := <-ch:
+		return layers, nil
+	}
+}
+
+// PaintProfile is an element query action that profiles the painting of the
+// compositing layer of the first element node matching the selector.
+//
+// The layer is the one owned by the element, or else by its closest ancestor
+// owning a layer, or else the root layer of the page.
+//
+// The snapshot of the layer is replayed once, and timings is set to a single
+// profile holding the duration in seconds of each paint command of the
+// snapshot.
+func PaintProfile(sel interface{}, timings *[]layertree.PaintProfile, opts ...QueryOption) QueryAction {
+	if timings == nil {
+		panic("timings cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		// the layers are released when the domain is disabled, so keep
+		// it enabled until the snapshot is profiled.
+		defer layertree.Disable().Do(ctx)
+		layers, err := enableLayerTree(ctx)
+		if err != nil {
+			return err
+		}
+
+		layer := layerOf(nodes[0], layers)
+		if layer == nil {
+			return fmt.Errorf("selector %q matched a node with no layer", sel)
+		}
+
+		snapshotID, err := layertree.MakeSnapshot(layer.LayerID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		defer layertree.ReleaseSnapshot(snapshotID).Do(ctx)
+
+		*timings, err = layertree.ProfileSnapshot(snapshotID).Do(ctx)
+		return err
+	}, append(opts, NodeVisible)...)
+}
+
+// layerOf returns the layer painting n: the layer owned by n or its closest
+// ancestor, or else the first layer drawing content.
+func layerOf(n *cdp.Node, layers []*layertree.Layer) *layertree.Layer {
+	byNode := make(map[cdp.BackendNodeID]*layertree.Layer)
+	for _, l := range layers {
+		if l.BackendNodeID != 0 && l.DrawsContent {
+			byNode[l.BackendNodeID] = l
+		}
+	}
+	for ; n != nil; n = n.Parent {
+		if l, ok := byNode[n.BackendNodeID]; ok {
+			return l
+		}
+	}
+	for _, l := range layers {
+		if l.DrawsContent {
+			return l
+		}
+	}
+	return nil
+}
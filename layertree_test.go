@@ -0,0 +1,58 @@
+package chromedp
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/layertree"
+)
+
+func TestLayerTree(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "layers.html")
+	defer cancel()
+
+	var nodes []*cdp.Node
+	var layers []*layertree.Layer
+	if err := Run(ctx,
+		Nodes("#layer", &nodes, ByQuery),
+		LayerTree(&layers),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) < 2 {
+		t.Fatalf("want at least 2 layers, got %d", len(layers))
+	}
+	found := false
+	for _, l := range layers {
+		if l.BackendNodeID == nodes[0].BackendNodeID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no layer owned by #layer")
+	}
+}
+
+func TestPaintProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{"#layer", "#inner", "#static"}
+	for _, sel := range tests {
+		sel := sel
+		t.Run(sel, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := testAllocate(t, "layers.html")
+			defer cancel()
+
+			var timings []layertree.PaintProfile
+			if err := Run(ctx, PaintProfile(sel, &timings, ByQuery)); err != nil {
+				t.Fatal(err)
+			}
+			if len(timings) != 1 || len(timings[0]) == 0 {
+				t.Errorf("want a single non-empty profile, got %v", timings)
+			}
+		})
+	}
+}
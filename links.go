@@ -0,0 +1,160 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/io"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// LinkResult is the result of the check of a link by CheckLinks.
+type LinkResult struct {
+	// URL is the absolute URL of the link, without its fragment.
+	URL string
+	// Text is the text of the first link to URL.
+	Text string
+	// SameOrigin is whether URL is of the origin of the page, so that it
+	// was fetched by the page, rather than by the network stack of the
+	// browser.
+	SameOrigin bool
+	// Status is the status code of the response, after the redirects, or 0
+	// if the link couldn't be loaded.
+	Status int64
+	// Error is the error of the load of the link, such as
+	// "net::ERR_NAME_NOT_RESOLVED", or of its check, such as when the
+	// browser rejected the URL, or empty if it got a response.
+	Error string
+}
+
+// Broken reports whether the link couldn't be loaded, or its status code is
+// an error one.
+func (r LinkResult) Broken() bool {
+	return r.Error != "" || r.Status >= 400
+}
+
+// CheckLinks checks the http and https links, such as of the a elements, in
+// the element nodes matching the selector scope, and its subtrees, and returns
+// their results in the order of the document. The links to the same URL,
+// regardless of their fragment, are checked once.
+//
+// The links of the origin of the page are fetched by the page, with its
+// cookies; the others are loaded by the network stack of the browser, with the
+// cookies of the browser, as the page can't read the responses of the other
+// origins. At most concurrency links are checked at once. The links which
+// couldn't be checked are reported as broken, with their error; an error is
+// only returned when the links couldn't be checked at all, such as when ctx is
+// done. Example:
+//
+//	res, err := chromedp.CheckLinks(ctx, "main", 4, chromedp.ByQuery)
+//	if err != nil {
+//		// handle error
+//	}
+//	for _, r := range res {
+//		if r.Broken() {
+//			log.Printf("broken link %s: %d %s", r.URL, r.Status, r.Error)
+//		}
+//	}
+func CheckLinks(ctx context.Context, scope interface{}, concurrency int, opts ...QueryOption) ([]LinkResult, error) {
+	var res []LinkResult
+	if err := Run(ctx, checkLinksAction(scope, concurrency, &res, opts...)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func checkLinksAction(scope interface{}, concurrency int, res *[]LinkResult, opts ...QueryOption) Action {
+	return QueryAfter(scope, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", scope)
+		}
+		var links []LinkResult
+		seen := make(map[string]bool)
+		for _, n := range nodes {
+			var nodeLinks []LinkResult
+			if err := callFunctionOnNode(ctx, n, linksJS, &nodeLinks); err != nil {
+				return err
+			}
+			for _, l := range nodeLinks {
+				if !seen[l.URL] {
+					seen[l.URL] = true
+					links = append(links, l)
+				}
+			}
+		}
+
+		var frameID cdp.FrameID
+		if c := FromContext(ctx); c != nil && c.Target != nil {
+			c.Target.frameMu.RLock()
+			frameID = c.Target.cur
+			c.Target.frameMu.RUnlock()
+		}
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range links {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				// a link which couldn't be checked is reported as broken,
+				// without failing the check of the others.
+				if err := checkLink(ctx, frameID, &links[i]); err != nil {
+					links[i].Error = err.Error()
+				}
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		*res = links
+		return nil
+	}, opts...)
+}
+
+// checkLink checks the link l of the frame frameID, and sets its result.
+func checkLink(ctx context.Context, frameID cdp.FrameID, l *LinkResult) error {
+	if l.SameOrigin {
+		arg, err := json.Marshal(l.URL)
+		if err != nil {
+			return err
+		}
+		var r struct {
+			Status int64  `json:"status"`
+			Error  string `json:"error"`
+		}
+		if err := Evaluate(fmt.Sprintf("(%s)(%s)", checkLinkJS, arg), &r, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}).Do(ctx); err != nil {
+			return err
+		}
+		l.Status, l.Error = r.Status, r.Error
+		return nil
+	}
+
+	r, err := network.LoadNetworkResource(l.URL, &network.LoadNetworkResourceOptions{
+		IncludeCredentials: true,
+	}).WithFrameID(frameID).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if r.Stream != "" {
+		// only the status code is needed.
+		_ = io.Close(r.Stream).Do(ctx)
+	}
+	l.Status = int64(r.HTTPStatusCode)
+	if !r.Success && r.NetErrorName != "" {
+		l.Error = r.NetErrorName
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package chromedp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCheckLinks(t *testing.T) {
+	t.Parallel()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.Error(w, "gone", http.StatusGone)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer other.Close()
+
+	var mu sync.Mutex
+	var cookies []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		fmt.Fprintf(w, `<html><body>
+			<nav><a href="/missing">missing</a></nav>
+			<main>
+				<a href="/ok#top">ok</a>
+				<a href="/ok">ok again</a>
+				<a href="/redirect">redirect</a>
+				<a href="/nohead">no head</a>
+				<a href="mailto:a@example.com">mail</a>
+				<a href="%[1]s/">other</a>
+				<a href="%[1]s/gone">other gone</a>
+				<a href="http://127.0.0.1:1/">refused</a>
+			</main>
+		</body></html>`, other.URL)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err == nil {
+			mu.Lock()
+			cookies = append(cookies, c.Value)
+			mu.Unlock()
+		}
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/nohead", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := CheckLinks(ctx, "main", 3, ByQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type result struct {
+		url    string
+		same   bool
+		status int64
+		broken bool
+	}
+	var got []result
+	for _, r := range res {
+		got = append(got, result{r.URL, r.SameOrigin, r.Status, r.Broken()})
+	}
+	want := []result{
+		{s.URL + "/ok", true, 200, false},
+		{s.URL + "/redirect", true, 200, false},
+		{s.URL + "/nohead", true, 200, false},
+		{other.URL + "/", false, 200, false},
+		{other.URL + "/gone", false, 410, true},
+		{"http://127.0.0.1:1/", false, 0, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if res[0].Text != "ok" {
+		t.Errorf("got the text %q, want %q", res[0].Text, "ok")
+	}
+	if res[5].Error == "" {
+		t.Errorf("want an error for a refused connection")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(cookies) == 0 {
+		t.Errorf("want the cookies of the page to be sent")
+	}
+}
@@ -0,0 +1,118 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+)
+
+// LocatorChain is a chain of CSS selectors and indexes, as built by Locator,
+// which can be used as the selector of any element query action.
+//
+// It's resolved each time the query is run, so that it's retried until it
+// matches the wanted nodes, as any other selector. When it's used as the
+// selector, the By* options don't apply, but FromNode does.
+type LocatorChain struct {
+	steps []locatorStep
+}
+
+// locatorStep is a step of a LocatorChain, which either queries all the
+// elements matching css within the nodes from the previous step, or picks the
+// nth of them, if css is empty.
+type locatorStep struct {
+	css string
+	nth int
+}
+
+// Locator returns a LocatorChain matching the elements by the CSS selector
+// css, similar to calling document.querySelectorAll() in the browser. The
+// chain can then be narrowed down by its methods, each returning a new chain.
+//
+// Example:
+//
+//	err := chromedp.Run(ctx,
+//		chromedp.Click(chromedp.Locator("table").Locator("tr").Nth(3).Locator("button")),
+//	)
+func Locator(css string) *LocatorChain {
+	return new(LocatorChain).Locator(css)
+}
+
+// Locator returns a new chain matching the elements by the CSS selector css
+// within the elements matched by l.
+func (l *LocatorChain) Locator(css string) *LocatorChain {
+	return l.with(locatorStep{css: css})
+}
+
+// Nth returns a new chain matching only the element at index i of those
+// matched by l, starting at 0. A negative index counts from the last element,
+// such as -1 for the last one. It matches no element if there are not enough
+// of them.
+func (l *LocatorChain) Nth(i int) *LocatorChain {
+	return l.with(locatorStep{nth: i})
+}
+
+// with returns a copy of l with the step added, so that the chains built from
+// the same one don't share their steps.
+func (l *LocatorChain) with(step locatorStep) *LocatorChain {
+	steps := make([]locatorStep, len(l.steps), len(l.steps)+1)
+	copy(steps, l.steps)
+	return &LocatorChain{steps: append(steps, step)}
+}
+
+// String satisfies the fmt.Stringer interface, formatting the chain as the Go
+// code building it.
+func (l *LocatorChain) String() string {
+	var sb strings.Builder
+	for i, step := range l.steps {
+		switch {
+		case step.css == "":
+			fmt.Fprintf(&sb, ".Nth(%d)", step.nth)
+		case i == 0:
+			fmt.Fprintf(&sb, "Locator(%q)", step.css)
+		default:
+			fmt.Fprintf(&sb, ".Locator(%q)", step.css)
+		}
+	}
+	return sb.String()
+}
+
+// query returns the IDs of the nodes matched by the chain under n, in the
+// document order of each step.
+func (l *LocatorChain) query(ctx context.Context, n *cdp.Node) ([]cdp.NodeID, error) {
+	ids := []cdp.NodeID{n.NodeID}
+	for _, step := range l.steps {
+		if step.css == "" {
+			i := step.nth
+			if i < 0 {
+				i += len(ids)
+			}
+			if i < 0 || i >= len(ids) {
+				return []cdp.NodeID{}, nil
+			}
+			ids = []cdp.NodeID{ids[i]}
+			continue
+		}
+		var next []cdp.NodeID
+		seen := make(map[cdp.NodeID]bool)
+		for _, id := range ids {
+			matched, err := dom.QuerySelectorAll(id, step.css).Do(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range matched {
+				if !seen[id] {
+					seen[id] = true
+					next = append(next, id)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return []cdp.NodeID{}, nil
+		}
+		ids = next
+	}
+	return ids, nil
+}
@@ -0,0 +1,84 @@
+package chromedp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+)
+
+func TestLocator(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "table.html")
+	defer cancel()
+
+	rows := Locator("tbody").Locator("tr")
+	tests := []struct {
+		name string
+		l    *LocatorChain
+		want string
+	}{
+		{"First", Locator("td"), "1.1"},
+		{"Nth", rows.Nth(1).Locator("td").Nth(2), "2.3"},
+		{"Last", rows.Nth(-1).Locator("td").Nth(-1), "3.3"},
+		{"Nested", Locator("table").Locator("#footer td"), "3.1"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			if err := Run(ctx, Text(test.l, &got, NodeVisible)); err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	var nodes []*cdp.Node
+	if err := Run(ctx, Nodes(rows.Locator("td"), &nodes)); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 9 {
+		t.Errorf("want 9 cells, got %d", len(nodes))
+	}
+	if err := Run(ctx, Nodes(rows.Nth(5), &nodes, AtLeast(0))); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Errorf("want no row out of range, got %d", len(nodes))
+	}
+
+	if want, got := `Locator("tbody").Locator("tr").Nth(1)`, rows.Nth(1).String(); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+	// the chains built from rows don't share their steps.
+	if want, got := `Locator("tbody").Locator("tr")`, rows.String(); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func TestLocatorRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "table.html")
+	defer cancel()
+
+	// the chain is resolved again until the row is added.
+	var got string
+	if err := Run(ctx,
+		Evaluate(`setTimeout(() => {
+			const row = document.createElement('tr');
+			row.innerHTML = '<td>4.1</td><td>4.2</td>';
+			document.querySelector('tbody').append(row);
+		}, 100)`, nil),
+		Text(Locator("tbody").Locator("tr").Nth(3).Locator("td").Nth(1), &got, NodeVisible, RetryInterval(10*time.Millisecond)),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "4.2"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,63 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// MutationOpts are the DOM mutations waited for by WaitForMutation, as the
+// options of MutationObserver.observe. At least one of Attributes,
+// AttributeFilter, ChildList and CharacterData must be set.
+type MutationOpts struct {
+	// Attributes waits for a change of the attributes of the node.
+	Attributes bool `json:"attributes,omitempty"`
+	// AttributeFilter only waits for a change of the attributes with these
+	// names. It implies Attributes.
+	AttributeFilter []string `json:"attributeFilter,omitempty"`
+	// ChildList waits for the addition or removal of the children of the
+	// node.
+	ChildList bool `json:"childList,omitempty"`
+	// CharacterData waits for a change of the data of the text nodes.
+	CharacterData bool `json:"characterData,omitempty"`
+	// Subtree extends the other options to the whole subtree of the node.
+	Subtree bool `json:"subtree,omitempty"`
+}
+
+// WaitForMutation is an element query action that waits for a DOM mutation of
+// the first element node matching the selector, as observed by a
+// MutationObserver set up with opts. It returns [ErrMutationTimeout] when
+// timeout is reached first; a timeout of 0 waits until ctx is done.
+//
+// The observer is set up once the node was queried, so that the mutations
+// caused by the previous actions could be missed. To wait for the effect of an
+// action, such as a click, either schedule it first, or run WaitForMutation
+// concurrently in another Run call.
+//
+// Example:
+//
+//	err := chromedp.Run(ctx,
+//		chromedp.Evaluate(`setTimeout(() => document.querySelector("#list").append("x"), 100)`, nil),
+//		chromedp.WaitForMutation(`#list`, chromedp.MutationOpts{ChildList: true}, 5*time.Second, chromedp.ByQuery),
+//	)
+func WaitForMutation(sel interface{}, opts MutationOpts, timeout time.Duration, queryOpts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return errors.New("expected at least one element")
+		}
+		if !opts.Attributes && opts.AttributeFilter == nil && !opts.ChildList && !opts.CharacterData {
+			return errors.New("no mutations to wait for")
+		}
+		var records int64
+		if err := awaitFunctionOnNode(ctx, nodes[0], waitForMutationJS, &records, opts, timeout.Milliseconds()); err != nil {
+			return err
+		}
+		if records == 0 {
+			return ErrMutationTimeout
+		}
+		return nil
+	}, queryOpts...)
+}
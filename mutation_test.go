@@ -0,0 +1,56 @@
+package chromedp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForMutation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		change string
+		opts   MutationOpts
+	}{
+		{"Attributes", `el.setAttribute("data-x", "1")`, MutationOpts{Attributes: true}},
+		{"AttributeFilter", `el.setAttribute("data-y", "1"); el.setAttribute("data-x", "1")`, MutationOpts{AttributeFilter: []string{"data-x"}}},
+		{"ChildList", `el.append(document.createElement("span"))`, MutationOpts{ChildList: true}},
+		{"Subtree", `el.querySelector("input").setAttribute("data-x", "1")`, MutationOpts{Attributes: true, Subtree: true}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := testAllocate(t, "form.html")
+			defer cancel()
+
+			start := time.Now()
+			if err := Run(ctx,
+				Evaluate(`const el = document.querySelector("#form"); setTimeout(() => { `+test.change+` }, 100)`, nil),
+				WaitForMutation(`#form`, test.opts, 10*time.Second, ByQuery),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if d := time.Since(start); d < 100*time.Millisecond {
+				t.Errorf("want the wait to last until the mutation, got %v", d)
+			}
+		})
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testAllocate(t, "form.html")
+		defer cancel()
+
+		// the mutation of another attribute is ignored.
+		err := Run(ctx,
+			Evaluate(`setTimeout(() => document.querySelector("#form").setAttribute("data-y", "1"), 10)`, nil),
+			WaitForMutation(`#form`, MutationOpts{AttributeFilter: []string{"data-x"}}, 300*time.Millisecond, ByQuery),
+		)
+		if !errors.Is(err, ErrMutationTimeout) {
+			t.Errorf("want error %v, got %v", ErrMutationTimeout, err)
+		}
+	})
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 )
 
@@ -110,3 +114,135 @@ func Title(title *string) Action {
 	}
 	return EvaluateAsDevTools(`document.title`, title)
 }
+
+// MetaTags is an action that retrieves the content of the document's meta
+// tags, keyed by their name, property, http-equiv or itemprop attribute
+// (such as "description" or "og:title"). The charset meta tag, if any, is
+// keyed by "charset".
+//
+// When multiple meta tags have the same key, the first one is used.
+func MetaTags(tags *map[string]string) Action {
+	if tags == nil {
+		panic("tags cannot be nil")
+	}
+	return EvaluateAsDevTools(fmt.Sprintf(`(%s)()`, metaTagsJS), tags)
+}
+
+// CanonicalURL is an action that retrieves the absolute URL of the document's
+// canonical link (i.e., <link rel="canonical">). It's set to an empty string
+// when the document has no canonical link.
+func CanonicalURL(urlstr *string) Action {
+	if urlstr == nil {
+		panic("urlstr cannot be nil")
+	}
+	return EvaluateAsDevTools(`document.querySelector('link[rel="canonical"]')?.href ?? ''`, urlstr)
+}
+
+// RedirectHop is a hop of the redirect chain of a navigation, as reported by
+// NavigateFollow.
+type RedirectHop struct {
+	// URL is the URL requested by the hop.
+	URL string
+	// Status is the status code of its response, such as 301 for a redirect,
+	// or 0 if the hop didn't get a response.
+	Status     int64
+	StatusText string
+	// Headers are the headers of its response, such as the Location header
+	// of a redirect.
+	Headers network.Headers
+}
+
+// NavigateFollow is an action like Navigate, which stores in hops the redirect
+// chain of the navigation, each redirect followed by the browser being a hop,
+// the last one being the final response of the page.
+//
+// It fails with ErrTooManyRedirects once the navigation is redirected more
+// than maxRedirects times, and with ErrRedirectTimeout when a hop doesn't get
+// its response within perHopTimeout, if it's more than 0; the navigation is
+// then stopped, and hops has the chain followed until then. Example:
+//
+//	var hops []*chromedp.RedirectHop
+//	err := chromedp.Run(ctx, chromedp.NavigateFollow("http://example.com", 10, 5*time.Second, &hops))
+//	for _, h := range hops {
+//		log.Printf("%d %s", h.Status, h.URL)
+//	}
+func NavigateFollow(urlstr string, maxRedirects int, perHopTimeout time.Duration, hops *[]*RedirectHop) NavigateAction {
+	if hops == nil {
+		panic("hops cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		c := FromContext(ctx)
+		if c == nil || c.Target == nil {
+			return ErrInvalidContext
+		}
+		c.Target.frameMu.RLock()
+		frameID := c.Target.cur
+		c.Target.frameMu.RUnlock()
+
+		nctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		var mu sync.Mutex
+		var chain []*RedirectHop
+		var loaderID cdp.LoaderID
+		timer := time.AfterFunc(perHopTimeout, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			hop := urlstr
+			if len(chain) > 0 {
+				hop = chain[len(chain)-1].URL
+			}
+			cancel(fmt.Errorf("%w: %s", ErrRedirectTimeout, hop))
+		})
+		if perHopTimeout <= 0 {
+			timer.Stop()
+		}
+		defer timer.Stop()
+
+		ListenTarget(nctx, func(ev interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch ev := ev.(type) {
+			case *network.EventRequestWillBeSent:
+				if ev.FrameID != frameID || ev.Type != network.ResourceTypeDocument {
+					return
+				}
+				if loaderID == "" {
+					loaderID = ev.LoaderID
+				}
+				if ev.LoaderID != loaderID {
+					return
+				}
+				if r := ev.RedirectResponse; r != nil && len(chain) > 0 {
+					last := chain[len(chain)-1]
+					last.Status, last.StatusText, last.Headers = r.Status, r.StatusText, r.Headers
+				}
+				chain = append(chain, &RedirectHop{URL: ev.Request.URL})
+				if len(chain) > maxRedirects+1 {
+					cancel(fmt.Errorf("%w: %d redirects", ErrTooManyRedirects, len(chain)-1))
+					return
+				}
+				if perHopTimeout > 0 {
+					timer.Reset(perHopTimeout)
+				}
+			case *network.EventResponseReceived:
+				if ev.LoaderID != loaderID || ev.Type != network.ResourceTypeDocument || len(chain) == 0 {
+					return
+				}
+				timer.Stop()
+				last := chain[len(chain)-1]
+				last.Status, last.StatusText, last.Headers = ev.Response.Status, ev.Response.StatusText, ev.Response.Headers
+			}
+		})
+
+		err := Navigate(urlstr).Do(nctx)
+		mu.Lock()
+		*hops = chain
+		mu.Unlock()
+		if cause := context.Cause(nctx); cause != nil && ctx.Err() == nil {
+			_ = page.StopLoading().Do(ctx)
+			return cause
+		}
+		return err
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -239,6 +240,53 @@ func TestTitle(t *testing.T) {
 	}
 }
 
+func TestMetaTags(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "meta.html")
+	defer cancel()
+
+	var tags map[string]string
+	if err := Run(ctx, MetaTags(&tags)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"charset":          "utf-8",
+		"description":      "this is a description",
+		"og:title":         "og title",
+		"refresh-disabled": "30",
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Fatalf("want: %v, got: %v", want, tags)
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "meta.html")
+	defer cancel()
+
+	var urlstr string
+	if err := Run(ctx, CanonicalURL(&urlstr)); err != nil {
+		t.Fatal(err)
+	}
+	if want := testdataDir + "/canonical.html"; urlstr != want {
+		t.Fatalf("want: %q, got: %q", want, urlstr)
+	}
+
+	if err := Run(ctx,
+		Navigate(testdataDir+"/form.html"),
+		CanonicalURL(&urlstr),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if urlstr != "" {
+		t.Fatalf("want an empty canonical URL, got: %q", urlstr)
+	}
+}
+
 func TestQueryIframe(t *testing.T) {
 	t.Parallel()
 
@@ -423,3 +471,81 @@ func TestNavigateCancelled(t *testing.T) {
 		t.Fatalf("expected error to be %q, got: %v", context.Canceled, err)
 	}
 }
+
+func TestNavigateFollow(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Hop", "b")
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>c</body></html>")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	mux.HandleFunc("/to-slow", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/slow", http.StatusFound)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var hops []*RedirectHop
+	if err := Run(ctx, NavigateFollow(s.URL+"/a", 10, 5*time.Second, &hops)); err != nil {
+		t.Fatal(err)
+	}
+	type hop struct {
+		url    string
+		status int64
+	}
+	var got []hop
+	for _, h := range hops {
+		got = append(got, hop{h.URL, h.Status})
+	}
+	want := []hop{{s.URL + "/a", 302}, {s.URL + "/b", 301}, {s.URL + "/c", 200}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got the chain %v, want %v", got, want)
+	}
+	if h := hops[1].Headers; h["X-Hop"] != "b" || h["Location"] != "/c" {
+		t.Errorf("got the headers %v", h)
+	}
+
+	err := Run(ctx, NavigateFollow(s.URL+"/a", 1, 0, &hops))
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("want ErrTooManyRedirects, got %v", err)
+	}
+	if len(hops) != 3 || hops[1].Status != 301 {
+		t.Errorf("got %d hops, want 3", len(hops))
+	}
+
+	start := time.Now()
+	err = Run(ctx, NavigateFollow(s.URL+"/to-slow", 10, 300*time.Millisecond, &hops))
+	if !errors.Is(err, ErrRedirectTimeout) {
+		t.Fatalf("want ErrRedirectTimeout, got %v", err)
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("want the navigation to time out quickly, took %v", d)
+	}
+	if len(hops) != 2 || hops[1].URL != s.URL+"/slow" || hops[1].Status != 0 {
+		t.Errorf("got the hops %+v", hops)
+	}
+
+	// the tab can still navigate.
+	if err := Run(ctx, NavigateFollow(s.URL+"/c", 0, 0, &hops)); err != nil {
+		t.Fatal(err)
+	}
+	if len(hops) != 1 || hops[0].Status != 200 {
+		t.Errorf("got the hops %+v", hops)
+	}
+}
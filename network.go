@@ -0,0 +1,448 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// CacheSource is where the response of a NetworkRequest came from.
+type CacheSource string
+
+// CacheSource values.
+const (
+	CacheNetwork       CacheSource = "network"
+	CacheMemory        CacheSource = "memory"
+	CacheDisk          CacheSource = "disk"
+	CacheServiceWorker CacheSource = "service-worker"
+	CachePrefetch      CacheSource = "prefetch"
+)
+
+// NetworkRequest is a request made by a page, as recorded by RecordNetwork,
+// with the connection it went over, which is useful to debug the CDNs and
+// the HTTP/2 connection reuse.
+//
+// Each redirect is a separate NetworkRequest, with the same RequestID.
+type NetworkRequest struct {
+	RequestID network.RequestID    `json:"requestId"`
+	FrameID   cdp.FrameID          `json:"frameId,omitempty"`
+	URL       string               `json:"url"`
+	Method    string               `json:"method"`
+	Type      network.ResourceType `json:"type,omitempty"`
+	// RequestHeaders are the request headers as sent over the wire, such as
+	// the cookies, or the headers set by the page when the raw ones aren't
+	// available.
+	RequestHeaders network.Headers `json:"requestHeaders,omitempty"`
+	// Started is the wall time the request was sent at, and Duration the
+	// time until it finished, or failed.
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+
+	// Status is the status code of the response, which is 0 if there was
+	// no response.
+	Status     int64  `json:"status,omitempty"`
+	StatusText string `json:"statusText,omitempty"`
+	MimeType   string `json:"mimeType,omitempty"`
+	// Headers are the response headers as received over the wire, such as
+	// the cache headers added by a CDN, or the headers processed by the
+	// browser when the raw ones aren't available.
+	Headers network.Headers `json:"headers,omitempty"`
+
+	// Protocol is the protocol of the response, such as "http/1.1", "h2" or
+	// "h3".
+	Protocol        string `json:"protocol,omitempty"`
+	RemoteIPAddress string `json:"remoteIPAddress,omitempty"`
+	RemotePort      int64  `json:"remotePort,omitempty"`
+	// ConnectionID is the id of the connection the request went over, and
+	// ConnectionReused is whether the connection was already open, so that
+	// the requests multiplexed over the same HTTP/2 connection have the
+	// same ConnectionID.
+	ConnectionID     float64     `json:"connectionId,omitempty"`
+	ConnectionReused bool        `json:"connectionReused,omitempty"`
+	Cache            CacheSource `json:"cache,omitempty"`
+	// Timing are the timings of the phases of the request, such as the DNS
+	// lookup and the TLS handshake, which are nil for the cached responses.
+	Timing *network.ResourceTiming `json:"timing,omitempty"`
+
+	// EncodedDataLength is the number of bytes received, including the
+	// headers.
+	EncodedDataLength float64 `json:"encodedDataLength,omitempty"`
+	// Finished is whether the request finished or failed before the
+	// recording ended, and ErrorText is the error of a failed request.
+	Finished  bool   `json:"finished"`
+	ErrorText string `json:"errorText,omitempty"`
+
+	// PostData is the body of the request, such as the data of a POST
+	// request, which is only recorded with the WithRequestBodies option.
+	// PostDataTruncated is whether it was truncated to the limit of the
+	// option.
+	PostData          []byte `json:"postData,omitempty"`
+	PostDataTruncated bool   `json:"postDataTruncated,omitempty"`
+	// Body is the body of the response, as decoded by the browser, which is
+	// only recorded with the WithResponseBodies option, for the requests
+	// which finished. BodyTruncated is whether it was truncated to the
+	// limit of the option.
+	Body          []byte `json:"body,omitempty"`
+	BodyTruncated bool   `json:"bodyTruncated,omitempty"`
+
+	// postDataMissing is whether the request has a body which wasn't sent
+	// with its event, as the large bodies are omitted.
+	postDataMissing bool
+}
+
+// RecordNetworkOption is a RecordNetwork option.
+type RecordNetworkOption = func(*networkRecorder)
+
+// WithRequestBodies is a RecordNetwork option to record the bodies of the
+// requests, truncated to limit bytes, or entirely if limit is 0 or less.
+func WithRequestBodies(limit int) RecordNetworkOption {
+	return func(r *networkRecorder) {
+		r.bodies = true
+		r.bodyLimit = limit
+	}
+}
+
+// WithResponseBodies is a RecordNetwork option to record the bodies of the
+// responses, truncated to limit bytes, or entirely if limit is 0 or less.
+//
+// The bodies are retrieved as soon as the responses finish loading, before the
+// browser discards them, such as when the page navigates. The bodies of the
+// responses it doesn't keep, such as the large ones, are left out.
+func WithResponseBodies(limit int) RecordNetworkOption {
+	return func(r *networkRecorder) {
+		r.responseBodies = true
+		r.responseBodyLimit = limit
+	}
+}
+
+// RequestPostData is an action that retrieves the body of the request with
+// the id requestID, such as the data of a POST request, and stores it in
+// body. The request can be found with RecordNetwork, or by listening to the
+// network.EventRequestWillBeSent events.
+//
+// The files of the multipart requests are omitted. It fails when the request
+// has no body, or is no longer kept by the browser.
+func RequestPostData(requestID network.RequestID, body *[]byte) Action {
+	if body == nil {
+		panic("body cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		data, err := network.GetRequestPostData(requestID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		*body = []byte(data)
+		return nil
+	})
+}
+
+// RecordNetwork is an action that runs action, and stores in reqs the
+// requests made by the page while it ran, in the order they were sent, as set
+// up by opts.
+//
+// The requests which haven't finished when action ends are stored with
+// Finished false. Example:
+//
+//	var reqs []*chromedp.NetworkRequest
+//	err := chromedp.Run(ctx, chromedp.RecordNetwork(&reqs,
+//		chromedp.Navigate("https://example.com"),
+//		chromedp.WithRequestBodies(64<<10),
+//	))
+//	for _, r := range reqs {
+//		log.Printf("%s %s reused=%t cache=%s", r.URL, r.Protocol, r.ConnectionReused, r.Cache)
+//	}
+func RecordNetwork(reqs *[]*NetworkRequest, action Action, opts ...RecordNetworkOption) Action {
+	if reqs == nil {
+		panic("reqs cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		rec := newNetworkRecorder()
+		for _, o := range opts {
+			o(rec)
+		}
+		rec.ctx = ctx
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, rec.handle)
+
+		if err := action.Do(ctx); err != nil {
+			return err
+		}
+		cancel()
+		rec.stop()
+		res := rec.requests()
+		rec.fetchBodies(ctx, res)
+		*reqs = res
+		return nil
+	})
+}
+
+// fetchBodies retrieves the bodies of the requests reqs which weren't sent
+// with their events.
+func (r *networkRecorder) fetchBodies(ctx context.Context, reqs []*NetworkRequest) {
+	for _, req := range reqs {
+		if req.postDataMissing {
+			var body []byte
+			if err := RequestPostData(req.RequestID, &body).Do(ctx); err == nil {
+				r.setPostData(req, body)
+			}
+		}
+	}
+}
+
+// fetchResponseBody retrieves the body of the response of req, which finished
+// loading, with the WithResponseBodies option.
+func (r *networkRecorder) fetchResponseBody(req *NetworkRequest) {
+	defer r.fetches.Done()
+	body, err := network.GetResponseBody(req.RequestID).Do(r.ctx)
+	if err != nil {
+		// the response is no longer kept.
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.responseBodyLimit > 0 && len(body) > r.responseBodyLimit {
+		body, req.BodyTruncated = body[:r.responseBodyLimit], true
+	}
+	req.Body = body
+}
+
+// stop stops retrieving the bodies of the responses which finish loading, and
+// waits for those being retrieved.
+func (r *networkRecorder) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.fetches.Wait()
+}
+
+// networkRecorder builds the NetworkRequests from the Network events.
+type networkRecorder struct {
+	bodies            bool
+	bodyLimit         int
+	responseBodies    bool
+	responseBodyLimit int
+
+	// ctx is the context the bodies of the responses are retrieved with,
+	// as they finish loading, until the recorder is stopped; fetches waits
+	// for them.
+	ctx     context.Context
+	fetches sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+	reqs    []*NetworkRequest
+	// pending are the last requests of each request id, and their start
+	// timestamp.
+	pending map[network.RequestID]*pendingRequest
+	// extra are the raw headers and status codes of the responses received
+	// before their request was sent, or whose response wasn't received
+	// yet, as they may come in any order.
+	extra map[network.RequestID]*network.EventResponseReceivedExtraInfo
+	// requestExtra are the raw headers of the requests sent before their
+	// request was, likewise.
+	requestExtra map[network.RequestID]*network.EventRequestWillBeSentExtraInfo
+}
+
+type pendingRequest struct {
+	req   *NetworkRequest
+	start time.Time
+	// extra and requestExtra are whether the raw headers of the response
+	// and of the request were already set.
+	extra        bool
+	requestExtra bool
+}
+
+func newNetworkRecorder() *networkRecorder {
+	return &networkRecorder{
+		pending:      make(map[network.RequestID]*pendingRequest),
+		extra:        make(map[network.RequestID]*network.EventResponseReceivedExtraInfo),
+		requestExtra: make(map[network.RequestID]*network.EventRequestWillBeSentExtraInfo),
+	}
+}
+
+// handle updates the recorder with the event ev.
+func (r *networkRecorder) handle(ev interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if p := r.pending[ev.RequestID]; p != nil && ev.RedirectResponse != nil {
+			r.setResponse(p, ev.RedirectResponse)
+			r.finish(p, ev.Timestamp)
+		}
+		req := &NetworkRequest{
+			RequestID:      ev.RequestID,
+			FrameID:        ev.FrameID,
+			URL:            ev.Request.URL,
+			Method:         ev.Request.Method,
+			Type:           ev.Type,
+			RequestHeaders: ev.Request.Headers,
+		}
+		if ev.WallTime != nil {
+			req.Started = ev.WallTime.Time()
+		}
+		if r.bodies && ev.Request.HasPostData {
+			r.setPostDataEntries(req, ev.Request.PostDataEntries)
+		}
+		p := &pendingRequest{req: req}
+		if ev.Timestamp != nil {
+			p.start = ev.Timestamp.Time()
+		}
+		if extra := r.requestExtra[ev.RequestID]; extra != nil {
+			delete(r.requestExtra, ev.RequestID)
+			setRequestExtraInfo(p, extra)
+		}
+		r.reqs = append(r.reqs, req)
+		r.pending[ev.RequestID] = p
+	case *network.EventRequestWillBeSentExtraInfo:
+		if p := r.pending[ev.RequestID]; p != nil && !p.requestExtra {
+			setRequestExtraInfo(p, ev)
+			return
+		}
+		r.requestExtra[ev.RequestID] = ev
+	case *network.EventResponseReceived:
+		if p := r.pending[ev.RequestID]; p != nil {
+			r.setResponse(p, ev.Response)
+		}
+	case *network.EventResponseReceivedExtraInfo:
+		if p := r.pending[ev.RequestID]; p != nil && p.req.Status != 0 && !p.extra {
+			setExtraInfo(p, ev)
+			return
+		}
+		r.extra[ev.RequestID] = ev
+	case *network.EventRequestServedFromCache:
+		if p := r.pending[ev.RequestID]; p != nil {
+			p.req.Cache = CacheMemory
+		}
+	case *network.EventLoadingFinished:
+		if p := r.pending[ev.RequestID]; p != nil {
+			p.req.EncodedDataLength = ev.EncodedDataLength
+			r.finish(p, ev.Timestamp)
+			delete(r.pending, ev.RequestID)
+			// the redirects are finished by the next request of
+			// their id, so that this is the last one, with the body.
+			if r.responseBodies && !r.stopped {
+				r.fetches.Add(1)
+				go r.fetchResponseBody(p.req)
+			}
+		}
+		delete(r.extra, ev.RequestID)
+		delete(r.requestExtra, ev.RequestID)
+	case *network.EventLoadingFailed:
+		if p := r.pending[ev.RequestID]; p != nil {
+			p.req.ErrorText = ev.ErrorText
+			r.finish(p, ev.Timestamp)
+			delete(r.pending, ev.RequestID)
+		}
+		delete(r.extra, ev.RequestID)
+		delete(r.requestExtra, ev.RequestID)
+	}
+}
+
+// setPostDataEntries sets the body of the request req from the entries of its
+// event, or marks it as missing if they were omitted.
+func (r *networkRecorder) setPostDataEntries(req *NetworkRequest, entries []*network.PostDataEntry) {
+	if len(entries) == 0 {
+		req.postDataMissing = true
+		return
+	}
+	var body []byte
+	for _, e := range entries {
+		buf, err := base64.StdEncoding.DecodeString(e.Bytes)
+		if err != nil {
+			req.postDataMissing = true
+			return
+		}
+		body = append(body, buf...)
+	}
+	r.setPostData(req, body)
+}
+
+// setPostData sets the body of the request req, truncated to the limit.
+func (r *networkRecorder) setPostData(req *NetworkRequest, body []byte) {
+	req.postDataMissing = false
+	if r.bodyLimit > 0 && len(body) > r.bodyLimit {
+		body, req.PostDataTruncated = body[:r.bodyLimit], true
+	}
+	req.PostData = body
+}
+
+// setResponse sets the response resp of the pending request p.
+func (r *networkRecorder) setResponse(p *pendingRequest, resp *network.Response) {
+	req := p.req
+	req.Status = resp.Status
+	req.StatusText = resp.StatusText
+	req.MimeType = resp.MimeType
+	if !p.extra {
+		req.Headers = resp.Headers
+	}
+	req.Protocol = resp.Protocol
+	req.RemoteIPAddress = resp.RemoteIPAddress
+	req.RemotePort = resp.RemotePort
+	req.ConnectionID = resp.ConnectionID
+	req.ConnectionReused = resp.ConnectionReused
+	req.Timing = resp.Timing
+	switch {
+	case req.Cache == CacheMemory:
+	case resp.FromServiceWorker:
+		req.Cache = CacheServiceWorker
+	case resp.FromPrefetchCache:
+		req.Cache = CachePrefetch
+	case resp.FromDiskCache:
+		req.Cache = CacheDisk
+	default:
+		req.Cache = CacheNetwork
+	}
+	if ev := r.extra[req.RequestID]; ev != nil && !p.extra {
+		delete(r.extra, req.RequestID)
+		setExtraInfo(p, ev)
+	}
+}
+
+// setExtraInfo sets the raw headers and status code of ev to the pending
+// request p, which has a response.
+func setExtraInfo(p *pendingRequest, ev *network.EventResponseReceivedExtraInfo) {
+	p.extra = true
+	if len(ev.Headers) > 0 {
+		p.req.Headers = ev.Headers
+	}
+	if ev.StatusCode != 0 {
+		// the status of the revalidated cached responses is 304, instead
+		// of 200.
+		p.req.Status = ev.StatusCode
+	}
+}
+
+// setRequestExtraInfo sets the raw headers of the request of ev to the pending
+// request p.
+func setRequestExtraInfo(p *pendingRequest, ev *network.EventRequestWillBeSentExtraInfo) {
+	p.requestExtra = true
+	if len(ev.Headers) > 0 {
+		p.req.RequestHeaders = ev.Headers
+	}
+}
+
+// finish marks the pending request p as finished at the timestamp ts.
+func (r *networkRecorder) finish(p *pendingRequest, ts *cdp.MonotonicTime) {
+	p.req.Finished = true
+	if ts != nil && !p.start.IsZero() {
+		p.req.Duration = ts.Time().Sub(p.start)
+	}
+}
+
+// requests returns a copy of the recorded requests.
+func (r *networkRecorder) requests() []*NetworkRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reqs := make([]*NetworkRequest, len(r.reqs))
+	for i, req := range r.reqs {
+		cp := *req
+		reqs[i] = &cp
+	}
+	return reqs
+}
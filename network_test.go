@@ -0,0 +1,219 @@
+package chromedp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestNetworkRecorder(t *testing.T) {
+	t.Parallel()
+
+	ts := func(sec float64) *cdp.MonotonicTime {
+		t := cdp.MonotonicTime(time.Unix(0, int64(sec*float64(time.Second))))
+		return &t
+	}
+	rec := newNetworkRecorder()
+	for _, ev := range []interface{}{
+		&network.EventRequestWillBeSentExtraInfo{RequestID: "1", Headers: network.Headers{"Cookie": "a=1"}},
+		&network.EventRequestWillBeSent{
+			RequestID: "1", Request: &network.Request{URL: "http://a/", Method: "GET", Headers: network.Headers{"Accept": "*/*"}}, Timestamp: ts(1),
+		},
+		&network.EventResponseReceivedExtraInfo{RequestID: "1", StatusCode: 302, Headers: network.Headers{"location": "/b"}},
+		&network.EventRequestWillBeSent{
+			RequestID: "1", Request: &network.Request{URL: "http://a/b", Method: "GET"}, Timestamp: ts(1.5),
+			RedirectResponse: &network.Response{Status: 302, Protocol: "h2", ConnectionID: 7},
+		},
+		&network.EventRequestWillBeSent{RequestID: "2", Request: &network.Request{URL: "http://a/c", Method: "GET"}, Timestamp: ts(2)},
+		&network.EventResponseReceived{RequestID: "1", Response: &network.Response{
+			Status: 200, StatusText: "OK", Protocol: "h2", ConnectionID: 7, ConnectionReused: true,
+			RemoteIPAddress: "10.0.0.1", RemotePort: 443, Headers: network.Headers{"x-cache": "processed"},
+		}},
+		&network.EventResponseReceivedExtraInfo{RequestID: "1", StatusCode: 200, Headers: network.Headers{"x-cache": "HIT"}},
+		&network.EventLoadingFinished{RequestID: "1", Timestamp: ts(2.5), EncodedDataLength: 100},
+		&network.EventRequestServedFromCache{RequestID: "2"},
+		&network.EventResponseReceived{RequestID: "2", Response: &network.Response{Status: 200}},
+		&network.EventLoadingFailed{RequestID: "2", Timestamp: ts(3), ErrorText: "net::ERR_ABORTED"},
+		&network.EventRequestWillBeSent{RequestID: "3", Request: &network.Request{URL: "http://a/d", Method: "POST"}, Timestamp: ts(3)},
+	} {
+		rec.handle(ev)
+	}
+
+	reqs := rec.requests()
+	if len(reqs) != 4 {
+		t.Fatalf("got %d requests, want 4", len(reqs))
+	}
+	if r := reqs[0]; r.Status != 302 || r.Headers["location"] != "/b" || r.RequestHeaders["Cookie"] != "a=1" || r.Duration != 500*time.Millisecond || !r.Finished || r.Cache != CacheNetwork {
+		t.Errorf("got the redirect %+v", r)
+	}
+	if r := reqs[1]; r.URL != "http://a/b" || r.Headers["x-cache"] != "HIT" || r.StatusText != "OK" || !r.ConnectionReused || r.ConnectionID != 7 ||
+		r.RemotePort != 443 || r.EncodedDataLength != 100 || r.Duration != time.Second {
+		t.Errorf("got the redirected request %+v", r)
+	}
+	if r := reqs[2]; r.Cache != CacheMemory || r.ErrorText != "net::ERR_ABORTED" || !r.Finished {
+		t.Errorf("got the cached request %+v", r)
+	}
+	if r := reqs[3]; r.Method != "POST" || r.Finished || r.Status != 0 {
+		t.Errorf("got the pending request %+v", r)
+	}
+}
+
+func TestRecordNetwork(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>ok</body></html>")
+	})
+	mux.HandleFunc("/cached", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Header().Set("X-Cache", "MISS")
+		fmt.Fprint(w, "cached")
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var reqs []*NetworkRequest
+	if err := Run(ctx, RecordNetwork(&reqs, Tasks{
+		Navigate(s.URL),
+		Evaluate(`(async () => {
+			await (await fetch('/cached')).text();
+			await (await fetch('/cached')).text();
+		})()`, nil, EvalAsValue, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	})); err != nil {
+		t.Fatal(err)
+	}
+	var doc *NetworkRequest
+	var cached []*NetworkRequest
+	for _, r := range reqs {
+		switch {
+		case r.URL == s.URL+"/":
+			doc = r
+		case strings.HasSuffix(r.URL, "/cached"):
+			cached = append(cached, r)
+		}
+	}
+	if doc == nil || len(cached) != 2 {
+		t.Fatalf("got the requests %+v", reqs)
+	}
+	port := s.Listener.Addr().(*net.TCPAddr).Port
+	if doc.Status != 200 || doc.Protocol != "http/1.1" || doc.RemoteIPAddress != "127.0.0.1" || int(doc.RemotePort) != port ||
+		doc.Cache != CacheNetwork || !doc.Finished || doc.Timing == nil || doc.Type != network.ResourceTypeDocument {
+		t.Errorf("got the document request %+v", doc)
+	}
+	if r := cached[0]; !r.ConnectionReused || r.ConnectionID != doc.ConnectionID || r.Headers["X-Cache"] != "MISS" || r.Cache != CacheNetwork {
+		t.Errorf("want the first fetch over the connection of the document, got %+v", r)
+	}
+	if r := cached[1]; r.Cache != CacheDisk && r.Cache != CacheMemory {
+		t.Errorf("want the second fetch from the cache, got %+v", r)
+	}
+}
+
+func TestRecordNetworkBodies(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>ok</body></html>")
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	const large = 1 << 20
+	post := func(limit int) []*NetworkRequest {
+		var reqs []*NetworkRequest
+		if err := Run(ctx, RecordNetwork(&reqs, Evaluate(fmt.Sprintf(`(async () => {
+			await fetch('/small', {method: 'POST', body: 'a=1&b=2'});
+			await fetch('/large', {method: 'POST', body: 'x'.repeat(%d)});
+			await fetch('/get');
+		})()`, large), nil, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}), WithRequestBodies(limit))); err != nil {
+			t.Fatal(err)
+		}
+		if len(reqs) != 3 {
+			t.Fatalf("got %d requests, want 3", len(reqs))
+		}
+		return reqs
+	}
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := post(0)
+	if got := string(reqs[0].PostData); got != "a=1&b=2" || reqs[0].PostDataTruncated {
+		t.Errorf("got the small body %q", got)
+	}
+	if got := reqs[1].PostData; len(got) != large || reqs[1].PostDataTruncated {
+		t.Errorf("got a large body of %d bytes, want %d", len(got), large)
+	}
+	if reqs[2].PostData != nil {
+		t.Errorf("want no body for a GET request, got %q", reqs[2].PostData)
+	}
+
+	var body []byte
+	if err := Run(ctx, RequestPostData(reqs[1].RequestID, &body)); err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != large {
+		t.Errorf("got a body of %d bytes, want %d", len(body), large)
+	}
+
+	reqs = post(4)
+	if got := string(reqs[0].PostData); got != "a=1&" || !reqs[0].PostDataTruncated {
+		t.Errorf("got the truncated small body %q", got)
+	}
+	if got := string(reqs[1].PostData); got != "xxxx" || !reqs[1].PostDataTruncated {
+		t.Errorf("got the truncated large body %q", got)
+	}
+}
+
+func TestRecordNetworkResponseBodies(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body>%s</body></html>", r.URL.Path)
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the body of the first page is retrieved before it's navigated away
+	// from.
+	var reqs []*NetworkRequest
+	if err := Run(ctx, RecordNetwork(&reqs, Tasks{
+		Navigate(s.URL + "/first"),
+		// the document may finish loading after its load event.
+		Sleep(200 * time.Millisecond),
+		Navigate(s.URL + "/second"),
+	}, WithResponseBodies(0))); err != nil {
+		t.Fatal(err)
+	}
+	bodies := make(map[string]string)
+	for _, req := range reqs {
+		bodies[strings.TrimPrefix(req.URL, s.URL)] = string(req.Body)
+	}
+	for _, path := range []string{"/first", "/second"} {
+		if want := "<html><body>" + path + "</body></html>"; bodies[path] != want {
+			t.Errorf("%s: got the body %q", path, bodies[path])
+		}
+	}
+}
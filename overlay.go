@@ -0,0 +1,183 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/overlay"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// pickColor is the color PickElement highlights the hovered elements in, as
+// the element picker of the DevTools does.
+var pickColor = &cdp.RGBA{R: 111, G: 168, B: 220, A: 0.66}
+
+// HighlightNode is an element query action that highlights the first element
+// node matching the selector, filling its content box with color, and drawing
+// its padding, border and margin boxes in lighter shades of it, as the
+// DevTools do when inspecting an element. The color should be translucent,
+// such as &cdp.RGBA{R: 255, A: 0.5}, for the content of the element to remain
+// visible.
+//
+// The highlight is painted on top of the page, so that it's captured by the
+// screenshots and screencasts, until HideHighlight is run, or another node is
+// highlighted. Note that chrome-headless-shell doesn't paint the overlays of
+// the Overlay domain; use Chrome in its new headless mode instead.
+func HighlightNode(sel interface{}, color *cdp.RGBA, opts ...QueryOption) QueryAction {
+	if color == nil {
+		panic("color cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+		if err := overlay.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return overlay.HighlightNode(highlightConfig(color)).WithNodeID(nodes[0].NodeID).Do(ctx)
+	}, opts...)
+}
+
+// HideHighlight is an action that hides the highlight of HighlightNode.
+func HideHighlight() Action {
+	return overlay.HideHighlight()
+}
+
+// ShowFPSCounter is an action that shows the frame rate of the page, with a
+// graph of the frames painted recently, on top of the page, or hides it.
+func ShowFPSCounter(show bool) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if err := overlay.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return overlay.SetShowFPSCounter(show).Do(ctx)
+	})
+}
+
+// ShowPaintRects is an action that flashes the areas of the page which are
+// repainted, on top of the page, or stops flashing them.
+func ShowPaintRects(show bool) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if err := overlay.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return overlay.SetShowPaintRects(show).Do(ctx)
+	})
+}
+
+// PickElement lets the user pick an element of the page of ctx by clicking on
+// it, with the page in inspect mode, as with the element picker of the
+// DevTools, and returns its node. The elements are highlighted as the mouse
+// hovers them. It's meant for the interactive tools running a headful browser,
+// such as to record the elements to act on; the FullXPath method of the node
+// returns a selector for it, to use with BySearch.
+//
+// It waits until an element is picked, or until ctx is done. The inspect mode
+// is turned off once it returns.
+func PickElement(ctx context.Context) (*cdp.Node, error) {
+	c := FromContext(ctx)
+	if c == nil {
+		return nil, ErrInvalidContext
+	}
+	var node *cdp.Node
+	err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		var err error
+		node, err = c.Target.pickElement(ctx)
+		return err
+	}))
+	return node, err
+}
+
+// pickElement turns the inspect mode on, and waits for an element to be
+// picked.
+func (t *Target) pickElement(ctx context.Context) (*cdp.Node, error) {
+	ch := make(chan cdp.BackendNodeID, 1)
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ListenTarget(lctx, func(ev interface{}) {
+		if ev, ok := ev.(*overlay.EventInspectNodeRequested); ok {
+			select {
+			case ch <- ev.BackendNodeID:
+			default:
+			}
+		}
+	})
+
+	if err := overlay.Enable().Do(ctx); err != nil {
+		return nil, err
+	}
+	if err := overlay.SetInspectMode(overlay.InspectModeSearchForNode).
+		WithHighlightConfig(highlightConfig(pickColor)).
+		Do(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// turn the inspect mode off even if ctx is done.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), targetCleanupTimeout)
+		defer cancel()
+		overlay.SetInspectMode(overlay.InspectModeNone).Do(ctx)
+	}()
+
+	var backendID cdp.BackendNodeID
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case backendID = <-ch:
+	}
+	ids, err := dom.PushNodesByBackendIDsToFrontend([]cdp.BackendNodeID{backendID}).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) < 1 || ids[0] == 0 {
+		return nil, fmt.Errorf("could not resolve the picked node %d", backendID)
+	}
+	return t.pickedNode(ctx, ids[0])
+}
+
+// pickedNode returns the picked node, once its path was pushed to the target,
+// which tracks it with its parents. The nodes of the documents of the iframes
+// aren't tracked by the target, so that they're described without their
+// parents instead.
+func (t *Target) pickedNode(ctx context.Context, id cdp.NodeID) (*cdp.Node, error) {
+	// the nodes of the path are set by the DOM.setChildNodes events of the
+	// push, which are received before its reply, but may not be handled yet.
+	if err := t.syncEvents(ctx); err != nil {
+		return nil, err
+	}
+	t.frameMu.RLock()
+	f := t.frames[t.cur]
+	t.frameMu.RUnlock()
+	if f != nil {
+		f.RLock()
+		n := f.Nodes[id]
+		f.RUnlock()
+		if n != nil {
+			return n, nil
+		}
+	}
+	n, err := dom.DescribeNode().WithNodeID(id).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n.NodeID = id
+	return n, nil
+}
+
+// highlightConfig returns the config highlighting the boxes of a node in
+// shades of color.
+func highlightConfig(color *cdp.RGBA) *overlay.HighlightConfig {
+	shade := func(alpha float64) *cdp.RGBA {
+		c := *color
+		c.A = color.A * alpha
+		return &c
+	}
+	return &overlay.HighlightConfig{
+		ContentColor: color,
+		PaddingColor: shade(0.6),
+		BorderColor:  shade(0.8),
+		MarginColor:  shade(0.4),
+	}
+}
@@ -0,0 +1,101 @@
+package chromedp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+)
+
+func TestHighlightNode(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "form.html")
+	defer cancel()
+
+	if err := Run(ctx,
+		HighlightNode("#bar", &cdp.RGBA{R: 255, A: 0.5}, ByQuery),
+		HighlightNode("#btn1", &cdp.RGBA{G: 255, A: 0.5}, ByQuery),
+		HideHighlight(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	config := highlightConfig(&cdp.RGBA{R: 255, A: 0.5})
+	if c := config.ContentColor; c.R != 255 || c.A != 0.5 {
+		t.Errorf("want the content box in the color, got %+v", c)
+	}
+	if c := config.MarginColor; c.R != 255 || c.A >= 0.5 {
+		t.Errorf("want the margin box in a lighter shade, got %+v", c)
+	}
+}
+
+func TestShowOverlays(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "form.html")
+	defer cancel()
+
+	if err := Run(ctx,
+		ShowFPSCounter(true),
+		ShowPaintRects(true),
+		SetValue("#foo", "bar", ByQuery),
+		ShowFPSCounter(false),
+		ShowPaintRects(false),
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPickElement(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "form.html")
+	defer cancel()
+
+	var model *dom.BoxModel
+	if err := Run(ctx, Dimensions("span#foo", &model, ByQuery)); err != nil {
+		t.Fatal(err)
+	}
+	x := (model.Content[0] + model.Content[4]) / 2
+	y := (model.Content[1] + model.Content[5]) / 2
+
+	// hover and click on the span until it's picked, as the inspect mode is
+	// turned on by PickElement; the inspect mode picks the hovered element.
+	pctx, pcancel := context.WithTimeout(ctx, 10*time.Second)
+	defer pcancel()
+	go func() {
+		for pctx.Err() == nil {
+			Run(pctx, MouseEvent(input.MouseMoved, x, y), MouseClickXY(x, y), Sleep(50*time.Millisecond))
+		}
+	}()
+	node, err := PickElement(pctx)
+	pcancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.LocalName != "span" || node.AttributeValue("id") != "foo" {
+		t.Fatalf("want the span picked, got %s", node.Dump("", "", false))
+	}
+
+	var text string
+	if err := Run(ctx, Text(node.FullXPath(), &text, BySearch)); err != nil {
+		t.Fatal(err)
+	}
+	if text != "insert" {
+		t.Errorf("want the text of the picked span, got %q", text)
+	}
+
+	// the inspect mode is turned off once the context is done.
+	cctx, ccancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer ccancel()
+	if _, err := PickElement(cctx); err != context.DeadlineExceeded {
+		t.Errorf("want the deadline to be exceeded, got %v", err)
+	}
+	if err := Run(ctx, Click("#btn1", ByQuery)); err != nil {
+		t.Fatal(err)
+	}
+}
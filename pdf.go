@@ -0,0 +1,193 @@
+package chromedp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+// PDFOption is the type for the options of PrintToPDF.
+type PDFOption = func(*page.PrintToPDFParams) *page.PrintToPDFParams
+
+// PrintToPDF is an action that prints the current page to a PDF, as set up by
+// opts, and stores it in res.
+//
+// The TaggedPDF and DocumentOutline options are checked against the printed
+// PDF, as the browsers which don't support them silently ignore them. When
+// they aren't honored, the PDF is still stored, and the WithFeatureWarning
+// func of the browser is called.
+//
+// Example:
+//
+//	var buf []byte
+//	err := chromedp.Run(ctx, chromedp.PrintToPDF(&buf,
+//		chromedp.TaggedPDF,
+//		chromedp.DocumentOutline,
+//		func(p *page.PrintToPDFParams) *page.PrintToPDFParams {
+//			return p.WithPrintBackground(true)
+//		},
+//	))
+func PrintToPDF(res *[]byte, opts ...PDFOption) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		p := page.PrintToPDF()
+		for _, o := range opts {
+			p = o(p)
+		}
+		buf, _, err := p.Do(ctx)
+		if err != nil {
+			return err
+		}
+		if c := FromContext(ctx); c != nil && c.Browser != nil {
+			for _, feature := range missingPDFFeatures(p, buf) {
+				c.Browser.warnFeature(feature, "not supported by the browser")
+			}
+		}
+		*res = buf
+		return nil
+	})
+}
+
+// TaggedPDF is a PDF option to generate a tagged PDF, which embeds the
+// structure of the document, such as its headings, tables and the alternative
+// texts of its images, as required by assistive technologies and the
+// accessibility standards such as PDF/UA.
+func TaggedPDF(p *page.PrintToPDFParams) *page.PrintToPDFParams {
+	return p.WithGenerateTaggedPDF(true)
+}
+
+// DocumentOutline is a PDF option to embed the outline of the document, built
+// from its headings, which PDF readers show as bookmarks. As a document
+// without headings has no outline, PrintToPDF warns that it's missing.
+func DocumentOutline(p *page.PrintToPDFParams) *page.PrintToPDFParams {
+	return p.WithGenerateDocumentOutline(true)
+}
+
+// missingPDFFeatures returns the features requested by p which are missing
+// from the printed PDF buf.
+func missingPDFFeatures(p *page.PrintToPDFParams, buf []byte) []string {
+	var missing []string
+	if p.GenerateTaggedPDF && !bytes.Contains(buf, []byte("/StructTreeRoot")) {
+		missing = append(missing, "tagged PDF")
+	}
+	if p.GenerateDocumentOutline && !bytes.Contains(buf, []byte("/Outlines")) {
+		missing = append(missing, "PDF document outline")
+	}
+	return missing
+}
+
+// defaultHeaderFooterFontSize is the font size of the HeaderFooter templates,
+// in pixels, when it's not set.
+const defaultHeaderFooterFontSize = 10
+
+// HeaderFooter builds the header and footer templates of page.PrintToPDF,
+// which are tiny HTML documents rendered in the margins of each page, with the
+// values of the current page injected into the elements with the title, url,
+// date, pageNumber and totalPages classes.
+//
+// The templates are rendered with a font size of 0 by default, so that any
+// text without an explicit font size is invisible; the built template always
+// sets one, 10px unless set with FontSize. The margins of the PDF must leave
+// room for the template, as it isn't rendered otherwise.
+//
+// The zero value is an empty template. The methods return a copy of the
+// template, so that a base template can be shared. Example:
+//
+//	footer := chromedp.HeaderFooter{}.
+//		Text("Page ").PageNumber().Text(" of ").TotalPages().
+//		Align("right")
+//	_, _, err := page.PrintToPDF().
+//		WithDisplayHeaderFooter(true).
+//		WithHeaderTemplate(chromedp.HeaderFooter{}.Title().String()).
+//		WithFooterTemplate(footer.String()).
+//		WithMarginTop(0.5).
+//		WithMarginBottom(0.5).
+//		Do(ctx)
+type HeaderFooter struct {
+	items    []string
+	fontSize float64
+	align    string
+}
+
+// with returns a copy of the template, with the HTML item appended.
+func (h HeaderFooter) with(item string) HeaderFooter {
+	h.items = append(slices.Clip(h.items), item)
+	return h
+}
+
+// Title appends the title of the printed document.
+func (h HeaderFooter) Title() HeaderFooter {
+	return h.with(`<span class="title"></span>`)
+}
+
+// URL appends the URL of the printed document.
+func (h HeaderFooter) URL() HeaderFooter {
+	return h.with(`<span class="url"></span>`)
+}
+
+// Date appends the formatted print date.
+func (h HeaderFooter) Date() HeaderFooter {
+	return h.with(`<span class="date"></span>`)
+}
+
+// PageNumber appends the number of the current page.
+func (h HeaderFooter) PageNumber() HeaderFooter {
+	return h.with(`<span class="pageNumber"></span>`)
+}
+
+// TotalPages appends the total number of pages of the printed document.
+func (h HeaderFooter) TotalPages() HeaderFooter {
+	return h.with(`<span class="totalPages"></span>`)
+}
+
+// Text appends the text, which is escaped.
+func (h HeaderFooter) Text(text string) HeaderFooter {
+	return h.with(html.EscapeString(text))
+}
+
+// CustomHTML appends the raw HTML, which isn't escaped. As the template can't
+// load any resource, images and styles must be inlined, such as with data
+// URLs.
+func (h HeaderFooter) CustomHTML(html string) HeaderFooter {
+	return h.with(html)
+}
+
+// FontSize sets the font size of the template, in pixels. A size of 0 or less
+// resets it to the default one.
+func (h HeaderFooter) FontSize(px float64) HeaderFooter {
+	h.fontSize = px
+	return h
+}
+
+// Align sets the horizontal alignment of the template, as the CSS text-align
+// property, such as "left", "center" or "right". It's centered by default.
+func (h HeaderFooter) Align(align string) HeaderFooter {
+	h.align = align
+	return h
+}
+
+// String returns the HTML template, as passed to the WithHeaderTemplate and
+// WithFooterTemplate methods of page.PrintToPDFParams.
+func (h HeaderFooter) String() string {
+	fontSize := h.fontSize
+	if fontSize <= 0 {
+		fontSize = defaultHeaderFooterFontSize
+	}
+	align := h.align
+	if align == "" {
+		align = "center"
+	}
+	return fmt.Sprintf(`<div style="font-size: %spx; width: 100%%; text-align: %s; margin: 0 0.4cm;">%s</div>`,
+		strconv.FormatFloat(fontSize, 'f', -1, 64),
+		html.EscapeString(align),
+		strings.Join(h.items, ""))
+}
@@ -0,0 +1,22 @@
+// paged wraps the after hook of window.PagedConfig, which Paged.js calls once
+// it paginated the document, so that it flags the document as rendered, even
+// when the page sets its own config later on.
+(() => {
+    const rendered = () => { window.__chromedpPaged = true; };
+    const wrap = (config) => {
+        config = config || {};
+        const after = config.after;
+        config.after = function (...args) {
+            const res = after && after.apply(this, args);
+            Promise.resolve(res).then(rendered, rendered);
+            return res;
+        };
+        return config;
+    };
+    let config = wrap(window.PagedConfig);
+    Object.defineProperty(window, 'PagedConfig', {
+        configurable: true,
+        get: () => config,
+        set: (c) => { if (c !== config) config = wrap(c); },
+    });
+})();
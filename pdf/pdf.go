@@ -0,0 +1,228 @@
+// Package pdf generates the PDFs of web pages once they're rendered, in print
+// media, as needed by the pages which render themselves with JavaScript, such
+// as the reports and invoices built with Paged.js. Generate navigates to the
+// page, waits for the render hooks set up by the options and for the fonts to
+// load, and then prints the page.
+//
+// The page is printed with chromedp.PrintToPDF, so that the size, margins and
+// outline of the PDF are set up with its options, via WithPDFOptions.
+//
+//	ctx, cancel := chromedp.NewContext(context.Background())
+//	defer cancel()
+//	buf, err := pdf.Generate(ctx, "https://example.com/invoice/42",
+//		pdf.WithWindowStatus("ready"),
+//		pdf.WithTimeout(10*time.Second),
+//		pdf.WithPDFOptions(func(p *page.PrintToPDFParams) *page.PrintToPDFParams {
+//			return p.WithPrintBackground(true)
+//		}),
+//	)
+//
+// The page is loaded in the current tab of ctx, so that the generations of
+// several PDFs at once need a tab each.
+package pdf
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrNoPagedJS is the error of Generate with WithPagedJS when the page
+// doesn't load Paged.js.
+var ErrNoPagedJS = errors.New("the page doesn't load Paged.js")
+
+// pagedJS is a JavaScript snippet, evaluated before the scripts of the page,
+// that flags the page as rendered once Paged.js paginated it.
+//
+//go:embed js/paged.js
+var pagedJS string
+
+// DefaultTimeout is how long Generate waits for the page to be rendered when
+// WithTimeout isn't set.
+const DefaultTimeout = 30 * time.Second
+
+// pollInterval is how often Generate checks whether the page is rendered.
+const pollInterval = 50 * time.Millisecond
+
+// Option is a Generate option.
+type Option = func(*generator)
+
+// generator is the set up of Generate.
+type generator struct {
+	media   string
+	status  string
+	waitFor []string
+	paged   bool
+	timeout time.Duration
+	pdfOpts []chromedp.PDFOption
+}
+
+// WithScreenMedia renders the page with the CSS of the screen media, instead
+// of the print one.
+func WithScreenMedia() Option {
+	return func(g *generator) {
+		g.media = "screen"
+	}
+}
+
+// WithWindowStatus waits for the page to set window.status to status, which
+// is the flag the pages traditionally set once they're rendered.
+func WithWindowStatus(status string) Option {
+	return func(g *generator) {
+		g.status = status
+	}
+}
+
+// WithWaitFor waits for the JavaScript expression to be truthy, such as
+// `window.chartsDrawn`. When the expression returns a promise, such as
+// `window.renderDone`, it waits for the promise to resolve to a truthy value.
+// It can be used several times, in which case the expressions are waited for
+// in order.
+func WithWaitFor(expression string) Option {
+	return func(g *generator) {
+		g.waitFor = append(g.waitFor, expression)
+	}
+}
+
+// WithPagedJS waits for Paged.js to paginate the page, without changing the
+// page, and prints it in the page size set by its CSS. The page must load
+// Paged.js itself, and Generate fails with ErrNoPagedJS otherwise.
+func WithPagedJS() Option {
+	return func(g *generator) {
+		g.paged = true
+	}
+}
+
+// WithTimeout sets how long Generate waits for the page to be rendered, once
+// it's loaded, for all of its render hooks and fonts. It's DefaultTimeout by
+// default, and 0 waits for as long as ctx isn't done.
+func WithTimeout(d time.Duration) Option {
+	return func(g *generator) {
+		g.timeout = d
+	}
+}
+
+// WithPDFOptions adds the options of chromedp.PrintToPDF the page is printed
+// with, such as chromedp.TaggedPDF or the margins of the pages.
+func WithPDFOptions(opts ...chromedp.PDFOption) Option {
+	return func(g *generator) {
+		g.pdfOpts = append(g.pdfOpts, opts...)
+	}
+}
+
+// Generate navigates the current tab of ctx to urlstr, waits for the page to
+// be rendered, as set up by opts, and returns the PDF of the page.
+func Generate(ctx context.Context, urlstr string, opts ...Option) ([]byte, error) {
+	g := &generator{
+		media:   "print",
+		timeout: DefaultTimeout,
+	}
+	for _, o := range opts {
+		o(g)
+	}
+	var buf []byte
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return g.generate(ctx, urlstr, &buf)
+	})); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// generate loads urlstr in the current page of ctx and stores its PDF in res.
+// The media type is emulated while the page is loaded and printed, keeping the
+// media features emulated by the tab, such as with chromedp.DarkMode. The
+// previous media emulation is restored and the Paged.js hook is removed
+// afterwards, so that the tab can be reused.
+func (g *generator) generate(ctx context.Context, urlstr string, res *[]byte) error {
+	return chromedp.EmulateMediaType(g.media, chromedp.ActionFunc(func(ctx context.Context) error {
+		return g.print(ctx, urlstr, res)
+	})).Do(ctx)
+}
+
+// print loads urlstr and stores its PDF in res, with the media type emulated.
+func (g *generator) print(ctx context.Context, urlstr string, res *[]byte) (err error) {
+	if g.paged {
+		id, err := page.AddScriptToEvaluateOnNewDocument(pagedJS).Do(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if rerr := page.RemoveScriptToEvaluateOnNewDocument(id).Do(ctx); err == nil {
+				err = rerr
+			}
+		}()
+	}
+	if err := chromedp.Navigate(urlstr).Do(ctx); err != nil {
+		return err
+	}
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+
+	var pdfOpts []chromedp.PDFOption
+	if g.paged {
+		pdfOpts = append(pdfOpts, func(p *page.PrintToPDFParams) *page.PrintToPDFParams {
+			return p.WithPreferCSSPageSize(true)
+		})
+	}
+	return chromedp.PrintToPDF(res, append(pdfOpts, g.pdfOpts...)...).Do(ctx)
+}
+
+// wait waits for the render hooks of the loaded page, and then for its fonts,
+// all within the timeout.
+func (g *generator) wait(ctx context.Context) error {
+	var deadline time.Time
+	if g.timeout > 0 {
+		deadline = time.Now().Add(g.timeout)
+	}
+	poll := func(name, expression string, res interface{}) error {
+		var timeout time.Duration
+		if !deadline.IsZero() {
+			if timeout = time.Until(deadline); timeout < time.Millisecond {
+				return fmt.Errorf("waiting for %s: %w", name, chromedp.ErrPollingTimeout)
+			}
+		}
+		err := chromedp.Poll(expression, res,
+			chromedp.WithPollingInterval(pollInterval),
+			chromedp.WithPollingTimeout(timeout),
+		).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("waiting for %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if g.status != "" {
+		status, err := json.Marshal(g.status)
+		if err != nil {
+			return err
+		}
+		if err := poll("window.status "+string(status), "window.status === "+string(status), nil); err != nil {
+			return err
+		}
+	}
+	for _, expr := range g.waitFor {
+		if err := poll(expr, expr, nil); err != nil {
+			return err
+		}
+	}
+	if g.paged {
+		expr := `window.__chromedpPaged === true ? 'rendered' :
+			document.readyState === 'complete' && !window.PagedPolyfill ? 'missing' : false`
+		var state string
+		if err := poll("Paged.js", expr, &state); err != nil {
+			return err
+		}
+		if state == "missing" {
+			return ErrNoPagedJS
+		}
+	}
+	return poll("the fonts", "document.fonts.ready.then(() => true)", nil)
+}
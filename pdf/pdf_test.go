@@ -0,0 +1,190 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	pdfreader "github.com/ledongthuc/pdf"
+)
+
+var allocCtx context.Context
+
+func TestMain(m *testing.M) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.DisableGPU)
+	if execPath := os.Getenv("CHROMEDP_TEST_RUNNER"); execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+	if noSandbox := os.Getenv("CHROMEDP_NO_SANDBOX"); noSandbox != "false" {
+		opts = append(opts, chromedp.NoSandbox)
+	}
+	var cancel context.CancelFunc
+	allocCtx, cancel = chromedp.NewExecAllocator(context.Background(), opts...)
+
+	code := m.Run()
+	cancel()
+	os.Exit(code)
+}
+
+var testPages = map[string]string{
+	"/status": `<p id="text">loading</p>
+<script>
+  setTimeout(() => {
+    document.getElementById('text').textContent = 'rendered by the status page';
+    window.status = 'ready';
+  }, 300);
+</script>`,
+	"/promise": `<p id="text">loading</p>
+<script>
+  window.renderDone = new Promise((resolve) => setTimeout(() => {
+    document.getElementById('text').textContent = 'rendered by the promise page';
+    resolve(true);
+  }, 300));
+</script>`,
+	"/media": `<style>
+  @media screen { .print { display: none } }
+  @media print { .screen { display: none } }
+</style>
+<p class="screen">shown on screens</p>
+<p class="print">shown on paper</p>`,
+	"/dark": `<style>
+  @media (prefers-color-scheme: dark) { .light { display: none } }
+  @media (prefers-color-scheme: light) { .dark { display: none } }
+</style>
+<p class="dark">printed in the dark scheme</p>
+<p class="light">printed in the light scheme</p>`,
+	// a stand-in for Paged.js, which calls the after hook of its config
+	// once it paginated the document, and the page's own hook.
+	"/paged": `<script>
+  window.PagedConfig = {
+    after: () => { document.getElementById('hook').textContent = 'hooked by the page'; },
+  };
+</script>
+<script>
+  window.PagedConfig = window.PagedConfig || {};
+  window.PagedPolyfill = {};
+  setTimeout(async () => {
+    document.getElementById('text').textContent = 'paginated by paged';
+    await window.PagedConfig.after({total: 1});
+  }, 300);
+</script>
+<p id="text">loading</p>
+<p id="hook"></p>`,
+	"/plain": `<p>no paged</p>`,
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>" + testPages[r.URL.Path] + "</body></html>"))
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		path    string
+		opts    []Option
+		want    []string
+		notWant string
+	}{
+		{"/status", []Option{WithWindowStatus("ready")}, []string{"rendered by the status page"}, "loading"},
+		{"/promise", []Option{WithWaitFor("window.renderDone")}, []string{"rendered by the promise page"}, "loading"},
+		{"/media", nil, []string{"shown on paper"}, "shown on screens"},
+		{"/media", []Option{WithScreenMedia()}, []string{"shown on screens"}, "shown on paper"},
+		{"/paged", []Option{WithPagedJS()}, []string{"paginated by paged", "hooked by the page"}, "loading"},
+	}
+	for _, test := range tests {
+		ctx, cancel := chromedp.NewContext(allocCtx)
+		buf, err := Generate(ctx, s.URL+test.path, test.opts...)
+		cancel()
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		text := pdfText(t, buf)
+		for _, want := range test.want {
+			if !strings.Contains(text, want) {
+				t.Errorf("%s: want %q in the PDF, got %q", test.path, want, text)
+			}
+		}
+		if strings.Contains(text, test.notWant) {
+			t.Errorf("%s: want no %q in the PDF, got %q", test.path, test.notWant, text)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>" + testPages[r.URL.Path] + "</body></html>"))
+	}))
+	defer s.Close()
+
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	start := time.Now()
+	_, err := Generate(ctx, s.URL+"/status", WithWindowStatus("never"), WithTimeout(500*time.Millisecond))
+	if !errors.Is(err, chromedp.ErrPollingTimeout) {
+		t.Errorf("want ErrPollingTimeout, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("want the timeout to be honored, took %v", d)
+	}
+	if _, err := Generate(ctx, s.URL+"/plain", WithPagedJS()); !errors.Is(err, ErrNoPagedJS) {
+		t.Errorf("want ErrNoPagedJS, got %v", err)
+	}
+
+	// the media features of the tab are kept while printing, and the tab is
+	// left without the print media emulation.
+	if err := chromedp.Run(ctx, chromedp.DarkMode(true)); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := Generate(ctx, s.URL+"/dark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := pdfText(t, buf); !strings.Contains(text, "dark scheme") || strings.Contains(text, "light scheme") {
+		t.Errorf("want the PDF in the dark scheme, got %q", text)
+	}
+	var print, dark bool
+	if err := chromedp.Run(ctx,
+		chromedp.Evaluate(`matchMedia('print').matches`, &print),
+		chromedp.Evaluate(`matchMedia('(prefers-color-scheme: dark)').matches`, &dark),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if print {
+		t.Error("want the print media emulation to be reset")
+	}
+	if !dark {
+		t.Error("want the dark color scheme to be restored")
+	}
+}
+
+// pdfText returns the text of the PDF buf.
+func pdfText(t *testing.T, buf []byte) string {
+	t.Helper()
+	r, err := pdfreader.NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := r.GetPlainText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text)
+}
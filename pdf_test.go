@@ -0,0 +1,127 @@
+package chromedp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/ledongthuc/pdf"
+)
+
+func TestHeaderFooterString(t *testing.T) {
+	t.Parallel()
+
+	base := HeaderFooter{}.Text("<b> & ")
+	tests := []struct {
+		h    HeaderFooter
+		want string
+	}{
+		{
+			HeaderFooter{},
+			`<div style="font-size: 10px; width: 100%; text-align: center; margin: 0 0.4cm;"></div>`,
+		},
+		{
+			base.PageNumber(),
+			`<div style="font-size: 10px; width: 100%; text-align: center; margin: 0 0.4cm;">&lt;b&gt; &amp; <span class="pageNumber"></span></div>`,
+		},
+		{
+			base.TotalPages().FontSize(7.5).Align("right"),
+			`<div style="font-size: 7.5px; width: 100%; text-align: right; margin: 0 0.4cm;">&lt;b&gt; &amp; <span class="totalPages"></span></div>`,
+		},
+		{
+			HeaderFooter{}.Title().URL().Date().CustomHTML(`<img src="data:,">`).FontSize(-1),
+			`<div style="font-size: 10px; width: 100%; text-align: center; margin: 0 0.4cm;"><span class="title"></span><span class="url"></span><span class="date"></span><img src="data:,"></div>`,
+		},
+	}
+	for i, test := range tests {
+		if got := test.h.String(); got != test.want {
+			t.Errorf("test %d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestHeaderFooterPrint(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	header := HeaderFooter{}.Title().Text(" -- ").Text("header")
+	footer := HeaderFooter{}.Text("(").PageNumber().Text(" / ").TotalPages().Text(")")
+	var buf []byte
+	if err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		var err error
+		buf, _, err = page.PrintToPDF().
+			WithMarginTop(0.5).
+			WithMarginBottom(0.5).
+			WithDisplayHeaderFooter(true).
+			WithHeaderTemplate(header.String()).
+			WithFooterTemplate(footer.String()).
+			Do(ctx)
+		return err
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := r.GetPlainText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := io.ReadAll(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"this is title -- header", "(1 / 1)"} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("want the PDF to contain %q, got %q", want, text)
+		}
+	}
+}
+
+func TestPrintToPDFTagged(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "markdown.html")
+	defer cancel()
+
+	var buf []byte
+	if err := Run(ctx, PrintToPDF(&buf, TaggedPDF, DocumentOutline)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf, []byte("%PDF-")) {
+		t.Fatal("want a PDF")
+	}
+	p := DocumentOutline(TaggedPDF(page.PrintToPDF()))
+	if missing := missingPDFFeatures(p, buf); len(missing) > 0 {
+		t.Errorf("want a tagged PDF with an outline, missing %q", missing)
+	}
+}
+
+func TestMissingPDFFeatures(t *testing.T) {
+	t.Parallel()
+
+	tagged := []byte("<< /Type /Catalog /StructTreeRoot 3 0 R >>")
+	tests := []struct {
+		p    *page.PrintToPDFParams
+		buf  []byte
+		want []string
+	}{
+		{page.PrintToPDF(), nil, nil},
+		{TaggedPDF(page.PrintToPDF()), tagged, nil},
+		{TaggedPDF(page.PrintToPDF()), []byte("<< /Type /Catalog >>"), []string{"tagged PDF"}},
+		{DocumentOutline(TaggedPDF(page.PrintToPDF())), tagged, []string{"PDF document outline"}},
+	}
+	for i, test := range tests {
+		if got := missingPDFFeatures(test.p, test.buf); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("test %d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
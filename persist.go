@@ -0,0 +1,268 @@
+package chromedp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/domstorage"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/storage"
+)
+
+// browserContextState is the state of a BrowserContext persisted by
+// ExportBrowserContext.
+type browserContextState struct {
+	Cookies []*network.CookieParam `json:"cookies"`
+	// LocalStorage holds the local storage items, by origin.
+	LocalStorage map[string]map[string]string `json:"localStorage"`
+}
+
+// ExportBrowserContext writes the cookies of the BrowserContext of ctx, and
+// the local storage of the origins of the frames of its current page, to the
+// file at path, encrypted with key using AES-GCM. The key must be 16, 24 or 32
+// bytes long.
+//
+// The file can be restored with ImportBrowserContext, for example to resume a
+// session in a new browser when the user data directory can't be reused. Note
+// that it holds the session cookies too, which are often enough to log in.
+func ExportBrowserContext(ctx context.Context, path string, key []byte) error {
+	var st browserContextState
+	if err := Run(ctx, exportBrowserContextAction(&st)); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(&st)
+	if err != nil {
+		return err
+	}
+	buf, err = sealBrowserContextState(key, buf)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf, 0o600)
+}
+
+func exportBrowserContextAction(st *browserContextState) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		c := FromContext(ctx)
+		cookies, err := storage.GetCookies().
+			WithBrowserContextID(c.BrowserContextID).
+			Do(cdp.WithExecutor(ctx, c.Browser))
+		if err != nil {
+			return err
+		}
+		for _, cookie := range cookies {
+			// such cookies can't be set again.
+			if cookie.PartitionKeyOpaque {
+				continue
+			}
+			st.Cookies = append(st.Cookies, cookieParam(cookie))
+		}
+
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		st.LocalStorage = make(map[string]map[string]string)
+		var walk func(*page.FrameTree) error
+		walk = func(t *page.FrameTree) error {
+			origin := t.Frame.SecurityOrigin
+			if _, ok := st.LocalStorage[origin]; !ok && origin != "" && origin != "null" {
+				items, err := domstorage.GetDOMStorageItems(&domstorage.StorageID{
+					SecurityOrigin: origin,
+					IsLocalStorage: true,
+				}).Do(ctx)
+				if err != nil {
+					return fmt.Errorf("could not get the local storage of %q: %w", origin, err)
+				}
+				m := make(map[string]string, len(items))
+				for _, item := range items {
+					if len(item) == 2 {
+						m[item[0]] = item[1]
+					}
+				}
+				st.LocalStorage[origin] = m
+			}
+			for _, child := range t.ChildFrames {
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return walk(tree)
+	})
+}
+
+// cookieParam returns the parameters to set cookie again.
+func cookieParam(cookie *network.Cookie) *network.CookieParam {
+	p := &network.CookieParam{
+		Name:         cookie.Name,
+		Value:        cookie.Value,
+		Path:         cookie.Path,
+		Secure:       cookie.Secure,
+		HTTPOnly:     cookie.HTTPOnly,
+		SameSite:     cookie.SameSite,
+		Priority:     cookie.Priority,
+		SourceScheme: cookie.SourceScheme,
+		SourcePort:   cookie.SourcePort,
+		PartitionKey: cookie.PartitionKey,
+	}
+	if strings.HasPrefix(cookie.Domain, ".") {
+		p.Domain = cookie.Domain
+	} else {
+		p.URL = hostOnlyCookieURL(cookie.Domain, cookie.Path, cookie.Secure)
+	}
+	if !cookie.Session {
+		expires := cdp.TimeSinceEpoch(time.Unix(0, int64(cookie.Expires*float64(time.Second))))
+		p.Expires = &expires
+	}
+	return p
+}
+
+// hostOnlyCookieURL returns the URL to set a host-only cookie of domain for, as
+// setting its domain would send it to the subdomains too.
+func hostOnlyCookieURL(domain, path string, secure bool) string {
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return scheme + "://" + strings.TrimPrefix(domain, ".") + path
+}
+
+// ImportBrowserContext restores the state written by ExportBrowserContext to
+// the BrowserContext of ctx, decrypting the file at path with key.
+//
+// The cookies are set right away. The local storage of an origin is restored
+// when the current page of ctx loads a document of this origin for the first
+// time, before the scripts of the document run; it's restored right away for
+// the origins of the frames of the current page.
+func ImportBrowserContext(ctx context.Context, path string, key []byte) error {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	buf, err = openBrowserContextState(key, buf)
+	if err != nil {
+		return err
+	}
+	var st browserContextState
+	if err := json.Unmarshal(buf, &st); err != nil {
+		return err
+	}
+	return Run(ctx, importBrowserContextAction(&st))
+}
+
+// restoreLocalStorageScript restores the local storage items of the origin of
+// the document once per page, as the session storage of the origin survives
+// navigations.
+const restoreLocalStorageScript = `(function(state) {
+	const items = state[location.origin];
+	const key = '__chromedp_restored';
+	try {
+		if (!items || sessionStorage.getItem(key)) {
+			return;
+		}
+		for (const [k, v] of Object.entries(items)) {
+			localStorage.setItem(k, v);
+		}
+		sessionStorage.setItem(key, '1');
+	} catch (e) {}
+})(%s);`
+
+func importBrowserContextAction(st *browserContextState) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		c := FromContext(ctx)
+		if len(st.Cookies) > 0 {
+			if err := storage.SetCookies(st.Cookies).
+				WithBrowserContextID(c.BrowserContextID).
+				Do(cdp.WithExecutor(ctx, c.Browser)); err != nil {
+				return err
+			}
+		}
+		if len(st.LocalStorage) == 0 {
+			return nil
+		}
+
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		var walk func(*page.FrameTree) error
+		walk = func(t *page.FrameTree) error {
+			for k, v := range st.LocalStorage[t.Frame.SecurityOrigin] {
+				if err := domstorage.SetDOMStorageItem(&domstorage.StorageID{
+					SecurityOrigin: t.Frame.SecurityOrigin,
+					IsLocalStorage: true,
+				}, k, v).Do(ctx); err != nil {
+					return err
+				}
+			}
+			for _, child := range t.ChildFrames {
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := walk(tree); err != nil {
+			return err
+		}
+
+		buf, err := json.Marshal(st.LocalStorage)
+		if err != nil {
+			return err
+		}
+		_, err = page.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(restoreLocalStorageScript, buf)).Do(ctx)
+		return err
+	})
+}
+
+// sealBrowserContextState encrypts and authenticates the state buf with key,
+// prefixing it with a random nonce.
+func sealBrowserContextState(key, buf []byte) ([]byte, error) {
+	aead, err := newBrowserContextStateCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(buf)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, buf, nil), nil
+}
+
+// openBrowserContextState decrypts the state buf sealed with key.
+func openBrowserContextState(key, buf []byte) ([]byte, error) {
+	aead, err := newBrowserContextStateCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < aead.NonceSize() {
+		return nil, errors.New("invalid browser context state")
+	}
+	nonce, buf := buf[:aead.NonceSize()], buf[aead.NonceSize():]
+	buf, err = aead.Open(nil, nonce, buf, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt browser context state: %w", err)
+	}
+	return buf, nil
+}
+
+func newBrowserContextStateCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
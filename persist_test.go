@@ -0,0 +1,89 @@
+package chromedp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportImportBrowserContext(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.Header().Set("Set-Cookie", "session=secret; Max-Age=3600")
+		}
+		fmt.Fprint(w, `<html><body>session</body></html>`)
+	}))
+	defer s.Close()
+
+	tctx, tcancel := testAllocate(t, "")
+	defer tcancel()
+
+	key := bytes.Repeat([]byte{1}, 32)
+	path := filepath.Join(t.TempDir(), "state")
+
+	ctx1, cancel1 := NewContext(tctx, WithNewBrowserContext())
+	defer cancel1()
+	if err := Run(ctx1,
+		Navigate(s.URL+"/login"),
+		Evaluate(`localStorage.setItem("user", "gopher")`, nil),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportBrowserContext(ctx1, path, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ImportBrowserContext(ctx1, path, bytes.Repeat([]byte{2}, 32)); err == nil {
+		t.Error("want an error with a wrong key")
+	}
+
+	ctx2, cancel2 := NewContext(tctx, WithNewBrowserContext())
+	defer cancel2()
+	var cookie, user string
+	if err := Run(ctx2,
+		Navigate(s.URL),
+		Evaluate(`document.cookie`, &cookie),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if cookie != "" {
+		t.Fatalf("want no cookie in a new browser context, got %q", cookie)
+	}
+	if err := Run(ctx2, Navigate("about:blank")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ImportBrowserContext(ctx2, path, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx2,
+		Navigate(s.URL),
+		Evaluate(`document.cookie`, &cookie),
+		Evaluate(`localStorage.getItem("user")`, &user),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "session=secret"; cookie != want {
+		t.Errorf("want cookie %q, got %q", want, cookie)
+	}
+	if want := "gopher"; user != want {
+		t.Errorf("want local storage item %q, got %q", want, user)
+	}
+
+	// the page's changes are not overwritten by later navigations.
+	if err := Run(ctx2,
+		Evaluate(`localStorage.setItem("user", "changed")`, nil),
+		Reload(),
+		Evaluate(`localStorage.getItem("user")`, &user),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "changed"; user != want {
+		t.Errorf("want local storage item %q, got %q", want, user)
+	}
+}
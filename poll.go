@@ -108,6 +108,9 @@ func (p *pollTask) Do(ctx context.Context) error {
 // Only apply this option when the predicate is built from a function.
 // See [PollFunction].
 //
+// To bound the size of the value returned by the predicate, wrap res with
+// [LimitResultSize].
+//
 // [page.waitForFunction]: https://github.com/puppeteer/puppeteer/blob/v8.0.0/docs/api.md#pagewaitforfunctionpagefunction-options-args
 func Poll(expression string, res interface{}, opts ...PollOption) PollAction {
 	predicate := fmt.Sprintf(`return (%s);`, expression)
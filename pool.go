@@ -0,0 +1,353 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/storage"
+)
+
+// DefaultPoolRecycleTimeout is how long a Pool has to recycle a tab, once its
+// job is done, when PoolRecycleTimeout isn't set.
+const DefaultPoolRecycleTimeout = 10 * time.Second
+
+// errTabCrashed is the error recycling a tab of a Pool which crashed.
+var errTabCrashed = errors.New("tab crashed")
+
+// Pool is a fixed set of pre-warmed tabs, or of browsers, which run the jobs
+// given to Do one at a time each, so that at most as many jobs run at once as
+// there are tabs. The tabs are recycled between the jobs, and replaced once
+// they crash. It's safe for concurrent use.
+type Pool struct {
+	allocCtx       context.Context
+	size           int
+	perBrowser     bool
+	healthCheck    Action
+	recycleTimeout time.Duration
+
+	// idle holds the tabs waiting for a job; a tab whose ctx is nil is to be
+	// opened by the next job.
+	idle chan *poolTab
+
+	// ctx is cancelled by Close, which cancels the running jobs.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu guards closed, so that no job starts once Close waits for the
+	// running ones, and the shared browser.
+	mu            sync.Mutex
+	closed        bool
+	jobs          sync.WaitGroup
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+}
+
+// poolTab is a tab of a Pool.
+type poolTab struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	crashed atomic.Bool
+
+	// origins holds the security origins of the documents loaded by the
+	// tab, whose storage is cleared when it's recycled.
+	originsMu sync.Mutex
+	origins   map[string]bool
+}
+
+// PoolOption is a Pool option.
+type PoolOption = func(*Pool)
+
+// PoolSize is a Pool option to set the number of tabs of the pool, which is
+// the number of jobs it runs at once. It's 1 by default.
+func PoolSize(n int) PoolOption {
+	return func(p *Pool) {
+		if n > 0 {
+			p.size = n
+		}
+	}
+}
+
+// PoolBrowsers is a Pool option to run each job in a browser of its own,
+// allocated by the allocator of the pool, instead of in a tab of a browser
+// shared by the jobs.
+func PoolBrowsers() PoolOption {
+	return func(p *Pool) { p.perBrowser = true }
+}
+
+// PoolHealthCheck is a Pool option to run check on the tabs once they're
+// recycled, such as to check that the page can reach a server. The tabs for
+// which check fails are replaced by new ones.
+func PoolHealthCheck(check Action) PoolOption {
+	return func(p *Pool) { p.healthCheck = check }
+}
+
+// PoolRecycleTimeout is a Pool option to set how long a tab has to be recycled
+// once its job is done, including the health check, before it's replaced by a
+// new one. It's DefaultPoolRecycleTimeout by default.
+func PoolRecycleTimeout(d time.Duration) PoolOption {
+	return func(p *Pool) { p.recycleTimeout = d }
+}
+
+// NewPool creates a Pool of tabs allocated by the allocator of allocCtx, such
+// as the one set up by NewExecAllocator, and opens them. Each tab runs in a
+// BrowserContext of its own, so that the concurrent jobs don't share their
+// cookies. Example:
+//
+//	pool, err := chromedp.NewPool(allocCtx, chromedp.PoolSize(8))
+//	if err != nil {
+//		// handle error
+//	}
+//	defer pool.Close()
+//	err = pool.Do(ctx, func(ctx context.Context) error {
+//		return chromedp.Run(ctx, chromedp.Navigate(urlstr), chromedp.Title(&title))
+//	})
+func NewPool(allocCtx context.Context, opts ...PoolOption) (*Pool, error) {
+	p := &Pool{
+		allocCtx:       allocCtx,
+		size:           1,
+		recycleTimeout: DefaultPoolRecycleTimeout,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.idle = make(chan *poolTab, p.size)
+	var err error
+	for i := 0; i < p.size; i++ {
+		t := new(poolTab)
+		if err == nil {
+			err = p.open(t)
+		}
+		p.idle <- t
+	}
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Do runs fn with the context of an idle tab of the pool, waiting for one if
+// they're all busy, and recycles the tab once fn returns. The context of the
+// tab is cancelled when ctx is done, or when the pool is closed, which only
+// interrupts the actions of fn running at the time; the tab isn't closed.
+//
+// The tab is recycled in the background: its page is navigated to
+// about:blank, and its cookies and the storage of the origins it loaded are
+// cleared. Do fails with ErrPoolClosed once the pool is closed.
+func (p *Pool) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	t, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	var jctx context.Context
+	var cancel context.CancelFunc
+	if deadline, ok := ctx.Deadline(); ok {
+		jctx, cancel = context.WithDeadline(t.ctx, deadline)
+	} else {
+		jctx, cancel = context.WithCancel(t.ctx)
+	}
+	stop := context.AfterFunc(ctx, cancel)
+	stopClose := context.AfterFunc(p.ctx, cancel)
+	defer func() {
+		stop()
+		stopClose()
+		cancel()
+		go p.release(t)
+	}()
+	return fn(jctx)
+}
+
+// Close closes the pool, cancelling the running jobs, and waits for their tabs
+// to be closed.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.cancel()
+	p.mu.Unlock()
+
+	p.jobs.Wait()
+	var err error
+	for i := 0; i < p.size; i++ {
+		t := <-p.idle
+		if t.ctx != nil {
+			if cerr := Cancel(t.ctx); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if p.browserCtx != nil {
+		if cerr := Cancel(p.browserCtx); err == nil {
+			err = cerr
+		}
+		p.browserCancel()
+	}
+	return err
+}
+
+// acquire waits for an idle tab, opening it if needed, and registers its job.
+func (p *Pool) acquire(ctx context.Context) (*poolTab, error) {
+	var t *poolTab
+	select {
+	case <-p.ctx.Done():
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case t = <-p.idle:
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.idle <- t
+		return nil, ErrPoolClosed
+	}
+	p.jobs.Add(1)
+	p.mu.Unlock()
+
+	if t.ctx == nil {
+		// the tab was evicted, and couldn't be replaced then.
+		if err := p.open(t); err != nil {
+			p.idle <- t
+			p.jobs.Done()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// release recycles the tab t once its job is done, replacing it if it crashed
+// or if it couldn't be recycled, and makes it idle again.
+func (p *Pool) release(t *poolTab) {
+	defer p.jobs.Done()
+	// once the pool is closed, Close closes the tab.
+	if p.ctx.Err() == nil {
+		if err := p.recycle(t); err != nil && p.ctx.Err() == nil {
+			p.evict(t)
+			// the tab is opened by the next job if this fails.
+			p.open(t)
+		}
+	}
+	p.idle <- t
+}
+
+// recycle resets the tab t for the next job, and checks its health.
+func (p *Pool) recycle(t *poolTab) error {
+	if t.crashed.Load() {
+		return errTabCrashed
+	}
+	ctx := t.ctx
+	if p.recycleTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.recycleTimeout)
+		defer cancel()
+	}
+	t.originsMu.Lock()
+	origins := t.origins
+	t.origins = nil
+	t.originsMu.Unlock()
+	return Run(ctx, ActionFunc(func(ctx context.Context) error {
+		// stop the navigation an interrupted job may have left.
+		if err := page.StopLoading().Do(ctx); err != nil {
+			return err
+		}
+		if err := Navigate("about:blank").Do(ctx); err != nil {
+			return err
+		}
+		if err := ClearCookies().Do(ctx); err != nil {
+			return err
+		}
+		for origin := range origins {
+			if err := storage.ClearDataForOrigin(origin, "all").Do(ctx); err != nil {
+				return err
+			}
+		}
+		if p.healthCheck != nil {
+			if err := p.healthCheck.Do(ctx); err != nil {
+				return err
+			}
+		}
+		if t.crashed.Load() {
+			return errTabCrashed
+		}
+		return nil
+	}))
+}
+
+// open opens a new tab for t, in the shared browser of the pool, or in a new
+// browser with PoolBrowsers.
+func (p *Pool) open(t *poolTab) error {
+	parent := p.allocCtx
+	var opts []ContextOption
+	if !p.perBrowser {
+		var err error
+		if parent, err = p.sharedBrowser(); err != nil {
+			return err
+		}
+		opts = append(opts, WithNewBrowserContext())
+	}
+	ctx, cancel := NewContext(parent, opts...)
+	ListenTarget(ctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *inspector.EventTargetCrashed:
+			t.crashed.Store(true)
+		case *page.EventFrameNavigated:
+			if origin := ev.Frame.SecurityOrigin; origin != "" && origin != "null" && origin != "://" {
+				t.originsMu.Lock()
+				if t.origins == nil {
+					t.origins = make(map[string]bool)
+				}
+				t.origins[origin] = true
+				t.originsMu.Unlock()
+			}
+		}
+	})
+	if err := Run(ctx); err != nil {
+		cancel()
+		return err
+	}
+	t.ctx, t.cancel = ctx, cancel
+	t.crashed.Store(false)
+	return nil
+}
+
+// evict closes the tab t, such as once it crashed.
+func (p *Pool) evict(t *poolTab) {
+	if err := Cancel(t.ctx); err != nil {
+		// the browser may be gone already.
+		t.cancel()
+	}
+	t.ctx, t.cancel = nil, nil
+	t.originsMu.Lock()
+	t.origins = nil
+	t.originsMu.Unlock()
+}
+
+// sharedBrowser returns the context of the browser shared by the tabs of the
+// pool, allocating it, or allocating a new one if it's gone.
+func (p *Pool) sharedBrowser() (context.Context, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.browserCtx != nil && p.browserCtx.Err() == nil {
+		return p.browserCtx, nil
+	}
+	if p.browserCancel != nil {
+		p.browserCancel()
+	}
+	ctx, cancel := NewContext(p.allocCtx)
+	if err := Run(ctx); err != nil {
+		cancel()
+		p.browserCtx, p.browserCancel = nil, nil
+		return nil, err
+	}
+	p.browserCtx, p.browserCancel = ctx, cancel
+	return ctx, nil
+}
@@ -0,0 +1,168 @@
+package chromedp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+)
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>pool</body></html>`)
+	}))
+	defer s.Close()
+
+	pool, err := NewPool(allocCtx, PoolSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	var running, maxRunning atomic.Int32
+	var mu sync.Mutex
+	targets := make(map[target.ID]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := pool.Do(context.Background(), func(ctx context.Context) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					max := maxRunning.Load()
+					if n <= max || maxRunning.CompareAndSwap(max, n) {
+						break
+					}
+				}
+				mu.Lock()
+				targets[FromContext(ctx).Target.TargetID] = true
+				mu.Unlock()
+
+				var cookie, item string
+				if err := Run(ctx,
+					Navigate(s.URL),
+					Evaluate(`document.cookie`, &cookie),
+					Evaluate(`localStorage.getItem("job") || ""`, &item),
+				); err != nil {
+					return err
+				}
+				if cookie != "" || item != "" {
+					return fmt.Errorf("want a recycled tab, got the cookie %q and the item %q", cookie, item)
+				}
+				return Run(ctx,
+					Evaluate(`document.cookie = "job=1"; localStorage.setItem("job", "1")`, nil),
+					Sleep(50*time.Millisecond),
+				)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := maxRunning.Load(); n != 2 {
+		t.Errorf("want 2 jobs at once, got %d", n)
+	}
+	if len(targets) != 2 {
+		t.Errorf("want the 2 tabs to be reused, got %d", len(targets))
+	}
+}
+
+func TestPoolCrashedTab(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(allocCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	var crashedID target.ID
+	if err := pool.Do(context.Background(), func(ctx context.Context) error {
+		crashedID = FromContext(ctx).Target.TargetID
+		cctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(cctx, func(ev interface{}) {
+			if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+				cancel()
+			}
+		})
+		// the tab crashes, so the command never replies.
+		Run(cctx, page.Crash())
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pool.Do(context.Background(), func(ctx context.Context) error {
+		if id := FromContext(ctx).Target.TargetID; id == crashedID {
+			return errors.New("want the crashed tab to be replaced")
+		}
+		return Run(ctx, Navigate(testdataDir+"/form.html"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	t.Parallel()
+
+	pool, err := NewPool(allocCtx, PoolBrowsers())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cancelling the job's context doesn't close its tab.
+	var id target.ID
+	ctx, cancel := context.WithCancel(context.Background())
+	err = pool.Do(ctx, func(ctx context.Context) error {
+		id = FromContext(ctx).Target.TargetID
+		cancel()
+		return Run(ctx, Navigate(testdataDir+"/form.html"))
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want the job to be cancelled, got %v", err)
+	}
+	if err := pool.Do(context.Background(), func(ctx context.Context) error {
+		if FromContext(ctx).Target.TargetID != id {
+			return errors.New("want the tab to be reused")
+		}
+		return Run(ctx, Navigate(testdataDir+"/form.html"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// closing the pool cancels the running jobs.
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Do(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	<-started
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("want the running job to be cancelled, got %v", err)
+	}
+	if err := pool.Do(context.Background(), func(ctx context.Context) error { return nil }); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("want ErrPoolClosed, got %v", err)
+	}
+}
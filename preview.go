@@ -0,0 +1,193 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+)
+
+// PreviewHandler returns an http.Handler serving a live MJPEG stream of the
+// target associated with ctx, built from the frames of Page.startScreencast.
+//
+// It allows watching what a headless browser is doing from any regular
+// browser, by opening the URL of the handler or by using it as the src of an
+// <img> element:
+//
+//	http.Handle("/preview", chromedp.PreviewHandler(ctx))
+//
+// The screencast is started when the first client connects, and stopped once
+// the last one disconnects. The stream of each client ends when ctx is done.
+func PreviewHandler(ctx context.Context) http.Handler {
+	return &previewHandler{
+		ctx:     ctx,
+		clients: make(map[chan []byte]struct{}),
+	}
+}
+
+type previewHandler struct {
+	ctx context.Context
+
+	// mu guards the starting and stopping of the screencast.
+	mu     sync.Mutex
+	cancel context.CancelFunc
+
+	// clientsMu guards clients. It's separate from mu, since it's used by
+	// the event listener, which must never wait on a CDP command.
+	clientsMu sync.Mutex
+	clients   map[chan []byte]struct{}
+}
+
+func (h *previewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	frames := make(chan []byte, 1)
+	if err := h.subscribe(frames); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer h.unsubscribe(frames)
+
+	// The screencast only sends frames when the page is repainted, so start
+	// with a screenshot of the current state, taken before the response is
+	// started, so that its error can still be sent.
+	var frame []byte
+	if err := Run(h.ctx, ActionFunc(func(ctx context.Context) error {
+		// Stop waiting for the screenshot once the client is gone.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(r.Context(), cancel)
+		defer stop()
+		var err error
+		frame, err = page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormatJpeg).Do(ctx)
+		return err
+	})); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mw.Boundary())
+	w.Header().Set("Cache-Control", "no-cache")
+	rc := http.NewResponseController(w)
+	writeFrame := func(frame []byte) error {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":   {"image/jpeg"},
+			"Content-Length": {strconv.Itoa(len(frame))},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write(frame); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	if err := writeFrame(frame); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.ctx.Done():
+			return
+		case frame := <-frames:
+			if err := writeFrame(frame); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribe adds frames to the clients receiving the screencast frames,
+// starting the screencast if needed.
+func (h *previewHandler) subscribe(frames chan []byte) error {
+	h.clientsMu.Lock()
+	h.clients[frames] = struct{}{}
+	h.clientsMu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		return nil
+	}
+	lctx, cancel := context.WithCancel(h.ctx)
+	if err := Run(lctx, ActionFunc(func(ctx context.Context) error {
+		ListenTarget(lctx, h.onEvent(lctx))
+		return page.StartScreencast().WithFormat(page.ScreencastFormatJpeg).Do(ctx)
+	})); err != nil {
+		cancel()
+		h.clientsMu.Lock()
+		delete(h.clients, frames)
+		h.clientsMu.Unlock()
+		return err
+	}
+	h.cancel = cancel
+	return nil
+}
+
+// unsubscribe removes frames from the clients receiving the screencast
+// frames, stopping the screencast if it was the last one.
+func (h *previewHandler) unsubscribe(frames chan []byte) {
+	h.clientsMu.Lock()
+	delete(h.clients, frames)
+	last := len(h.clients) == 0
+	h.clientsMu.Unlock()
+	if !last {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clientsMu.Lock()
+	last = len(h.clients) == 0
+	h.clientsMu.Unlock()
+	if !last || h.cancel == nil {
+		return
+	}
+	// Ignore the error, as the target might already be gone.
+	_ = Run(h.ctx, page.StopScreencast())
+	h.cancel()
+	h.cancel = nil
+}
+
+// onEvent returns the listener forwarding the screencast frames to the
+// clients. A slow client only gets the latest frame, instead of blocking the
+// others.
+func (h *previewHandler) onEvent(ctx context.Context) func(ev interface{}) {
+	return func(ev interface{}) {
+		ev2, ok := ev.(*page.EventScreencastFrame)
+		if !ok {
+			return
+		}
+		// The next frame is only sent once this one is acknowledged. This
+		// must not block the listener, as it runs on the target's event
+		// loop.
+		go func() {
+			c := FromContext(ctx)
+			_ = page.ScreencastFrameAck(ev2.SessionID).Do(cdp.WithExecutor(ctx, c.Target))
+		}()
+
+		frame, err := base64.StdEncoding.DecodeString(ev2.Data)
+		if err != nil {
+			return
+		}
+		h.clientsMu.Lock()
+		defer h.clientsMu.Unlock()
+		for frames := range h.clients {
+			select {
+			case <-frames:
+			default:
+			}
+			select {
+			case frames <- frame:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package chromedp
+
+import (
+	"bytes"
+	"image/jpeg"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreviewHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	s := httptest.NewServer(PreviewHandler(ctx))
+	defer s.Close()
+
+	res, err := http.Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "multipart/x-mixed-replace"; mediaType != want {
+		t.Fatalf("want media type %q, got %q", want, mediaType)
+	}
+	mr := multipart.NewReader(res.Body, params["boundary"])
+	for i := 0; i < 2; i++ {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "image/jpeg"; part.Header.Get("Content-Type") != want {
+			t.Fatalf("want part type %q, got %q", want, part.Header.Get("Content-Type"))
+		}
+		var buf bytes.Buffer
+		if _, err := buf.ReadFrom(part); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := jpeg.Decode(&buf); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if i == 0 {
+			// Trigger a new frame.
+			if err := Run(ctx, Evaluate(`document.body.style.background = 'red'`, nil)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package chromedp
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ProcessLogLine is a line of the output of a browser process, as passed to
+// the sink set up by WithProcessLogSink.
+//
+// The fields other than Raw and Message are only set for the lines logged by
+// the browser in its usual format, such as:
+//
+//	[1234:1250:1014/101530.123456:ERROR:gpu_process_host.cc(991)] GPU process exited unexpectedly
+type ProcessLogLine struct {
+	// Raw is the line, without its line ending.
+	Raw string
+	// PID and TID are the ids of the process and thread which logged the
+	// line, if logged.
+	PID int
+	TID int
+	// Time is the time the line was logged at, in local time. The log
+	// lines have no year, so the current one is used.
+	Time time.Time
+	// Severity is the severity of the line, such as "INFO", "WARNING",
+	// "ERROR", "FATAL" or "VERBOSE1".
+	Severity string
+	// Component is the source file which logged the line, such as
+	// "gpu_process_host.cc", and Line its line number.
+	Component string
+	Line      int
+	// Message is the logged message, which is Raw for the other lines.
+	Message string
+}
+
+// WithProcessLogSink is the option to call sink with each line of the stdout
+// and stderr of the browser, parsed as a ProcessLogLine. It can be used to
+// route the browser errors, such as GPU process crashes or sandbox errors, to
+// a logging system.
+//
+// sink is called from a single goroutine, in the order of the lines. It can be
+// used together with CombinedOutput.
+func WithProcessLogSink(sink func(line ProcessLogLine)) ExecAllocatorOption {
+	return func(a *ExecAllocator) {
+		a.processLogSink = sink
+	}
+}
+
+// outputWriter returns the writer the output of the browser is forwarded to,
+// which is nil if the output is not needed, and a func to flush it once the
+// output ends.
+func (a *ExecAllocator) outputWriter() (io.Writer, func()) {
+	if a.processLogSink == nil {
+		return a.combinedOutputWriter, func() {}
+	}
+	lw := &processLogWriter{sink: a.processLogSink}
+	if a.combinedOutputWriter == nil {
+		return lw, lw.flush
+	}
+	return io.MultiWriter(a.combinedOutputWriter, lw), lw.flush
+}
+
+// processLogWriter is a writer splitting its input in lines for a
+// WithProcessLogSink sink.
+type processLogWriter struct {
+	sink func(ProcessLogLine)
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *processLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.sink(parseProcessLogLine(string(bytes.TrimRight(w.buf[:i], "\r")), time.Now()))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush passes the last line to the sink, if it has no line ending.
+func (w *processLogWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.sink(parseProcessLogLine(string(bytes.TrimRight(w.buf, "\r")), time.Now()))
+		w.buf = nil
+	}
+}
+
+// processLogRegexp matches the prefix of the lines logged by the browser, with
+// the optional process and thread ids, and the date and time.
+var processLogRegexp = regexp.MustCompile(`^\[(?:(\d+):(\d+):)?(\d{4}/\d{6}(?:\.\d+)?):([A-Z]+\d*):([^\]()]+)\((\d+)\)\] ?`)
+
+// parseProcessLogLine parses a line of the output of the browser, logged at
+// the time now.
+func parseProcessLogLine(raw string, now time.Time) ProcessLogLine {
+	l := ProcessLogLine{Raw: raw, Message: raw}
+	m := processLogRegexp.FindStringSubmatch(raw)
+	if m == nil {
+		return l
+	}
+	l.PID, _ = strconv.Atoi(m[1])
+	l.TID, _ = strconv.Atoi(m[2])
+	if t, err := time.ParseInLocation("0102/150405.999999999", m[3], now.Location()); err == nil {
+		l.Time = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), now.Location())
+		if l.Time.After(now.Add(24 * time.Hour)) {
+			// logged before the new year.
+			l.Time = l.Time.AddDate(-1, 0, 0)
+		}
+	}
+	l.Severity = m[4]
+	l.Component = m[5]
+	l.Line, _ = strconv.Atoi(m[6])
+	l.Message = raw[len(m[0]):]
+	return l
+}
@@ -0,0 +1,113 @@
+package chromedp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseProcessLogLine(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		raw  string
+		want ProcessLogLine
+	}{
+		{
+			"[1234:1250:0101/101530.123456:ERROR:gpu_process_host.cc(991)] GPU process exited unexpectedly: exit_code=139",
+			ProcessLogLine{
+				PID:       1234,
+				TID:       1250,
+				Time:      time.Date(2024, 1, 1, 10, 15, 30, 123456000, time.UTC),
+				Severity:  "ERROR",
+				Component: "gpu_process_host.cc",
+				Line:      991,
+				Message:   "GPU process exited unexpectedly: exit_code=139",
+			},
+		},
+		{
+			// logged in the previous year.
+			"[1231/235959.5:VERBOSE1:zygote_host_impl_linux.cc(126)] No usable sandbox!",
+			ProcessLogLine{
+				Time:      time.Date(2023, 12, 31, 23, 59, 59, 500000000, time.UTC),
+				Severity:  "VERBOSE1",
+				Component: "zygote_host_impl_linux.cc",
+				Line:      126,
+				Message:   "No usable sandbox!",
+			},
+		},
+		{
+			"DevTools listening on ws://127.0.0.1:9222/devtools/browser/x",
+			ProcessLogLine{Message: "DevTools listening on ws://127.0.0.1:9222/devtools/browser/x"},
+		},
+	}
+	for _, test := range tests {
+		test.want.Raw = test.raw
+		if got := parseProcessLogLine(test.raw, now); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseProcessLogLine(%q):\nwant %+v\ngot  %+v", test.raw, test.want, got)
+		}
+	}
+}
+
+func TestProcessLogWriter(t *testing.T) {
+	t.Parallel()
+
+	var msgs []string
+	w := &processLogWriter{sink: func(l ProcessLogLine) {
+		msgs = append(msgs, l.Severity+" "+l.Message)
+	}}
+	for _, s := range []string{
+		"[1:2:0101/000000.000001:WARNING:a.cc(1)] fir",
+		"st\r\n[0101/000000:INFO:b.cc(2)] second\nthi",
+		"rd",
+	} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.flush()
+	want := []string{"WARNING first", "INFO second", " third"}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("want lines %q, got %q", want, msgs)
+	}
+}
+
+func TestWithProcessLogSink(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var lines []ProcessLogLine
+	var out strings.Builder
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)],
+			CombinedOutput(&out),
+			WithProcessLogSink(func(l ProcessLogLine) {
+				mu.Lock()
+				lines = append(lines, l)
+				mu.Unlock()
+			}))...)
+	ctx, _ := NewContext(allocCtx)
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	found := false
+	for _, l := range lines {
+		if strings.HasPrefix(l.Message, "DevTools listening on ws://") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("want the DevTools line in the lines, got %+v", lines)
+	}
+	if !strings.Contains(out.String(), "DevTools listening on") {
+		t.Errorf("want the output to be written to CombinedOutput too, got %q", out.String())
+	}
+}
@@ -0,0 +1,318 @@
+package chromedp
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"time"
+
+	"github.com/chromedp/cdproto/profiler"
+)
+
+// JSProfileOption is a StartJSProfile option.
+type JSProfileOption = func(interval *time.Duration)
+
+// WithSamplingInterval is a StartJSProfile option to sample the JavaScript
+// call stacks every interval, instead of the default of the browser, which is
+// 1ms by default.
+func WithSamplingInterval(interval time.Duration) JSProfileOption {
+	return func(i *time.Duration) { *i = interval }
+}
+
+// StartJSProfile is an action that starts the sampling CPU profiler of the
+// JavaScript of the page, until StopJSProfile is run. Example:
+//
+//	var prof *profiler.Profile
+//	err := chromedp.Run(ctx,
+//		chromedp.StartJSProfile(),
+//		chromedp.Click("#render", chromedp.ByQuery),
+//		chromedp.StopJSProfile(&prof),
+//	)
+//	if err != nil {
+//		// handle error
+//	}
+//	f, err := os.Create("render.pb.gz")
+//	if err != nil {
+//		// handle error
+//	}
+//	defer f.Close()
+//	err = chromedp.WriteJSProfile(f, prof)
+//
+// The saved profile can then be analyzed with "go tool pprof render.pb.gz".
+func StartJSProfile(opts ...JSProfileOption) Action {
+	var interval time.Duration
+	for _, o := range opts {
+		o(&interval)
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		if err := profiler.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if interval > 0 {
+			us := interval.Microseconds()
+			if us < 1 {
+				us = 1
+			}
+			if err := profiler.SetSamplingInterval(us).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return profiler.Start().Do(ctx)
+	})
+}
+
+// StopJSProfile is an action that stops the profiler started by
+// StartJSProfile, and stores the profile in profile. See WriteJSProfile to
+// write it in the pprof format.
+func StopJSProfile(profile **profiler.Profile) Action {
+	if profile == nil {
+		panic("profile cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		p, err := profiler.Stop().Do(ctx)
+		if err != nil {
+			return err
+		}
+		*profile = p
+		return profiler.Disable().Do(ctx)
+	})
+}
+
+// WriteJSProfile writes the JavaScript CPU profile p, as stored by
+// StopJSProfile, to w in the gzipped protocol buffer format of pprof, with the
+// number of samples and the CPU time of each call stack.
+//
+// The functions are named after their JavaScript names, or "(anonymous)", and
+// are located in the files of their scripts' URLs. The samples of the idle
+// browser, which aren't CPU use, are left out; those of the browser itself,
+// such as of its garbage collector, are kept under names such as
+// "(garbage collector)" and "(program)".
+func WriteJSProfile(w io.Writer, p *profiler.Profile) error {
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(jsProfileToPprof(p)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// jsProfileToPprof converts the JavaScript CPU profile p to an uncompressed
+// pprof profile.
+//
+// See https://github.com/google/pprof/blob/main/proto/profile.proto.
+func jsProfileToPprof(p *profiler.Profile) []byte {
+	strs := newPprofStrings()
+	nodes := make(map[int64]*profiler.ProfileNode, len(p.Nodes))
+	parents := make(map[int64]int64, len(p.Nodes))
+	for _, n := range p.Nodes {
+		nodes[n.ID] = n
+		for _, c := range n.Children {
+			parents[c] = n.ID
+		}
+	}
+
+	// the duration of a sample lasts until the next one; the last one takes
+	// the mean duration.
+	var period int64
+	if len(p.Samples) > 0 {
+		period = int64(p.EndTime-p.StartTime) * 1000 / int64(len(p.Samples))
+	}
+	counts := make(map[int64]int64)
+	durations := make(map[int64]int64)
+	for i, id := range p.Samples {
+		d := period
+		if i+1 < len(p.TimeDeltas) {
+			d = p.TimeDeltas[i+1] * 1000
+		}
+		counts[id]++
+		durations[id] += d
+	}
+	if len(p.Samples) == 0 {
+		// the profiles of the old browsers only have the hit counts.
+		for _, n := range p.Nodes {
+			counts[n.ID] = n.HitCount
+		}
+	}
+
+	var root int64
+	if len(p.Nodes) > 0 {
+		root = p.Nodes[0].ID
+	}
+	type funcKey struct {
+		name, url string
+		line      int64
+	}
+	funcs := make(map[funcKey]uint64)
+	var funcBuf, locBuf, sampleBuf pprofBuffer
+	// locations maps the node ids to the ids of their locations, as each
+	// node is a distinct call site.
+	locations := make(map[int64]uint64)
+	location := func(n *profiler.ProfileNode) uint64 {
+		if id, ok := locations[n.ID]; ok {
+			return id
+		}
+		cf := n.CallFrame
+		name := cf.FunctionName
+		if name == "" {
+			name = "(anonymous)"
+		}
+		k := funcKey{name, cf.URL, cf.LineNumber}
+		fid, ok := funcs[k]
+		if !ok {
+			fid = uint64(len(funcs) + 1)
+			funcs[k] = fid
+			var f pprofBuffer
+			f.uint64(1, fid)
+			f.int64(2, strs.index(name))
+			f.int64(3, strs.index(name))
+			f.int64(4, strs.index(cf.URL))
+			f.int64(5, cf.LineNumber+1)
+			funcBuf.message(5, &f)
+		}
+		id := uint64(len(locations) + 1)
+		locations[n.ID] = id
+		var line pprofBuffer
+		line.uint64(1, fid)
+		line.int64(2, cf.LineNumber+1)
+		line.int64(3, cf.ColumnNumber+1)
+		var loc pprofBuffer
+		loc.uint64(1, id)
+		loc.message(4, &line)
+		locBuf.message(4, &loc)
+		return id
+	}
+
+	for _, n := range p.Nodes {
+		count := counts[n.ID]
+		if count == 0 || n.ID == root || n.CallFrame.FunctionName == "(idle)" {
+			continue
+		}
+		// the call stack, from the leaf to the root, which is left out.
+		var stack []uint64
+		for id := n.ID; id != root; {
+			node := nodes[id]
+			if node == nil {
+				break
+			}
+			stack = append(stack, location(node))
+			parent, ok := parents[id]
+			if !ok {
+				break
+			}
+			id = parent
+		}
+		dur := durations[n.ID]
+		if len(p.Samples) == 0 {
+			dur = count * period
+		}
+		var s pprofBuffer
+		s.packedUint64(1, stack)
+		s.packedInt64(2, []int64{count, dur})
+		sampleBuf.message(2, &s)
+	}
+
+	var b pprofBuffer
+	for _, t := range [][2]string{{"samples", "count"}, {"cpu", "nanoseconds"}} {
+		var vt pprofBuffer
+		vt.int64(1, strs.index(t[0]))
+		vt.int64(2, strs.index(t[1]))
+		b.message(1, &vt)
+	}
+	b.buf = append(b.buf, sampleBuf.buf...)
+	b.buf = append(b.buf, locBuf.buf...)
+	b.buf = append(b.buf, funcBuf.buf...)
+	var pt pprofBuffer
+	pt.int64(1, strs.index("cpu"))
+	pt.int64(2, strs.index("nanoseconds"))
+	for _, s := range strs.list {
+		b.string(6, s)
+	}
+	b.int64(10, int64(p.EndTime-p.StartTime)*1000)
+	b.message(11, &pt)
+	b.int64(12, period)
+	return b.buf
+}
+
+// pprofStrings is the string table of a pprof profile, whose first string is
+// the empty one.
+type pprofStrings struct {
+	list []string
+	m    map[string]int64
+}
+
+func newPprofStrings() *pprofStrings {
+	return &pprofStrings{list: []string{""}, m: map[string]int64{"": 0}}
+}
+
+// index returns the index of s, which is added if needed.
+func (t *pprofStrings) index(s string) int64 {
+	if i, ok := t.m[s]; ok {
+		return i
+	}
+	i := int64(len(t.list))
+	t.list = append(t.list, s)
+	t.m[s] = i
+	return i
+}
+
+// pprofBuffer encodes a protocol buffer message, as needed by the pprof
+// format.
+type pprofBuffer struct {
+	buf []byte
+}
+
+func (b *pprofBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.buf = append(b.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	b.buf = append(b.buf, byte(x))
+}
+
+// key encodes the key of the field with the number field and the wire type
+// typ.
+func (b *pprofBuffer) key(field int, typ uint64) {
+	b.varint(uint64(field)<<3 | typ)
+}
+
+func (b *pprofBuffer) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, 0)
+	b.varint(x)
+}
+
+func (b *pprofBuffer) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+func (b *pprofBuffer) bytes(field int, buf []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(buf)))
+	b.buf = append(b.buf, buf...)
+}
+
+// string encodes the string s, even when empty, as in the string tables.
+func (b *pprofBuffer) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+func (b *pprofBuffer) message(field int, m *pprofBuffer) {
+	b.bytes(field, m.buf)
+}
+
+func (b *pprofBuffer) packedUint64(field int, xs []uint64) {
+	var p pprofBuffer
+	for _, x := range xs {
+		p.varint(x)
+	}
+	b.bytes(field, p.buf)
+}
+
+func (b *pprofBuffer) packedInt64(field int, xs []int64) {
+	var p pprofBuffer
+	for _, x := range xs {
+		p.varint(uint64(x))
+	}
+	b.bytes(field, p.buf)
+}
@@ -0,0 +1,191 @@
+package chromedp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/profiler"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestJSProfile(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var prof *profiler.Profile
+	if err := Run(ctx,
+		StartJSProfile(WithSamplingInterval(100*time.Microsecond)),
+		Evaluate(`(function busyLoop() {
+			const end = performance.now() + 200;
+			let x = 0;
+			while (performance.now() < end) {
+				x += Math.sqrt(x + 1);
+			}
+			return x;
+		})()`, nil),
+		StopJSProfile(&prof),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var hits int
+	for _, id := range prof.Samples {
+		for _, n := range prof.Nodes {
+			if n.ID == id && n.CallFrame.FunctionName == "busyLoop" {
+				hits++
+			}
+		}
+	}
+	if hits == 0 {
+		t.Fatalf("no samples of busyLoop in %d samples", len(prof.Samples))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSProfile(&buf, prof); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte("busyLoop")) {
+		t.Error("want the busyLoop function in the pprof profile")
+	}
+}
+
+func TestJSProfileToPprof(t *testing.T) {
+	t.Parallel()
+
+	frame := func(name string, line int64) *runtime.CallFrame {
+		return &runtime.CallFrame{FunctionName: name, URL: "https://example.com/app.js", LineNumber: line}
+	}
+	p := &profiler.Profile{
+		Nodes: []*profiler.ProfileNode{
+			{ID: 1, CallFrame: frame("(root)", -1), Children: []int64{2, 4}},
+			{ID: 2, CallFrame: frame("", 0), Children: []int64{3}},
+			{ID: 3, CallFrame: frame("render", 9)},
+			{ID: 4, CallFrame: frame("(idle)", -1)},
+		},
+		StartTime:  1000,
+		EndTime:    5000,
+		Samples:    []int64{3, 3, 2, 4},
+		TimeDeltas: []int64{0, 1000, 1000, 1000},
+	}
+	prof := decodeProto(t, jsProfileToPprof(p))
+
+	var strs []string
+	for _, s := range prof[6] {
+		strs = append(strs, string(s.([]byte)))
+	}
+	str := func(v interface{}) string { return strs[v.(uint64)] }
+	if strs[0] != "" {
+		t.Errorf("want the empty string first, got %q", strs[0])
+	}
+
+	var types [][2]string
+	for _, vt := range prof[1] {
+		m := decodeProto(t, vt.([]byte))
+		types = append(types, [2]string{str(m[1][0]), str(m[2][0])})
+	}
+	if want := [][2]string{{"samples", "count"}, {"cpu", "nanoseconds"}}; !reflect.DeepEqual(types, want) {
+		t.Errorf("want the sample types %q, got %q", want, types)
+	}
+
+	funcs := make(map[uint64]string)
+	for _, f := range prof[5] {
+		m := decodeProto(t, f.([]byte))
+		funcs[m[1][0].(uint64)] = str(m[2][0])
+		if got := str(m[4][0]); got != "https://example.com/app.js" {
+			t.Errorf("want the file of the script, got %q", got)
+		}
+	}
+	locs := make(map[uint64]string)
+	for _, l := range prof[4] {
+		m := decodeProto(t, l.([]byte))
+		line := decodeProto(t, m[4][0].([]byte))
+		locs[m[1][0].(uint64)] = funcs[line[1][0].(uint64)]
+	}
+
+	got := make(map[string][]uint64)
+	for _, s := range prof[2] {
+		m := decodeProto(t, s.([]byte))
+		var stack string
+		for _, id := range decodeVarints(t, m[1][0].([]byte)) {
+			stack += locs[id] + ";"
+		}
+		got[stack] = decodeVarints(t, m[2][0].([]byte))
+	}
+	// the last sample takes the mean duration of 1ms, and the idle one is
+	// left out.
+	want := map[string][]uint64{
+		"render;(anonymous);": {2, 2000000},
+		"(anonymous);":        {1, 1000000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want the samples %v, got %v", want, got)
+	}
+	if got := prof[10][0].(uint64); got != 4000000 {
+		t.Errorf("want the duration 4000000, got %d", got)
+	}
+	if got := prof[12][0].(uint64); got != 1000000 {
+		t.Errorf("want the period 1000000, got %d", got)
+	}
+}
+
+// decodeProto decodes the fields of the protocol buffer message buf, whose
+// values are the uint64 varints and the []byte length-delimited fields.
+func decodeProto(t *testing.T, buf []byte) map[int][]interface{} {
+	t.Helper()
+	m := make(map[int][]interface{})
+	for len(buf) > 0 {
+		key, n := decodeVarint(t, buf)
+		buf = buf[n:]
+		switch key & 7 {
+		case 0:
+			x, n := decodeVarint(t, buf)
+			buf = buf[n:]
+			m[int(key>>3)] = append(m[int(key>>3)], x)
+		case 2:
+			l, n := decodeVarint(t, buf)
+			buf = buf[n:]
+			m[int(key>>3)] = append(m[int(key>>3)], buf[:l])
+			buf = buf[l:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return m
+}
+
+func decodeVarints(t *testing.T, buf []byte) []uint64 {
+	t.Helper()
+	var xs []uint64
+	for len(buf) > 0 {
+		x, n := decodeVarint(t, buf)
+		xs = append(xs, x)
+		buf = buf[n:]
+	}
+	return xs
+}
+
+func decodeVarint(t *testing.T, buf []byte) (uint64, int) {
+	t.Helper()
+	var x uint64
+	for i, b := range buf {
+		x |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return x, i + 1
+		}
+	}
+	t.Fatal("truncated varint")
+	return 0, 0
+}
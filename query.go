@@ -2,12 +2,18 @@ package chromedp
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/chromedp/cdproto"
@@ -35,6 +41,37 @@ type Selector struct {
 	by            func(context.Context, *cdp.Node) ([]cdp.NodeID, error)
 	wait          func(context.Context, *cdp.Frame, runtime.ExecutionContextID, ...cdp.NodeID) ([]*cdp.Node, error)
 	after         []func(context.Context, runtime.ExecutionContextID, ...*cdp.Node) error
+
+	// collapseWhitespace is set up by CollapseWhitespace.
+	collapseWhitespace bool
+
+	// skipChecks holds the actionability checks skipped by NodeActionable,
+	// as set up by SkipVisibleCheck, SkipStableCheck, SkipHitTestCheck and
+	// SkipEnabledCheck.
+	skipChecks actionabilityCheck
+
+	// verifyClickTarget is set up by VerifyClickTarget.
+	verifyClickTarget bool
+
+	// scrollBlock, scrollInline and scrollMargin are set up by ScrollBlock,
+	// ScrollInline and WithScrollMargin.
+	scrollBlock  ScrollAlignment
+	scrollInline ScrollAlignment
+	scrollMargin float64
+
+	// noRequery is set up by NoRequery.
+	noRequery bool
+
+	// forAtLeast is set up by ForAtLeast.
+	forAtLeast time.Duration
+
+	// searchInShadow is set up by SearchInShadow.
+	searchInShadow bool
+
+	// filters are set up by Filter, and nth by Nth, if hasNth is set.
+	filters []func(*cdp.Node) bool
+	nth     int
+	hasNth  bool
 }
 
 // Query is a query action that queries the browser for specific element
@@ -75,9 +112,15 @@ type Selector struct {
 // specified condition is true. When not specified, queries will use the
 // [NodeReady] wait condition.
 //
+// The selector can also be a [LocatorChain], as built by [Locator], to chain
+// CSS selectors and indexes, in which case the By* options don't apply.
+//
 // The [AtLeast] option alters the minimum number of nodes that must be returned
 // by the element query. If not specified, the default value is 1.
 //
+// The [Filter] and [Nth] options select some of the elements returned by the
+// element query, before the node condition is waited for.
+//
 // The [After] option is used to specify a func that will be executed when
 // element query has returned one or more elements, and after the node condition is
 // true.
@@ -115,6 +158,11 @@ type Selector struct {
 // The [NodeVisible] option causes the query to wait until all element nodes
 // matching the selector have been retrieved from the browser, and are visible.
 //
+// The [NodeActionable] option causes the query to wait until all element nodes
+// matching the selector have been retrieved from the browser, and can be
+// interacted with: they are visible, stable, not covered by other elements,
+// and enabled. It's used by interaction actions, such as [Click].
+//
 // The [NodeNotVisible] option causes the query to wait until all element nodes
 // matching the selector have been retrieved from the browser, and are not
 // visible.
@@ -141,6 +189,9 @@ func Query(sel interface{}, opts ...QueryOption) QueryAction {
 		o(s)
 	}
 
+	if l, ok := sel.(*LocatorChain); ok {
+		ByFunc(l.query)(s)
+	}
 	if s.by == nil {
 		BySearch(s)
 	}
@@ -154,12 +205,33 @@ func Query(sel interface{}, opts ...QueryOption) QueryAction {
 
 // Do executes the selector, only finishing if the selector's by, wait, and
 // after funcs succeed, or if the context is cancelled.
+//
+// When an after func fails because a node was removed from the document since
+// it was queried, such as when a single-page application renders it again,
+// the query is run again once, unless the [NoRequery] option is used. The
+// after funcs then go on from the one which failed, with the new nodes; the
+// ones which succeeded aren't run again. An after func which already changed
+// the page isn't run again either, as told by any of its commands having
+// succeeded, so that a Click or a SendKeys never acts twice.
 func (s *Selector) Do(ctx context.Context) error {
 	t := cdp.ExecutorFromContext(ctx).(*Target)
 	if t == nil {
 		return ErrInvalidTarget
 	}
+	requeried := false
+	// next is the after func to go on from once the query is run again.
+	next := 0
+	// since is when the node condition was met, and kept being met since then,
+	// for ForAtLeast.
+	var since time.Time
+	ctx = context.WithValue(ctx, scrolledKey{}, new(sync.Map))
 	return retryWithSleep(ctx, s.retryInterval, func(ctx context.Context) (bool, error) {
+		met := false
+		defer func() {
+			if !met {
+				since = time.Time{}
+			}
+		}()
 		frame, root, execCtx, ok := t.ensureFrame()
 		if !ok {
 			return false, nil
@@ -204,6 +276,12 @@ func (s *Selector) Do(ctx context.Context) error {
 			}
 			return false, nil
 		}
+		if len(s.filters) > 0 || s.hasNth {
+			ids = s.pick(frame, ids)
+			if ids == nil {
+				return false, nil
+			}
+		}
 		if len(ids) < s.exp {
 			return false, nil
 		}
@@ -212,8 +290,23 @@ func (s *Selector) Do(ctx context.Context) error {
 		if nodes == nil || err != nil {
 			return false, nil
 		}
-		for _, f := range s.after {
-			if err := f(ctx, execCtx, nodes...); err != nil {
+		met = true
+		if s.forAtLeast > 0 {
+			if since.IsZero() {
+				since = time.Now()
+			}
+			if time.Since(since) < s.forAtLeast {
+				return false, nil
+			}
+		}
+		for i := next; i < len(s.after); i++ {
+			rec := &commandRecord{parent: commandRecordFrom(ctx)}
+			if err := s.after[i](context.WithValue(ctx, commandRecordKey{}, rec), execCtx, nodes...); err != nil {
+				if !s.noRequery && !requeried && !rec.succeeded.Load() && isStaleNodeError(err) {
+					requeried = true
+					next = i
+					return false, nil
+				}
 				return true, err
 			}
 		}
@@ -221,6 +314,85 @@ func (s *Selector) Do(ctx context.Context) error {
 	})
 }
 
+// commandRecordKey is the context key of the commandRecord of an after func.
+type commandRecordKey struct{}
+
+// commandRecord records whether a command sent by an after func of a query,
+// and of the queries it runs itself, succeeded, in which case it may have
+// changed the page, and isn't run again if the query is.
+type commandRecord struct {
+	succeeded atomic.Bool
+	parent    *commandRecord
+}
+
+// commandRecordFrom returns the commandRecord of ctx, if any.
+func commandRecordFrom(ctx context.Context) *commandRecord {
+	rec, _ := ctx.Value(commandRecordKey{}).(*commandRecord)
+	return rec
+}
+
+// recordCommand records that a command sent with ctx succeeded.
+func recordCommand(ctx context.Context) {
+	for rec := commandRecordFrom(ctx); rec != nil; rec = rec.parent {
+		rec.succeeded.Store(true)
+	}
+}
+
+// pick returns the queried ids kept by the Filter and Nth options, or nil if
+// the nodes aren't yet known of the frame.
+func (s *Selector) pick(frame *cdp.Frame, ids []cdp.NodeID) []cdp.NodeID {
+	if len(s.filters) > 0 {
+		nodes := make([]*cdp.Node, len(ids))
+		frame.RLock()
+		for i, id := range ids {
+			nodes[i] = frame.Nodes[id]
+		}
+		frame.RUnlock()
+		kept := make([]cdp.NodeID, 0, len(ids))
+	nodes:
+		for i, n := range nodes {
+			if n == nil {
+				// not yet ready
+				return nil
+			}
+			for _, f := range s.filters {
+				if !f(n) {
+					continue nodes
+				}
+			}
+			kept = append(kept, ids[i])
+		}
+		ids = kept
+	}
+	if s.hasNth {
+		i := s.nth
+		if i < 0 {
+			i += len(ids)
+		}
+		if i < 0 || i >= len(ids) {
+			return []cdp.NodeID{}
+		}
+		ids = []cdp.NodeID{ids[i]}
+	}
+	return ids
+}
+
+// staleNodeErrors are the messages of the protocol errors of the commands
+// run with the ID of a node which was removed from the document.
+var staleNodeErrors = []string{
+	"Could not find node with given id",
+	"No node with given id found",
+	"Node is detached from document",
+	"Node with given id does not belong to the document",
+}
+
+// isStaleNodeError reports whether err is the protocol error of a command run
+// with the ID of a node which was removed from the document.
+func isStaleNodeError(err error) bool {
+	var e *cdproto.Error
+	return errors.As(err, &e) && slices.Contains(staleNodeErrors, e.Message)
+}
+
 // selAsString forces sel into a string.
 func (s *Selector) selAsString() string {
 	if sel, ok := s.sel.(string); ok {
@@ -285,6 +457,9 @@ type QueryOption = func(*Selector)
 // is, the query will only look at the node's element sub-tree. By default, or
 // when passed nil, the document's root element will be used.
 //
+// For example, the rows of a table can be queried first, and then the cells
+// of each of them with ByQuery and FromNode(row).
+//
 // Note that, at present, BySearch and ByJSPath do not support FromNode; this
 // option is mainly useful for ByQuery selectors.
 func FromNode(node *cdp.Node) QueryOption {
@@ -339,7 +514,11 @@ func ByID(s *Selector) {
 // command. It matches nodes by plain text, CSS selector or XPath query.
 func BySearch(s *Selector) {
 	ByFunc(func(ctx context.Context, n *cdp.Node) ([]cdp.NodeID, error) {
-		id, count, err := dom.PerformSearch(s.selAsString()).Do(ctx)
+		p := dom.PerformSearch(s.selAsString())
+		if s.searchInShadow {
+			p = p.WithIncludeUserAgentShadowDOM(true)
+		}
+		id, count, err := p.Do(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -361,6 +540,16 @@ func BySearch(s *Selector) {
 	})(s)
 }
 
+// SearchInShadow is an element query option to also search the user-agent
+// shadow DOM with BySearch, if include is true, such as the internal elements
+// of the input controls, or of the media elements. The shadow trees of the
+// page itself are always searched.
+func SearchInShadow(include bool) QueryOption {
+	return func(s *Selector) {
+		s.searchInShadow = include
+	}
+}
+
 // ByJSPath is an element query option to select elements by the "JS Path"
 // value (as shown in the Chrome DevTools UI).
 //
@@ -437,13 +626,23 @@ func NodeReady(s *Selector) {
 }
 
 func callFunctionOnNode(ctx context.Context, node *cdp.Node, function string, res interface{}, args ...interface{}) error {
+	return callFunctionOnNodeWith(ctx, node, function, false, res, args...)
+}
+
+// awaitFunctionOnNode is like callFunctionOnNode, but for a function returning
+// a promise, whose result is placed in res once it is resolved.
+func awaitFunctionOnNode(ctx context.Context, node *cdp.Node, function string, res interface{}, args ...interface{}) error {
+	return callFunctionOnNodeWith(ctx, node, function, true, res, args...)
+}
+
+func callFunctionOnNodeWith(ctx context.Context, node *cdp.Node, function string, await bool, res interface{}, args ...interface{}) error {
 	r, err := dom.ResolveNode().WithNodeID(node.NodeID).Do(ctx)
 	if err != nil {
 		return err
 	}
 	err = CallFunctionOn(function, res,
 		func(p *runtime.CallFunctionOnParams) *runtime.CallFunctionOnParams {
-			return p.WithObjectID(r.ObjectID)
+			return p.WithObjectID(r.ObjectID).WithAwaitPromise(await)
 		},
 		args...,
 	).Do(ctx)
@@ -464,29 +663,142 @@ func callFunctionOnNode(ctx context.Context, node *cdp.Node, function string, re
 // nodes have been sent by the browser and are visible.
 func NodeVisible(s *Selector) {
 	WaitFunc(s.waitReady(func(ctx context.Context, execCtx runtime.ExecutionContextID, n *cdp.Node) error {
-		// check box model
-		_, err := dom.GetBoxModel().WithNodeID(n.NodeID).Do(ctx)
-		if err != nil {
-			if isCouldNotComputeBoxModelError(err) {
-				return ErrNotVisible
-			}
+		return checkVisible(ctx, n)
+	}))(s)
+}
 
-			return err
+// checkVisible returns ErrNotVisible if n is not visible.
+func checkVisible(ctx context.Context, n *cdp.Node) error {
+	// check box model
+	_, err := dom.GetBoxModel().WithNodeID(n.NodeID).Do(ctx)
+	if err != nil {
+		if isCouldNotComputeBoxModelError(err) {
+			return ErrNotVisible
 		}
 
-		// check visibility
-		var res bool
-		err = callFunctionOnNode(ctx, n, visibleJS, &res)
-		if err != nil {
+		return err
+	}
+
+	// check visibility
+	var res bool
+	err = callFunctionOnNode(ctx, n, visibleJS, &res)
+	if err != nil {
+		return err
+	}
+	if !res {
+		return ErrNotVisible
+	}
+	return nil
+}
+
+// actionabilityCheck is a set of the checks of NodeActionable.
+type actionabilityCheck uint8
+
+// Actionability checks.
+const (
+	visibleCheck actionabilityCheck = 1 << iota
+	stableCheck
+	hitTestCheck
+	enabledCheck
+)
+
+// NodeActionable is an element query option to wait until all queried element
+// nodes have been sent by the browser and are actionable, in the following
+// order:
+//   - visible, as with [NodeVisible];
+//   - stable, that is, their bounding box is the same over two animation
+//     frames (or over 100ms in the background tabs, which don't paint any
+//     frames), so that they are not moving because of an animation;
+//   - receiving the events at their center point, that is, not covered by
+//     another element such as an overlay (the element is scrolled into view
+//     as needed, once, before polling);
+//   - enabled, that is, not disabled (either by a 'disabled' attribute, or by
+//     a disabled ancestor such as a fieldset).
+//
+// This is the condition used by [Click], [DoubleClick] and [SendKeys]. The
+// checks but the first one (the nodes being attached to the document) can be
+// skipped with [SkipVisibleCheck], [SkipStableCheck], [SkipHitTestCheck] and
+// [SkipEnabledCheck].
+func NodeActionable(s *Selector) {
+	WaitFunc(s.waitReady(func(ctx context.Context, execCtx runtime.ExecutionContextID, n *cdp.Node) error {
+		if s.skipChecks&visibleCheck == 0 {
+			if err := checkVisible(ctx, n); err != nil {
+				return err
+			}
+		}
+		stable := s.skipChecks&stableCheck == 0
+		hitTest := s.skipChecks&(visibleCheck|hitTestCheck) == 0
+		enabled := s.skipChecks&enabledCheck == 0
+		if !stable && !hitTest && !enabled {
+			return nil
+		}
+
+		var res string
+		scroll := hitTest && firstScroll(ctx, n)
+		if err := awaitFunctionOnNode(ctx, n, actionableJS, &res, stable, hitTest, enabled, scroll); err != nil {
 			return err
 		}
-		if !res {
-			return ErrNotVisible
+		switch res {
+		case "stable":
+			return ErrNotStable
+		case "hitTest":
+			return ErrObscured
+		case "enabled":
+			return ErrDisabled
 		}
 		return nil
 	}))(s)
 }
 
+// scrolledKey is the context key of the set of the nodes scrolled into view by
+// NodeActionable during a run of a query.
+type scrolledKey struct{}
+
+// firstScroll reports whether n is to be scrolled into view before its hit
+// test, which is only done once in a run of the query of ctx, rather than on
+// every poll.
+func firstScroll(ctx context.Context, n *cdp.Node) bool {
+	scrolled, _ := ctx.Value(scrolledKey{}).(*sync.Map)
+	if scrolled == nil {
+		return true
+	}
+	_, ok := scrolled.LoadOrStore(n.NodeID, true)
+	return !ok
+}
+
+// SkipVisibleCheck is an element query option to skip the visibility check of
+// [NodeActionable]. It also skips the hit test, which requires the nodes to be
+// visible.
+func SkipVisibleCheck(s *Selector) {
+	s.skipChecks |= visibleCheck
+}
+
+// SkipStableCheck is an element query option to skip the stability check of
+// [NodeActionable], such as to click an element which is always animated.
+func SkipStableCheck(s *Selector) {
+	s.skipChecks |= stableCheck
+}
+
+// SkipHitTestCheck is an element query option to skip the hit test of
+// [NodeActionable], such as to act on an element covered by a transparent
+// overlay which forwards the events.
+func SkipHitTestCheck(s *Selector) {
+	s.skipChecks |= hitTestCheck
+}
+
+// SkipEnabledCheck is an element query option to skip the enabled check of
+// [NodeActionable].
+func SkipEnabledCheck(s *Selector) {
+	s.skipChecks |= enabledCheck
+}
+
+// NoRequery is an element query option to return the errors of the after funcs
+// caused by a node removed from the document since it was queried, instead of
+// running the query again once.
+func NoRequery(s *Selector) {
+	s.noRequery = true
+}
+
 // NodeNotVisible is an element query option to wait until all queried element
 // nodes have been sent by the browser and are not visible.
 func NodeNotVisible(s *Selector) {
@@ -564,6 +876,21 @@ func NodeNotPresent(s *Selector) {
 	})(s)
 }
 
+// ForAtLeast is an element query option to wait until the node condition has
+// been met for at least d, without interruption, instead of proceeding as soon
+// as it's met. The condition is checked at each retry interval, and waiting
+// starts over whenever it's not met anymore.
+//
+// For example, it avoids the races of WaitNotPresent with the pages which
+// render an element again, removing it only for a moment:
+//
+//	chromedp.WaitNotPresent(`#spinner`, chromedp.ByQuery, chromedp.ForAtLeast(500*time.Millisecond))
+func ForAtLeast(d time.Duration) QueryOption {
+	return func(s *Selector) {
+		s.forAtLeast = d
+	}
+}
+
 // AtLeast is an element query option to set a minimum number of elements that
 // must be returned by the query.
 //
@@ -574,6 +901,33 @@ func AtLeast(n int) QueryOption {
 	}
 }
 
+// Nth is an element query option to only select the element at index i of
+// those returned by the query, starting at 0, before the node condition is
+// waited for. A negative index counts from the last element, such as -1 for
+// the last one. The query is retried until there are enough elements.
+//
+// It's applied after the Filter options, and it's meant to be used with the
+// By* options returning several elements, such as ByQueryAll.
+func Nth(i int) QueryOption {
+	return func(s *Selector) {
+		s.nth = i
+		s.hasNth = true
+	}
+}
+
+// Filter is an element query option to only select the elements returned by
+// the query for which keep returns true, before the node condition is waited
+// for. Several filters can be used; the elements must then be kept by all of
+// them.
+//
+// keep can read the fields of the node, such as its attributes with
+// Node.AttributeValue, but it must not run any action.
+func Filter(keep func(*cdp.Node) bool) QueryOption {
+	return func(s *Selector) {
+		s.filters = append(s.filters, keep)
+	}
+}
+
 // RetryInterval is an element query action option to set the retry interval to specify
 // how often it should retry when it failed to select the target element(s).
 //
@@ -663,6 +1017,9 @@ func WaitSelected(sel interface{}, opts ...QueryOption) QueryAction {
 
 // WaitNotPresent is an element query action that waits until no elements are
 // present matching the selector.
+//
+// Use [ForAtLeast] to wait until the elements stay absent for some time, such
+// as when the page could render them again.
 func WaitNotPresent(sel interface{}, opts ...QueryOption) QueryAction {
 	return Query(sel, append(opts, NodeNotPresent)...)
 }
@@ -680,6 +1037,101 @@ func Nodes(sel interface{}, nodes *[]*cdp.Node, opts ...QueryOption) QueryAction
 	}, opts...)
 }
 
+// nodesIterChunkSize is the number of nodes NodesIter resolves at once.
+const nodesIterChunkSize = 1000
+
+// NodesIter is an element query action that calls fn with each element node
+// matching the selector, stopping at the first error returned by fn.
+//
+// Unlike [Nodes], the matches are resolved in chunks, with DOM.performSearch
+// and DOM.getSearchResults, so that only the IDs and nodes of a chunk are
+// retrieved and waited for at once, once fn was called with the previous ones,
+// and that the matches after the one fn stopped at aren't resolved. As
+// with [BySearch], the selector can be plain text, a CSS selector or an XPath
+// query; the other By* options, [FromNode], [Filter] and [Nth] don't apply.
+// The wait condition of the query, such as [NodeVisible], applies to each
+// chunk.
+//
+// The [After] options passed in opts are called without nodes, once fn was
+// called with all of them.
+func NodesIter(sel interface{}, fn func(*cdp.Node) error, opts ...QueryOption) QueryAction {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+	s := Query(sel, opts...).(*Selector)
+
+	return ActionFunc(func(ctx context.Context) error {
+		t := cdp.ExecutorFromContext(ctx).(*Target)
+		if t == nil {
+			return ErrInvalidTarget
+		}
+		var frame *cdp.Frame
+		var execCtx runtime.ExecutionContextID
+		var searchID string
+		var count int64
+		if err := retryWithSleep(ctx, s.retryInterval, func(ctx context.Context) (bool, error) {
+			var ok bool
+			if frame, _, execCtx, ok = t.ensureFrame(); !ok {
+				return false, nil
+			}
+			p := dom.PerformSearch(s.selAsString())
+			if s.searchInShadow {
+				p = p.WithIncludeUserAgentShadowDOM(true)
+			}
+			var err error
+			if searchID, count, err = p.Do(ctx); err != nil {
+				return false, nil
+			}
+			if count < int64(s.exp) {
+				_ = dom.DiscardSearchResults(searchID).Do(ctx)
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			return err
+		}
+		defer func() {
+			_ = dom.DiscardSearchResults(searchID).Do(ctx)
+		}()
+
+		for from := int64(0); from < count; from += nodesIterChunkSize {
+			to := from + nodesIterChunkSize
+			if to > count {
+				to = count
+			}
+			ids, err := dom.GetSearchResults(searchID, from, to).Do(ctx)
+			if err != nil {
+				return err
+			}
+			var nodes []*cdp.Node
+			if err := retryWithSleep(ctx, s.retryInterval, func(ctx context.Context) (bool, error) {
+				var err error
+				nodes, err = s.wait(ctx, frame, execCtx, ids...)
+				// only the unmet conditions of the wait, such as
+				// ErrNotVisible, are waited out.
+				var cond Error
+				if err != nil && !errors.As(err, &cond) {
+					return true, err
+				}
+				return nodes != nil && err == nil, nil
+			}); err != nil {
+				return err
+			}
+			for _, n := range nodes {
+				if err := fn(n); err != nil {
+					return err
+				}
+			}
+		}
+		for _, f := range s.after {
+			if err := f(ctx, execCtx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // NodeIDs is an element query action that retrieves the element node IDs matching the
 // selector.
 func NodeIDs(sel interface{}, ids *[]cdp.NodeID, opts ...QueryOption) QueryAction {
@@ -699,6 +1151,35 @@ func NodeIDs(sel interface{}, ids *[]cdp.NodeID, opts ...QueryOption) QueryActio
 	}, opts...)
 }
 
+// FullPath is an element query action that retrieves the CSS selector path
+// and the full XPath of the first element node matching the selector, such as
+// to generate the selectors of the recorded elements. Either cssPath or xPath
+// can be nil.
+//
+// The CSS selector path is generated as by the "Copy selector" command of the
+// DevTools: it starts at the closest ancestor with a unique id, and only uses
+// the classes or the positions of the elements when needed to tell them apart
+// from their siblings, as in "#footer > td:nth-child(2)". The full XPath is
+// the one of Node.FullXPath.
+func FullPath(sel interface{}, cssPath, xPath *string, opts ...QueryOption) QueryAction {
+	if cssPath == nil && xPath == nil {
+		panic("cssPath and xPath cannot both be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+		if xPath != nil {
+			*xPath = nodes[0].FullXPath()
+		}
+		if cssPath != nil {
+			return callFunctionOnNode(ctx, nodes[0], cssPathJS, cssPath)
+		}
+		return nil
+	}, opts...)
+}
+
 // Focus is an element query action that focuses the first element node matching the
 // selector.
 func Focus(sel interface{}, opts ...QueryOption) QueryAction {
@@ -781,6 +1262,69 @@ func TextContent(sel interface{}, text *string, opts ...QueryOption) QueryAction
 	}, opts...)
 }
 
+// InnerText is an element query action that retrieves the rendered text (i.e.,
+// innerText) of the first element node matching the selector. Unlike
+// TextContent, the text is layout-aware: hidden elements are skipped, and line
+// breaks are inserted as they are rendered.
+//
+// Unlike Text, the text is retrieved even when the element itself is not
+// visible. Use the [CollapseWhitespace] option to collapse the whitespace.
+func InnerText(sel interface{}, text *string, opts ...QueryOption) QueryAction {
+	if text == nil {
+		panic("text cannot be nil")
+	}
+
+	return Query(sel, append(opts, func(s *Selector) {
+		After(func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+			if len(nodes) < 1 {
+				return fmt.Errorf("selector %q did not return any nodes", sel)
+			}
+
+			var res string
+			if err := callFunctionOnNode(ctx, nodes[0], innerTextJS, &res); err != nil {
+				return err
+			}
+			if s.collapseWhitespace {
+				res = strings.Join(strings.Fields(res), " ")
+			}
+			*text = res
+			return nil
+		})(s)
+	})...)
+}
+
+// CollapseWhitespace is an element query option to collapse each run of
+// whitespace (including line breaks) to a single space, and to trim the
+// leading and trailing whitespace of the text retrieved by [InnerText].
+func CollapseWhitespace(s *Selector) {
+	s.collapseWhitespace = true
+}
+
+// Markdown is an element query action that converts the first element node
+// matching the selector and its subtree to Markdown, using a converter
+// injected into the page.
+//
+// The converter handles the common elements (headings, paragraphs, emphasis,
+// links, images, lists, block quotes, code and simple tables), and skips the
+// hidden elements and the ones without textual content (such as scripts and
+// styles). Relative link and image URLs are resolved against the document's
+// base URL. The result is meant to be readable, such as when feeding the
+// content of a page to a text processing pipeline, rather than to round-trip
+// to the original HTML.
+func Markdown(sel interface{}, md *string, opts ...QueryOption) QueryAction {
+	if md == nil {
+		panic("md cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return callFunctionOnNode(ctx, nodes[0], markdownJS, md)
+	}, opts...)
+}
+
 // Clear is an element query action that clears the values of any input/textarea element
 // nodes matching the selector.
 func Clear(sel interface{}, opts ...QueryOption) QueryAction {
@@ -857,6 +1401,10 @@ func Value(sel interface{}, value *string, opts ...QueryOption) QueryAction {
 //
 // Useful for setting an element's JavaScript value, namely form, input,
 // textarea, select, or other element with a '.value' field.
+//
+// Unlike [SendKeys], it doesn't wait for the node to be actionable, so that it
+// can set the value of hidden or disabled inputs; pass [NodeActionable] to
+// wait until the node is actionable first.
 func SetValue(sel interface{}, value string, opts ...QueryOption) QueryAction {
 	return SetJavascriptAttribute(sel, "value", value, opts...)
 }
@@ -968,6 +1516,110 @@ func AttributeValue(sel interface{}, name string, value *string, ok *bool, opts
 	}, opts...)
 }
 
+// AttributeValueInt is an element query action that retrieves the element
+// attribute value for the specified name, parsed as a base 10 integer, for the
+// first element node matching the selector. It fails when the attribute is
+// missing or isn't an integer.
+func AttributeValueInt(sel interface{}, name string, value *int64, opts ...QueryOption) QueryAction {
+	if value == nil {
+		panic("value cannot be nil")
+	}
+
+	return parsedAttribute(sel, name, func(s string) error {
+		v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return err
+		}
+		*value = v
+		return nil
+	}, opts...)
+}
+
+// AttributeValueBool is an element query action that retrieves whether the
+// element attribute for the specified name is set for the first element node
+// matching the selector, as for the boolean attributes such as "disabled". As
+// for the ARIA attributes such as "aria-hidden", the attribute is false if its
+// value is "false" as well.
+func AttributeValueBool(sel interface{}, name string, value *bool, opts ...QueryOption) QueryAction {
+	if value == nil {
+		panic("value cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		v, ok := nodes[0].Attribute(name)
+		*value = ok && !strings.EqualFold(strings.TrimSpace(v), "false")
+		return nil
+	}, opts...)
+}
+
+// AttributeValueJSON is an element query action that retrieves the element
+// attribute value for the specified name, decoded as JSON into v, for the
+// first element node matching the selector, such as for the data attributes
+// holding the state of a component. It fails when the attribute is missing.
+func AttributeValueJSON(sel interface{}, name string, v interface{}, opts ...QueryOption) QueryAction {
+	if v == nil {
+		panic("v cannot be nil")
+	}
+
+	return parsedAttribute(sel, name, func(s string) error {
+		return json.Unmarshal([]byte(s), v)
+	}, opts...)
+}
+
+// parsedAttribute is an element query action that parses the element
+// attribute value for the specified name with parse, for the first element
+// node matching the selector.
+func parsedAttribute(sel interface{}, name string, parse func(string) error, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		v, ok := nodes[0].Attribute(name)
+		if !ok {
+			return fmt.Errorf("node %d has no attribute %q", nodes[0].NodeID, name)
+		}
+		if err := parse(v); err != nil {
+			return fmt.Errorf("could not parse attribute %q of node %d: %w", name, nodes[0].NodeID, err)
+		}
+		return nil
+	}, opts...)
+}
+
+// DataAttributes is an element query action that retrieves the data-*
+// attributes of the first element node matching the selector, keyed by their
+// names without the "data-" prefix, such as "user-id" for "data-user-id".
+func DataAttributes(sel interface{}, attributes *map[string]string, opts ...QueryOption) QueryAction {
+	if attributes == nil {
+		panic("attributes cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		nodes[0].RLock()
+		defer nodes[0].RUnlock()
+
+		m := make(map[string]string)
+		attrs := nodes[0].Attributes
+		for i := 0; i+1 < len(attrs); i += 2 {
+			if name, ok := strings.CutPrefix(attrs[i], "data-"); ok {
+				m[name] = attrs[i+1]
+			}
+		}
+
+		*attributes = m
+
+		return nil
+	}, opts...)
+}
+
 // SetAttributeValue is an element query action that sets the element attribute with
 // name to value for the first element node matching the selector.
 func SetAttributeValue(sel interface{}, name, value string, opts ...QueryOption) QueryAction {
@@ -1052,36 +1704,63 @@ func InnerHTML(sel interface{}, html *string, opts ...QueryOption) QueryAction {
 
 // Click is an element query action that sends a mouse click event to the first element
 // node matching the selector.
+//
+// It waits until the node is actionable, as described by [NodeActionable].
+// Use [VerifyClickTarget] to make sure that the click is received by the node.
 func Click(sel interface{}, opts ...QueryOption) QueryAction {
-	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-		if len(nodes) < 1 {
-			return fmt.Errorf("selector %q did not return any nodes", sel)
-		}
+	return Query(sel, append(opts, NodeActionable, func(s *Selector) {
+		After(func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+			if len(nodes) < 1 {
+				return fmt.Errorf("selector %q did not return any nodes", sel)
+			}
 
-		return MouseClickNode(nodes[0]).Do(ctx)
-	}, append(opts, NodeVisible)...)
+			return clickNode(ctx, nodes[0], s.verifyClickTarget)
+		})(s)
+	})...)
 }
 
 // DoubleClick is an element query action that sends a mouse double click event to the
 // first element node matching the selector.
+//
+// It waits until the node is actionable, as described by [NodeActionable].
+// Use [VerifyClickTarget] to make sure that the clicks are received by the node.
 func DoubleClick(sel interface{}, opts ...QueryOption) QueryAction {
-	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-		if len(nodes) < 1 {
-			return fmt.Errorf("selector %q did not return any nodes", sel)
-		}
+	return Query(sel, append(opts, NodeActionable, func(s *Selector) {
+		After(func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+			if len(nodes) < 1 {
+				return fmt.Errorf("selector %q did not return any nodes", sel)
+			}
 
-		return MouseClickNode(nodes[0], ClickCount(2)).Do(ctx)
-	}, append(opts, NodeVisible)...)
+			return clickNode(ctx, nodes[0], s.verifyClickTarget, ClickCount(2))
+		})(s)
+	})...)
+}
+
+// VerifyClickTarget is an element query option to make [Click] and
+// [DoubleClick] check, with DOM.getNodeForLocation, that the node (or one of
+// its descendants) is the element at the click point, right before clicking.
+//
+// Unlike the hit test of [NodeActionable], which waits for the node to be
+// uncovered, the check is done once, and the action fails with an error
+// wrapping [ErrClickIntercepted] and describing the covering element (such as
+// "click intercepted by div#overlay"); this catches the elements covering the
+// node between the wait and the click.
+func VerifyClickTarget(s *Selector) {
+	s.verifyClickTarget = true
 }
 
 // SendKeys is an element query action that synthesizes the key up, char, and down
 // events as needed for the runes in v, sending them to the first element node
 // matching the selector.
 //
+// It waits until the node is actionable, as described by [NodeActionable].
+//
 // See the [keys] for a complete example on how to use SendKeys.
 //
 // Note: when the element query matches an input[type="file"] node, then
 // dom.SetFileInputFiles is used to set the upload path of the input node to v.
+// With WithInPageUploads, the file at v is read instead, and created in the
+// page, so that a remote browser can upload it.
 //
 // [keys]: https://github.com/chromedp/examples/tree/master/keys
 func SendKeys(sel interface{}, v string, opts ...QueryOption) QueryAction {
@@ -1092,37 +1771,136 @@ func SendKeys(sel interface{}, v string, opts ...QueryOption) QueryAction {
 
 		n := nodes[0]
 
-		// grab type attribute from node
-		typ, attrs := "", n.Attributes
-		n.RLock()
-		for i := 0; i < len(attrs); i += 2 {
-			if attrs[i] == "type" {
-				typ = attrs[i+1]
-			}
-		}
-		n.RUnlock()
-
 		// when working with input[type="file"], call dom.SetFileInputFiles
-		if n.NodeName == "INPUT" && typ == "file" {
-			return dom.SetFileInputFiles([]string{v}).WithNodeID(n.NodeID).Do(ctx)
+		if isFileInput(n) {
+			return setFileInputFiles(ctx, n, []string{v})
 		}
 
 		return KeyEventNode(n, v).Do(ctx)
-	}, append(opts, NodeVisible)...)
+	}, append(opts, NodeActionable)...)
 }
 
 // SetUploadFiles is an element query action that sets the files to upload (i.e., for a
 // input[type="file"] node) for the first element node matching the selector.
+//
+// Note: as for SendKeys, the files are read in the page as set up by
+// WithInPageUploads, if so.
 func SetUploadFiles(sel interface{}, files []string, opts ...QueryOption) QueryAction {
 	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
 		if len(nodes) < 1 {
 			return fmt.Errorf("selector %q did not return any nodes", sel)
 		}
 
-		return dom.SetFileInputFiles(files).WithNodeID(nodes[0].NodeID).Do(ctx)
+		return setFileInputFiles(ctx, nodes[0], files)
 	}, opts...)
 }
 
+// SetUploadBytes is an element query action that sets the files to upload,
+// keyed by their names, for the first element node matching the selector,
+// without having to write them to disk first.
+//
+// When the node is an input[type="file"] node, the files are written to a
+// temporary directory, removed once the chromedp context is done, and set with
+// dom.SetFileInputFiles; with WithInPageUploads, they're created in the page
+// instead. Otherwise, the files are dropped onto the node, as with a drag and
+// drop from outside of the page, such as for a drop zone.
+func SetUploadBytes(sel interface{}, files map[string][]byte, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		names := make([]string, 0, len(files))
+		for name := range files {
+			if !filepath.IsLocal(name) || filepath.Base(name) != name {
+				return fmt.Errorf("invalid upload file name %q", name)
+			}
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		n := nodes[0]
+		c := FromContext(ctx)
+		if c == nil {
+			return ErrInvalidContext
+		}
+		if isFileInput(n) && !c.inPageUploads() {
+			paths, err := c.writeUploadFiles(files, names)
+			if err != nil {
+				return err
+			}
+			return dom.SetFileInputFiles(paths).WithNodeID(n.NodeID).Do(ctx)
+		}
+
+		uploads := make([]uploadFile, len(names))
+		for i, name := range names {
+			uploads[i] = newUploadFile(name, files[name])
+		}
+		return uploadInPage(ctx, n, uploads)
+	}, opts...)
+}
+
+// uploadFile is a file created in the page by uploadFilesJS.
+type uploadFile struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Data []byte `json:"data"`
+}
+
+// newUploadFile returns the upload file with the name and content, and the
+// type matching the extension of the name.
+func newUploadFile(name string, data []byte) uploadFile {
+	// like the file inputs, leave out the parameters of the type.
+	typ, _, _ := strings.Cut(mime.TypeByExtension(filepath.Ext(name)), ";")
+	return uploadFile{Name: name, Type: typ, Data: data}
+}
+
+// uploadInPage creates the files in the page, and sets them as the files of
+// the file input node n, or drops them onto n otherwise.
+func uploadInPage(ctx context.Context, n *cdp.Node, files []uploadFile) error {
+	var res int64
+	if err := callFunctionOnNode(ctx, n, uploadFilesJS, &res, files); err != nil {
+		return err
+	}
+	if res != int64(len(files)) {
+		return fmt.Errorf("could not upload files to node %d", n.NodeID)
+	}
+	return nil
+}
+
+// setFileInputFiles sets the files at the paths as the files of the file input
+// node n, with dom.SetFileInputFiles, or by reading them and creating them in
+// the page as set up by WithInPageUploads.
+func setFileInputFiles(ctx context.Context, n *cdp.Node, paths []string) error {
+	if c := FromContext(ctx); c == nil || !c.inPageUploads() {
+		return dom.SetFileInputFiles(paths).WithNodeID(n.NodeID).Do(ctx)
+	}
+	files := make([]uploadFile, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[i] = newUploadFile(filepath.Base(path), data)
+	}
+	return uploadInPage(ctx, n, files)
+}
+
+// isFileInput reports whether the node is an input[type="file"] node.
+func isFileInput(n *cdp.Node) bool {
+	if n.NodeName != "INPUT" {
+		return false
+	}
+	n.RLock()
+	defer n.RUnlock()
+	for i := 0; i+1 < len(n.Attributes); i += 2 {
+		if n.Attributes[i] == "type" {
+			return n.Attributes[i+1] == "file"
+		}
+	}
+	return false
+}
+
 // Submit is an element query action that submits the parent form of the first element
 // node matching the selector.
 func Submit(sel interface{}, opts ...QueryOption) QueryAction {
@@ -1218,14 +1996,68 @@ func MatchedStyle(sel interface{}, style **css.GetMatchedStylesForNodeReturns, o
 
 // ScrollIntoView is an element query action that scrolls the window to the
 // first element node matching the selector.
+//
+// By default, the node is scrolled into view with DOM.scrollIntoViewIfNeeded.
+// When any of the [ScrollBlock], [ScrollInline] and [WithScrollMargin] options
+// is given, it's scrolled with element.scrollIntoView() instead, which also
+// scrolls its nested scroll containers, aligning the node as specified (by
+// default, to the nearest edge) while keeping the margin around it visible.
 func ScrollIntoView(sel interface{}, opts ...QueryOption) QueryAction {
-	return QueryAfter(sel, func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
-		if len(nodes) < 1 {
-			return fmt.Errorf("selector %q did not return any nodes", sel)
-		}
+	return Query(sel, append(opts, func(s *Selector) {
+		After(func(ctx context.Context, execCtx runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+			if len(nodes) < 1 {
+				return fmt.Errorf("selector %q did not return any nodes", sel)
+			}
 
-		return dom.ScrollIntoViewIfNeeded().WithNodeID(nodes[0].NodeID).Do(ctx)
-	}, opts...)
+			if s.scrollBlock == "" && s.scrollInline == "" && s.scrollMargin == 0 {
+				return dom.ScrollIntoViewIfNeeded().WithNodeID(nodes[0].NodeID).Do(ctx)
+			}
+			block, inline := s.scrollBlock, s.scrollInline
+			if block == "" {
+				block = ScrollAlignNearest
+			}
+			if inline == "" {
+				inline = ScrollAlignNearest
+			}
+			return callFunctionOnNode(ctx, nodes[0], scrollIntoViewJS, nil, block, inline, s.scrollMargin)
+		})(s)
+	})...)
+}
+
+// ScrollAlignment is the alignment of an element scrolled by [ScrollIntoView].
+type ScrollAlignment string
+
+// ScrollAlignment values, as defined for element.scrollIntoView().
+const (
+	ScrollAlignStart   ScrollAlignment = "start"
+	ScrollAlignCenter  ScrollAlignment = "center"
+	ScrollAlignEnd     ScrollAlignment = "end"
+	ScrollAlignNearest ScrollAlignment = "nearest"
+)
+
+// ScrollBlock is an element query option to set the vertical alignment of the
+// element scrolled by [ScrollIntoView].
+func ScrollBlock(align ScrollAlignment) QueryOption {
+	return func(s *Selector) {
+		s.scrollBlock = align
+	}
+}
+
+// ScrollInline is an element query option to set the horizontal alignment of
+// the element scrolled by [ScrollIntoView].
+func ScrollInline(align ScrollAlignment) QueryOption {
+	return func(s *Selector) {
+		s.scrollInline = align
+	}
+}
+
+// WithScrollMargin is an element query option to keep a margin of px CSS
+// pixels around the element scrolled by [ScrollIntoView], such as to keep it
+// from being hidden behind a fixed or sticky header.
+func WithScrollMargin(px float64) QueryOption {
+	return func(s *Selector) {
+		s.scrollMargin = px
+	}
 }
 
 // DumpTo is an element query action that writes a readable tree of the first
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -20,6 +22,7 @@ import (
 	"github.com/chromedp/cdproto/dom"
 	cdpruntime "github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp/kb"
+	"github.com/chromedp/chromedp/sel"
 )
 
 func TestWaitReady(t *testing.T) {
@@ -175,6 +178,50 @@ func TestWaitNotPresent(t *testing.T) {
 	}
 }
 
+func TestWaitNotPresentForAtLeast(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []QueryOption
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		// without ForAtLeast, the first removal is enough.
+		{"Once", nil, 0, 500 * time.Millisecond},
+		// the element is rendered again, so waiting starts over after the
+		// last removal, at 600ms.
+		{"ForAtLeast", []QueryOption{ForAtLeast(100 * time.Millisecond)}, 700 * time.Millisecond, 5 * time.Second},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := testAllocate(t, "js.html")
+			defer cancel()
+
+			start := time.Now()
+			if err := Run(ctx,
+				Evaluate(`
+					const el = document.createElement('div');
+					el.id = 'flicker';
+					document.body.append(el);
+					setTimeout(() => el.remove(), 50);
+					setTimeout(() => document.body.append(el), 120);
+					setTimeout(() => el.remove(), 600);
+				`, nil),
+				WaitNotPresent(`#flicker`, append(test.opts, ByQuery)...),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed < test.wantMin || elapsed > test.wantMax {
+				t.Errorf("want to wait between %v and %v, got %v", test.wantMin, test.wantMax, elapsed)
+			}
+		})
+	}
+}
+
 func TestAtLeast(t *testing.T) {
 	t.Parallel()
 
@@ -335,6 +382,124 @@ func TestNodes(t *testing.T) {
 	}
 }
 
+func TestNodesIter(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "table.html")
+	defer cancel()
+
+	// more nodes than a chunk.
+	const n = 2*nodesIterChunkSize + 500
+	if err := Run(ctx, Evaluate(fmt.Sprintf(`for (let i = 0; i < %d; i++) {
+		const div = document.createElement("div");
+		div.className = "many";
+		div.textContent = i;
+		document.body.appendChild(div);
+	}`, n), nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	errStop := errors.New("stop")
+	count := 0
+	err := Run(ctx, NodesIter(`div.many`, func(node *cdp.Node) error {
+		count++
+		if count == 3 {
+			return errStop
+		}
+		return nil
+	}))
+	if !errors.Is(err, errStop) || count != 3 {
+		t.Errorf("want the iteration stopped at the error, got %v after %d nodes", err, count)
+	}
+
+	count = 0
+	if err := Run(ctx, NodesIter(`div.many`, func(node *cdp.Node) error {
+		if node.NodeName != "DIV" {
+			return fmt.Errorf("unexpected node %s", node.NodeName)
+		}
+		count++
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if count != n {
+		t.Errorf("want %d nodes, got %d", n, count)
+	}
+
+	// a failure of the wait is returned, instead of being waited out until
+	// the timeout.
+	errWait := errors.New("wait failed")
+	failWait := func(s *Selector) {
+		s.wait = func(context.Context, *cdp.Frame, cdpruntime.ExecutionContextID, ...cdp.NodeID) ([]*cdp.Node, error) {
+			return nil, errWait
+		}
+	}
+	tctx, tcancel := context.WithTimeout(ctx, 10*time.Second)
+	defer tcancel()
+	start := time.Now()
+	err = Run(tctx, NodesIter(`div.many`, func(*cdp.Node) error { return nil }, failWait))
+	if !errors.Is(err, errWait) {
+		t.Errorf("want the wait error, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("want the wait error returned at once, got it after %v", d)
+	}
+}
+
+func TestRequery(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "form.html")
+	defer cancel()
+
+	rerender := Evaluate(`document.querySelector("#keyword").replaceWith(document.querySelector("#keyword").cloneNode())`, nil)
+	// the first after func renders the node again, so that the second one
+	// uses a removed node.
+	var renders, calls int
+	render := func(ctx context.Context, _ cdpruntime.ExecutionContextID, nodes ...*cdp.Node) error {
+		renders++
+		return rerender.Do(ctx)
+	}
+	outerHTML := func(ctx context.Context, _ cdpruntime.ExecutionContextID, nodes ...*cdp.Node) error {
+		calls++
+		_, err := dom.GetOuterHTML().WithNodeID(nodes[0].NodeID).Do(ctx)
+		return err
+	}
+	if err := Run(ctx, Query(`#keyword`, ByQuery, After(render), After(outerHTML))); err != nil {
+		t.Fatal(err)
+	}
+	if renders != 1 || calls != 2 {
+		t.Errorf("want the query run again once, from the failed after func, got %d renders and %d calls", renders, calls)
+	}
+
+	renders, calls = 0, 0
+	err := Run(ctx, Query(`#keyword`, ByQuery, After(render), After(outerHTML), NoRequery))
+	if !isStaleNodeError(err) || renders != 1 || calls != 1 {
+		t.Errorf("want the stale node error with NoRequery, got %v after %d renders and %d calls", err, renders, calls)
+	}
+
+	// an after func failing once it changed the page isn't run again.
+	calls = 0
+	err = Run(ctx, QueryAfter(`#keyword`, func(ctx context.Context, _ cdpruntime.ExecutionContextID, nodes ...*cdp.Node) error {
+		calls++
+		if err := Evaluate(`window.inputs = (window.inputs || 0) + 1`, nil).Do(ctx); err != nil {
+			return err
+		}
+		if err := rerender.Do(ctx); err != nil {
+			return err
+		}
+		_, err := dom.GetOuterHTML().WithNodeID(nodes[0].NodeID).Do(ctx)
+		return err
+	}, ByQuery))
+	var inputs int
+	if err := Run(ctx, Evaluate(`window.inputs`, &inputs)); err != nil {
+		t.Fatal(err)
+	}
+	if !isStaleNodeError(err) || calls != 1 || inputs != 1 {
+		t.Errorf("want the stale node error without running the after func again, got %v after %d calls and %d inputs", err, calls, inputs)
+	}
+}
+
 func TestNodeIDs(t *testing.T) {
 	t.Parallel()
 
@@ -501,6 +666,69 @@ func TestTextContent(t *testing.T) {
 	}
 }
 
+func TestInnerText(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "form.html")
+	defer cancel()
+
+	tests := []struct {
+		sel string
+		by  QueryOption
+		exp string
+	}{
+		{"#foo", ByID, "insert"},
+		{"#inner-hidden", ByID, "this is"},
+		// Not rendered, so innerText is the same as textContent.
+		{"#hidden", ByID, "hidden"},
+	}
+
+	for i, test := range tests {
+		var text string
+		if err := Run(ctx, InnerText(test.sel, &text, test.by)); err != nil {
+			t.Fatalf("test %d got error: %v", i, err)
+		}
+
+		if text != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, text)
+		}
+	}
+
+	var text string
+	if err := Run(ctx,
+		Navigate(testdataDir+"/visible.html"),
+		InnerText("#box2", &text, ByID, CollapseWhitespace),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "box2"; text != exp {
+		t.Errorf("expected %q, got: %q", exp, text)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "markdown.html")
+	defer cancel()
+
+	var md string
+	if err := Run(ctx, Markdown("#article", &md, ByID)); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "# Title\n\n" +
+		"Some **bold** and _emphasized_ text with a [link](" + testdataDir + "/child1.html) and `code`.\n\n" +
+		"- one\n- two\n\n" +
+		"1. first\n2. second\n\n" +
+		"> quoted\n\n" +
+		"```\nline 1\nline 2\n```\n\n" +
+		"| a | b |\n| --- | --- |\n| 1 | 2 |"
+	if md != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, md)
+	}
+}
+
 func TestClear(t *testing.T) {
 	t.Parallel()
 
@@ -919,6 +1147,75 @@ func TestAttributeValue(t *testing.T) {
 	}
 }
 
+func TestAttributeValueTyped(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "js.html")
+	defer cancel()
+
+	if err := Run(ctx, Evaluate(`
+		const el = document.createElement("div");
+		el.id = "typed";
+		el.setAttribute("data-count", " 42 ");
+		el.setAttribute("data-user-id", "u1");
+		el.setAttribute("data-state", '{"open":true,"tabs":["a","b"]}');
+		el.setAttribute("aria-hidden", "false");
+		el.setAttribute("hidden", "");
+		el.setAttribute("title", "not data");
+		document.body.append(el);
+	`, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	var hidden, ariaHidden, missing bool
+	var state struct {
+		Open bool     `json:"open"`
+		Tabs []string `json:"tabs"`
+	}
+	var data map[string]string
+	if err := Run(ctx,
+		AttributeValueInt(`#typed`, "data-count", &count, ByQuery),
+		AttributeValueBool(`#typed`, "hidden", &hidden, ByQuery),
+		AttributeValueBool(`#typed`, "aria-hidden", &ariaHidden, ByQuery),
+		AttributeValueBool(`#typed`, "disabled", &missing, ByQuery),
+		AttributeValueJSON(`#typed`, "data-state", &state, ByQuery),
+		DataAttributes(`#typed`, &data, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if count != 42 {
+		t.Errorf("want count 42, got %d", count)
+	}
+	if !hidden || ariaHidden || missing {
+		t.Errorf("want hidden only, got hidden=%t aria-hidden=%t disabled=%t", hidden, ariaHidden, missing)
+	}
+	if !state.Open || !reflect.DeepEqual(state.Tabs, []string{"a", "b"}) {
+		t.Errorf("unexpected state: %+v", state)
+	}
+	want := map[string]string{
+		"count":   " 42 ",
+		"user-id": "u1",
+		"state":   `{"open":true,"tabs":["a","b"]}`,
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("want data attributes %v, got %v", want, data)
+	}
+
+	for _, test := range []struct {
+		name   string
+		action Action
+	}{
+		{"not an int", AttributeValueInt(`#typed`, "data-user-id", &count, ByQuery)},
+		{"missing", AttributeValueInt(`#typed`, "data-missing", &count, ByQuery)},
+		{"not json", AttributeValueJSON(`#typed`, "title", &state, ByQuery)},
+	} {
+		if err := Run(ctx, test.action); err == nil {
+			t.Errorf("%s: want an error", test.name)
+		}
+	}
+}
+
 func TestSetAttributeValue(t *testing.T) {
 	t.Parallel()
 
@@ -1045,6 +1342,152 @@ func TestClick(t *testing.T) {
 	}
 }
 
+func TestClickActionable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		sel    string
+		enable string
+		opts   []QueryOption
+		want   string
+	}{
+		{
+			name:   "obscured",
+			sel:    "#covered",
+			enable: "document.getElementById('overlay').remove()",
+			want:   "covered;",
+		},
+		{
+			name:   "disabled",
+			sel:    "#disabled",
+			enable: "document.getElementById('overlay').remove(); document.getElementById('fieldset').disabled = false",
+			want:   "disabled;",
+		},
+		{
+			name: "skip hit test",
+			sel:  "#covered",
+			opts: []QueryOption{SkipHitTestCheck},
+			want: "overlay;",
+		},
+		{
+			name:   "moving",
+			sel:    "#moving",
+			enable: "document.getElementById('overlay').remove()",
+			want:   "moving;",
+		},
+		{
+			name:   "below the fold",
+			sel:    "#below",
+			enable: "document.getElementById('overlay').remove()",
+			want:   "below;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := testAllocate(t, "actionable.html")
+			defer cancel()
+
+			if tt.enable != "" {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					_ = Run(ctx, Evaluate(tt.enable, nil))
+				}()
+			}
+			var log string
+			if err := Run(ctx,
+				Click(tt.sel, append([]QueryOption{ByQuery}, tt.opts...)...),
+				Text("#log", &log, ByQuery),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if log != tt.want {
+				t.Fatalf("want clicks %q, got %q", tt.want, log)
+			}
+		})
+	}
+}
+
+func TestNodeActionable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		sel     string
+		opts    []QueryOption
+		wantErr error
+	}{
+		{"obscured", "#covered", nil, context.DeadlineExceeded},
+		{"skip all", "#disabled", []QueryOption{SkipHitTestCheck, SkipEnabledCheck}, nil},
+		{"skip visible", "#overlay", []QueryOption{SkipVisibleCheck}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := testAllocate(t, "actionable.html")
+			defer cancel()
+
+			tctx, tcancel := context.WithTimeout(ctx, 300*time.Millisecond)
+			defer tcancel()
+			err := Run(tctx, Query(tt.sel, append([]QueryOption{ByQuery, NodeActionable}, tt.opts...)...))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyClickTarget(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "actionable.html")
+	defer cancel()
+
+	err := Run(ctx, Click("#covered", ByQuery, SkipHitTestCheck, VerifyClickTarget))
+	if !errors.Is(err, ErrClickIntercepted) {
+		t.Fatalf("want error %v, got: %v", ErrClickIntercepted, err)
+	}
+	if want := "click intercepted by div#overlay"; err.Error() != want {
+		t.Fatalf("want error %q, got %q", want, err)
+	}
+
+	var log string
+	if err := Run(ctx,
+		Evaluate(`document.getElementById('overlay').remove()`, nil),
+		Click("#covered", ByQuery, VerifyClickTarget),
+		Text("#log", &log, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "covered;"; log != want {
+		t.Fatalf("want clicks %q, got %q", want, log)
+	}
+}
+
+func TestSetValueActionable(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "actionable.html")
+	defer cancel()
+
+	// the value of a disabled node is set, unless NodeActionable is given.
+	var value string
+	if err := Run(ctx,
+		SetValue("#disabled", "x", ByQuery),
+		Value("#disabled", &value, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if value != "x" {
+		t.Fatalf("want value %q, got %q", "x", value)
+	}
+
+	tctx, tcancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer tcancel()
+	if err := Run(tctx, SetValue("#disabled", "y", ByQuery, NodeActionable, SkipHitTestCheck)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want error %v, got: %v", context.DeadlineExceeded, err)
+	}
+}
+
 func TestDoubleClick(t *testing.T) {
 	t.Parallel()
 
@@ -1286,6 +1729,7 @@ func TestFileUpload(t *testing.T) {
 	}{
 		{SendKeys(`input[name="upload"]`, uploadFile, NodeVisible)},
 		{SetUploadFiles(`input[name="upload"]`, []string{uploadFile}, NodeVisible)},
+		{SetUploadBytes(`input[name="upload"]`, map[string][]byte{"upload.html": []byte(uploadHTML)}, NodeVisible)},
 	}
 
 	// Don't run these tests in parallel. The only way to do so would be to
@@ -1315,6 +1759,126 @@ func TestFileUpload(t *testing.T) {
 	}
 }
 
+func TestSetUploadBytes(t *testing.T) {
+	t.Parallel()
+
+	files := map[string][]byte{
+		"b.txt": []byte("second"),
+		"a.txt": []byte("first"),
+	}
+	tests := []struct {
+		name string
+		sel  string
+	}{
+		{"Input", `#input`},
+		{"DropZone", `#dropzone`},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, _ := testAllocate(t, "dropzone.html")
+
+			var result string
+			if err := Run(ctx,
+				SetUploadBytes(test.sel, files, ByQuery),
+				Poll(`document.querySelector('#result').textContent`, &result),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if want := "a.txt:text/plain:first,b.txt:text/plain:second"; result != want {
+				t.Errorf("got %q, want %q", result, want)
+			}
+
+			c := FromContext(ctx)
+			c.uploadMu.Lock()
+			dirs := slices.Clone(c.uploadDirs)
+			c.uploadMu.Unlock()
+			if err := Cancel(ctx); err != nil {
+				t.Fatal(err)
+			}
+			for _, dir := range dirs {
+				if _, err := os.Stat(dir); !os.IsNotExist(err) {
+					t.Errorf("expected %s to be removed, got: %v", dir, err)
+				}
+			}
+		})
+	}
+
+	t.Run("InvalidName", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := testAllocate(t, "dropzone.html")
+		defer cancel()
+
+		err := Run(ctx, SetUploadBytes(`#input`, map[string][]byte{"../a.txt": nil}, ByQuery))
+		if err == nil || !strings.Contains(err.Error(), "invalid upload file name") {
+			t.Fatalf("expected an invalid name error, got: %v", err)
+		}
+	})
+}
+
+func TestInPageUploads(t *testing.T) {
+	t.Parallel()
+
+	allocCtx, cancel := NewRemoteAllocator(context.Background(), startRemoteBrowser(t), WithInPageUploads())
+	t.Cleanup(cancel)
+
+	tests := []struct {
+		name string
+		a    func(path string) Action
+	}{
+		{"SendKeys", func(path string) Action {
+			return SendKeys(`#input`, path, ByQuery)
+		}},
+		{"SetUploadFiles", func(path string) Action {
+			return SetUploadFiles(`#input`, []string{path}, ByQuery)
+		}},
+		{"SetUploadBytes", func(path string) Action {
+			return SetUploadBytes(`#input`, map[string][]byte{"a.txt": []byte("first")}, ByQuery)
+		}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "a.txt")
+			if err := os.WriteFile(path, []byte("first"), 0o666); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := NewContext(allocCtx)
+			defer cancel()
+
+			var result string
+			if err := Run(ctx,
+				Navigate(testdataDir+"/dropzone.html"),
+				test.a(path),
+				Poll(`document.querySelector('#result').textContent`, &result),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if want := "a.txt:text/plain:first"; result != want {
+				t.Errorf("got %q, want %q", result, want)
+			}
+
+			// the files are in the page, so they can still be read once the
+			// local ones are removed.
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			if err := Run(ctx,
+				Evaluate(`document.querySelector('#result').textContent = ''; show(document.querySelector('#input').files)`, nil),
+				Poll(`document.querySelector('#result').textContent`, &result, WithPollingTimeout(5*time.Second)),
+			); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func TestInnerHTML(t *testing.T) {
 	t.Parallel()
 
@@ -1394,6 +1958,56 @@ func TestScrollIntoView(t *testing.T) {
 	}
 }
 
+func TestScrollIntoViewAlignment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []QueryOption
+		wantTop float64
+	}{
+		{"start", []QueryOption{ScrollBlock(ScrollAlignStart)}, 0},
+		{"start with margin", []QueryOption{ScrollBlock(ScrollAlignStart), WithScrollMargin(50)}, 50},
+		{"center", []QueryOption{ScrollBlock(ScrollAlignCenter), ScrollInline(ScrollAlignCenter)}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := testAllocate(t, "scroll.html")
+			defer cancel()
+
+			var rect struct {
+				Top, Left, ViewportHeight float64
+				Style                     *string
+			}
+			if err := Run(ctx,
+				ScrollIntoView("#target", append([]QueryOption{ByQuery}, tt.opts...)...),
+				Evaluate(`(() => {
+					const el = document.getElementById('target');
+					const r = el.getBoundingClientRect();
+					return {top: r.top, left: r.left, viewportHeight: innerHeight, style: el.getAttribute('style')};
+				})()`, &rect),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if rect.Style != nil {
+				t.Errorf("want the style attribute to be restored, got %q", *rect.Style)
+			}
+			wantTop := tt.wantTop
+			if wantTop < 0 {
+				// The target is centered in its container, itself centered
+				// in the viewport.
+				wantTop = (rect.ViewportHeight - 20) / 2
+			}
+			if math.Abs(rect.Top-wantTop) > 1 {
+				t.Errorf("want the target at %v from the top, got %v", wantTop, rect.Top)
+			}
+			if rect.Left < 0 || rect.Left > 800 {
+				t.Errorf("want the target to be scrolled horizontally into view, got %v from the left", rect.Left)
+			}
+		})
+	}
+}
+
 func TestSVGFullXPath(t *testing.T) {
 	t.Parallel()
 
@@ -1528,3 +2142,184 @@ func TestFromNode(t *testing.T) {
 		})
 	}
 }
+
+func TestFromNodeRows(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "table.html")
+	defer cancel()
+
+	var rows []*cdp.Node
+	if err := Run(ctx, Nodes(`tbody tr`, &rows, ByQueryAll)); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, row := range rows {
+		var cell string
+		if err := Run(ctx, Text(`td:nth-child(2)`, &cell, ByQuery, FromNode(row))); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, cell)
+	}
+	if want := []string{"1.2", "2.2", "3.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want the cells %q, got %q", want, got)
+	}
+}
+
+func TestNthFilter(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "table.html")
+	defer cancel()
+
+	footer := func(n *cdp.Node) bool { return n.AttributeValue("id") == "footer" }
+	tests := []struct {
+		name string
+		sel  string
+		opts []QueryOption
+		want string
+	}{
+		{"Nth", "td", []QueryOption{Nth(4)}, "2.2"},
+		{"Last", "td", []QueryOption{Nth(-1)}, "3.3"},
+		{"Filter", "tr", []QueryOption{Filter(footer)}, "3.1\t3.2\t3.3"},
+		{"FilterNth", "tr", []QueryOption{Filter(func(n *cdp.Node) bool { return !footer(n) }), Nth(-1)}, "2.1\t2.2\t2.3"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			if err := Run(ctx, Text(test.sel, &got, append(test.opts, ByQueryAll)...)); err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	var nodes []*cdp.Node
+	if err := Run(ctx, Nodes("tr", &nodes, ByQueryAll, Filter(footer), Nth(1), AtLeast(0))); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Errorf("want no node out of range, got %d", len(nodes))
+	}
+
+	// the query is retried until there are enough rows.
+	var got string
+	if err := Run(ctx,
+		Evaluate(`setTimeout(() => {
+			const row = document.createElement('tr');
+			row.innerHTML = '<td>4.1</td>';
+			document.querySelector('tbody').append(row);
+		}, 100)`, nil),
+		Text("tbody tr", &got, ByQueryAll, Nth(3)),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "4.1"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSelExpr(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "table.html")
+	defer cancel()
+
+	tests := []struct {
+		name string
+		e    *sel.Expr
+		want string
+	}{
+		{"Text", sel.Text("2.2"), "2.2"},
+		{"Has", sel.Tag("tr").Has(sel.Text("2.1")).Child("td").Nth(2), "2.3"},
+		{"Attr", sel.ID("footer").Child("td").Last(), "3.3"},
+		{"Sibling", sel.Tag("td").WithText("1.1").FollowingSibling("td"), "1.2"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			if err := Run(ctx, Text(test.e, &got, NodeVisible)); err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestFullPath(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "nested.html")
+	defer cancel()
+
+	if err := Run(ctx, Evaluate(`document.querySelector('#empty').innerHTML = '<span class="x a"></span><span class="x b"></span>'`, nil)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sel       string
+		wantCSS   string
+		wantXPath string
+	}{
+		{`body > p`, `body > p`, `/html[1]/body[1]/p[1]`},
+		{`#child1 p`, `#child1 > p`, `/html[1]/body[1]/div[1]/div[1]/p[1]`},
+		{`#child2 p + p`, `#child2 > p:nth-child(2)`, `/html[1]/body[1]/div[2]/div[1]/p[2]`},
+		{`#empty .b`, `#empty > span.b`, `/html[1]/body[1]/div[3]/span[2]`},
+		{`html`, `html`, `/html[1]`},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.sel, func(t *testing.T) {
+			var cssPath, xPath string
+			if err := Run(ctx, FullPath(test.sel, &cssPath, &xPath, ByQuery)); err != nil {
+				t.Fatal(err)
+			}
+			if cssPath != test.wantCSS {
+				t.Errorf("want CSS path %q, got %q", test.wantCSS, cssPath)
+			}
+			if xPath != test.wantXPath {
+				t.Errorf("want XPath %q, got %q", test.wantXPath, xPath)
+			}
+
+			// both paths match the same node.
+			var want, gotCSS, gotXPath []cdp.NodeID
+			if err := Run(ctx,
+				NodeIDs(test.sel, &want, ByQuery),
+				NodeIDs(cssPath, &gotCSS, ByQueryAll),
+				NodeIDs(xPath, &gotXPath, BySearch),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotCSS, want) || !reflect.DeepEqual(gotXPath, want) {
+				t.Errorf("want the paths to match %v, got %v and %v", want, gotCSS, gotXPath)
+			}
+		})
+	}
+}
+
+func TestSearchInShadow(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "input.html")
+	defer cancel()
+
+	// the value set by the script is only in the user-agent shadow DOM of the
+	// input, not in its attributes.
+	if err := Run(ctx, SetJavascriptAttribute(`#input3`, "value", "shadowed value", ByQuery)); err != nil {
+		t.Fatal(err)
+	}
+	for _, include := range []bool{false, true} {
+		var nodes []*cdp.Node
+		if err := Run(ctx, Nodes(`shadowed value`, &nodes, SearchInShadow(include), AtLeast(0))); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(nodes) > 0; got != include {
+			t.Errorf("SearchInShadow(%v): want found=%v, got %d nodes", include, include, len(nodes))
+		}
+	}
+}
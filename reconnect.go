@@ -0,0 +1,331 @@
+package chromedp
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/mailru/easyjson"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+)
+
+// maxReconnectBackoff is the longest a browser waits between two attempts to
+// reconnect.
+const maxReconnectBackoff = 30 * time.Second
+
+// targetResumeTimeout bounds the commands which set up a target again, once
+// its browser reconnected.
+const targetResumeTimeout = 10 * time.Second
+
+// reconnectPolicy is how a browser reconnects once the connection is lost, as
+// set up by WithReconnect.
+type reconnectPolicy struct {
+	attempts int
+	backoff  time.Duration
+	// url returns the websocket URL to dial, which may have changed if the
+	// browser restarted.
+	url func(ctx context.Context) (string, error)
+}
+
+// shouldReconnect returns whether the browser should reconnect once its
+// connection is lost, which it doesn't when it's closed.
+func (b *Browser) shouldReconnect(ctx context.Context) bool {
+	if b.reconnect == nil || ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-b.closingGracefully:
+		return false
+	default:
+		return true
+	}
+}
+
+// reconnectTargets dials the browser again once the connection was lost, with
+// the backoff of the reconnect policy, and attaches to its targets again, as
+// their sessions ended with the connection. It's called by the main loop of
+// run, so that the commands are queued meanwhile.
+func (b *Browser) reconnectTargets(ctx context.Context) error {
+	b.conn.Close()
+	// the replies to the commands sent over the lost connection won't come.
+	b.failSent(ErrConnectionLost)
+	backoff := b.reconnect.backoff
+	var conn Transport
+	var sessions map[target.SessionID]target.SessionID
+	for attempt := 1; ; attempt++ {
+		var err error
+		if conn, sessions, err = b.redial(ctx); err == nil {
+			break
+		}
+		if attempt >= b.reconnect.attempts {
+			return err
+		}
+		b.errf("could not reconnect to the browser, retrying in %v: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+	b.conn = conn
+
+	// the commands queued with the previous sessions are sent with the new
+	// ones.
+	aliases := make(map[target.SessionID]target.SessionID, len(sessions))
+	for prev, cur := range b.sessionAliases {
+		if sessionID, ok := sessions[cur]; ok {
+			aliases[prev] = sessionID
+		}
+	}
+	// the commands held for the targets which weren't resumed yet are held
+	// until they're resumed in their new sessions, and those of the targets
+	// which are gone fail.
+	held := make(map[target.SessionID][]*cdproto.Message, len(sessions))
+	for prev, msgs := range b.held {
+		sessionID, ok := sessions[prev]
+		if !ok {
+			for _, msg := range msgs {
+				b.failPending(msg.ID, ErrTargetLost)
+			}
+			continue
+		}
+		for _, msg := range msgs {
+			msg.SessionID = sessionID
+		}
+		held[sessionID] = msgs
+	}
+	pages := make(map[target.SessionID]*Target, len(b.pages))
+	for prev, t := range b.pages {
+		sessionID, ok := sessions[prev]
+		t.sessionMu.Lock()
+		if ok {
+			t.SessionID = sessionID
+		} else {
+			t.lost = true
+		}
+		t.sessionMu.Unlock()
+		if !ok {
+			b.errf("could not attach to target %s again once reconnected", t.TargetID)
+			continue
+		}
+		aliases[prev] = sessionID
+		pages[sessionID] = t
+		if held[sessionID] == nil {
+			held[sessionID] = []*cdproto.Message{}
+		}
+		go t.resume(ctx, sessionID)
+	}
+	b.pages = pages
+	b.sessionAliases = aliases
+	b.held = held
+	return nil
+}
+
+// redial dials the browser, at the URL it may have after a restart, and
+// attaches to its targets by their IDs. It returns the new connection, and the
+// new sessions of the targets indexed by their previous ones; the targets
+// which are gone have none.
+func (b *Browser) redial(ctx context.Context) (Transport, map[target.SessionID]target.SessionID, error) {
+	urlstr, err := b.reconnect.url(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := b.dial(ctx, urlstr)
+	if err != nil {
+		return nil, nil, err
+	}
+	sessions, err := b.attachTargets(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, sessions, nil
+}
+
+// attachTargets attaches to the targets of the browser over conn, before the
+// reading goroutine of run reads from it, within the dial timeout.
+func (b *Browser) attachTargets(ctx context.Context, conn Transport) (map[target.SessionID]target.SessionID, error) {
+	if b.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.dialTimeout)
+		defer cancel()
+	}
+	// the reads are blocking, so break them by closing conn.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	sessions := make(map[target.SessionID]target.SessionID, len(b.pages))
+	for prev, t := range b.pages {
+		params, err := easyjson.Marshal(target.AttachToTarget(t.TargetID).WithFlatten(true))
+		if err != nil {
+			return nil, err
+		}
+		id := atomic.AddInt64(&b.next, 1)
+		if err := conn.Write(ctx, &cdproto.Message{
+			ID:     id,
+			Method: target.CommandAttachToTarget,
+			Params: params,
+		}); err != nil {
+			return nil, err
+		}
+		for {
+			msg := new(cdproto.Message)
+			if err := conn.Read(ctx, msg); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, err
+			}
+			// the events sent before the domains are enabled again
+			// aren't needed.
+			if msg.ID != id {
+				continue
+			}
+			if msg.Error == nil {
+				var res target.AttachToTargetReturns
+				if err := easyjson.Unmarshal(msg.Result, &res); err != nil {
+					return nil, err
+				}
+				sessions[prev] = res.SessionID
+			}
+			break
+		}
+	}
+	return sessions, nil
+}
+
+// pendingCommand is a command waiting for its reply, tracked with
+// WithReconnect.
+type pendingCommand struct {
+	// sent is whether the command was sent over the current connection,
+	// rather than queued or held.
+	sent bool
+	// lost is sent the error of the command if it won't be replied to.
+	lost chan error
+}
+
+// addPending tracks the command id until its reply is read, returning the
+// channel its error is sent to if it's lost, and the func to call once it's
+// done waiting. Without WithReconnect, the channel is nil.
+func (b *Browser) addPending(id int64) (<-chan error, func()) {
+	if b.reconnect == nil {
+		return nil, func() {}
+	}
+	cmd := &pendingCommand{lost: make(chan error, 1)}
+	b.pendingMu.Lock()
+	if b.pending == nil {
+		b.pending = make(map[int64]*pendingCommand)
+	}
+	b.pending[id] = cmd
+	b.pendingMu.Unlock()
+	return cmd.lost, func() { b.replied(id) }
+}
+
+// sent marks the command id as sent over the current connection.
+func (b *Browser) sent(id int64) {
+	if b.reconnect == nil {
+		return
+	}
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	if cmd, ok := b.pending[id]; ok {
+		cmd.sent = true
+	}
+}
+
+// replied stops tracking the command id. It's called by the reading goroutine
+// of run as soon as the reply is read, so that the command isn't failed once
+// the connection is lost afterwards.
+func (b *Browser) replied(id int64) {
+	if b.reconnect == nil {
+		return
+	}
+	b.pendingMu.Lock()
+	delete(b.pending, id)
+	b.pendingMu.Unlock()
+}
+
+// failSent fails the commands sent over the current connection with err.
+func (b *Browser) failSent(err error) {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	for id, cmd := range b.pending {
+		if cmd.sent {
+			cmd.lost <- err
+			delete(b.pending, id)
+		}
+	}
+}
+
+// failPending fails the command id with err, if it's tracked.
+func (b *Browser) failPending(id int64, err error) {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	if cmd, ok := b.pending[id]; ok {
+		cmd.lost <- err
+		delete(b.pending, id)
+	}
+}
+
+// resumeKey is the context key of the commands which set up a target again
+// once its browser reconnected, which aren't held until it's resumed.
+type resumeKey struct{}
+
+// commandQueue returns the queue to send the commands executed with ctx to.
+func (t *Target) commandQueue(ctx context.Context) chan<- *cdproto.Message {
+	if ctx.Value(resumeKey{}) != nil {
+		return t.browser.resumeQueue
+	}
+	return t.browser.cmdQueue
+}
+
+// resume sets up the target again once its browser reconnected and attached to
+// it in the session sessionID, enabling its domains and applying its emulation,
+// as they were reset with the previous session. The commands of the actions
+// are held until it's done.
+func (t *Target) resume(ctx context.Context, sessionID target.SessionID) {
+	err := t.setUpAgain(ctx)
+	select {
+	case <-ctx.Done():
+		return
+	case t.browser.resumedQueue <- sessionID:
+	}
+	if err != nil {
+		t.errf("could not set up target %s again once reconnected: %v", t.TargetID, err)
+		return
+	}
+	// the node IDs of the previous session are no longer valid. This is
+	// done once the commands are no longer held, as the target may be
+	// retrieving its document already.
+	if !t.isWorker {
+		dctx, cancel := context.WithTimeout(ctx, targetResumeTimeout)
+		defer cancel()
+		t.documentUpdated(dctx)
+	}
+}
+
+// setUpAgain enables the domains of the target and applies its emulation in
+// its new session, bypassing the commands held until it's resumed.
+func (t *Target) setUpAgain(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, resumeKey{}, true), targetResumeTimeout)
+	defer cancel()
+	if err := runtime.Enable().Do(cdp.WithExecutor(ctx, t)); err != nil {
+		return err
+	}
+	if err := t.enableDomains(ctx); err != nil {
+		return err
+	}
+	if !t.isWorker {
+		if err := t.applyEmulation(ctx, t.currentEmulation()); err != nil {
+			return err
+		}
+	}
+	return t.applyNetworkConditions(ctx)
+}
@@ -0,0 +1,200 @@
+package chromedp
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+)
+
+// recycleCloseTimeout is how long a recycled browser has to close itself
+// before it's killed.
+const recycleCloseTimeout = 5 * time.Second
+
+// WithRecycleAfter is an ExecAllocatorOption to restart the browser once it
+// opened tabs tabs, once it's older than age, or once the resident memory of
+// its processes exceeds rssBytes, as returned by Browser.ResidentMemory. Zero
+// values disable the corresponding threshold.
+//
+// The thresholds are checked when the child contexts of the context which
+// allocated the browser open their tabs, on their first Run. When one is
+// reached, the browser is drained: the new tabs wait for the open ones to be
+// closed by cancelling their contexts. The browser is then closed, and a new
+// one is started for the new tabs, transparently.
+//
+// The context which allocated the browser is moved to a new blank tab of the
+// new browser. The listeners added by ListenBrowser are moved to the new
+// browser too, but the events of the browser which was closed are lost.
+func WithRecycleAfter(tabs int, age time.Duration, rssBytes int64) ExecAllocatorOption {
+	return func(a *ExecAllocator) {
+		a.recycleTabs = tabs
+		a.recycleAge = age
+		a.recycleRSS = rssBytes
+	}
+}
+
+// recycles reports whether the browsers of a are restarted, as set up by
+// WithRecycleAfter.
+func (a *ExecAllocator) recycles() bool {
+	return a.recycleTabs > 0 || a.recycleAge > 0 || a.recycleRSS > 0
+}
+
+// recycler restarts the browser of the context which allocated it, as set up
+// by WithRecycleAfter. It's shared with the child contexts.
+type recycler struct {
+	alloc *ExecAllocator
+	root  *Context
+
+	// rootCtx is the context the browser was first allocated with, which
+	// holds root. Each browser runs with a child of it, cancelled by
+	// cancelGen once the browser is recycled.
+	rootCtx   context.Context
+	cancelGen context.CancelFunc
+
+	// mu is held while a tab is acquired, so that the new tabs wait for a
+	// browser being recycled.
+	mu   sync.Mutex
+	tabs int
+	// listeners are those of the recycled browser, kept if the new browser
+	// couldn't be started, to be moved to the next one.
+	listeners []cancelableListener
+}
+
+// startRecycler allocates the browser of the root context c, and sets up its
+// recycler.
+func (c *Context) startRecycler(ctx context.Context, a *ExecAllocator) error {
+	r := &recycler{alloc: a, root: c, rootCtx: ctx}
+	if err := r.start(c.browserListeners); err != nil {
+		return err
+	}
+	c.recycler = r
+	return nil
+}
+
+// start allocates a browser for the root context and attaches the root context
+// to its first tab.
+func (r *recycler) start(listeners []cancelableListener) error {
+	c := r.root
+	ctx, cancel := context.WithCancel(r.rootCtx)
+	b, err := c.Allocator.Allocate(ctx, c.browserOpts...)
+	if err != nil {
+		cancel()
+		c.Browser = nil
+		return err
+	}
+	c.Browser = b
+	b.listenersMu.Lock()
+	b.listeners = append(b.listeners, listeners...)
+	b.listenersMu.Unlock()
+	// the target of the root context runs with the browser, so that it's
+	// stopped along with it.
+	if err := c.newTarget(ctx); err != nil {
+		// kill it.
+		cancel()
+		<-c.allocated
+		r.resetRoot()
+		return err
+	}
+	r.cancelGen = cancel
+	r.tabs = 0
+	return nil
+}
+
+// acquire returns the browser a new tab is to be opened in, and registers its
+// cleanup. The browser is recycled first if it reached a threshold.
+func (r *recycler) acquire(ctx context.Context) (*Browser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.root.Browser == nil {
+		// the previous restart failed to start a browser.
+		if err := r.start(r.listeners); err != nil {
+			return nil, err
+		}
+		r.listeners = nil
+	}
+	if r.due() {
+		if err := r.restart(ctx); err != nil {
+			return nil, err
+		}
+	}
+	b := r.root.Browser
+	if !b.addCleanup() {
+		// a previous restart was interrupted while draining.
+		if err := r.restart(ctx); err != nil {
+			return nil, err
+		}
+		b = r.root.Browser
+		b.addCleanup()
+	}
+	r.tabs++
+	return b, nil
+}
+
+// due reports whether the browser reached a threshold of WithRecycleAfter.
+func (r *recycler) due() bool {
+	b, a := r.root.Browser, r.alloc
+	switch {
+	case a.recycleTabs > 0 && r.tabs >= a.recycleTabs:
+		return true
+	case a.recycleAge > 0 && time.Since(b.StartTime()) >= a.recycleAge:
+		return true
+	case a.recycleRSS > 0 && b.ResidentMemory() >= a.recycleRSS:
+		return true
+	}
+	return false
+}
+
+// restart waits for the tabs of the browser to be closed, closes it, and starts
+// a new one.
+func (r *recycler) restart(ctx context.Context) error {
+	c := r.root
+	old := c.Browser
+	drained := make(chan struct{})
+	go func() {
+		old.waitCleanups()
+		close(drained)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-drained:
+	}
+
+	// Close the browser as Cancel does; closingGracefully keeps the lost
+	// connection from cancelling the root context.
+	close(old.closingGracefully)
+	cctx, cancel := context.WithTimeout(ctx, recycleCloseTimeout)
+	defer cancel()
+	old.execute(cctx, browser.CommandClose, nil, nil)
+	select {
+	case <-c.allocated:
+	case <-cctx.Done():
+		// kill it.
+		r.cancelGen()
+		<-c.allocated
+	}
+	r.cancelGen()
+
+	old.listenersMu.Lock()
+	listeners := slices.Clone(old.listeners)
+	old.listenersMu.Unlock()
+
+	r.resetRoot()
+	if err := r.start(listeners); err != nil {
+		r.listeners = listeners
+		return err
+	}
+	return nil
+}
+
+// resetRoot resets the root context, once its browser exited, so that a new
+// one can be allocated.
+func (r *recycler) resetRoot() {
+	c := r.root
+	// set up the semaphore for Allocator.Allocate again.
+	c.allocated = make(chan struct{}, 1)
+	c.allocated <- struct{}{}
+	c.Browser, c.Target, c.targetID = nil, nil, ""
+}
@@ -0,0 +1,124 @@
+package chromedp
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tabBrowserPID opens a new tab from ctx, returning the PID of its browser and
+// the cancel func of its context.
+func tabBrowserPID(t *testing.T, ctx context.Context) (int, context.CancelFunc) {
+	t.Helper()
+	tabCtx, cancel := NewContext(ctx)
+	if err := Run(tabCtx, Navigate(testdataDir+"/form.html")); err != nil {
+		t.Fatal(err)
+	}
+	return FromContext(tabCtx).Browser.PID(), cancel
+}
+
+func TestRecycleAfterTabs(t *testing.T) {
+	t.Parallel()
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], WithRecycleAfter(2, 0, 0))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	var events atomic.Int32
+	ListenBrowser(ctx, func(ev interface{}) { events.Add(1) })
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	first := FromContext(ctx).Browser.PID()
+
+	var pids []int
+	for i := 0; i < 3; i++ {
+		pid, cancel := tabBrowserPID(t, ctx)
+		cancel()
+		pids = append(pids, pid)
+	}
+	if pids[0] != first || pids[1] != first {
+		t.Errorf("want the first 2 tabs in browser %d, got %v", first, pids)
+	}
+	if pids[2] == first {
+		t.Errorf("want the third tab in a new browser, got %v", pids)
+	}
+
+	// the root context moved to the new browser.
+	if pid := FromContext(ctx).Browser.PID(); pid != pids[2] {
+		t.Errorf("want the root context in browser %d, got %d", pids[2], pid)
+	}
+	// so did the browser listener.
+	n := events.Load()
+	var title string
+	if err := Run(ctx, Navigate(testdataDir+"/form.html"), Title(&title)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Cancel(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if events.Load() == n {
+		t.Error("want the browser listener to receive the events of the new browser")
+	}
+}
+
+func TestRecycleDrain(t *testing.T) {
+	t.Parallel()
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], WithRecycleAfter(1, 0, 0))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	pid1, cancel1 := tabBrowserPID(t, ctx)
+	// the second tab waits for the first one to be closed.
+	done := make(chan int, 1)
+	go func() {
+		tabCtx, cancel := NewContext(ctx)
+		defer cancel()
+		if err := Run(tabCtx); err != nil {
+			t.Error(err)
+		}
+		done <- FromContext(tabCtx).Browser.PID()
+	}()
+	select {
+	case <-done:
+		t.Fatal("want the second tab to wait for the first one")
+	case <-time.After(300 * time.Millisecond):
+	}
+	cancel1()
+	select {
+	case pid2 := <-done:
+		if pid2 == pid1 {
+			t.Errorf("want the second tab in a new browser, got %d", pid2)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("want the second tab to be opened once the first one is closed")
+	}
+}
+
+func TestRecycleAfterAge(t *testing.T) {
+	t.Parallel()
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts[:len(allocOpts):len(allocOpts)], WithRecycleAfter(0, time.Nanosecond, 0))...)
+	defer cancel()
+	ctx, cancel := NewContext(allocCtx)
+	defer cancel()
+	if err := Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pid1, cancel1 := tabBrowserPID(t, ctx)
+	cancel1()
+	pid2, cancel2 := tabBrowserPID(t, ctx)
+	cancel2()
+	if pid1 == pid2 {
+		t.Errorf("want each tab in a new browser, got %d twice", pid1)
+	}
+}
@@ -0,0 +1,321 @@
+package chromedp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// FormatRemoteObject formats obj as the DevTools console would, such as for the
+// arguments of a runtime.EventConsoleAPICalled event.
+//
+// Strings are formatted as is, the other primitive values as their JavaScript
+// literals, including the unserializable values such as NaN, -0 or BigInts
+// like 12n. Objects are formatted from their preview, when there's one, such
+// as
+//
+//	{a: 1, s: "x", o: {b: Array(2)}, ...}
+//	Foo {x: 1}
+//	[1, "x", Object]
+//	Map(2) {"k" => 1, {a: 1} => "v"}
+//
+// where ... marks the properties or entries which didn't fit in the preview.
+// The other objects, such as errors, dates and DOM nodes, are formatted as
+// their description.
+func FormatRemoteObject(obj *runtime.RemoteObject) string {
+	switch {
+	case obj == nil:
+		return ""
+	case obj.Type == runtime.TypeString:
+		var s string
+		if json.Unmarshal(obj.Value, &s) == nil {
+			return s
+		}
+		return obj.Description
+	case obj.UnserializableValue != "":
+		return string(obj.UnserializableValue)
+	case obj.Type == runtime.TypeUndefined:
+		return "undefined"
+	case obj.Subtype == runtime.SubtypeNull:
+		return "null"
+	case obj.Value != nil && obj.Type != runtime.TypeObject:
+		return string(obj.Value)
+	case obj.Preview != nil:
+		return formatObjectPreview(obj.Preview)
+	case obj.Description != "":
+		return obj.Description
+	case obj.Value != nil:
+		// an object returned by value.
+		return string(obj.Value)
+	}
+	return obj.ClassName
+}
+
+// formatObjectPreview formats p as FormatRemoteObject.
+func formatObjectPreview(p *runtime.ObjectPreview) string {
+	switch {
+	case p.Type == runtime.TypeString:
+		return strconv.Quote(p.Description)
+	case p.Type != runtime.TypeObject:
+		return p.Description
+	}
+	var items []string
+	var open, close string
+	switch p.Subtype {
+	case runtime.SubtypeNull:
+		return "null"
+	case runtime.SubtypeError, runtime.SubtypeDate, runtime.SubtypeRegexp, runtime.SubtypeNode:
+		return p.Description
+
+	case runtime.SubtypeArray, runtime.SubtypeTypedarray:
+		for _, prop := range p.Properties {
+			if _, err := strconv.Atoi(prop.Name); err == nil {
+				items = append(items, formatPropertyPreview(prop))
+			}
+		}
+		open, close = "[", "]"
+		if p.Subtype == runtime.SubtypeTypedarray {
+			open = p.Description + " ["
+		}
+
+	case runtime.SubtypeMap, runtime.SubtypeSet, runtime.SubtypeWeakmap, runtime.SubtypeWeakset:
+		for _, e := range p.Entries {
+			item := formatObjectPreview(e.Value)
+			if e.Key != nil {
+				item = formatObjectPreview(e.Key) + " => " + item
+			}
+			items = append(items, item)
+		}
+		open, close = p.Description+" {", "}"
+
+	default:
+		for _, prop := range p.Properties {
+			items = append(items, prop.Name+": "+formatPropertyPreview(prop))
+		}
+		open, close = "{", "}"
+		if p.Description != "" && p.Description != "Object" {
+			open = p.Description + " {"
+		}
+	}
+	if p.Overflow {
+		items = append(items, "...")
+	}
+	return open + strings.Join(items, ", ") + close
+}
+
+// formatPropertyPreview formats the value of the property p as
+// FormatRemoteObject.
+func formatPropertyPreview(p *runtime.PropertyPreview) string {
+	switch {
+	case p.ValuePreview != nil:
+		return formatObjectPreview(p.ValuePreview)
+	case p.Type == runtime.TypeString:
+		return strconv.Quote(p.Value)
+	case p.Type == runtime.TypeAccessor:
+		return "(...)"
+	}
+	return p.Value
+}
+
+// DecodeRemoteObject decodes obj into v, which must be a pointer.
+//
+// The values returned by value, such as by Evaluate, are decoded with
+// json.Unmarshal, as Evaluate does. The unserializable numbers (NaN, -0 and
+// ±Infinity) are decoded into floats, and BigInts into a *big.Int, an integer
+// or a float. The deep serialized values are converted as DeepEvaluate does,
+// except for the typed arrays and ArrayBuffers, which are left as
+// *runtime.DeepSerializedValue, as their contents are only in the browser.
+//
+// The other objects are decoded from their preview, such as the arguments of a
+// runtime.EventConsoleAPICalled event: arrays and sets into []interface{},
+// maps into []DeepMapEntry, and the other objects into map[string]interface{},
+// before being decoded into v with encoding/json, unless v is an
+// *interface{}. Note that the previews are shallow and can be truncated, and
+// hold the descriptions of the nested objects instead of their values, such as
+// "Object" or "Array(2)".
+func DecodeRemoteObject(obj *runtime.RemoteObject, v interface{}) error {
+	var x interface{}
+	switch {
+	case obj.UnserializableValue != "":
+		return decodeUnserializableValue(string(obj.UnserializableValue), v)
+	case obj.Value != nil || obj.Type == runtime.TypeUndefined || obj.Subtype == runtime.SubtypeNull:
+		return parseRemoteObject(obj, v)
+	case obj.DeepSerializedValue != nil:
+		var err error
+		if x, err = fromDeepSerializedValue(obj.DeepSerializedValue); err != nil {
+			return err
+		}
+	case obj.Preview != nil:
+		x = fromObjectPreview(obj.Preview)
+	default:
+		return fmt.Errorf("could not decode %s without a value or a preview", obj.Type)
+	}
+	if p, ok := v.(*interface{}); ok {
+		*p = x
+		return nil
+	}
+	buf, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// decodeUnserializableValue decodes the unserializable value s of a number or
+// a BigInt into v.
+func decodeUnserializableValue(s string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("could not decode %s into %T", s, v)
+	}
+	x := unserializableValue(s)
+	switch x := x.(type) {
+	case *big.Int:
+		switch p := v.(type) {
+		case *big.Int:
+			p.Set(x)
+			return nil
+		case **big.Int:
+			*p = x
+			return nil
+		}
+		switch e := rv.Elem(); e.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if x.IsInt64() && !e.OverflowInt(x.Int64()) {
+				e.SetInt(x.Int64())
+				return nil
+			}
+			return fmt.Errorf("could not decode %s into %T: out of range", s, v)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if x.IsUint64() && !e.OverflowUint(x.Uint64()) {
+				e.SetUint(x.Uint64())
+				return nil
+			}
+			return fmt.Errorf("could not decode %s into %T: out of range", s, v)
+		case reflect.Float32, reflect.Float64:
+			f, _ := new(big.Float).SetInt(x).Float64()
+			e.SetFloat(f)
+			return nil
+		}
+	case float64:
+		switch e := rv.Elem(); e.Kind() {
+		case reflect.Float32, reflect.Float64:
+			e.SetFloat(x)
+			return nil
+		}
+	case nil:
+		return fmt.Errorf("invalid unserializable value %q", s)
+	}
+	if e := rv.Elem(); e.Kind() == reflect.Interface && e.NumMethod() == 0 {
+		e.Set(reflect.ValueOf(x))
+		return nil
+	}
+	return fmt.Errorf("could not decode %s into %T", s, v)
+}
+
+// unserializableValue converts the unserializable value s of a number to a
+// float64, or of a BigInt to a *big.Int. It returns nil if s is invalid.
+func unserializableValue(s string) interface{} {
+	switch s {
+	case "NaN":
+		return math.NaN()
+	case "-0":
+		return math.Copysign(0, -1)
+	case "Infinity":
+		return math.Inf(1)
+	case "-Infinity":
+		return math.Inf(-1)
+	}
+	if digits, ok := strings.CutSuffix(s, "n"); ok {
+		if i, ok := new(big.Int).SetString(digits, 10); ok {
+			return i
+		}
+	}
+	return nil
+}
+
+// fromObjectPreview converts the object preview p to a Go value, as described
+// in DecodeRemoteObject.
+func fromObjectPreview(p *runtime.ObjectPreview) interface{} {
+	if p.Type != runtime.TypeObject || p.Subtype == runtime.SubtypeNull {
+		return fromPreviewValue(p.Type, p.Subtype, p.Description)
+	}
+	switch p.Subtype {
+	case runtime.SubtypeDate, runtime.SubtypeRegexp, runtime.SubtypeNode:
+		return p.Description
+
+	case runtime.SubtypeArray, runtime.SubtypeTypedarray:
+		var a []interface{}
+		for _, prop := range p.Properties {
+			if _, err := strconv.Atoi(prop.Name); err == nil {
+				a = append(a, fromPropertyPreview(prop))
+			}
+		}
+		return a
+
+	case runtime.SubtypeSet, runtime.SubtypeWeakset:
+		var a []interface{}
+		for _, e := range p.Entries {
+			a = append(a, fromObjectPreview(e.Value))
+		}
+		return a
+
+	case runtime.SubtypeMap, runtime.SubtypeWeakmap:
+		var m []DeepMapEntry
+		for _, e := range p.Entries {
+			var key interface{}
+			if e.Key != nil {
+				key = fromObjectPreview(e.Key)
+			}
+			m = append(m, DeepMapEntry{Key: key, Value: fromObjectPreview(e.Value)})
+		}
+		return m
+	}
+	m := make(map[string]interface{}, len(p.Properties))
+	for _, prop := range p.Properties {
+		if prop.Type != runtime.TypeAccessor {
+			m[prop.Name] = fromPropertyPreview(prop)
+		}
+	}
+	return m
+}
+
+// fromPropertyPreview converts the value of the property preview p to a Go
+// value.
+func fromPropertyPreview(p *runtime.PropertyPreview) interface{} {
+	if p.ValuePreview != nil {
+		return fromObjectPreview(p.ValuePreview)
+	}
+	return fromPreviewValue(p.Type, p.Subtype, p.Value)
+}
+
+// fromPreviewValue converts the user-friendly string s of a value of a preview
+// to a Go value. The objects are left as their description.
+func fromPreviewValue(typ runtime.Type, subtype runtime.Subtype, s string) interface{} {
+	switch typ {
+	case runtime.TypeUndefined:
+		return nil
+	case runtime.TypeNumber:
+		// ParseFloat also parses NaN, -0 and ±Infinity.
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case runtime.TypeBoolean:
+		return s == "true"
+	case runtime.TypeBigint:
+		if x, ok := unserializableValue(s).(*big.Int); ok {
+			return x
+		}
+	case runtime.TypeObject:
+		if subtype == runtime.SubtypeNull {
+			return nil
+		}
+	}
+	return s
+}
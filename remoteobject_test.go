@@ -0,0 +1,142 @@
+package chromedp
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// evalPreview evaluates expression, returning its result as a remote object
+// with a preview, as the arguments of the console events.
+func evalPreview(t *testing.T, expression string) *runtime.RemoteObject {
+	t.Helper()
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+	var obj *runtime.RemoteObject
+	if err := Run(ctx, Evaluate(expression, &obj, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithGeneratePreview(true)
+	})); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func TestFormatRemoteObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{`"s"`, `s`},
+		{`1.5`, `1.5`},
+		{`true`, `true`},
+		{`NaN`, `NaN`},
+		{`-0`, `-0`},
+		{`12n`, `12n`},
+		{`null`, `null`},
+		{`undefined`, `undefined`},
+		{`Symbol("x")`, `Symbol(x)`},
+		{`({a: 1, s: "x", n: null, u: undefined, o: {b: 1}})`, `{a: 1, s: "x", n: null, u: undefined, o: Object}`},
+		{`[1, "x", {a: 1}]`, `[1, "x", Object]`},
+		{`new Uint8Array(2)`, `Uint8Array(2) [0, 0]`},
+		{`new Map([["k", 1], [{a: 1}, "v"]])`, `Map(2) {"k" => 1, {a: 1} => "v"}`},
+		{`new Set([1, "a"])`, `Set(2) {1, "a"}`},
+		{`new (class Foo { constructor() { this.x = 1 } })`, `Foo {x: 1}`},
+		{`/ab/g`, `/ab/g`},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.expression, func(t *testing.T) {
+			t.Parallel()
+			if got := FormatRemoteObject(evalPreview(t, test.expression)); got != test.want {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+
+	overflow := FormatRemoteObject(evalPreview(t, `Array(200).fill(1)`))
+	if want := "1, ...]"; len(overflow) < len(want) || overflow[len(overflow)-len(want):] != want {
+		t.Errorf("want the overflow marked, got %q", overflow)
+	}
+
+	// the nested previews of the console events.
+	nested := &runtime.RemoteObject{
+		Type:        runtime.TypeObject,
+		Description: "Object",
+		Preview: &runtime.ObjectPreview{
+			Type:        runtime.TypeObject,
+			Description: "Object",
+			Properties: []*runtime.PropertyPreview{{
+				Name:  "o",
+				Type:  runtime.TypeObject,
+				Value: "Object",
+				ValuePreview: &runtime.ObjectPreview{
+					Type:        runtime.TypeObject,
+					Description: "Object",
+					Overflow:    true,
+					Properties:  []*runtime.PropertyPreview{{Name: "b", Type: runtime.TypeObject, Subtype: runtime.SubtypeArray, Value: "Array(2)"}},
+				},
+			}},
+		},
+	}
+	if got, want := FormatRemoteObject(nested), `{o: {b: Array(2), ...}}`; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestDecodeRemoteObject(t *testing.T) {
+	t.Parallel()
+
+	var f float64
+	if err := DecodeRemoteObject(evalPreview(t, `-0`), &f); err != nil || f != 0 || !math.Signbit(f) {
+		t.Errorf("want -0, got %v, %v", f, err)
+	}
+	if err := DecodeRemoteObject(evalPreview(t, `NaN`), &f); err != nil || !math.IsNaN(f) {
+		t.Errorf("want NaN, got %v, %v", f, err)
+	}
+
+	big1 := evalPreview(t, `12345678901234567890n`)
+	var b big.Int
+	if err := DecodeRemoteObject(big1, &b); err != nil || b.String() != "12345678901234567890" {
+		t.Errorf("want the BigInt, got %v, %v", &b, err)
+	}
+	var i8 int8
+	if err := DecodeRemoteObject(big1, &i8); err == nil {
+		t.Errorf("want an out of range error, got %v", i8)
+	}
+	var n int
+	if err := DecodeRemoteObject(evalPreview(t, `12n`), &n); err != nil || n != 12 {
+		t.Errorf("want 12, got %v, %v", n, err)
+	}
+
+	var s struct {
+		A int
+		S string
+		N *int
+	}
+	if err := DecodeRemoteObject(evalPreview(t, `({A: 1, S: "x", N: null})`), &s); err != nil || s.A != 1 || s.S != "x" || s.N != nil {
+		t.Errorf("want the object decoded from its preview, got %+v, %v", s, err)
+	}
+
+	var x interface{}
+	if err := DecodeRemoteObject(evalPreview(t, `new Map([["k", [1, 2]]])`), &x); err != nil {
+		t.Fatal(err)
+	}
+	if want := []DeepMapEntry{{Key: "k", Value: []interface{}{1.0, 2.0}}}; !reflect.DeepEqual(x, want) {
+		t.Errorf("want %#v, got %#v", want, x)
+	}
+	if err := DecodeRemoteObject(evalPreview(t, `new Set([1, "a", true])`), &x); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{1.0, "a", true}; !reflect.DeepEqual(x, want) {
+		t.Errorf("want %#v, got %#v", want, x)
+	}
+
+	if err := DecodeRemoteObject(evalPreview(t, `(function() {})`), &x); err == nil {
+		t.Error("want an error for a function without a preview")
+	}
+}
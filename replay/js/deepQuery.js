@@ -0,0 +1,22 @@
+function deepQuery(selector, text) {
+    // Walk the document and its open shadow roots, returning the first
+    // element matching the CSS selector, or the first innermost element
+    // containing text.
+    const roots = [document];
+    while (roots.length) {
+        const root = roots.shift();
+        for (const el of root.querySelectorAll('*')) {
+            if (selector && el.matches(selector)) {
+                return el;
+            }
+            if (text && el.textContent.includes(text) &&
+                !Array.from(el.children).some((c) => c.textContent.includes(text))) {
+                return el;
+            }
+            if (el.shadowRoot) {
+                roots.push(el.shadowRoot);
+            }
+        }
+    }
+    return null;
+}
@@ -0,0 +1,10 @@
+function setValue(value) {
+    this.focus();
+    if (this.isContentEditable) {
+        this.textContent = value;
+    } else {
+        this.value = value;
+    }
+    this.dispatchEvent(new Event('input', {bubbles: true}));
+    this.dispatchEvent(new Event('change', {bubbles: true}));
+}
@@ -0,0 +1,89 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Recording is a user flow, in the JSON format exported by the Chrome DevTools
+// Recorder panel.
+//
+// See https://github.com/puppeteer/replay for a description of the format.
+type Recording struct {
+	Title string `json:"title"`
+	// Timeout is the default timeout of the steps, in milliseconds.
+	Timeout int64   `json:"timeout,omitempty"`
+	Steps   []*Step `json:"steps"`
+}
+
+// Step is a single step of a Recording. Only the fields supported by this
+// package are decoded.
+type Step struct {
+	Type      string     `json:"type"`
+	Target    string     `json:"target,omitempty"`
+	Frame     []int      `json:"frame,omitempty"`
+	Selectors []Selector `json:"selectors,omitempty"`
+	// Timeout is the timeout of the step, in milliseconds.
+	Timeout int64 `json:"timeout,omitempty"`
+
+	// navigate
+	URL string `json:"url,omitempty"`
+
+	// change
+	Value string `json:"value,omitempty"`
+
+	// keyDown, keyUp
+	Key string `json:"key,omitempty"`
+
+	// click, doubleClick
+	Button string `json:"button,omitempty"`
+
+	// waitForElement
+	Visible *bool `json:"visible,omitempty"`
+
+	// waitForExpression
+	Expression string `json:"expression,omitempty"`
+
+	// scroll
+	X float64 `json:"x,omitempty"`
+	Y float64 `json:"y,omitempty"`
+
+	// setViewport
+	Width             int64   `json:"width,omitempty"`
+	Height            int64   `json:"height,omitempty"`
+	DeviceScaleFactor float64 `json:"deviceScaleFactor,omitempty"`
+	IsMobile          bool    `json:"isMobile,omitempty"`
+	HasTouch          bool    `json:"hasTouch,omitempty"`
+	IsLandscape       bool    `json:"isLandscape,omitempty"`
+}
+
+// Selector is a selector of a Step. Each element selects inside the shadow
+// root or the frame matched by the previous one, and may be prefixed with
+// "aria/", "text/", "xpath/" or "pierce/"; other elements are CSS selectors.
+type Selector []string
+
+// UnmarshalJSON satisfies json.Unmarshaler, as a selector is either a string
+// or an array of strings.
+func (s *Selector) UnmarshalJSON(buf []byte) error {
+	var str string
+	if err := json.Unmarshal(buf, &str); err == nil {
+		*s = Selector{str}
+		return nil
+	}
+	var strs []string
+	if err := json.Unmarshal(buf, &strs); err != nil {
+		return fmt.Errorf("invalid selector %s", buf)
+	}
+	*s = strs
+	return nil
+}
+
+// ReadRecording reads a Recording from r.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	rec := new(Recording)
+	if err := json.NewDecoder(r).Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
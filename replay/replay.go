@@ -0,0 +1,373 @@
+// Package replay runs the user flows exported by the Chrome DevTools Recorder
+// panel with chromedp.
+//
+// A recording exported as JSON can be run as is:
+//
+//	f, err := os.Open("recording.json")
+//	if err != nil {
+//		// handle error
+//	}
+//	defer f.Close()
+//	tasks, err := replay.FromRecorderJSON(f)
+//	if err != nil {
+//		// handle error
+//	}
+//	if err := chromedp.Run(ctx, tasks); err != nil {
+//		// handle error
+//	}
+package replay
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+var (
+	// deepQueryJS is a JavaScript snippet that returns the first element
+	// matching a CSS selector or containing a text, piercing the open shadow
+	// roots.
+	//go:embed js/deepQuery.js
+	deepQueryJS string
+
+	// setValueJS is a JavaScript snippet that sets the value of an element,
+	// and dispatches the input and change events like a user would.
+	//go:embed js/setValue.js
+	setValueJS string
+)
+
+// FromRecorderJSON reads a recording in the JSON format of the Chrome
+// DevTools Recorder panel from r, and returns the tasks replaying it.
+//
+// See [Tasks] for the supported steps.
+func FromRecorderJSON(r io.Reader) (chromedp.Tasks, error) {
+	rec, err := ReadRecording(r)
+	if err != nil {
+		return nil, err
+	}
+	return Tasks(rec)
+}
+
+// Tasks returns the tasks replaying rec.
+//
+// The following steps are supported: setViewport, navigate, click,
+// doubleClick, change, keyDown, keyUp, waitForElement, waitForExpression,
+// scroll and close. Steps in other targets (such as popups) or in frames are
+// not supported.
+//
+// The steps with selectors use the first of their selectors matching an
+// element, retrying until one does. The CSS, "xpath/", "pierce/", "text/" and
+// "aria/" selectors are supported; chained selectors, selecting elements in
+// shadow roots or frames, are not. Elements are clicked at their center,
+// regardless of the offsets of the step.
+func Tasks(rec *Recording) (chromedp.Tasks, error) {
+	var tasks chromedp.Tasks
+	for i, s := range rec.Steps {
+		a, err := stepAction(s)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		if a == nil {
+			continue
+		}
+		timeout := s.Timeout
+		if timeout == 0 {
+			timeout = rec.Timeout
+		}
+		if timeout > 0 {
+			a = withTimeout(a, time.Duration(timeout)*time.Millisecond)
+		}
+		tasks = append(tasks, a)
+	}
+	return tasks, nil
+}
+
+// stepAction returns the action of s, or nil if s is a no-op.
+func stepAction(s *Step) (chromedp.Action, error) {
+	if s.Target != "" && s.Target != "main" {
+		return nil, fmt.Errorf("unsupported target %q", s.Target)
+	}
+	if len(s.Frame) > 0 {
+		return nil, fmt.Errorf("unsupported frame %v", s.Frame)
+	}
+
+	switch s.Type {
+	case "setViewport":
+		var opts []chromedp.EmulateViewportOption
+		if s.DeviceScaleFactor != 0 {
+			opts = append(opts, chromedp.EmulateScale(s.DeviceScaleFactor))
+		}
+		if s.IsMobile {
+			opts = append(opts, chromedp.EmulateMobile)
+		}
+		if s.HasTouch {
+			opts = append(opts, chromedp.EmulateTouch)
+		}
+		if s.IsLandscape {
+			opts = append(opts, chromedp.EmulateLandscape)
+		}
+		return chromedp.EmulateViewport(s.Width, s.Height, opts...), nil
+	case "navigate":
+		return chromedp.Navigate(s.URL), nil
+	case "click", "doubleClick":
+		var opts []chromedp.MouseOption
+		switch s.Button {
+		case "", "primary":
+		case "auxiliary":
+			opts = append(opts, chromedp.ButtonMiddle)
+		case "secondary":
+			opts = append(opts, chromedp.ButtonRight)
+		case "back", "forward":
+			opts = append(opts, chromedp.ButtonType(input.MouseButton(s.Button)))
+		default:
+			return nil, fmt.Errorf("unsupported button %q", s.Button)
+		}
+		if s.Type == "doubleClick" {
+			opts = append(opts, chromedp.ClickCount(2))
+		}
+		return query(s, func(ctx context.Context, n *cdp.Node) error {
+			return chromedp.MouseClickNode(n, opts...).Do(ctx)
+		}, chromedp.NodeVisible)
+	case "change":
+		return query(s, func(ctx context.Context, n *cdp.Node) error {
+			return callFunctionOnNode(ctx, n, setValueJS, s.Value)
+		}, chromedp.NodeVisible)
+	case "keyDown", "keyUp":
+		events, err := keyEvents(s.Key)
+		if err != nil {
+			return nil, err
+		}
+		if s.Type == "keyDown" {
+			events = events[:len(events)-1]
+		} else {
+			events = events[len(events)-1:]
+		}
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			for _, ev := range events {
+				if err := ev.Do(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		}), nil
+	case "waitForElement":
+		if s.Visible != nil && !*s.Visible {
+			return query(s, nil, chromedp.NodeNotVisible)
+		}
+		return query(s, nil, chromedp.NodeVisible)
+	case "waitForExpression":
+		return chromedp.Poll(s.Expression, nil), nil
+	case "scroll":
+		if len(s.Selectors) > 0 {
+			return query(s, func(ctx context.Context, n *cdp.Node) error {
+				return dom.ScrollIntoViewIfNeeded().WithNodeID(n.NodeID).Do(ctx)
+			}, chromedp.NodeReady)
+		}
+		return chromedp.Evaluate(fmt.Sprintf("window.scrollTo(%s, %s)",
+			strconv.FormatFloat(s.X, 'f', -1, 64), strconv.FormatFloat(s.Y, 'f', -1, 64)), nil), nil
+	case "close":
+		// The target is closed by cancelling its context.
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported step type %q", s.Type)
+}
+
+// query returns the query action running f on the element selected by the
+// selectors of s, once it's ready according to wait.
+func query(s *Step, f func(context.Context, *cdp.Node) error, wait chromedp.QueryOption) (chromedp.Action, error) {
+	if len(s.Selectors) == 0 {
+		return nil, fmt.Errorf("no selectors for the %s step", s.Type)
+	}
+	for _, sel := range s.Selectors {
+		if len(sel) == 1 {
+			return chromedp.QueryAfter(s.Selectors, func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+				if f == nil {
+					return nil
+				}
+				if len(nodes) < 1 {
+					return fmt.Errorf("selectors %q did not return any nodes", s.Selectors)
+				}
+				return f(ctx, nodes[0])
+			}, bySelectors(s.Selectors), wait), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported chained selectors %q", s.Selectors)
+}
+
+// bySelectors is an element query option to select the first element matched
+// by any of sels, trying them in order.
+func bySelectors(sels []Selector) chromedp.QueryOption {
+	return chromedp.ByFunc(func(ctx context.Context, n *cdp.Node) ([]cdp.NodeID, error) {
+		for _, sel := range sels {
+			if len(sel) != 1 {
+				continue
+			}
+			id, err := querySelector(ctx, n, sel[0])
+			if err != nil {
+				return nil, err
+			}
+			if id != 0 {
+				return []cdp.NodeID{id}, nil
+			}
+		}
+		return []cdp.NodeID{}, nil
+	})
+}
+
+// ariaRE matches the "aria/" selectors, such as "aria/Submit[role=\"button\"]".
+var ariaRE = regexp.MustCompile(`^(.*?)(?:\[role="(.*)"\])?$`)
+
+// querySelector returns the first node matching the DevTools Recorder
+// selector sel in the document n, or 0 if there is none.
+func querySelector(ctx context.Context, n *cdp.Node, sel string) (cdp.NodeID, error) {
+	switch {
+	case strings.HasPrefix(sel, "xpath/"):
+		return performSearch(ctx, strings.TrimPrefix(sel, "xpath/"))
+	case strings.HasPrefix(sel, "pierce/"):
+		return deepQuery(ctx, strings.TrimPrefix(sel, "pierce/"), "")
+	case strings.HasPrefix(sel, "text/"):
+		return deepQuery(ctx, "", strings.TrimPrefix(sel, "text/"))
+	case strings.HasPrefix(sel, "aria/"):
+		m := ariaRE.FindStringSubmatch(strings.TrimPrefix(sel, "aria/"))
+		p := accessibility.QueryAXTree().WithNodeID(n.NodeID).WithAccessibleName(m[1])
+		if m[2] != "" {
+			p = p.WithRole(m[2])
+		}
+		nodes, err := p.Do(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, ax := range nodes {
+			if ax.Ignored || ax.BackendDOMNodeID == 0 {
+				continue
+			}
+			ids, err := dom.PushNodesByBackendIDsToFrontend([]cdp.BackendNodeID{ax.BackendDOMNodeID}).Do(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return ids[0], nil
+		}
+		return 0, nil
+	}
+	return dom.QuerySelector(n.NodeID, sel).Do(ctx)
+}
+
+// performSearch returns the first node matching the XPath expression.
+func performSearch(ctx context.Context, expr string) (cdp.NodeID, error) {
+	id, count, err := dom.PerformSearch(expr).Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = dom.DiscardSearchResults(id).Do(ctx)
+	}()
+	if count < 1 {
+		return 0, nil
+	}
+	nodes, err := dom.GetSearchResults(id, 0, 1).Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return nodes[0], nil
+}
+
+// deepQuery returns the first node matching the CSS selector or containing
+// text, piercing the open shadow roots.
+func deepQuery(ctx context.Context, selector, text string) (cdp.NodeID, error) {
+	// the arguments are encoded as JSON, which are valid JavaScript string
+	// literals, unlike the Go ones.
+	sel, err := json.Marshal(selector)
+	if err != nil {
+		return 0, err
+	}
+	txt, err := json.Marshal(text)
+	if err != nil {
+		return 0, err
+	}
+	obj, exp, err := runtime.Evaluate(fmt.Sprintf("(%s)(%s, %s)", deepQueryJS, sel, txt)).Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if exp != nil {
+		return 0, exp
+	}
+	if obj.ObjectID == "" {
+		return 0, nil
+	}
+	defer func() {
+		_ = runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+	}()
+	return dom.RequestNode(obj.ObjectID).Do(ctx)
+}
+
+// callFunctionOnNode calls function with the node as this, and args as its
+// arguments.
+func callFunctionOnNode(ctx context.Context, n *cdp.Node, function string, args ...interface{}) error {
+	obj, err := dom.ResolveNode().WithNodeID(n.NodeID).Do(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+	}()
+	var arguments []*runtime.CallArgument
+	for _, arg := range args {
+		buf, err := json.Marshal(arg)
+		if err != nil {
+			return err
+		}
+		arguments = append(arguments, &runtime.CallArgument{Value: buf})
+	}
+	_, exp, err := runtime.CallFunctionOn(function).
+		WithObjectID(obj.ObjectID).
+		WithArguments(arguments).
+		WithAwaitPromise(true).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exp != nil {
+		return exp
+	}
+	return nil
+}
+
+// keyEvents returns the key events of pressing and releasing the key with
+// the DOM key value key (such as "a" or "Enter"). The last event is the
+// release.
+func keyEvents(key string) ([]*input.DispatchKeyEventParams, error) {
+	if utf8.RuneCountInString(key) == 1 {
+		r, _ := utf8.DecodeRuneInString(key)
+		return kb.Encode(r), nil
+	}
+	for r, k := range kb.Keys {
+		if k.Key == key {
+			return kb.Encode(r), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported key %q", key)
+}
+
+// withTimeout returns an action running a with the timeout d.
+func withTimeout(a chromedp.Action, d time.Duration) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return a.Do(ctx)
+	})
+}
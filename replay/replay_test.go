@@ -0,0 +1,115 @@
+package replay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+)
+
+var allocCtx context.Context
+
+func TestMain(m *testing.M) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.DisableGPU)
+	if execPath := os.Getenv("CHROMEDP_TEST_RUNNER"); execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+	if noSandbox := os.Getenv("CHROMEDP_NO_SANDBOX"); noSandbox != "false" {
+		opts = append(opts, chromedp.NoSandbox)
+	}
+	var cancel context.CancelFunc
+	allocCtx, cancel = chromedp.NewExecAllocator(context.Background(), opts...)
+
+	code := m.Run()
+	cancel()
+	os.Exit(code)
+}
+
+const testPage = `<html><body>
+<input id="name" oninput="log('input ' + this.value)" onchange="log('change ' + this.value)"
+  onkeydown="log('keydown ' + event.key)" onkeyup="log('keyup ' + event.key)">
+<button onclick="log('click submit')">Submit</button>
+<div id="host"></div>
+<span ondblclick="log('dblclick span')">Double me</span>
+<b onclick="log('click tag')">Tag &#xE0041;</b>
+<script>
+  function log(s) { document.title += s + ';'; }
+  const root = document.getElementById('host').attachShadow({mode: 'open'});
+  root.innerHTML = '<a class="inner" onclick="log(\'click inner\')">Inner</a>';
+  setTimeout(() => {
+    const div = document.createElement('div');
+    div.id = 'late';
+    div.textContent = 'Late';
+    document.body.append(div);
+  }, 200);
+</script>
+</body></html>`
+
+const testRecording = `{
+  "title": "test",
+  "timeout": 5000,
+  "steps": [
+    {"type": "setViewport", "width": 800, "height": 600, "deviceScaleFactor": 1},
+    {"type": "navigate", "url": "URL"},
+    {"type": "change", "value": "chromedp", "selectors": [["#missing"], ["xpath///input"]]},
+    {"type": "keyDown", "key": "Enter"},
+    {"type": "keyUp", "key": "Enter"},
+    {"type": "click", "selectors": [["aria/Submit[role=\"button\"]"]]},
+    {"type": "click", "selectors": [["div > #missing"], ["pierce/.inner"]]},
+    {"type": "doubleClick", "selectors": [["text/Double"]]},
+    {"type": "click", "selectors": [["text/Tag \udb40\udc41"]]},
+    {"type": "waitForElement", "selectors": ["#late"]}
+  ]
+}`
+
+func TestFromRecorderJSON(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testPage))
+	}))
+	defer s.Close()
+
+	tasks, err := FromRecorderJSON(strings.NewReader(strings.Replace(testRecording, "URL", s.URL, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	var title string
+	if err := chromedp.Run(ctx, tasks, chromedp.Title(&title)); err != nil {
+		t.Fatal(err)
+	}
+	want := "input chromedp;change chromedp;keydown Enter;keyup Enter;click submit;click inner;dblclick span;click tag;"
+	if title != want {
+		t.Fatalf("want events %q, got %q", want, title)
+	}
+}
+
+func TestFromRecorderJSONUnsupported(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"type", `{"steps": [{"type": "customStep"}]}`, `step 0: unsupported step type "customStep"`},
+		{"target", `{"steps": [{"type": "click", "target": "popup", "selectors": ["a"]}]}`, `step 0: unsupported target "popup"`},
+		{"key", `{"steps": [{"type": "keyDown", "key": "NotAKey"}]}`, `step 0: unsupported key "NotAKey"`},
+		{"chained", `{"steps": [{"type": "click", "selectors": [["#host", ".inner"]]}]}`, `step 0: unsupported chained selectors [["#host" ".inner"]]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromRecorderJSON(strings.NewReader(tt.json))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("want error %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+)
+
+// SaveOptions are the options of SavePage.
+type SaveOptions struct {
+	// InlineCSS inlines the stylesheets of the document in style elements,
+	// rather than linking to their saved copies.
+	InlineCSS bool
+	// RewriteLinks rewrites the references of the document to its
+	// subresources, such as its images and stylesheets, to their saved
+	// copies, and the other relative links to absolute URLs, so that the
+	// saved document can be browsed offline. Otherwise, the document is
+	// saved as is, next to its subresources.
+	RewriteLinks bool
+}
+
+// savePageResourceDir is the directory of the subresources saved by SavePage,
+// relative to the saved document, named like the one of the browsers.
+const savePageResourceDir = "index_files"
+
+// SavePage saves the current page of ctx to the directory dir, which is
+// created if needed, as a copy which can be browsed offline: its document, as
+// currently rendered, in the index.html file, and its subresources, as loaded
+// by the browser, in the index_files directory.
+//
+// The subresources are those of the main frame, such as its images,
+// stylesheets, scripts and fonts; the documents of the frames and the
+// responses of the scripts, such as of fetch, are not saved. A subresource
+// which is no longer kept by the browser is left out. Example:
+//
+//	err := chromedp.Run(ctx, chromedp.Navigate("https://example.com"))
+//	if err != nil {
+//		// handle error
+//	}
+//	err = chromedp.SavePage(ctx, "example", chromedp.SaveOptions{
+//		InlineCSS:    true,
+//		RewriteLinks: true,
+//	})
+func SavePage(ctx context.Context, dir string, opts SaveOptions) error {
+	return Run(ctx, savePageAction(dir, opts))
+}
+
+// savedResource is a subresource saved by SavePage.
+type savedResource struct {
+	url     string
+	name    string
+	typ     network.ResourceType
+	content []byte
+}
+
+func savePageAction(dir string, opts SaveOptions) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		tree, err := page.GetResourceTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		var saved []*savedResource
+		names := make(map[string]string)
+		used := make(map[string]bool)
+		for _, r := range tree.Resources {
+			if r.Failed || r.Canceled || r.URL == tree.Frame.URL || strings.HasPrefix(r.URL, "data:") {
+				continue
+			}
+			if _, ok := names[r.URL]; ok {
+				continue
+			}
+			content, err := page.GetResourceContent(tree.Frame.ID, r.URL).Do(ctx)
+			if err != nil {
+				// the resource is no longer kept.
+				continue
+			}
+			name := resourceFileName(r.URL, r.MimeType, used)
+			names[r.URL] = name
+			saved = append(saved, &savedResource{url: r.URL, name: name, typ: r.Type, content: content})
+		}
+
+		// the references of the saved stylesheets are relative to them,
+		// and to the document once inlined.
+		resources := make(map[string]string)
+		styles := make(map[string]string)
+		for _, r := range saved {
+			if opts.RewriteLinks {
+				resources[r.url] = savePageResourceDir + "/" + r.name
+			}
+			if r.typ != network.ResourceTypeStylesheet {
+				continue
+			}
+			css := string(r.content)
+			if opts.InlineCSS {
+				styles[r.url] = rewriteCSSURLs(css, r.url, func(abs string) string {
+					if name, ok := names[abs]; ok && opts.RewriteLinks {
+						return savePageResourceDir + "/" + name
+					}
+					return abs
+				})
+			}
+			r.content = []byte(rewriteCSSURLs(css, r.url, func(abs string) string {
+				if name, ok := names[abs]; ok && opts.RewriteLinks {
+					return name
+				}
+				return abs
+			}))
+		}
+
+		args, err := json.Marshal([]interface{}{resources, opts.RewriteLinks, styles})
+		if err != nil {
+			return err
+		}
+		var doc string
+		if err := Evaluate(fmt.Sprintf("(%s)(...%s)", savePageJS, args), &doc).Do(ctx); err != nil {
+			return err
+		}
+
+		if len(saved) > 0 {
+			if err := os.MkdirAll(filepath.Join(dir, savePageResourceDir), 0o755); err != nil {
+				return err
+			}
+		} else if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		for _, r := range saved {
+			if err := os.WriteFile(filepath.Join(dir, savePageResourceDir, r.name), r.content, 0o644); err != nil {
+				return err
+			}
+		}
+		return os.WriteFile(filepath.Join(dir, "index.html"), []byte(doc), 0o644)
+	})
+}
+
+// unsafeFileNameRE matches the characters which are left out of the names of
+// the saved subresources.
+var unsafeFileNameRE = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// resourceFileName returns a name for the saved copy of the subresource at
+// rawURL with the MIME type mimeType, which isn't in used yet, and adds it to
+// used.
+func resourceFileName(rawURL, mimeType string, used map[string]bool) string {
+	var base string
+	if u, err := url.Parse(rawURL); err == nil {
+		base = path.Base(u.Path)
+	}
+	base = strings.Trim(unsafeFileNameRE.ReplaceAllString(base, "_"), "._")
+	if base == "" {
+		base = "resource"
+	}
+	ext := path.Ext(base)
+	if ext == "" {
+		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			ext = exts[0]
+			base += ext
+		}
+	}
+	name := base
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(base, ext), i, ext)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// cssURLRE matches the url() references and the @import rules of a
+// stylesheet, with the referenced URL in one of its groups.
+var cssURLRE = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^'"()\s]+))\s*\)|@import\s+(?:"([^"]*)"|'([^']*)')`)
+
+// cssStringReplacer escapes the characters of a CSS string between double
+// quotes.
+var cssStringReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `)
+
+// rewriteCSSURLs rewrites the references of the stylesheet css at baseURL,
+// which are resolved against it, to the ones returned by rewrite.
+func rewriteCSSURLs(css, baseURL string, rewrite func(abs string) string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return css
+	}
+	return cssURLRE.ReplaceAllStringFunc(css, func(m string) string {
+		sub := cssURLRE.FindStringSubmatch(m)
+		var ref string
+		for _, s := range sub[1:] {
+			if s != "" {
+				ref = s
+				break
+			}
+		}
+		if ref == "" || strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			return m
+		}
+		u, err := base.Parse(ref)
+		if err != nil {
+			return m
+		}
+		to := cssStringReplacer.Replace(rewrite(u.String()))
+		if strings.HasPrefix(m, "@import") {
+			return `@import "` + to + `"`
+		}
+		return `url("` + to + `")`
+	})
+}
@@ -0,0 +1,119 @@
+package chromedp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSavePage(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "savepage.html")
+	defer cancel()
+
+	tests := []struct {
+		opts     SaveOptions
+		want     []string
+		wantCSS  []string
+		notWants []string
+	}{
+		{
+			SaveOptions{},
+			[]string{`<!DOCTYPE html>`, `href="savepage.css"`, `src="images/brankas.png"`, `href="child1.html"`},
+			[]string{`url("` + testdataDir + `/images/github.png")`},
+			nil,
+		},
+		{
+			SaveOptions{RewriteLinks: true},
+			[]string{
+				`href="index_files/savepage.css"`,
+				`src="index_files/brankas.png"`,
+				`srcset="index_files/brankas.png 1x, index_files/github.png 2x"`,
+				`url(&quot;index_files/brankas.png&quot;)`,
+				`url("index_files/github.png")`,
+				`href="` + testdataDir + `/child1.html"`,
+				`href="#logo"`,
+			},
+			[]string{`url("github.png")`},
+			[]string{`href="savepage.css"`},
+		},
+		{
+			SaveOptions{RewriteLinks: true, InlineCSS: true},
+			[]string{`body { background: url("index_files/github.png") no-repeat; }`, `src="index_files/brankas.png"`},
+			[]string{`url("github.png")`},
+			[]string{`<link`},
+		},
+	}
+	for i, test := range tests {
+		dir := t.TempDir()
+		if err := SavePage(ctx, dir, test.opts); err != nil {
+			t.Fatal(err)
+		}
+		buf, err := os.ReadFile(filepath.Join(dir, "index.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := string(buf)
+		for _, want := range test.want {
+			if !strings.Contains(doc, want) {
+				t.Errorf("test %d: want the document to contain %q, got:\n%s", i, want, doc)
+			}
+		}
+		for _, notWant := range test.notWants {
+			if strings.Contains(doc, notWant) {
+				t.Errorf("test %d: want the document not to contain %q, got:\n%s", i, notWant, doc)
+			}
+		}
+		for _, name := range []string{"brankas.png", "github.png"} {
+			if _, err := os.Stat(filepath.Join(dir, savePageResourceDir, name)); err != nil {
+				t.Errorf("test %d: %v", i, err)
+			}
+		}
+		css, err := os.ReadFile(filepath.Join(dir, savePageResourceDir, "savepage.css"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range test.wantCSS {
+			if !strings.Contains(string(css), want) {
+				t.Errorf("test %d: want the stylesheet to contain %q, got:\n%s", i, want, css)
+			}
+		}
+	}
+}
+
+func TestResourceFileName(t *testing.T) {
+	t.Parallel()
+
+	used := make(map[string]bool)
+	tests := []struct {
+		url, mimeType string
+		want          string
+	}{
+		{"https://example.com/a/logo.png", "image/png", "logo.png"},
+		{"https://example.com/b/logo.png?v=2", "image/png", "logo-2.png"},
+		{"https://example.com/b/LOGO.png", "image/png", "LOGO-3.png"},
+		{"https://example.com/", "text/css", "resource.css"},
+		{"https://example.com/font?family=Open+Sans", "text/css", "font.css"},
+		{"https://example.com/%E2%9C%93 icon.svg", "image/svg+xml", "icon.svg"},
+	}
+	for _, test := range tests {
+		if got := resourceFileName(test.url, test.mimeType, used); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.url, got, test.want)
+		}
+	}
+}
+
+func TestRewriteCSSURLs(t *testing.T) {
+	t.Parallel()
+
+	css := `@import "base.css"; a { background: url( 'img/a.png' ) } b { background: url(data:image/png;base64,AA==) } c { src: url(/f.woff?a=1&b="2") }`
+	got := rewriteCSSURLs(css, "https://example.com/css/main.css", func(abs string) string {
+		return "<" + abs + ">"
+	})
+	want := `@import "<https://example.com/css/base.css>"; a { background: url("<https://example.com/css/img/a.png>") } b { background: url(data:image/png;base64,AA==) } c { src: url(/f.woff?a=1&b="2") }`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
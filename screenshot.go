@@ -1,11 +1,17 @@
 package chromedp
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
 	"math"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
 )
@@ -136,31 +142,160 @@ func CaptureScreenshot(res *[]byte) Action {
 //
 // The valid range of the compression quality is [0..100]. When this value is
 // 100, the image format is png; otherwise, the image format is jpeg.
+//
+// The screenshot is in device pixels, as scaled by the device scale factor of
+// the emulated viewport, if any. The pages taller than 16384 device pixels,
+// which the browsers with the GPU enabled can't capture at once, are captured in
+// parts which are stitched together; the fixed-position elements are laid out
+// once for the entire page, as with a single capture, rather than repeated in
+// each part.
 func FullScreenshot(res *[]byte, quality int) EmulateAction {
 	if res == nil {
 		panic("res cannot be nil")
 	}
 	return ActionFunc(func(ctx context.Context) error {
-		format := page.CaptureScreenshotFormatPng
-		if quality != 100 {
-			format = page.CaptureScreenshotFormatJpeg
-		}
+		return fullScreenshot(ctx, res, quality, maxScreenshotHeight)
+	})
+}
 
-		// capture screenshot
-		var err error
+// maxScreenshotHeight is the height, in device pixels, of the tallest
+// screenshot FullScreenshot captures at once, which is the maximum texture
+// size of the browsers with the GPU enabled.
+const maxScreenshotHeight = 16384
+
+// fullScreenshot captures the entire page, in parts of at most maxHeight
+// device pixels.
+func fullScreenshot(ctx context.Context, res *[]byte, quality int, maxHeight int) error {
+	format := page.CaptureScreenshotFormatPng
+	if quality != 100 {
+		format = page.CaptureScreenshotFormatJpeg
+	}
+
+	_, _, _, _, _, contentSize, err := page.GetLayoutMetrics().Do(ctx)
+	if err != nil {
+		return err
+	}
+	var scale float64
+	if err := Evaluate(`window.devicePixelRatio`, &scale).Do(ctx); err != nil {
+		return err
+	}
+	width, height := math.Ceil(contentSize.Width), math.Ceil(contentSize.Height)
+	if height*scale <= float64(maxHeight) {
 		*res, err = page.CaptureScreenshot().
 			WithCaptureBeyondViewport(true).
 			WithFromSurface(true).
 			WithFormat(format).
 			WithQuality(int64(quality)).
 			Do(ctx)
+		return err
+	}
+
+	// the parts are captured losslessly, and encoded once stitched.
+	partHeight := math.Floor(float64(maxHeight) / scale)
+	img := image.NewRGBA(image.Rect(0, 0, int(math.Round(width*scale)), int(math.Round(height*scale))))
+	for y := 0.0; y < height; y += partHeight {
+		buf, err := page.CaptureScreenshot().
+			WithCaptureBeyondViewport(true).
+			WithFromSurface(true).
+			WithClip(&page.Viewport{
+				X:      0,
+				Y:      y,
+				Width:  width,
+				Height: min(partHeight, height-y),
+				Scale:  1,
+			}).
+			Do(ctx)
 		if err != nil {
 			return err
 		}
-		return nil
+		part, err := png.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return err
+		}
+		top := int(math.Round(y * scale))
+		draw.Draw(img, part.Bounds().Add(image.Pt(0, top)), part, part.Bounds().Min, draw.Src)
+	}
+
+	var b bytes.Buffer
+	if format == page.CaptureScreenshotFormatPng {
+		err = png.Encode(&b, img)
+	} else {
+		err = jpeg.Encode(&b, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return err
+	}
+	*res = b.Bytes()
+	return nil
+}
+
+// PrintPreviewScreenshot is an action that captures a screenshot of the
+// current browser viewport, as it's rendered with the print media type.
+//
+// It emulates the print media type, waits for the page to be laid out again,
+// takes the screenshot, and then clears the media type emulation (so any
+// previous emulation of the media type is not restored). This is useful for
+// checking the print stylesheets of a page without generating a PDF.
+//
+// See [FullPrintPreviewScreenshot] to capture the entire page.
+func PrintPreviewScreenshot(res *[]byte) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+	return withPrintMedia(CaptureScreenshot(res))
+}
+
+// FullPrintPreviewScreenshot is like [PrintPreviewScreenshot], but captures
+// the entire page like [FullScreenshot], with the specified image quality.
+func FullPrintPreviewScreenshot(res *[]byte, quality int) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+	return withPrintMedia(FullScreenshot(res, quality))
+}
+
+// OmitBackground is an action that runs the actions with the default white
+// background of the pages made transparent, like the omitBackground option of
+// Puppeteer, so that the screenshots and PDFs taken by the actions, such as
+// with CaptureScreenshot or PrintToPDF, have a transparent background where
+// the page doesn't set one.
+//
+// The override is cleared afterwards, so any previous override of the default
+// background color is not restored. The screenshots must be in the png format
+// to keep the transparency, unlike the jpeg screenshots of FullScreenshot with
+// a quality under 100; the PDFs must be printed with the background graphics,
+// as with the WithPrintBackground option, which otherwise leaves out all the
+// backgrounds.
+func OmitBackground(actions ...Action) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if err := emulation.SetDefaultBackgroundColorOverride().
+			WithColor(&cdp.RGBA{R: 0, G: 0, B: 0, A: 0}).
+			Do(ctx); err != nil {
+			return err
+		}
+		// Wait for the transparent background to be painted.
+		err := Tasks{waitFrame(), Tasks(actions)}.Do(ctx)
+		if rerr := emulation.SetDefaultBackgroundColorOverride().Do(ctx); err == nil {
+			err = rerr
+		}
+		return err
 	})
 }
 
+// withPrintMedia runs a with the print media type emulated, as with
+// EmulateMediaType, once the new styles are applied and painted.
+func withPrintMedia(a Action) Action {
+	return EmulateMediaType("print", waitFrame(), a)
+}
+
+// waitFrame returns an action that waits for a new frame to be painted.
+func waitFrame() Action {
+	return Evaluate(`new Promise((resolve) => requestAnimationFrame(() => requestAnimationFrame(resolve)))`, nil,
+		func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		})
+}
+
 func extents(m, n, o, p float64) (float64, float64) {
 	a := min(m, o)
 	b := max(m+n, o+p)
@@ -2,15 +2,19 @@ package chromedp
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	_ "image/jpeg"
 	_ "image/png"
+	"math"
 	"os"
 	"path"
 	"testing"
 
 	"github.com/orisano/pixelmatch"
+
+	"github.com/chromedp/cdproto/emulation"
 )
 
 func TestScreenshot(t *testing.T) {
@@ -225,6 +229,190 @@ func TestFullScreenshot(t *testing.T) {
 	}
 }
 
+func TestFullScreenshotStitched(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		scale   float64
+		quality int
+	}{
+		{"scale 1", 1, 100},
+		{"scale 2", 2, 100},
+		{"quality 90", 1, 90},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := testAllocate(t, "grid.html")
+			defer cancel()
+
+			// the parts are stitched at heights which aren't multiples
+			// of the boxes of the grid, nor of the scale.
+			var whole, stitched []byte
+			if err := Run(ctx,
+				EmulateViewport(500, 500, EmulateScale(test.scale)),
+				Evaluate(`const header = document.createElement('div');
+					header.style = 'position: fixed; top: 0; width: 100%; height: 40px; background: black';
+					document.body.append(header);
+					document.documentElement.scrollTo(20, 30)`, nil),
+				ActionFunc(func(ctx context.Context) error {
+					return fullScreenshot(ctx, &whole, test.quality, math.MaxInt32)
+				}),
+				ActionFunc(func(ctx context.Context) error {
+					return fullScreenshot(ctx, &stitched, test.quality, 301)
+				}),
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			img1, format1, err := image.Decode(bytes.NewReader(whole))
+			if err != nil {
+				t.Fatal(err)
+			}
+			img2, format2, err := image.Decode(bytes.NewReader(stitched))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format1 != format2 || img1.Bounds() != img2.Bounds() {
+				t.Fatalf("want a %s image of %v, got a %s image of %v", format1, img1.Bounds(), format2, img2.Bounds())
+			}
+			if h := img1.Bounds().Dy(); h < 3*301 {
+				t.Fatalf("want an image in several parts, got the height %d", h)
+			}
+			diff, err := pixelmatch.MatchPixel(img1, img2, pixelmatch.Threshold(0.1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff != 0 {
+				t.Fatalf("the stitched screenshot does not match. diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestPrintPreviewScreenshot(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		action     func(*[]byte) Action
+		wantHeight int
+	}{
+		{
+			name:       "viewport",
+			action:     PrintPreviewScreenshot,
+			wantHeight: 500,
+		},
+		{
+			name: "full",
+			action: func(buf *[]byte) Action {
+				return FullPrintPreviewScreenshot(buf, 100)
+			},
+			wantHeight: 2000,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := testAllocate(t, "print.html")
+			defer cancel()
+
+			var buf []byte
+			var print bool
+			if err := Run(ctx,
+				EmulateViewport(500, 500),
+				test.action(&buf),
+				Evaluate(`matchMedia('print').matches`, &print),
+			); err != nil {
+				t.Fatal(err)
+			}
+			if print {
+				t.Fatal("want the print media emulation to be cleared")
+			}
+
+			img, _, err := image.Decode(bytes.NewReader(buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h := img.Bounds().Dy(); h != test.wantHeight {
+				t.Errorf("want height %d, got %d", test.wantHeight, h)
+			}
+			r, g, b, _ := img.At(10, 10).RGBA()
+			if r>>8 != 0xff || g>>8 != 0 || b>>8 != 0 {
+				t.Errorf("want the print background, got rgb(%d, %d, %d)", r>>8, g>>8, b>>8)
+			}
+		})
+	}
+}
+
+func TestPrintPreviewScreenshotRestoresMedia(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "print.html")
+	defer cancel()
+
+	// the media emulation of the caller is kept during the capture, but for
+	// the media type, and restored afterwards.
+	dark := []*emulation.MediaFeature{{Name: "prefers-color-scheme", Value: "dark"}}
+	var buf []byte
+	var screen, darkDuring, darkAfter bool
+	if err := Run(ctx,
+		emulation.SetEmulatedMedia().WithMedia("screen").WithFeatures(dark),
+		withPrintMedia(Evaluate(`matchMedia('(prefers-color-scheme: dark)').matches`, &darkDuring)),
+		PrintPreviewScreenshot(&buf),
+		Evaluate(`matchMedia('screen').matches`, &screen),
+		Evaluate(`matchMedia('(prefers-color-scheme: dark)').matches`, &darkAfter),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !darkDuring {
+		t.Error("want the emulated media features kept during the capture")
+	}
+	if !screen || !darkAfter {
+		t.Errorf("want the media emulation restored, got screen %v and dark %v", screen, darkAfter)
+	}
+}
+
+func TestOmitBackground(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var transparent, opaque []byte
+	if err := Run(ctx,
+		Navigate(`data:text/html,<body style="margin: 0"><div style="width: 10px; height: 10px; background: red"></div></body>`),
+		EmulateViewport(100, 100),
+		OmitBackground(CaptureScreenshot(&transparent)),
+		CaptureScreenshot(&opaque),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name      string
+		buf       []byte
+		wantAlpha uint32
+	}{
+		{"omitted", transparent, 0},
+		{"restored", opaque, 0xff},
+	} {
+		img, _, err := image.Decode(bytes.NewReader(test.buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, g, b, a := img.At(15, 5).RGBA(); a>>8 != test.wantAlpha {
+			t.Errorf("%s: want alpha %d, got rgba(%d, %d, %d, %d)", test.name, test.wantAlpha, r>>8, g>>8, b>>8, a>>8)
+		}
+		if r, _, _, a := img.At(5, 5).RGBA(); r>>8 != 0xff || a>>8 != 0xff {
+			t.Errorf("%s: want the red background of the element", test.name)
+		}
+	}
+}
+
 func matchPixel(buf []byte, want string) (int, error) {
 	img1, format1, err := image.Decode(bytes.NewReader(buf))
 	if err != nil {
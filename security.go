@@ -0,0 +1,253 @@
+package chromedp
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/audits"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/security"
+)
+
+// SecurityReport is the security state of a page, as reported by
+// SecurityState and ListenSecurityState.
+type SecurityReport struct {
+	State security.State `json:"state"`
+	// Certificate is the state of the connection and the certificate of
+	// the page, which is nil if the page was not loaded over TLS.
+	Certificate *security.CertificateSecurityState `json:"certificate,omitempty"`
+	// IssueIDs are the ids of the issues lowering the state, such as
+	// "scheme-is-not-cryptographic".
+	IssueIDs     []string               `json:"issueIds,omitempty"`
+	Explanations []*SecurityExplanation `json:"explanations,omitempty"`
+}
+
+// SecurityExplanationType is the type of a SecurityExplanation.
+type SecurityExplanationType string
+
+// SecurityExplanationType values.
+const (
+	// SecurityCertificateError is the type of the explanations of the
+	// certificate errors, such as an expired certificate.
+	SecurityCertificateError SecurityExplanationType = "certificateError"
+	// SecurityWeakCertificate is the type of the explanations of the
+	// certificates signed with a weak or SHA-1 signature.
+	SecurityWeakCertificate SecurityExplanationType = "weakCertificate"
+	// SecurityObsoleteConnection is the type of the explanations of the
+	// connections using an obsolete protocol, key exchange, cipher or
+	// signature.
+	SecurityObsoleteConnection SecurityExplanationType = "obsoleteConnection"
+	// SecurityMixedContent is the type of the explanations of the
+	// resources loaded over HTTP by a page loaded over HTTPS.
+	SecurityMixedContent SecurityExplanationType = "mixedContent"
+)
+
+// SecurityExplanation explains why the security state of a page is lowered.
+type SecurityExplanation struct {
+	Type        SecurityExplanationType `json:"type"`
+	Description string                  `json:"description"`
+	// MixedContent is the mixed content resource for SecurityMixedContent
+	// explanations.
+	MixedContent *audits.MixedContentIssueDetails `json:"mixedContent,omitempty"`
+}
+
+// SecurityState is an action that retrieves the security state of the current
+// page, with the explanations of the certificate errors and the mixed content
+// resources of the page.
+//
+// The Security and Audits domains are left enabled, as disabling them would
+// stop the events used by ListenSecurityState.
+//
+// Note that headless-shell does not report the security state of pages, and
+// the action blocks until ctx is done there.
+func SecurityState(state *SecurityReport) Action {
+	if state == nil {
+		panic("state cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if c := FromContext(ctx); c != nil && c.Browser != nil && c.Browser.Kind() == BrowserHeadlessShell {
+			c.Browser.warnFeature("security state", "not reported by "+BrowserHeadlessShell.String())
+		}
+		var st securityTracker
+		ch := make(chan struct{}, 1)
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			if st.handle(ev) && st.state != nil {
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		})
+
+		// the issues collected so far are sent when the Audits domain is
+		// enabled, so enable it first.
+		if err := audits.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if err := security.Enable().Do(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+		*state = *st.report()
+		return nil
+	})
+}
+
+// Certificate is an action that retrieves the certificate chain of the origin
+// of the current page, as received by the browser. The leaf certificate is
+// first, and the DER encoding of each one is in its Raw field.
+//
+// It returns an error if the page was not loaded over TLS.
+func Certificate(chain *[]*x509.Certificate) Action {
+	if chain == nil {
+		panic("chain cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var origin string
+		if err := EvaluateAsDevTools(`document.location.origin`, &origin).Do(ctx); err != nil {
+			return err
+		}
+		names, err := network.GetCertificate(origin).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no certificate for origin %q", origin)
+		}
+
+		certs := make([]*x509.Certificate, len(names))
+		for i, name := range names {
+			der, err := base64.StdEncoding.DecodeString(name)
+			if err != nil {
+				return fmt.Errorf("could not decode certificate %d: %w", i, err)
+			}
+			if certs[i], err = x509.ParseCertificate(der); err != nil {
+				return fmt.Errorf("could not parse certificate %d: %w", i, err)
+			}
+		}
+		*chain = certs
+		return nil
+	})
+}
+
+// ListenSecurityState is an action that enables the Security and Audits
+// domains, and calls fn with the security state of the page each time it
+// changes, or a mixed content resource is loaded by the page. fn is called
+// until ctx is cancelled.
+//
+// As with ListenTarget, fn is called synchronously while events are being
+// handled, so it must not block nor run actions.
+func ListenSecurityState(fn func(*SecurityReport)) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		var st securityTracker
+		ListenTarget(ctx, func(ev interface{}) {
+			if st.handle(ev) && st.state != nil {
+				fn(st.report())
+			}
+		})
+		if err := audits.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return security.Enable().Do(ctx)
+	})
+}
+
+// securityTracker tracks the security state of a page, and the mixed content
+// resources of its current document.
+type securityTracker struct {
+	mu    sync.Mutex
+	state *security.VisibleSecurityState
+	mixed []*audits.MixedContentIssueDetails
+}
+
+// handle updates the tracker with ev, and reports whether the state of the
+// page changed.
+func (t *securityTracker) handle(ev interface{}) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch ev := ev.(type) {
+	case *security.EventVisibleSecurityStateChanged:
+		t.state = ev.VisibleSecurityState
+		return true
+	case *audits.EventIssueAdded:
+		if ev.Issue.Code != audits.InspectorIssueCodeMixedContentIssue || ev.Issue.Details.MixedContentIssueDetails == nil {
+			return false
+		}
+		t.mixed = append(t.mixed, ev.Issue.Details.MixedContentIssueDetails)
+		return true
+	case *page.EventFrameNavigated:
+		if ev.Frame.ParentID == "" {
+			t.mixed = nil
+		}
+	}
+	return false
+}
+
+// report returns the report of the current state of the page.
+func (t *securityTracker) report() *SecurityReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := &SecurityReport{
+		State:       t.state.SecurityState,
+		Certificate: t.state.CertificateSecurityState,
+		IssueIDs:    t.state.SecurityStateIssueIDs,
+	}
+	if c := r.Certificate; c != nil {
+		if c.CertificateNetworkError != "" {
+			r.Explanations = append(r.Explanations, &SecurityExplanation{
+				Type:        SecurityCertificateError,
+				Description: c.CertificateNetworkError,
+			})
+		}
+		if c.CertificateHasWeakSignature || c.CertificateHasSha1signature {
+			desc := "certificate has a weak signature"
+			if c.CertificateHasSha1signature {
+				desc = "certificate has a SHA-1 signature"
+			}
+			r.Explanations = append(r.Explanations, &SecurityExplanation{
+				Type:        SecurityWeakCertificate,
+				Description: desc,
+			})
+		}
+		var obsolete []string
+		if c.ObsoleteSslProtocol {
+			obsolete = append(obsolete, "protocol "+c.Protocol)
+		}
+		if c.ObsoleteSslKeyExchange {
+			obsolete = append(obsolete, "key exchange "+c.KeyExchange)
+		}
+		if c.ObsoleteSslCipher {
+			obsolete = append(obsolete, "cipher "+c.Cipher)
+		}
+		if c.ObsoleteSslSignature {
+			obsolete = append(obsolete, "signature")
+		}
+		if len(obsolete) > 0 {
+			r.Explanations = append(r.Explanations, &SecurityExplanation{
+				Type:        SecurityObsoleteConnection,
+				Description: "obsolete " + strings.Join(obsolete, ", "),
+			})
+		}
+	}
+	for _, m := range t.mixed {
+		r.Explanations = append(r.Explanations, &SecurityExplanation{
+			Type:         SecurityMixedContent,
+			Description:  fmt.Sprintf("%s %s", m.ResolutionStatus, m.InsecureURL),
+			MixedContent: m,
+		})
+	}
+	return r
+}
@@ -0,0 +1,139 @@
+package chromedp
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/audits"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/security"
+)
+
+func TestSecurityState(t *testing.T) {
+	if os.Getenv("HEADLESS_SHELL") != "" {
+		t.Skip("Skip in headless-shell, as it does not report the visible security state")
+	}
+	t.Parallel()
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><script src="http://example.invalid/mixed.js"></script></body></html>`)
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var state SecurityReport
+	if err := Run(ctx,
+		security.Enable(),
+		security.SetIgnoreCertificateErrors(true),
+		Navigate(s.URL),
+		SecurityState(&state),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if state.State == "" || state.Certificate == nil {
+		t.Fatalf("want a state with a certificate, got %+v", state)
+	}
+	var mixed []string
+	for _, e := range state.Explanations {
+		if e.Type == SecurityMixedContent {
+			mixed = append(mixed, e.MixedContent.InsecureURL)
+		}
+	}
+	if want := []string{"http://example.invalid/mixed.js"}; !reflect.DeepEqual(mixed, want) {
+		t.Errorf("want mixed content %q, got %q", want, mixed)
+	}
+}
+
+func TestSecurityTracker(t *testing.T) {
+	t.Parallel()
+
+	mixed := &audits.MixedContentIssueDetails{
+		ResolutionStatus: audits.MixedContentResolutionStatusMixedContentBlocked,
+		InsecureURL:      "http://example.com/a.js",
+	}
+	var st securityTracker
+	for _, ev := range []interface{}{
+		&audits.EventIssueAdded{Issue: &audits.InspectorIssue{
+			Code:    audits.InspectorIssueCodeMixedContentIssue,
+			Details: &audits.InspectorIssueDetails{MixedContentIssueDetails: &audits.MixedContentIssueDetails{InsecureURL: "http://example.com/old.js"}},
+		}},
+		// a new document resets the mixed content
+		&page.EventFrameNavigated{Frame: &cdp.Frame{ID: "main"}},
+		&page.EventFrameNavigated{Frame: &cdp.Frame{ID: "child", ParentID: "main"}},
+		&audits.EventIssueAdded{Issue: &audits.InspectorIssue{
+			Code:    audits.InspectorIssueCodeMixedContentIssue,
+			Details: &audits.InspectorIssueDetails{MixedContentIssueDetails: mixed},
+		}},
+		&security.EventVisibleSecurityStateChanged{VisibleSecurityState: &security.VisibleSecurityState{
+			SecurityState: security.StateInsecureBroken,
+			CertificateSecurityState: &security.CertificateSecurityState{
+				Protocol:                    "TLS 1.0",
+				CertificateNetworkError:     "net::ERR_CERT_DATE_INVALID",
+				CertificateHasSha1signature: true,
+				ObsoleteSslProtocol:         true,
+			},
+		}},
+	} {
+		st.handle(ev)
+	}
+
+	r := st.report()
+	if r.State != security.StateInsecureBroken {
+		t.Errorf("want state %q, got %q", security.StateInsecureBroken, r.State)
+	}
+	want := []*SecurityExplanation{
+		{Type: SecurityCertificateError, Description: "net::ERR_CERT_DATE_INVALID"},
+		{Type: SecurityWeakCertificate, Description: "certificate has a SHA-1 signature"},
+		{Type: SecurityObsoleteConnection, Description: "obsolete protocol TLS 1.0"},
+		{Type: SecurityMixedContent, Description: "MixedContentBlocked http://example.com/a.js", MixedContent: mixed},
+	}
+	if !reflect.DeepEqual(r.Explanations, want) {
+		for _, e := range r.Explanations {
+			t.Errorf("got explanation %+v", e)
+		}
+	}
+}
+
+func TestCertificate(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>TLS</body></html>`)
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var chain []*x509.Certificate
+	if err := Run(ctx,
+		security.SetIgnoreCertificateErrors(true),
+		Navigate(s.URL),
+		Certificate(&chain),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 1 {
+		t.Fatalf("want 1 certificate, got %d", len(chain))
+	}
+	if !chain[0].Equal(s.Certificate()) {
+		t.Errorf("want the certificate of the server, got %v", chain[0].Subject)
+	}
+
+	// the test pages are not loaded over TLS.
+	err := Run(ctx,
+		Navigate(testdataDir+"/form.html"),
+		Certificate(&chain),
+	)
+	if err == nil {
+		t.Errorf("want an error for a page not loaded over TLS")
+	}
+}
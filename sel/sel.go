@@ -0,0 +1,178 @@
+// Package sel builds XPath selectors, such as the elements with a given text
+// or attribute, which can be passed to the element query actions of chromedp
+// with the default BySearch option, either as is or as strings:
+//
+//	err := chromedp.Run(ctx,
+//		chromedp.Click(sel.Attr("data-id", "x").Descendant("button")),
+//		chromedp.Click(sel.Tag("button").WithText("Login").String()),
+//	)
+//
+// The strings are quoted as XPath literals, so that they can contain quotes.
+package sel
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Expr is an XPath expression matching elements. It's immutable: its methods
+// return new expressions.
+type Expr struct {
+	path string
+}
+
+// Tag returns an expression matching the elements with the given tag name,
+// or all the elements if name is "*".
+func Tag(name string) *Expr {
+	return &Expr{path: "//" + name}
+}
+
+// Text returns an expression matching the elements with a text node equal to
+// text, once its whitespace is normalized.
+func Text(text string) *Expr {
+	return Tag("*").WithText(text)
+}
+
+// ContainsText returns an expression matching the elements with a text node
+// containing text, once its whitespace is normalized.
+func ContainsText(text string) *Expr {
+	return Tag("*").WithTextContaining(text)
+}
+
+// Attr returns an expression matching the elements with the attribute name
+// equal to value.
+func Attr(name, value string) *Expr {
+	return Tag("*").WithAttr(name, value)
+}
+
+// ID returns an expression matching the element with the given id.
+func ID(id string) *Expr {
+	return Attr("id", id)
+}
+
+// Class returns an expression matching the elements with the given class.
+func Class(class string) *Expr {
+	return Tag("*").WithClass(class)
+}
+
+// String returns the XPath expression.
+func (e *Expr) String() string {
+	return e.path
+}
+
+// step returns a new expression with s appended to the path of e.
+func (e *Expr) step(s string) *Expr {
+	return &Expr{path: e.path + s}
+}
+
+// Descendant returns an expression matching the descendants of the elements
+// matched by e with the given tag name, or "*".
+func (e *Expr) Descendant(name string) *Expr {
+	return e.step("//" + name)
+}
+
+// Child returns an expression matching the children of the elements matched
+// by e with the given tag name, or "*".
+func (e *Expr) Child(name string) *Expr {
+	return e.step("/" + name)
+}
+
+// Parent returns an expression matching the parents of the elements matched
+// by e.
+func (e *Expr) Parent() *Expr {
+	return e.step("/..")
+}
+
+// Ancestor returns an expression matching the ancestors of the elements
+// matched by e with the given tag name, or "*".
+func (e *Expr) Ancestor(name string) *Expr {
+	return e.step("/ancestor::" + name)
+}
+
+// FollowingSibling returns an expression matching the siblings after the
+// elements matched by e with the given tag name, or "*".
+func (e *Expr) FollowingSibling(name string) *Expr {
+	return e.step("/following-sibling::" + name)
+}
+
+// PrecedingSibling returns an expression matching the siblings before the
+// elements matched by e with the given tag name, or "*".
+func (e *Expr) PrecedingSibling(name string) *Expr {
+	return e.step("/preceding-sibling::" + name)
+}
+
+// WithText returns an expression matching the elements matched by e with a
+// text node equal to text, once its whitespace is normalized.
+func (e *Expr) WithText(text string) *Expr {
+	return e.step("[text()[normalize-space(.)=" + Literal(strings.Join(strings.Fields(text), " ")) + "]]")
+}
+
+// WithTextContaining returns an expression matching the elements matched by
+// e with a text node containing text, once its whitespace is normalized.
+func (e *Expr) WithTextContaining(text string) *Expr {
+	return e.step("[text()[contains(normalize-space(.), " + Literal(strings.Join(strings.Fields(text), " ")) + ")]]")
+}
+
+// WithAttr returns an expression matching the elements matched by e with the
+// attribute name equal to value.
+func (e *Expr) WithAttr(name, value string) *Expr {
+	return e.step("[@" + name + "=" + Literal(value) + "]")
+}
+
+// HasAttr returns an expression matching the elements matched by e with the
+// attribute name, whatever its value.
+func (e *Expr) HasAttr(name string) *Expr {
+	return e.step("[@" + name + "]")
+}
+
+// WithClass returns an expression matching the elements matched by e with
+// the given class.
+func (e *Expr) WithClass(class string) *Expr {
+	return e.step(`[contains(concat(" ", normalize-space(@class), " "), ` + Literal(" "+class+" ") + ")]")
+}
+
+// Has returns an expression matching the elements matched by e with a
+// descendant matched by sub, such as Tag("tr").Has(Text("Total")).
+func (e *Expr) Has(sub *Expr) *Expr {
+	path := sub.path
+	if strings.HasPrefix(path, "(") {
+		path = "(." + path[1:]
+	} else {
+		path = "." + path
+	}
+	return e.step("[" + path + "]")
+}
+
+// Nth returns an expression matching only the element at index i of those
+// matched by e, in document order, starting at 0.
+func (e *Expr) Nth(i int) *Expr {
+	return &Expr{path: "(" + e.path + ")[" + strconv.Itoa(i+1) + "]"}
+}
+
+// Last returns an expression matching only the last element of those matched
+// by e, in document order.
+func (e *Expr) Last() *Expr {
+	return &Expr{path: "(" + e.path + ")[last()]"}
+}
+
+// Literal quotes s as an XPath string literal. As XPath 1.0 has no escape
+// sequences, the strings with both kinds of quotes are built with concat.
+func Literal(s string) string {
+	switch {
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'"
+	}
+	parts := strings.Split(s, `"`)
+	args := make([]string, 0, 2*len(parts)-1)
+	for i, part := range parts {
+		if i > 0 {
+			args = append(args, `'"'`)
+		}
+		if part != "" {
+			args = append(args, `"`+part+`"`)
+		}
+	}
+	return "concat(" + strings.Join(args, ", ") + ")"
+}
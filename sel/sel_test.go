@@ -0,0 +1,49 @@
+package sel
+
+import "testing"
+
+func TestExpr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		e    *Expr
+		want string
+	}{
+		{Tag("button"), `//button`},
+		{Text(" Log\tin "), `//*[text()[normalize-space(.)="Log in"]]`},
+		{ContainsText("Log"), `//*[text()[contains(normalize-space(.), "Log")]]`},
+		{Attr("data-id", "x").Descendant("button"), `//*[@data-id="x"]//button`},
+		{ID("form").Child("input").HasAttr("required"), `//*[@id="form"]/input[@required]`},
+		{Class("row").Parent(), `//*[contains(concat(" ", normalize-space(@class), " "), " row ")]/..`},
+		{Tag("label").WithText("Name").FollowingSibling("input"), `//label[text()[normalize-space(.)="Name"]]/following-sibling::input`},
+		{Tag("td").PrecedingSibling("*").Ancestor("table"), `//td/preceding-sibling::*/ancestor::table`},
+		{Tag("tr").Has(Text("Total")), `//tr[.//*[text()[normalize-space(.)="Total"]]]`},
+		{Tag("tr").Has(Tag("td").Nth(0)), `//tr[(.//td)[1]]`},
+		{Tag("tr").Nth(2).Child("td").Last(), `((//tr)[3]/td)[last()]`},
+		{Tag("a").WithAttr("title", `it's "quoted"`), `//a[@title=concat("it's ", '"', "quoted", '"')]`},
+	}
+	for _, test := range tests {
+		if got := test.e.String(); got != test.want {
+			t.Errorf("want %s, got %s", test.want, got)
+		}
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s, want string
+	}{
+		{``, `""`},
+		{`it's`, `"it's"`},
+		{`say "hi"`, `'say "hi"'`},
+		{`"'`, `concat('"', "'")`},
+		{`a"b'c`, `concat("a", '"', "b'c")`},
+	}
+	for _, test := range tests {
+		if got := Literal(test.s); got != test.want {
+			t.Errorf("Literal(%q): want %s, got %s", test.s, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// StreamResource is an action that writes to w the content of the responses
+// of the page whose URL matches urlPattern, as the data is received, such as
+// the audio and video streams, or the event streams, which never finish. The
+// wildcard '*' of urlPattern matches zero or more characters, '?' matches
+// exactly one, and '\' escapes them, as in the patterns of the Fetch domain.
+//
+// The responses are streamed until they finish, or ctx is cancelled. The
+// data received before the streaming of a response was enabled is written
+// first; a response which finished before is written entirely. When several
+// responses match, their chunks are written to w as they're received, so
+// that they're interleaved. w is written to while the events are handled, so
+// it must not block.
+//
+// Example:
+//
+//	var buf bytes.Buffer
+//	err := chromedp.Run(ctx,
+//		chromedp.StreamResource("*/live/stream.ts", &buf),
+//		chromedp.Navigate("https://example.com/live"),
+//	)
+func StreamResource(urlPattern string, w io.Writer) Action {
+	re := urlPatternRegexp(urlPattern)
+	return ActionFunc(func(ctx context.Context) error {
+		s := &resourceStream{
+			w:    w,
+			re:   re,
+			reqs: make(map[network.RequestID]*streamedResponse),
+		}
+		ListenTarget(ctx, func(ev interface{}) {
+			s.handle(ctx, ev)
+		})
+		return nil
+	})
+}
+
+// urlPatternRegexp returns the regexp matching the URLs matched by pattern,
+// which has the wildcards of the Fetch domain.
+func urlPatternRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`^`)
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '*':
+			b.WriteString(`.*`)
+		case c == '?':
+			b.WriteString(`.`)
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.MustCompile(b.String())
+}
+
+// resourceStream streams the responses of a StreamResource action.
+type resourceStream struct {
+	w  io.Writer
+	re *regexp.Regexp
+
+	mu   sync.Mutex
+	reqs map[network.RequestID]*streamedResponse
+	// err is the error of the last write to w, after which nothing is
+	// written anymore.
+	err error
+}
+
+// streamedResponse is the state of a streamed response.
+type streamedResponse struct {
+	// enabled is whether the buffered data was written, so that the
+	// received chunks are written as is, rather than queued.
+	enabled bool
+	queued  [][]byte
+	// finished is whether the response finished.
+	finished bool
+}
+
+func (s *resourceStream) handle(ctx context.Context, ev interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch ev := ev.(type) {
+	case *network.EventResponseReceived:
+		if _, ok := s.reqs[ev.RequestID]; ok || !s.re.MatchString(ev.Response.URL) {
+			return
+		}
+		s.reqs[ev.RequestID] = new(streamedResponse)
+		go s.enable(ctx, ev.RequestID)
+	case *network.EventDataReceived:
+		r := s.reqs[ev.RequestID]
+		if r == nil || ev.Data == "" {
+			return
+		}
+		buf, err := base64.StdEncoding.DecodeString(ev.Data)
+		if err != nil {
+			return
+		}
+		if !r.enabled {
+			r.queued = append(r.queued, buf)
+			return
+		}
+		s.write(buf)
+	case *network.EventLoadingFinished:
+		s.finish(ev.RequestID)
+	case *network.EventLoadingFailed:
+		s.finish(ev.RequestID)
+	}
+}
+
+// finish marks the response of the request id as finished.
+func (s *resourceStream) finish(id network.RequestID) {
+	r := s.reqs[id]
+	switch {
+	case r == nil:
+	case r.enabled:
+		delete(s.reqs, id)
+	default:
+		r.finished = true
+	}
+}
+
+// enable enables the streaming of the response of the request id, and writes
+// the data buffered until then, followed by the chunks received meanwhile.
+func (s *resourceStream) enable(ctx context.Context, id network.RequestID) {
+	buf, err := network.StreamResourceContent(id).Do(ctx)
+	if err != nil {
+		// the response finished already.
+		buf, err = network.GetResponseBody(id).Do(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.reqs[id]
+	if err == nil {
+		s.write(buf)
+	}
+	for _, chunk := range r.queued {
+		s.write(chunk)
+	}
+	r.enabled, r.queued = true, nil
+	if r.finished || err != nil {
+		delete(s.reqs, id)
+	}
+}
+
+// write writes buf to w, unless a previous write failed.
+func (s *resourceStream) write(buf []byte) {
+	if s.err != nil || len(buf) == 0 {
+		return
+	}
+	_, s.err = s.w.Write(buf)
+}
@@ -0,0 +1,126 @@
+package chromedp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestURLPatternRegexp(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"*", "https://example.com/", true},
+		{"*/stream.ts", "https://example.com/live/stream.ts", true},
+		{"*/stream.ts", "https://example.com/live/stream.ts?t=1", false},
+		{"*/stream.ts*", "https://example.com/live/stream.ts?t=1", true},
+		{"https://example.com/?", "https://example.com/a", true},
+		{"https://example.com/?", "https://example.com/ab", false},
+		{`*/a\*b`, "https://example.com/a*b", true},
+		{`*/a\*b`, "https://example.com/axb", false},
+		{"*.m3u8", "https://example.com/index.m3u8", true},
+		{"*.m3u8", "https://example.com/indexxm3u8", false},
+	}
+	for _, test := range tests {
+		if got := urlPatternRegexp(test.pattern).MatchString(test.url); got != test.want {
+			t.Errorf("pattern %q on %q: got %t, want %t", test.pattern, test.url, got, test.want)
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStreamResource(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>ok</body></html>")
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		// a stream which never finishes.
+		w.Header().Set("Content-Type", "application/octet-stream")
+		for i := 0; ; i++ {
+			fmt.Fprintf(w, "chunk-%d\n", i)
+			w.(http.Flusher).Flush()
+			select {
+			case <-done:
+				return
+			case <-r.Context().Done():
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "other")
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+	defer close(done)
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var buf, other syncBuffer
+	if err := Run(ctx,
+		Navigate(s.URL),
+		StreamResource("*/stream", &buf),
+		StreamResource("*/other", &other),
+		Evaluate(`fetch('/other'); fetch('/stream').then(r => r.body.getReader().read()); true`, nil),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var want strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&want, "chunk-%d\n", i)
+	}
+	ctx, cancel = context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for !strings.HasPrefix(buf.String(), want.String()) {
+		if !strings.HasPrefix(want.String(), buf.String()) {
+			t.Fatalf("got the stream %q, want it to start with %q", buf.String(), want.String())
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("got the stream %q, want it to start with %q", buf.String(), want.String())
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	// the response which finished is written entirely.
+	for other.String() != "other" {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("got the response %q, want %q", other.String(), "other")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package chromedp
+
+import (
+	"context"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/memory"
+	"github.com/chromedp/cdproto/systeminfo"
+)
+
+// ProcessStats is the resource usage of a process of the browser, as returned
+// by ProcessInfo.
+type ProcessStats struct {
+	// Type is the type of the process, such as "browser", "renderer", "GPU",
+	// or the name of the service of a utility process, such as
+	// "network.mojom.NetworkService".
+	Type string
+	// PID is the process id.
+	PID int
+	// CPUTime is the CPU time used by all the threads of the process since
+	// it was started.
+	CPUTime time.Duration
+	// ResidentMemory is the resident memory of the process in bytes. It's
+	// only known on Linux, for the browsers started by the allocator, and is
+	// 0 otherwise.
+	ResidentMemory int64
+	// SampledMemory is the memory in bytes of the native allocations of the
+	// browser process, as estimated by the sampling heap profiler. It's only
+	// set for the "browser" process, once sampling was started with
+	// memory.StartSampling, and is 0 otherwise.
+	SampledMemory int64
+}
+
+// ProcessInfo returns the resource usage of the processes of the browser of the
+// context, such as its renderers and GPU process.
+//
+// Example:
+//
+//	stats, err := chromedp.ProcessInfo(ctx)
+//	if err != nil {
+//		// handle error
+//	}
+//	for _, s := range stats {
+//		fmt.Printf("%s (%d): %v CPU, %d bytes\n", s.Type, s.PID, s.CPUTime, s.ResidentMemory)
+//	}
+func ProcessInfo(ctx context.Context) ([]*ProcessStats, error) {
+	c, err := initContextBrowser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bctx := cdp.WithExecutor(ctx, c.Browser)
+	infos, err := systeminfo.GetProcessInfo().Do(bctx)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := memory.GetBrowserSamplingProfile().Do(bctx)
+	if err != nil {
+		return nil, err
+	}
+	var sampled int64
+	if profile != nil {
+		for _, sample := range profile.Samples {
+			sampled += int64(sample.Total)
+		}
+	}
+
+	// the PIDs are only those of the local processes for the browsers
+	// started by the allocator.
+	local := c.Browser.PID() != 0
+	stats := make([]*ProcessStats, 0, len(infos))
+	for _, info := range infos {
+		s := &ProcessStats{
+			Type:    info.Type,
+			PID:     int(info.ID),
+			CPUTime: time.Duration(info.CPUTime * float64(time.Second)),
+		}
+		if local {
+			s.ResidentMemory = processRSS(s.PID)
+		}
+		if s.Type == "browser" {
+			s.SampledMemory = sampled
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
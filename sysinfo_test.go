@@ -0,0 +1,34 @@
+package chromedp
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProcessStats(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	stats, err := ProcessInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string]*ProcessStats)
+	for _, s := range stats {
+		types[s.Type] = s
+	}
+	b, r := types["browser"], types["renderer"]
+	if b == nil || r == nil {
+		t.Fatalf("want browser and renderer processes, got %v", types)
+	}
+	if pid := FromContext(ctx).Browser.PID(); b.PID != pid {
+		t.Errorf("want the browser process %d, got %d", pid, b.PID)
+	}
+	if _, err := os.Stat("/proc/self/statm"); err == nil {
+		if b.ResidentMemory <= 0 || r.ResidentMemory <= 0 {
+			t.Errorf("want the resident memory of the processes, got %d and %d", b.ResidentMemory, r.ResidentMemory)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 
@@ -11,7 +12,12 @@ import (
 
 	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/css"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/cdproto/target"
@@ -19,23 +25,51 @@ import (
 
 // Target manages a Chrome DevTools Protocol target.
 type Target struct {
-	browser   *Browser
+	browser *Browser
+	// SessionID is the session attached to the target. It changes when the
+	// browser reconnects, with the WithReconnect option of RemoteAllocator.
 	SessionID target.SessionID
 	TargetID  target.ID
 
+	// sessionMu protects SessionID, and lost, which is set once the target
+	// couldn't be attached to again after the browser reconnected.
+	sessionMu sync.Mutex
+	lost      bool
+
 	listenersMu sync.Mutex
 	listeners   []cancelableListener
 
-	messageQueue chan *cdproto.Message
+	messageQueue *eventQueue
+	// syncEventQueue is used to handle events synchronously within Target.
+	// TODO: If this queue gets full, the goroutine of run receiving the
+	// events could get stuck on a send, and response callbacks would never
+	// run, resulting in a deadlock. Can we fix this without potentially
+	// using lots of memory?
+	syncEventQueue chan eventValue
 
-	// frameMu protects frames, execContexts, and cur.
+	// frameMu protects frames, execContexts, contexts and cur.
 	frameMu sync.RWMutex
 	// frames is the set of encountered frames.
 	frames       map[cdp.FrameID]*cdp.Frame
 	execContexts map[cdp.FrameID]runtime.ExecutionContextID
+	// contexts are all the execution contexts, including the isolated
+	// worlds, as listed by ExecutionContexts.
+	contexts map[runtime.ExecutionContextID]*ExecutionContext
 	// cur is the current top level frame.
 	cur cdp.FrameID
 
+	// emulationMu protects emulation, the emulation applied to the target,
+	// networkConditions, the network conditions it last emulated, and
+	// emulatedMedia, the media type and features it last emulated.
+	emulationMu       sync.Mutex
+	emulation         emulationState
+	networkConditions *network.EmulateNetworkConditionsParams
+	emulatedMedia     *emulation.SetEmulatedMediaParams
+	// persistEmulation is set up by WithPersistentEmulation, and
+	// isolateNetwork by WithIsolatedNetworkConditions.
+	persistEmulation bool
+	isolateNetwork   bool
+
 	// logging funcs
 	logf, errf func(string, ...interface{})
 
@@ -88,55 +122,95 @@ func (t *Target) ensureFrame() (*cdp.Frame, *cdp.Node, runtime.ExecutionContextI
 	return frame, root, execCtx, true
 }
 
-func (t *Target) run(ctx context.Context) {
-	type eventValue struct {
-		method cdproto.MethodType
-		value  interface{}
+// eventValue is an event handled by the main goroutine of run, or, without a
+// method, a barrier closed once the events queued before it were handled.
+type eventValue struct {
+	method cdproto.MethodType
+	value  interface{}
+}
+
+// syncEvents waits until the main goroutine of run handled the events queued
+// before, such as the DOM events setting the nodes pushed by a command which
+// returned, as they're queued before its reply is.
+func (t *Target) syncEvents(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case t.syncEventQueue <- eventValue{value: done}:
 	}
-	// syncEventQueue is used to handle events synchronously within Target.
-	// TODO: If this queue gets full, the goroutine below could get stuck on
-	// a send, and response callbacks would never run, resulting in a
-	// deadlock. Can we fix this without potentially using lots of memory?
-	syncEventQueue := make(chan eventValue, 4096)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+func (t *Target) run(ctx context.Context) {
+	syncEventQueue := t.syncEventQueue
 
 	// This goroutine receives events from the browser, calls listeners, and
 	// then passes the events onto the main goroutine for the target handler
 	// to update itself.
 	go func() {
+		defer t.messageQueue.close()
 		for {
-			select {
-			case <-ctx.Done():
+			msg, ok := t.messageQueue.pop(ctx)
+			if !ok {
 				return
-
-			case msg := <-t.messageQueue:
-				if msg.ID != 0 {
-					t.listenersMu.Lock()
-					t.listeners = runListeners(t.listeners, msg)
-					t.listenersMu.Unlock()
-					continue
-				}
-				ev, err := cdproto.UnmarshalMessage(msg)
-				if err != nil {
-					if _, ok := err.(cdp.ErrUnknownCommandOrEvent); ok {
-						// This is most likely an event received from an older
-						// Chrome which a newer cdproto doesn't have, as it is
-						// deprecated. Ignore that error.
-						continue
+			}
+			if msg.ID != 0 {
+				t.listenersMu.Lock()
+				t.listeners = runListeners(t.listeners, msg)
+				t.listenersMu.Unlock()
+				continue
+			}
+			ev, err := cdproto.UnmarshalMessage(msg)
+			if err != nil {
+				if _, ok := err.(cdp.ErrUnknownCommandOrEvent); ok {
+					// This is either an event which a user decoder was
+					// registered for, or most likely an event received
+					// from an older Chrome which a newer cdproto doesn't
+					// have, as it is deprecated. Ignore the latter.
+					ev, ok, err := decodeCustomEvent(msg)
+					if err != nil {
+						t.errf("could not decode event %s: %v", msg.Method, err)
+					} else if ok {
+						t.listenersMu.Lock()
+						t.listeners = runListeners(t.listeners, ev)
+						t.listenersMu.Unlock()
 					}
-					t.errf("could not unmarshal event: %v", err)
 					continue
 				}
-				t.listenersMu.Lock()
-				t.listeners = runListeners(t.listeners, ev)
-				t.listenersMu.Unlock()
-
-				switch msg.Method.Domain() {
-				case "Runtime", "Page", "DOM":
-					select {
-					case <-ctx.Done():
-						return
-					case syncEventQueue <- eventValue{msg.Method, ev}:
+				t.errf("could not unmarshal event: %v", err)
+				continue
+			}
+			t.listenersMu.Lock()
+			t.listeners = runListeners(t.listeners, ev)
+			t.listenersMu.Unlock()
+
+			if _, ok := ev.(*inspector.EventTargetReloadedAfterCrash); ok && t.persistEmulation {
+				go func() {
+					if err := t.applyEmulation(ctx, t.currentEmulation()); err != nil {
+						t.errf("could not apply the emulation after a crash: %v", err)
+					}
+				}()
+			}
+			if t.isolateNetwork && isNewDocument(ev) {
+				go func() {
+					if err := t.applyNetworkConditions(ctx); err != nil {
+						t.errf("could not apply the network conditions: %v", err)
 					}
+				}()
+			}
+
+			switch msg.Method.Domain() {
+			case "Runtime", "Page", "DOM":
+				select {
+				case <-ctx.Done():
+					return
+				case syncEventQueue <- eventValue{msg.Method, ev}:
 				}
 			}
 		}
@@ -147,6 +221,10 @@ func (t *Target) run(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case ev := <-syncEventQueue:
+			if done, ok := ev.value.(chan struct{}); ok {
+				close(done)
+				continue
+			}
 			switch ev.method.Domain() {
 			case "Runtime":
 				t.runtimeEvent(ev.value)
@@ -159,10 +237,66 @@ func (t *Target) run(ctx context.Context) {
 	}
 }
 
+// isNewDocument reports whether the target event ev is about the top-level
+// frame of the target having loaded a new document, after which its network
+// conditions are applied again. They're not applied as soon as the frame
+// navigated, as the response of the document would then stall.
+func isNewDocument(ev interface{}) bool {
+	switch ev.(type) {
+	case *page.EventDomContentEventFired, *inspector.EventTargetReloadedAfterCrash:
+		return true
+	}
+	return false
+}
+
+// session returns the session attached to the target, and whether the target
+// was lost once the browser reconnected.
+func (t *Target) session() (target.SessionID, bool) {
+	t.sessionMu.Lock()
+	defer t.sessionMu.Unlock()
+	return t.SessionID, t.lost
+}
+
+// enableDomains enables the domains chromedp relies on in the session of the
+// target, and discovers its targets.
+func (t *Target) enableDomains(ctx context.Context) error {
+	actions := []Action{
+		log.Enable(),
+		network.Enable(),
+	}
+	// These actions are not available on a worker target.
+	if !t.isWorker {
+		actions = append(actions, []Action{
+			inspector.Enable(),
+			page.Enable(),
+			dom.Enable(),
+			css.Enable(),
+			target.SetDiscoverTargets(true),
+			target.SetAutoAttach(true, false).WithFlatten(true),
+			page.SetLifecycleEventsEnabled(true),
+		}...)
+	}
+
+	for _, action := range actions {
+		if err := action.Do(cdp.WithExecutor(ctx, t)); err != nil {
+			return fmt.Errorf("unable to execute %T: %w", action, err)
+		}
+	}
+	return nil
+}
+
 func (t *Target) Execute(ctx context.Context, method string, params easyjson.Marshaler, res easyjson.Unmarshaler) error {
 	if method == target.CommandCloseTarget {
 		return errors.New("to close the target, cancel its context or use chromedp.Cancel")
 	}
+	if err := t.browser.filterCommand(method); err != nil {
+		return err
+	}
+	sessionID, lost := t.session()
+	if lost {
+		return ErrTargetLost
+	}
+	params = t.browser.adjustParams(method, params)
 
 	id := atomic.AddInt64(&t.browser.next, 1)
 	lctx, cancel := context.WithCancel(ctx)
@@ -191,27 +325,43 @@ func (t *Target) Execute(ctx context.Context, method string, params easyjson.Mar
 	}
 	cmd := &cdproto.Message{
 		ID:        id,
-		SessionID: t.SessionID,
+		SessionID: sessionID,
 		Method:    cdproto.MethodType(method),
 		Params:    buf,
 	}
+	failed, done := t.browser.addPending(id)
+	defer done()
+	timeout, stop := t.browser.commandTimer()
+	defer stop()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case t.browser.cmdQueue <- cmd:
+	case <-timeout:
+		cancel()
+		return t.browser.commandTimeoutError(method)
+	case t.commandQueue(ctx) <- cmd:
 	}
 
 	// wait for result
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
+	case <-timeout:
+		cancel()
+		return t.browser.commandTimeoutError(method)
+	case err := <-failed:
+		cancel()
+		return err
 	case msg := <-ch:
 		switch {
 		case msg == nil:
 			return ErrChannelClosed
 		case msg.Error != nil:
 			return msg.Error
-		case res != nil:
+		}
+		recordCommand(ctx)
+		t.trackEmulation(method, params)
+		if res != nil {
 			return easyjson.Unmarshal(msg.Result, res)
 		}
 	}
@@ -223,22 +373,36 @@ func (t *Target) runtimeEvent(ev interface{}) {
 	switch ev := ev.(type) {
 	case *runtime.EventExecutionContextCreated:
 		var aux struct {
-			FrameID cdp.FrameID
+			FrameID   cdp.FrameID
+			IsDefault *bool
+			Type      string
 		}
-		if len(ev.Context.AuxData) == 0 {
-			break
+		if len(ev.Context.AuxData) > 0 {
+			if err := json.Unmarshal(ev.Context.AuxData, &aux); err != nil {
+				t.errf("could not decode executionContextCreated auxData %q: %v", ev.Context.AuxData, err)
+				break
+			}
 		}
-		if err := json.Unmarshal(ev.Context.AuxData, &aux); err != nil {
-			t.errf("could not decode executionContextCreated auxData %q: %v", ev.Context.AuxData, err)
-			break
+		def := aux.IsDefault == nil || *aux.IsDefault
+		t.frameMu.Lock()
+		t.contexts[ev.Context.ID] = &ExecutionContext{
+			ID:       ev.Context.ID,
+			UniqueID: ev.Context.UniqueID,
+			FrameID:  aux.FrameID,
+			Name:     ev.Context.Name,
+			Origin:   ev.Context.Origin,
+			Type:     aux.Type,
+			Default:  def,
 		}
-		if aux.FrameID != "" {
-			t.frameMu.Lock()
+		// ignore the isolated worlds, such as those of WaitDOMEvent and
+		// of the content scripts of extensions.
+		if aux.FrameID != "" && def {
 			t.execContexts[aux.FrameID] = ev.Context.ID
-			t.frameMu.Unlock()
 		}
+		t.frameMu.Unlock()
 	case *runtime.EventExecutionContextDestroyed:
 		t.frameMu.Lock()
+		delete(t.contexts, ev.ExecutionContextID)
 		for frameID, ctxID := range t.execContexts {
 			if ctxID == ev.ExecutionContextID {
 				delete(t.execContexts, frameID)
@@ -250,6 +414,7 @@ func (t *Target) runtimeEvent(ev interface{}) {
 		for frameID := range t.execContexts {
 			delete(t.execContexts, frameID)
 		}
+		clear(t.contexts)
 		t.frameMu.Unlock()
 	}
 }
@@ -0,0 +1,158 @@
+package chromedp
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+)
+
+// UAMetadata builds the user agent client hints set by SetUserAgentMetadata,
+// which the browser sends in the Sec-CH-UA-* headers and returns from
+// navigator.userAgentData.
+//
+// The zero value has no brands. The methods return a copy of the metadata, so
+// that a base metadata can be shared. Example:
+//
+//	meta := chromedp.UAMetadata{}.
+//		Brand("Not_A Brand", "8.0.0.0").
+//		Brand("Chromium", "120.0.6099.71").
+//		Brand("Google Chrome", "120.0.6099.71").
+//		Platform("Windows", "15.0.0").
+//		Architecture("x86", "64").
+//		NavigatorPlatform("Win32")
+//
+// See UAMetadataFor to build the metadata matching a user agent.
+type UAMetadata struct {
+	meta              emulation.UserAgentMetadata
+	navigatorPlatform string
+}
+
+// UAMetadataFor returns the metadata matching the user agent ua of a Chrome
+// browser, with its brands, its platform and whether it's mobile, so that the
+// client hints don't reveal the real browser once ua is overridden. The
+// versions of the platform and the model, which ua doesn't tell, are left
+// empty.
+func UAMetadataFor(ua string) UAMetadata {
+	var m UAMetadata
+	if v := chromeVersionRE.FindStringSubmatch(ua); v != nil {
+		full := v[1] + ".0.0.0"
+		if _, after, ok := strings.Cut(ua, v[0]); ok && strings.HasPrefix(after, ".") {
+			full = v[1] + strings.Fields(after)[0]
+		}
+		m = m.Brand("Not_A Brand", "8.0.0.0").
+			Brand("Chromium", full).
+			Brand("Google Chrome", full)
+	}
+	platform, _ := uaOS(ua)
+	m = m.Platform(platform, "")
+	switch {
+	case platform == "Windows":
+		m = m.NavigatorPlatform("Win32").Architecture("x86", "64")
+	case platform == "macOS":
+		m = m.NavigatorPlatform("MacIntel").Architecture("arm", "64")
+	case platform == "Android":
+		m = m.NavigatorPlatform("Linux armv8l")
+	case platform == "iOS":
+		m = m.NavigatorPlatform("iPhone")
+	case platform != "":
+		m = m.NavigatorPlatform("Linux x86_64").Architecture("x86", "64")
+	}
+	return m.Mobile(strings.Contains(ua, "Mobile") || platform == "Android")
+}
+
+// Brand adds a brand, such as "Chromium", with its full version, such as
+// "120.0.6099.71". The major version is used in the Sec-CH-UA header, and the
+// full one in the Sec-CH-UA-Full-Version-List header.
+func (m UAMetadata) Brand(brand, fullVersion string) UAMetadata {
+	major, _, _ := strings.Cut(fullVersion, ".")
+	m.meta.Brands = append(slices.Clip(m.meta.Brands), &emulation.UserAgentBrandVersion{Brand: brand, Version: major})
+	m.meta.FullVersionList = append(slices.Clip(m.meta.FullVersionList), &emulation.UserAgentBrandVersion{Brand: brand, Version: fullVersion})
+	return m
+}
+
+// Platform sets the platform, such as "Windows", "macOS", "Linux" or
+// "Android", and its version, such as "15.0.0".
+func (m UAMetadata) Platform(platform, version string) UAMetadata {
+	m.meta.Platform, m.meta.PlatformVersion = platform, version
+	return m
+}
+
+// Architecture sets the CPU architecture, such as "x86" or "arm", and its
+// bitness, such as "64".
+func (m UAMetadata) Architecture(arch, bitness string) UAMetadata {
+	m.meta.Architecture, m.meta.Bitness = arch, bitness
+	return m
+}
+
+// Model sets the model of the device, such as "Pixel 7", which is empty for
+// the desktops.
+func (m UAMetadata) Model(model string) UAMetadata {
+	m.meta.Model = model
+	return m
+}
+
+// Mobile sets whether the device is mobile.
+func (m UAMetadata) Mobile(mobile bool) UAMetadata {
+	m.meta.Mobile = mobile
+	return m
+}
+
+// NavigatorPlatform sets the value of navigator.platform, such as "Win32",
+// which otherwise still reveals the real platform.
+func (m UAMetadata) NavigatorPlatform(platform string) UAMetadata {
+	m.navigatorPlatform = platform
+	return m
+}
+
+// Metadata returns the low-level metadata.
+func (m UAMetadata) Metadata() *emulation.UserAgentMetadata {
+	meta := m.meta
+	meta.Brands = slices.Clone(meta.Brands)
+	meta.FullVersionList = slices.Clone(meta.FullVersionList)
+	return &meta
+}
+
+// SetUserAgentMetadata is an action to override the user agent client hints
+// with meta, keeping the user agent, as overridden by Emulate or
+// emulation.SetUserAgentOverride, or the one of the browser otherwise.
+//
+// The client hints are otherwise those of the real browser, so that
+// overriding only the user agent leaks the real platform and version via the
+// Sec-CH-UA-* headers and navigator.userAgentData. To override both, run
+// emulation.SetUserAgentOverride first, or use UAMetadataFor:
+//
+//	err := chromedp.Run(ctx,
+//		emulation.SetUserAgentOverride(ua),
+//		chromedp.SetUserAgentMetadata(chromedp.UAMetadataFor(ua)),
+//	)
+func SetUserAgentMetadata(meta UAMetadata) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		p := emulation.SetUserAgentOverride("")
+		if t, _ := cdp.ExecutorFromContext(ctx).(*Target); t != nil {
+			if ua := t.currentEmulation().userAgent; ua != nil {
+				cp := *ua
+				p = &cp
+			}
+		}
+		if p.UserAgent == "" {
+			c := FromContext(ctx)
+			if c == nil || c.Browser == nil {
+				return ErrInvalidContext
+			}
+			_, _, _, ua, _, err := browser.GetVersion().Do(cdp.WithExecutor(ctx, c.Browser))
+			if err != nil {
+				return err
+			}
+			p.UserAgent = ua
+		}
+		p.UserAgentMetadata = meta.Metadata()
+		if meta.navigatorPlatform != "" {
+			p.Platform = meta.navigatorPlatform
+		}
+		return p.Do(ctx)
+	})
+}
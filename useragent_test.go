@@ -0,0 +1,176 @@
+package chromedp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestUAMetadataFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ua       string
+		platform string
+		navPlat  string
+		mobile   bool
+		brands   int
+		full     string
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.71 Safari/537.36",
+			"Windows", "Win32", false, 3, "120.0.6099.71",
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.0.0 Safari/537.36",
+			"macOS", "MacIntel", false, 3, "99.0.0.0",
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			"Android", "Linux armv8l", true, 3, "120.0.0.0",
+		},
+		{
+			"Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+			"Linux", "Linux x86_64", false, 0, "",
+		},
+	}
+	for i, test := range tests {
+		m := UAMetadataFor(test.ua)
+		meta := m.Metadata()
+		if meta.Platform != test.platform || m.navigatorPlatform != test.navPlat || meta.Mobile != test.mobile {
+			t.Errorf("test %d: got %q, %q and %t, want %q, %q and %t", i,
+				meta.Platform, m.navigatorPlatform, meta.Mobile, test.platform, test.navPlat, test.mobile)
+		}
+		if len(meta.Brands) != test.brands || len(meta.FullVersionList) != test.brands {
+			t.Fatalf("test %d: got %d brands, want %d", i, len(meta.Brands), test.brands)
+		}
+		if test.brands > 0 {
+			got := meta.FullVersionList[1]
+			if got.Brand != "Chromium" || got.Version != test.full {
+				t.Errorf("test %d: got %+v, want Chromium %s", i, got, test.full)
+			}
+		}
+	}
+}
+
+func TestUAMetadataBuilder(t *testing.T) {
+	t.Parallel()
+
+	base := UAMetadata{}.Brand("Chromium", "120.0.6099.71")
+	a := base.Brand("Google Chrome", "120.0.6099.71").Metadata()
+	b := base.Brand("Microsoft Edge", "120.0.2210.61").Model("Pixel 7").Metadata()
+	want := []*emulation.UserAgentBrandVersion{
+		{Brand: "Chromium", Version: "120"},
+		{Brand: "Google Chrome", Version: "120"},
+	}
+	if !reflect.DeepEqual(a.Brands, want) {
+		t.Errorf("got %v, want %v", a.Brands, want)
+	}
+	if b.Brands[1].Brand != "Microsoft Edge" || b.FullVersionList[1].Version != "120.0.2210.61" || b.Model != "Pixel 7" {
+		t.Errorf("got %+v", b)
+	}
+	if a.Model != "" {
+		t.Errorf("want the base metadata to be left as is, got model %q", a.Model)
+	}
+}
+
+func TestSetUserAgentMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var ua string
+	if err := Run(ctx, Evaluate(`navigator.userAgent`, &ua)); err != nil {
+		t.Fatal(err)
+	}
+	meta := UAMetadata{}.
+		Brand("Not_A Brand", "8.0.0.0").
+		Brand("Chromium", "120.0.6099.71").
+		Platform("Windows", "15.0.0").
+		Architecture("x86", "64").
+		NavigatorPlatform("Win32")
+	var res struct {
+		UserAgent string `json:"userAgent"`
+		Platform  string `json:"platform"`
+		Brands    []struct {
+			Brand string `json:"brand"`
+		} `json:"brands"`
+		UAPlatform      string `json:"uaPlatform"`
+		PlatformVersion string `json:"platformVersion"`
+		FullVersion     string `json:"fullVersion"`
+	}
+	if err := Run(ctx,
+		SetUserAgentMetadata(meta),
+		Evaluate(`navigator.userAgentData.getHighEntropyValues(["platformVersion", "uaFullVersion"]).then(v => ({
+			userAgent: navigator.userAgent,
+			platform: navigator.platform,
+			brands: navigator.userAgentData.brands,
+			uaPlatform: navigator.userAgentData.platform,
+			platformVersion: v.platformVersion,
+			fullVersion: v.uaFullVersion,
+		}))`, &res, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if res.UserAgent != ua {
+		t.Errorf("want the user agent %q to be kept, got %q", ua, res.UserAgent)
+	}
+	if res.Platform != "Win32" || res.UAPlatform != "Windows" || res.PlatformVersion != "15.0.0" {
+		t.Errorf("got the platforms %q, %q and %q", res.Platform, res.UAPlatform, res.PlatformVersion)
+	}
+	if len(res.Brands) != 2 || res.Brands[1].Brand != "Chromium" {
+		t.Errorf("got the brands %+v", res.Brands)
+	}
+
+	// the client hints are sent to the trustworthy origins.
+	headers := make(chan string, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case headers <- r.Header.Get("Sec-CH-UA-Platform"):
+		default:
+		}
+		fmt.Fprint(w, "<html><body>ok</body></html>")
+	}))
+	defer s.Close()
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-headers; got != `"Windows"` {
+		t.Errorf("got the Sec-CH-UA-Platform header %q, want %q", got, `"Windows"`)
+	}
+}
+
+func TestSetUserAgentMetadataFor(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	const ua = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.0.0 Safari/537.36"
+	var f Fingerprint
+	if err := Run(ctx,
+		emulation.SetUserAgentOverride(ua).WithAcceptLanguage("fr-FR"),
+		SetUserAgentMetadata(UAMetadataFor(ua)),
+		Navigate(testdataDir+"/image.html"),
+		FingerprintReport(&f),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if f.UserAgent != ua || f.Language != "fr-FR" {
+		t.Errorf("want the user agent and language to be kept, got %q and %q", f.UserAgent, f.Language)
+	}
+	for _, s := range f.Inconsistencies {
+		if s != "navigator.webdriver is true" && !strings.Contains(s, "software") && !strings.Contains(s, "locale") {
+			t.Errorf("unexpected inconsistency: %s", s)
+		}
+	}
+}
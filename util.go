@@ -2,15 +2,15 @@ package chromedp
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"net"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp/client"
 )
 
 // forceIP tries to force the host component in urlstr to be an IP address.
@@ -63,11 +63,12 @@ func resolveHost(ctx context.Context, host string) (string, error) {
 // Otherwise, it will construct a URL like http://[host]:[port]/json/version
 // and query the valid websocket debugger URL from this endpoint. The [host]
 // and [port] are parsed from the urlstr. If the host component is not an IP,
-// it will be resolved to an IP first. Example parameters:
+// it will be resolved to an IP first. The path of the endpoint is versionPath
+// instead, if not empty. Example parameters:
 //   - ws://127.0.0.1:9222/
 //   - http://127.0.0.1:9222/
 //   - http://container-name:9222/
-func modifyURL(ctx context.Context, urlstr string) (string, error) {
+func modifyURL(ctx context.Context, urlstr, versionPath string) (string, error) {
 	lctx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
@@ -75,38 +76,16 @@ func modifyURL(ctx context.Context, urlstr string) (string, error) {
 		return forceIP(lctx, urlstr)
 	}
 
-	// replace the scheme and path to construct a URL like:
-	// http://127.0.0.1:9222/json/version
-	u, err := url.Parse(urlstr)
-	if err != nil {
-		return "", err
-	}
-	u.Scheme = "http"
-	host, port, err := net.SplitHostPort(u.Host)
-	if err != nil {
-		return "", err
-	}
-	host, err = resolveHost(ctx, host)
-	if err != nil {
-		return "", err
-	}
-	u.Host = net.JoinHostPort(host, port)
-	u.Path = "/json/version"
-
-	// to get "webSocketDebuggerUrl" in the response
-	req, err := http.NewRequestWithContext(lctx, "GET", u.String(), nil)
-	if err != nil {
-		return "", err
+	// get "webSocketDebuggerUrl" from the /json/version endpoint of a URL
+	// like http://127.0.0.1:9222/json/version. The client resolves the host
+	// to be an IP first.
+	var opts []client.Option
+	if versionPath != "" {
+		opts = append(opts, client.WithVersionPath(versionPath))
 	}
-	resp, err := http.DefaultClient.Do(req)
+	v, err := client.New(urlstr, opts...).Version(lctx)
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+		return "", fmt.Errorf("could not get the version information of %s: %w", urlstr, err)
 	}
 	// the browser will construct the debugger URL using the "host" header of
 	// the /json/version request. For example, run headless-shell in a container:
@@ -115,8 +94,10 @@ func modifyURL(ctx context.Context, urlstr string) (string, error) {
 	//     curl http://127.0.0.1:9000/json/version
 	// and the websocket debugger URL will be something like:
 	// ws://127.0.0.1:9000/devtools/browser/...
-	wsURL := result["webSocketDebuggerUrl"].(string)
-	return wsURL, nil
+	if v.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("no webSocketDebuggerUrl in the version information of %s", urlstr)
+	}
+	return v.WebSocketDebuggerURL, nil
 }
 
 func runListeners(list []cancelableListener, ev interface{}) []cancelableListener {
@@ -411,3 +392,11 @@ func isCouldNotComputeBoxModelError(err error) bool {
 	e, ok := err.(*cdproto.Error)
 	return ok && e.Code == -32000 && e.Message == "Could not compute box model."
 }
+
+// isExecutionTerminatedError determines if err is the error returned by the
+// browser when the execution of a script is terminated, such as when it
+// exceeds the timeout of Runtime.evaluate.
+func isExecutionTerminatedError(err error) bool {
+	e, ok := err.(*cdproto.Error)
+	return ok && e.Code == -32000 && e.Message == "Execution was terminated"
+}